@@ -36,4 +36,6 @@ export interface FeatureToggles {
   showFeatureFlagsInUI?: boolean;
   disable_http_request_histogram?: boolean;
   validatedQueries?: boolean;
+  fullTextDashboardSearch?: boolean;
+  scheduledReports?: boolean;
 }
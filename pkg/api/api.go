@@ -277,6 +277,14 @@ func (hs *HTTPServer) registerRoutes() {
 			datasourceRoute.Delete("/name/:name", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesDelete, ScopeDatasourceName)), routing.Wrap(hs.DeleteDataSourceByName))
 			datasourceRoute.Get("/:id", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead)), routing.Wrap(hs.GetDataSourceById))
 			datasourceRoute.Get("/uid/:uid", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead)), routing.Wrap(hs.GetDataSourceByUID))
+			datasourceRoute.Get("/uid/:uid/health/history", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead)), routing.Wrap(hs.GetDataSourceHealthHistory))
+			datasourceRoute.Get("/uid/:uid/secrets", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead)), routing.Wrap(hs.GetDataSourceSecretRotation))
+			datasourceRoute.Get("/uid/:uid/secrets/audit", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead)), routing.Wrap(hs.GetDataSourceSecretAudit))
+			datasourceRoute.Put("/uid/:uid/secrets/staged", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesWrite, ScopeDatasourceUID)), routing.Wrap(hs.StageDataSourceSecrets))
+			datasourceRoute.Delete("/uid/:uid/secrets/staged", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesWrite, ScopeDatasourceUID)), routing.Wrap(hs.DiscardStagedDataSourceSecrets))
+			datasourceRoute.Post("/uid/:uid/secrets/staged/test", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesWrite, ScopeDatasourceUID)), routing.Wrap(hs.TestStagedDataSourceSecrets))
+			datasourceRoute.Post("/uid/:uid/secrets/promote", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesWrite, ScopeDatasourceUID)), routing.Wrap(hs.PromoteStagedDataSourceSecrets))
+			datasourceRoute.Post("/uid/:uid/secrets/rollback", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesWrite, ScopeDatasourceUID)), routing.Wrap(hs.RollbackDataSourceSecrets))
 			datasourceRoute.Get("/name/:name", authorize(reqOrgAdmin, ac.EvalPermission(ActionDatasourcesRead)), routing.Wrap(GetDataSourceByName))
 		})
 
@@ -347,6 +355,7 @@ func (hs *HTTPServer) registerRoutes() {
 				dashIdRoute.Get("/versions", routing.Wrap(GetDashboardVersions))
 				dashIdRoute.Get("/versions/:id", routing.Wrap(GetDashboardVersion))
 				dashIdRoute.Post("/restore", routing.Wrap(hs.RestoreDashboardVersion))
+				dashIdRoute.Post("/merge", routing.Wrap(MergeDashboardVersions))
 
 				dashIdRoute.Group("/permissions", func(dashboardPermissionRoute routing.RouteRegister) {
 					dashboardPermissionRoute.Get("/", routing.Wrap(hs.GetDashboardPermissionList))
@@ -515,6 +524,7 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Post("/api/snapshots/", reqSnapshotPublicModeOrSignedIn, CreateDashboardSnapshot)
 	r.Get("/api/snapshot/shared-options/", reqSignedIn, GetSharingOptions)
 	r.Get("/api/snapshots/:key", routing.Wrap(GetDashboardSnapshot))
+	r.Get("/api/snapshots/:key/access-log", reqSignedIn, routing.Wrap(GetDashboardSnapshotAccessLog))
 	r.Get("/api/snapshots-delete/:deleteKey", reqSnapshotPublicModeOrSignedIn, routing.Wrap(DeleteDashboardSnapshotByDeleteKey))
 	r.Delete("/api/snapshots/:key", reqEditorRole, routing.Wrap(DeleteDashboardSnapshot))
 
@@ -67,6 +67,10 @@ func loggedInUserScenarioWithRole(t *testing.T, desc string, method string, url
 			sc.m.Get(routePattern, sc.defaultHandler)
 		case "DELETE":
 			sc.m.Delete(routePattern, sc.defaultHandler)
+		case "POST":
+			sc.m.Post(routePattern, sc.defaultHandler)
+		case "PUT":
+			sc.m.Put(routePattern, sc.defaultHandler)
 		}
 		fn(sc)
 	})
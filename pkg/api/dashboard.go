@@ -668,6 +668,60 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *models.ReqContext) response.Res
 	return hs.postDashboard(c, saveCmd)
 }
 
+// MergeDashboardVersions performs a three-way merge of two versions of a
+// dashboard that were both based on the same version. When theirsVersion is
+// omitted the latest saved version is used, and ours can be either a saved
+// version or an unsaved dashboard model.
+func MergeDashboardVersions(c *models.ReqContext) response.Response {
+	apiCmd := dtos.MergeDashboardVersionsCommand{}
+	if err := web.Bind(c.Req, &apiCmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	dashboardId, err := strconv.ParseInt(web.Params(c.Req)[":dashboardId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "dashboardId is invalid", err)
+	}
+	if apiCmd.OursVersion == 0 && apiCmd.UnsavedDashboard == nil {
+		return response.Error(http.StatusBadRequest, "either oursVersion or unsavedDashboard is required", nil)
+	}
+
+	dash, rsp := getDashboardHelper(c.Req.Context(), c.OrgId, dashboardId, "")
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(c.Req.Context(), dash.Id, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	theirsVersion := apiCmd.TheirsVersion
+	if theirsVersion == 0 {
+		theirsVersion = dash.Version
+	}
+
+	options := dashdiffs.MergeOptions{
+		OrgId:  c.OrgId,
+		Base:   dashdiffs.DiffTarget{DashboardId: dash.Id, Version: apiCmd.BaseVersion},
+		Theirs: dashdiffs.DiffTarget{DashboardId: dash.Id, Version: theirsVersion},
+		Ours: dashdiffs.DiffTarget{
+			DashboardId:      dash.Id,
+			Version:          apiCmd.OursVersion,
+			UnsavedDashboard: apiCmd.UnsavedDashboard,
+		},
+	}
+
+	result, err := dashdiffs.MergeDashboardVersions(c.Req.Context(), &options)
+	if err != nil {
+		if errors.Is(err, models.ErrDashboardVersionNotFound) {
+			return response.Error(404, "Dashboard version not found", err)
+		}
+		return response.Error(500, "Unable to merge dashboard versions", err)
+	}
+
+	return response.JSON(200, result)
+}
+
 func GetDashboardTags(c *models.ReqContext) {
 	query := models.GetDashboardTagsQuery{OrgId: c.OrgId}
 	err := bus.Dispatch(c.Req.Context(), &query)
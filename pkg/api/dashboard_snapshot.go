@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -19,6 +20,9 @@ import (
 	"github.com/grafana/grafana/pkg/web"
 )
 
+// snapshotPasswordHeader carries the password of a password protected snapshot.
+const snapshotPasswordHeader = "X-Grafana-Snapshot-Password"
+
 var client = &http.Client{
 	Timeout:   time.Second * 5,
 	Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
@@ -84,6 +88,9 @@ func CreateDashboardSnapshot(c *models.ReqContext) response.Response {
 	if cmd.Name == "" {
 		cmd.Name = "Unnamed snapshot"
 	}
+	if cmd.MaxViews < 0 {
+		return response.Error(http.StatusBadRequest, models.ErrDashboardSnapshotInvalidMaxViews.Error(), nil)
+	}
 
 	var url string
 	cmd.ExternalUrl = ""
@@ -96,6 +103,11 @@ func CreateDashboardSnapshot(c *models.ReqContext) response.Response {
 			return nil
 		}
 
+		if cmd.Password != "" || cmd.MaxViews > 0 {
+			c.JsonApiErr(400, "Password protection and view limits are not supported for external snapshots", nil)
+			return nil
+		}
+
 		response, err := createExternalDashboardSnapshot(cmd)
 		if err != nil {
 			c.JsonApiErr(500, "Failed to create external snapshot", err)
@@ -170,6 +182,20 @@ func GetDashboardSnapshot(c *models.ReqContext) response.Response {
 		return response.Error(404, "Dashboard snapshot not found", err)
 	}
 
+	accessCmd := &models.AccessDashboardSnapshotCommand{
+		Snapshot:  snapshot,
+		Password:  c.Req.Header.Get(snapshotPasswordHeader),
+		ClientIp:  c.RemoteAddr(),
+		UserAgent: c.Req.UserAgent(),
+	}
+	if c.SignedInUser != nil {
+		accessCmd.UserId = c.SignedInUser.UserId
+		accessCmd.Login = c.SignedInUser.Login
+	}
+	if err := bus.Dispatch(c.Req.Context(), accessCmd); err != nil {
+		return snapshotAccessErrorResponse(err)
+	}
+
 	dto := dtos.DashboardFullWithMeta{
 		Dashboard: snapshot.Dashboard,
 		Meta: dtos.DashboardMeta{
@@ -182,9 +208,67 @@ func GetDashboardSnapshot(c *models.ReqContext) response.Response {
 
 	metrics.MApiDashboardSnapshotGet.Inc()
 
+	// restricted snapshots must not be served from a cache, so that every
+	// view is checked and counted
+	if snapshot.IsPasswordProtected() || snapshot.MaxViews > 0 {
+		return response.JSON(200, dto).SetHeader("Cache-Control", "private, no-store")
+	}
+
 	return response.JSON(200, dto).SetHeader("Cache-Control", "public, max-age=3600")
 }
 
+func snapshotAccessErrorResponse(err error) response.Response {
+	switch {
+	case errors.Is(err, models.ErrDashboardSnapshotPasswordRequired),
+		errors.Is(err, models.ErrDashboardSnapshotInvalidPassword):
+		return response.Error(401, err.Error(), nil)
+	case errors.Is(err, models.ErrDashboardSnapshotTooManyAttempts):
+		return response.Error(429, err.Error(), nil)
+	case errors.Is(err, models.ErrDashboardSnapshotViewLimitReached):
+		return response.Error(410, err.Error(), nil)
+	case errors.Is(err, models.ErrDashboardSnapshotRevoked):
+		return response.Error(404, "Dashboard snapshot not found", nil)
+	}
+	return response.Error(500, "Failed to check dashboard snapshot access", err)
+}
+
+// GET /api/snapshots/:key/access-log
+func GetDashboardSnapshotAccessLog(c *models.ReqContext) response.Response {
+	key := web.Params(c.Req)[":key"]
+	if len(key) == 0 {
+		return response.Error(404, "Snapshot not found", nil)
+	}
+
+	query := &models.GetDashboardSnapshotQuery{Key: key}
+	if err := bus.Dispatch(c.Req.Context(), query); err != nil {
+		if errors.Is(err, models.ErrDashboardSnapshotNotFound) {
+			return response.Error(404, "Snapshot not found", nil)
+		}
+		return response.Error(500, "Failed to get dashboard snapshot", err)
+	}
+
+	if query.Result.OrgId != c.OrgId {
+		return response.Error(404, "Snapshot not found", nil)
+	}
+
+	if query.Result.UserId != c.SignedInUser.UserId && c.OrgRole != models.ROLE_ADMIN {
+		return response.Error(403, "Access denied to this snapshot", nil)
+	}
+
+	logQuery := &models.GetDashboardSnapshotAccessLogQuery{
+		SnapshotId: query.Result.Id,
+		Limit:      c.QueryInt("limit"),
+	}
+	if logQuery.Limit <= 0 {
+		logQuery.Limit = 1000
+	}
+	if err := bus.Dispatch(c.Req.Context(), logQuery); err != nil {
+		return response.Error(500, "Failed to get snapshot access log", err)
+	}
+
+	return response.JSON(200, logQuery.Result)
+}
+
 func deleteExternalDashboardSnapshot(externalUrl string) error {
 	response, err := client.Get(externalUrl)
 	if err != nil {
@@ -322,16 +406,20 @@ func SearchDashboardSnapshots(c *models.ReqContext) response.Response {
 	dtos := make([]*models.DashboardSnapshotDTO, len(searchQuery.Result))
 	for i, snapshot := range searchQuery.Result {
 		dtos[i] = &models.DashboardSnapshotDTO{
-			Id:          snapshot.Id,
-			Name:        snapshot.Name,
-			Key:         snapshot.Key,
-			OrgId:       snapshot.OrgId,
-			UserId:      snapshot.UserId,
-			External:    snapshot.External,
-			ExternalUrl: snapshot.ExternalUrl,
-			Expires:     snapshot.Expires,
-			Created:     snapshot.Created,
-			Updated:     snapshot.Updated,
+			Id:                snapshot.Id,
+			Name:              snapshot.Name,
+			Key:               snapshot.Key,
+			OrgId:             snapshot.OrgId,
+			UserId:            snapshot.UserId,
+			External:          snapshot.External,
+			ExternalUrl:       snapshot.ExternalUrl,
+			PasswordProtected: len(snapshot.PasswordEncrypted) > 0,
+			MaxViews:          snapshot.MaxViews,
+			ViewCount:         snapshot.ViewCount,
+			Revoked:           snapshot.Revoked,
+			Expires:           snapshot.Expires,
+			Created:           snapshot.Created,
+			Updated:           snapshot.Updated,
 		}
 	}
 
@@ -54,6 +54,10 @@ func TestDashboardSnapshotAPIEndpoint_singleSnapshot(t *testing.T) {
 			return nil
 		})
 
+		bus.AddHandler("test", func(ctx context.Context, cmd *models.AccessDashboardSnapshotCommand) error {
+			return nil
+		})
+
 		bus.AddHandler("test", func(ctx context.Context, query *models.GetDashboardAclInfoListQuery) error {
 			query.Result = aclMockResp
 			return nil
@@ -248,5 +252,34 @@ func TestDashboardSnapshotAPIEndpoint_singleSnapshot(t *testing.T) {
 
 				assert.Equal(t, int64(100), id.MustInt64())
 			})
+
+		loggedInUserScenarioWithRole(t, "Should not be able to read a password protected snapshot without password when calling GET on",
+			"GET", "/api/snapshots/12345", "/api/snapshots/:key", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				setUpSnapshotTest(t)
+				bus.AddHandler("test", func(ctx context.Context, cmd *models.AccessDashboardSnapshotCommand) error {
+					if cmd.Password == "" {
+						return models.ErrDashboardSnapshotPasswordRequired
+					}
+					return nil
+				})
+
+				sc.handlerFunc = GetDashboardSnapshot
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{"key": "12345"}).exec()
+
+				assert.Equal(t, 401, sc.resp.Code)
+			})
+
+		loggedInUserScenarioWithRole(t, "Should not be able to read a snapshot past its view limit when calling GET on",
+			"GET", "/api/snapshots/12345", "/api/snapshots/:key", models.ROLE_EDITOR, func(sc *scenarioContext) {
+				setUpSnapshotTest(t)
+				bus.AddHandler("test", func(ctx context.Context, cmd *models.AccessDashboardSnapshotCommand) error {
+					return models.ErrDashboardSnapshotViewLimitReached
+				})
+
+				sc.handlerFunc = GetDashboardSnapshot
+				sc.fakeReqWithParams("GET", sc.url, map[string]string{"key": "12345"}).exec()
+
+				assert.Equal(t, 410, sc.resp.Code)
+			})
 	})
 }
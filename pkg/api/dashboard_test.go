@@ -936,6 +936,61 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 			})
 	})
 
+	t.Run("Given concurrent edits of a dashboard being merged", func(t *testing.T) {
+		setUp := func() {
+			fakeDash := models.NewDashboard("Dash")
+			fakeDash.Id = 2
+			fakeDash.Version = 3
+			fakeDash.HasAcl = false
+
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetDashboardQuery) error {
+				query.Result = fakeDash
+				return nil
+			})
+
+			versions := map[int]string{
+				1: `{"title": "Dash", "version": 1, "panels": [{"id": 1, "title": "A"}, {"id": 2, "title": "B"}]}`,
+				2: `{"title": "Dash", "version": 2, "panels": [{"id": 1, "title": "A2"}, {"id": 2, "title": "B"}]}`,
+				3: `{"title": "Dash", "version": 3, "panels": [{"id": 1, "title": "A"}, {"id": 2, "title": "B3"}]}`,
+			}
+			bus.AddHandler("test", func(ctx context.Context, query *models.GetDashboardVersionQuery) error {
+				raw, ok := versions[query.Version]
+				if !ok {
+					return models.ErrDashboardVersionNotFound
+				}
+				data, err := simplejson.NewJson([]byte(raw))
+				require.NoError(t, err)
+				query.Result = &models.DashboardVersion{DashboardId: 2, Version: query.Version, Data: data}
+				return nil
+			})
+		}
+
+		cmd := dtos.MergeDashboardVersionsCommand{BaseVersion: 1, OursVersion: 2}
+		mergeDashboardVersionsScenario(t, "When calling POST on", "/api/dashboards/id/2/merge",
+			"/api/dashboards/id/:dashboardId/merge", cmd, func(sc *scenarioContext) {
+				setUp()
+
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+				require.Equal(t, 200, sc.resp.Code)
+
+				result := sc.ToJSON()
+				assert.Empty(t, result.Get("conflicts").MustArray())
+				dash := result.Get("dashboard")
+				assert.Equal(t, 3, dash.Get("version").MustInt())
+				assert.Equal(t, "A2", dash.Get("panels").GetIndex(0).Get("title").MustString())
+				assert.Equal(t, "B3", dash.Get("panels").GetIndex(1).Get("title").MustString())
+			})
+
+		cmd = dtos.MergeDashboardVersionsCommand{BaseVersion: 7, OursVersion: 2}
+		mergeDashboardVersionsScenario(t, "When calling POST with an unknown base version on", "/api/dashboards/id/2/merge",
+			"/api/dashboards/id/:dashboardId/merge", cmd, func(sc *scenarioContext) {
+				setUp()
+
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+				assert.Equal(t, 404, sc.resp.Code)
+			})
+	})
+
 	t.Run("Given provisioned dashboard", func(t *testing.T) {
 		setUp := func() {
 			bus.AddHandler("test", func(ctx context.Context, query *models.GetDashboardsBySlugQuery) error {
@@ -1228,6 +1283,30 @@ func restoreDashboardVersionScenario(t *testing.T, desc string, url string, rout
 	})
 }
 
+func mergeDashboardVersionsScenario(t *testing.T, desc string, url string, routePattern string,
+	cmd dtos.MergeDashboardVersionsCommand, fn scenarioFunc) {
+	t.Run(fmt.Sprintf("%s %s", desc, url), func(t *testing.T) {
+		defer bus.ClearBusHandlers()
+
+		sc := setupScenarioContext(t, url)
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			c.Req.Body = mockRequestBody(cmd)
+			sc.context = c
+			sc.context.SignedInUser = &models.SignedInUser{
+				OrgId:  testOrgID,
+				UserId: testUserID,
+			}
+			sc.context.OrgRole = models.ROLE_ADMIN
+
+			return MergeDashboardVersions(c)
+		})
+
+		sc.m.Post(routePattern, sc.defaultHandler)
+
+		fn(sc)
+	})
+}
+
 func (sc *scenarioContext) ToJSON() *simplejson.Json {
 	var result *simplejson.Json
 	err := json.NewDecoder(sc.resp.Body).Decode(&result)
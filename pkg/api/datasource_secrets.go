@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// GET /api/datasources/uid/:uid/secrets
+func (hs *HTTPServer) GetDataSourceSecretRotation(c *models.ReqContext) response.Response {
+	ds, errResp := getDataSourceForSecrets(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	status, err := hs.DataSourcesService.SecretRotationStatus(c.Req.Context(), ds)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get data source secrets", err)
+	}
+
+	return response.JSON(http.StatusOK, status)
+}
+
+// PUT /api/datasources/uid/:uid/secrets/staged
+func (hs *HTTPServer) StageDataSourceSecrets(c *models.ReqContext) response.Response {
+	cmd := dtos.StageDataSourceSecretsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	ds, errResp := getDataSourceForSecrets(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	if err := hs.DataSourcesService.StageSecrets(c.Req.Context(), c.SignedInUser, ds, cmd.SecureJsonData); err != nil {
+		return toSecretRotationError(err, "Failed to stage data source secrets")
+	}
+
+	return response.Success("Data source secrets staged")
+}
+
+// DELETE /api/datasources/uid/:uid/secrets/staged
+func (hs *HTTPServer) DiscardStagedDataSourceSecrets(c *models.ReqContext) response.Response {
+	ds, errResp := getDataSourceForSecrets(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	if err := hs.DataSourcesService.DiscardStagedSecrets(c.Req.Context(), c.SignedInUser, ds); err != nil {
+		return toSecretRotationError(err, "Failed to discard data source secrets")
+	}
+
+	return response.Success("Staged data source secrets discarded")
+}
+
+// POST /api/datasources/uid/:uid/secrets/staged/test
+func (hs *HTTPServer) TestStagedDataSourceSecrets(c *models.ReqContext) response.Response {
+	ds, errResp := getDataSourceForSecrets(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	_, resp, errResp := hs.checkStagedSecrets(c, ds)
+	if errResp != nil {
+		return errResp
+	}
+
+	return healthCheckResponse(resp)
+}
+
+// POST /api/datasources/uid/:uid/secrets/promote
+//
+// The staged secrets are health checked first, and only promoted when the
+// data source is healthy with them.
+func (hs *HTTPServer) PromoteStagedDataSourceSecrets(c *models.ReqContext) response.Response {
+	ds, errResp := getDataSourceForSecrets(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	tested, resp, errResp := hs.checkStagedSecrets(c, ds)
+	if errResp != nil {
+		return errResp
+	}
+	if resp.Status != backend.HealthStatusOk {
+		return response.Error(http.StatusBadRequest, "Staged data source secrets failed the health check: "+resp.Message, nil)
+	}
+
+	if err := hs.DataSourcesService.PromoteStagedSecrets(c.Req.Context(), c.SignedInUser, ds, tested); err != nil {
+		return toSecretRotationError(err, "Failed to promote data source secrets")
+	}
+
+	return response.Success("Data source secrets promoted")
+}
+
+// POST /api/datasources/uid/:uid/secrets/rollback
+func (hs *HTTPServer) RollbackDataSourceSecrets(c *models.ReqContext) response.Response {
+	ds, errResp := getDataSourceForSecrets(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	if err := hs.DataSourcesService.RollbackSecrets(c.Req.Context(), c.SignedInUser, ds); err != nil {
+		return toSecretRotationError(err, "Failed to roll back data source secrets")
+	}
+
+	return response.Success("Data source secrets rolled back")
+}
+
+// GET /api/datasources/uid/:uid/secrets/audit
+func (hs *HTTPServer) GetDataSourceSecretAudit(c *models.ReqContext) response.Response {
+	ds, errResp := getDataSourceForSecrets(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	limit := c.QueryInt("limit")
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	entries, err := hs.DataSourcesService.SecretAuditTrail(c.Req.Context(), ds, limit)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get data source secrets audit", err)
+	}
+
+	return response.JSON(http.StatusOK, entries)
+}
+
+// checkStagedSecrets health checks the data source with its staged secrets
+// and records the outcome in the audit trail.
+func (hs *HTTPServer) checkStagedSecrets(c *models.ReqContext, ds *models.DataSource) (*models.DataSource, *backend.CheckHealthResult, response.Response) {
+	staged, err := hs.DataSourcesService.WithStagedSecrets(c.Req.Context(), ds)
+	if err != nil {
+		return nil, nil, toSecretRotationError(err, "Failed to get staged data source secrets")
+	}
+
+	resp, errResp := hs.checkDatasourceHealth(c, staged)
+	if errResp != nil {
+		return nil, nil, errResp
+	}
+
+	success := resp.Status == backend.HealthStatusOk
+	if err := hs.DataSourcesService.RecordSecretTest(c.Req.Context(), c.SignedInUser, ds, success, resp.Message); err != nil {
+		return nil, nil, response.Error(http.StatusInternalServerError, "Failed to record data source secrets test", err)
+	}
+
+	return staged, resp, nil
+}
+
+func getDataSourceForSecrets(c *models.ReqContext) (*models.DataSource, response.Response) {
+	ds, err := getRawDataSourceByUID(c.Req.Context(), web.Params(c.Req)[":uid"], c.OrgId)
+	if err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return nil, response.Error(http.StatusNotFound, "Data source not found", nil)
+		}
+		return nil, response.Error(http.StatusInternalServerError, "Failed to query datasource", err)
+	}
+
+	return ds, nil
+}
+
+func toSecretRotationError(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, datasources.ErrNoStagedSecrets), errors.Is(err, datasources.ErrNoPreviousSecrets):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, datasources.ErrEmptySecrets):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, models.ErrDatasourceIsReadOnly):
+		return response.Error(http.StatusForbidden, "Cannot update read-only data source", nil)
+	case errors.Is(err, models.ErrDataSourceNotFound):
+		return response.Error(http.StatusNotFound, "Data source not found", nil)
+	case errors.Is(err, datasources.ErrSecretsChanged), errors.Is(err, models.ErrDataSourceUpdatingOldVersion):
+		return response.Error(http.StatusConflict, "Data source has been updated by someone else. Please test the secrets again", err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
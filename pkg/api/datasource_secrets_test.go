@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSourceSecretRotation(t *testing.T) {
+	type testContext struct {
+		hs       *HTTPServer
+		client   *fakeHealthCheckClient
+		ds       *models.DataSource
+		password func() string
+	}
+
+	setup := func(t *testing.T) *testContext {
+		ctx := context.Background()
+		sqlStore := sqlstore.InitTestDB(t)
+		secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+		dsService := datasources.ProvideService(bus.New(), sqlStore, secretsService, &acmock.Mock{})
+
+		cmd := &models.AddDataSourceCommand{
+			OrgId:          testOrgID,
+			Name:           "postgres",
+			Uid:            "postgres",
+			Type:           "postgres",
+			SecureJsonData: map[string]string{"password": "old"},
+		}
+		require.NoError(t, dsService.AddDataSource(ctx, cmd))
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetDataSourceQuery) error {
+			return sqlStore.GetDataSource(ctx, query)
+		})
+
+		client := &fakeHealthCheckClient{passwords: map[string]backend.HealthStatus{"new": backend.HealthStatusOk}}
+		return &testContext{
+			hs: &HTTPServer{
+				Cfg:                    setting.NewCfg(),
+				DataSourcesService:     dsService,
+				SecretsService:         secretsService,
+				PluginRequestValidator: &testPluginRequestValidator{},
+				pluginStore: &fakePluginStore{plugins: map[string]plugins.PluginDTO{
+					"postgres": {JSONData: plugins.JSONData{ID: "postgres", Backend: true}},
+				}},
+				pluginClient: client,
+				log:          log.New("test"),
+			},
+			client: client,
+			ds:     cmd.Result,
+			password: func() string {
+				query := &models.GetDataSourceQuery{OrgId: testOrgID, Uid: "postgres"}
+				require.NoError(t, sqlStore.GetDataSource(ctx, query))
+				decrypted, err := secretsService.DecryptJsonData(ctx, query.Result.SecureJsonData)
+				require.NoError(t, err)
+				return decrypted["password"]
+			},
+		}
+	}
+
+	stage := func(t *testing.T, tc *testContext, password string) {
+		user := &models.SignedInUser{OrgId: testOrgID, UserId: testUserID, Login: testUserLogin}
+		require.NoError(t, tc.hs.DataSourcesService.StageSecrets(context.Background(), user, tc.ds, map[string]string{"password": password}))
+	}
+
+	auditTrail := func(t *testing.T, tc *testContext) []*datasources.DataSourceSecretAudit {
+		entries, err := tc.hs.DataSourcesService.SecretAuditTrail(context.Background(), tc.ds, 100)
+		require.NoError(t, err)
+		return entries
+	}
+
+	loggedInUserScenarioWithRole(t, "Should stage secrets without changing the current ones when calling PUT on", "PUT",
+		"/api/datasources/uid/postgres/secrets/staged", "/api/datasources/uid/:uid/secrets/staged", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			tc := setup(sc.t)
+			sc.handlerFunc = func(c *models.ReqContext) response.Response {
+				c.Req.Body = mockRequestBody(dtos.StageDataSourceSecretsCommand{SecureJsonData: map[string]string{"password": "new"}})
+				return tc.hs.StageDataSourceSecrets(c)
+			}
+			sc.fakeReqWithParams("PUT", sc.url, map[string]string{}).exec()
+			require.Equal(sc.t, http.StatusOK, sc.resp.Code)
+			assert.Equal(sc.t, "old", tc.password())
+
+			entries := auditTrail(sc.t, tc)
+			require.Len(sc.t, entries, 1)
+			assert.Equal(sc.t, datasources.SecretActionStage, entries[0].Action)
+		})
+
+	loggedInUserScenarioWithRole(t, "Should list the staged secrets without their values when calling GET on", "GET",
+		"/api/datasources/uid/postgres/secrets", "/api/datasources/uid/:uid/secrets", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			tc := setup(sc.t)
+			stage(sc.t, tc, "new")
+			sc.handlerFunc = tc.hs.GetDataSourceSecretRotation
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+			require.Equal(sc.t, http.StatusOK, sc.resp.Code)
+			assert.NotContains(sc.t, sc.resp.Body.String(), "new")
+
+			var status datasources.SecretRotationStatus
+			require.NoError(sc.t, json.NewDecoder(sc.resp.Body).Decode(&status))
+			require.NotNil(sc.t, status.Staged)
+			assert.Equal(sc.t, []string{"password"}, status.Staged.Fields)
+			assert.Nil(sc.t, status.Previous)
+		})
+
+	loggedInUserScenarioWithRole(t, "Should promote staged secrets that pass the health check when calling POST on", "POST",
+		"/api/datasources/uid/postgres/secrets/promote", "/api/datasources/uid/:uid/secrets/promote", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			tc := setup(sc.t)
+			stage(sc.t, tc, "new")
+			sc.handlerFunc = tc.hs.PromoteStagedDataSourceSecrets
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			require.Equal(sc.t, http.StatusOK, sc.resp.Code)
+			assert.Equal(sc.t, "new", tc.password())
+
+			// The staged secrets are checked with a plugin instance of their own.
+			require.NotNil(sc.t, tc.client.checked)
+			assert.Equal(sc.t, -tc.ds.Id, tc.client.checked.DataSourceInstanceSettings.ID)
+
+			entries := auditTrail(sc.t, tc)
+			require.Len(sc.t, entries, 3)
+			assert.Equal(sc.t, datasources.SecretActionPromote, entries[0].Action)
+			assert.True(sc.t, entries[0].Success)
+		})
+
+	loggedInUserScenarioWithRole(t, "Should not promote staged secrets that fail the health check when calling POST on", "POST",
+		"/api/datasources/uid/postgres/secrets/promote", "/api/datasources/uid/:uid/secrets/promote", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			tc := setup(sc.t)
+			stage(sc.t, tc, "wrong")
+			sc.handlerFunc = tc.hs.PromoteStagedDataSourceSecrets
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			require.Equal(sc.t, http.StatusBadRequest, sc.resp.Code)
+			assert.Equal(sc.t, "old", tc.password())
+
+			entries := auditTrail(sc.t, tc)
+			require.Len(sc.t, entries, 2)
+			assert.Equal(sc.t, datasources.SecretActionTest, entries[0].Action)
+			assert.False(sc.t, entries[0].Success)
+		})
+
+	loggedInUserScenarioWithRole(t, "Should record a failed rollback without previous secrets when calling POST on", "POST",
+		"/api/datasources/uid/postgres/secrets/rollback", "/api/datasources/uid/:uid/secrets/rollback", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			tc := setup(sc.t)
+			sc.handlerFunc = tc.hs.RollbackDataSourceSecrets
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			require.Equal(sc.t, http.StatusNotFound, sc.resp.Code)
+
+			entries := auditTrail(sc.t, tc)
+			require.Len(sc.t, entries, 1)
+			assert.Equal(sc.t, datasources.SecretActionRollback, entries[0].Action)
+			assert.False(sc.t, entries[0].Success)
+		})
+
+	loggedInUserScenarioWithRole(t, "Should return 404 for unknown data sources when calling DELETE on", "DELETE",
+		"/api/datasources/uid/unknown/secrets/staged", "/api/datasources/uid/:uid/secrets/staged", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			tc := setup(sc.t)
+			sc.handlerFunc = tc.hs.DiscardStagedDataSourceSecrets
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+			require.Equal(sc.t, http.StatusNotFound, sc.resp.Code)
+		})
+}
+
+// fakeHealthCheckClient reports data sources as healthy with the passwords
+// it knows.
+type fakeHealthCheckClient struct {
+	plugins.Client
+
+	passwords map[string]backend.HealthStatus
+	checked   *backend.PluginContext
+}
+
+func (c *fakeHealthCheckClient) CheckHealth(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	c.checked = &req.PluginContext
+	settings := req.PluginContext.DataSourceInstanceSettings
+	if settings == nil {
+		return nil, errors.New("no data source")
+	}
+	if status, ok := c.passwords[settings.DecryptedSecureJSONData["password"]]; ok {
+		return &backend.CheckHealthResult{Status: status, Message: "Database Connection OK"}, nil
+	}
+	return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: "password authentication failed"}, nil
+}
+
+type testPluginRequestValidator struct{}
+
+func (testPluginRequestValidator) Validate(string, *http.Request) error {
+	return nil
+}
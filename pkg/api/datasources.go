@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -36,6 +37,11 @@ func (hs *HTTPServer) GetDataSources(c *models.ReqContext) response.Response {
 		return response.Error(500, "Failed to query datasources", err)
 	}
 
+	health, err := hs.latestDataSourceHealthChecks(c)
+	if err != nil {
+		return response.Error(500, "Failed to query datasource health", err)
+	}
+
 	result := make(dtos.DataSourceList, 0)
 	for _, ds := range filtered {
 		dsItem := dtos.DataSourceListItemDTO{
@@ -56,6 +62,14 @@ func (hs *HTTPServer) GetDataSources(c *models.ReqContext) response.Response {
 			ReadOnly:  ds.ReadOnly,
 		}
 
+		if check, ok := health[ds.Id]; ok {
+			dsItem.Health = &dtos.DataSourceHealthDTO{
+				Status:  check.Status,
+				Message: check.Message,
+				Checked: check.Created,
+			}
+		}
+
 		if plugin, exists := hs.pluginStore.Plugin(c.Req.Context(), ds.Type); exists {
 			dsItem.TypeLogoUrl = plugin.Info.Logos.Small
 			dsItem.TypeName = plugin.Name
@@ -71,6 +85,15 @@ func (hs *HTTPServer) GetDataSources(c *models.ReqContext) response.Response {
 	return response.JSON(200, &result)
 }
 
+// latestDataSourceHealthChecks returns the latest scheduled health checks of
+// the data sources of the organization, by data source ID.
+func (hs *HTTPServer) latestDataSourceHealthChecks(c *models.ReqContext) (map[int64]*datasourcehealth.DataSourceHealthCheck, error) {
+	if hs.DataSourceHealthService == nil || !hs.DataSourceHealthService.IsEnabled() {
+		return nil, nil
+	}
+	return hs.DataSourceHealthService.LatestChecks(c.Req.Context(), c.OrgId)
+}
+
 func (hs *HTTPServer) getDataSourceAccessControlMetadata(c *models.ReqContext, dsID int64) (accesscontrol.Metadata, error) {
 	if hs.AccessControl.IsDisabled() || !c.QueryBool("accesscontrol") {
 		return nil, nil
@@ -147,6 +170,11 @@ func (hs *HTTPServer) DeleteDataSourceById(c *models.ReqContext) response.Respon
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	warning := hs.dataSourceUsageWarning(ds)
+	if c.QueryBool("dryRun") {
+		return dataSourceDeleteDryRun(ds, warning)
+	}
+
 	cmd := &models.DeleteDataSourceCommand{ID: id, OrgID: c.OrgId}
 
 	err = bus.Dispatch(c.Req.Context(), cmd)
@@ -156,6 +184,13 @@ func (hs *HTTPServer) DeleteDataSourceById(c *models.ReqContext) response.Respon
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, ds.Uid)
 
+	if warning != "" {
+		return response.JSON(200, util.DynMap{
+			"message": "Data source deleted",
+			"warning": warning,
+		})
+	}
+
 	return response.Success("Data source deleted")
 }
 
@@ -187,6 +222,36 @@ func (hs *HTTPServer) GetDataSourceByUID(c *models.ReqContext) response.Response
 	return response.JSON(200, &dto)
 }
 
+// GET /api/datasources/uid/:uid/health/history
+func (hs *HTTPServer) GetDataSourceHealthHistory(c *models.ReqContext) response.Response {
+	ds, err := getRawDataSourceByUID(c.Req.Context(), web.Params(c.Req)[":uid"], c.OrgId)
+	if err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return response.Error(http.StatusNotFound, "Data source not found", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to query datasource", err)
+	}
+
+	filtered, err := filterDatasourcesByQueryPermission(c.Req.Context(), c.SignedInUser, []*models.DataSource{ds})
+	if err != nil || len(filtered) != 1 {
+		return response.Error(404, "Data source not found", err)
+	}
+
+	checks := make([]*datasourcehealth.DataSourceHealthCheck, 0)
+	if hs.DataSourceHealthService != nil {
+		limit := c.QueryInt("limit")
+		if limit <= 0 || limit > 1000 {
+			limit = 100
+		}
+		checks, err = hs.DataSourceHealthService.History(c.Req.Context(), c.OrgId, ds.Id, limit)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to query datasource health", err)
+		}
+	}
+
+	return response.JSON(200, checks)
+}
+
 // DELETE /api/datasources/uid/:uid
 func (hs *HTTPServer) DeleteDataSourceByUID(c *models.ReqContext) response.Response {
 	uid := web.Params(c.Req)[":uid"]
@@ -207,6 +272,11 @@ func (hs *HTTPServer) DeleteDataSourceByUID(c *models.ReqContext) response.Respo
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	warning := hs.dataSourceUsageWarning(ds)
+	if c.QueryBool("dryRun") {
+		return dataSourceDeleteDryRun(ds, warning)
+	}
+
 	cmd := &models.DeleteDataSourceCommand{UID: uid, OrgID: c.OrgId}
 
 	err = bus.Dispatch(c.Req.Context(), cmd)
@@ -216,10 +286,15 @@ func (hs *HTTPServer) DeleteDataSourceByUID(c *models.ReqContext) response.Respo
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, ds.Uid)
 
-	return response.JSON(200, util.DynMap{
+	result := util.DynMap{
 		"message": "Data source deleted",
 		"id":      ds.Id,
-	})
+	}
+	if warning != "" {
+		result["warning"] = warning
+	}
+
+	return response.JSON(200, result)
 }
 
 // DELETE /api/datasources/name/:name
@@ -242,6 +317,11 @@ func (hs *HTTPServer) DeleteDataSourceByName(c *models.ReqContext) response.Resp
 		return response.Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	warning := hs.dataSourceUsageWarning(getCmd.Result)
+	if c.QueryBool("dryRun") {
+		return dataSourceDeleteDryRun(getCmd.Result, warning)
+	}
+
 	cmd := &models.DeleteDataSourceCommand{Name: name, OrgID: c.OrgId}
 	err := bus.Dispatch(c.Req.Context(), cmd)
 	if err != nil {
@@ -250,10 +330,45 @@ func (hs *HTTPServer) DeleteDataSourceByName(c *models.ReqContext) response.Resp
 
 	hs.Live.HandleDatasourceDelete(c.OrgId, getCmd.Result.Uid)
 
-	return response.JSON(200, util.DynMap{
+	result := util.DynMap{
 		"message": "Data source deleted",
 		"id":      getCmd.Result.Id,
-	})
+	}
+	if warning != "" {
+		result["warning"] = warning
+	}
+
+	return response.JSON(200, result)
+}
+
+// dataSourceUsageWarning returns a warning when dashboards still reference
+// the data source that is about to be deleted.
+func (hs *HTTPServer) dataSourceUsageWarning(ds *models.DataSource) string {
+	if hs.DashboardRefsService == nil {
+		return ""
+	}
+
+	ids := hs.DashboardRefsService.DashboardsUsingDataSource(ds)
+	if len(ids) == 0 {
+		return ""
+	}
+
+	datasourcesLogger.Warn("Deleting data source that is still in use", "uid", ds.Uid, "dashboards", len(ids))
+	return fmt.Sprintf("Data source is still used by %d dashboard(s)", len(ids))
+}
+
+// dataSourceDeleteDryRun answers a delete request with dryRun set, so that the
+// usage warning can be shown before the data source is actually deleted.
+func dataSourceDeleteDryRun(ds *models.DataSource, warning string) response.Response {
+	result := util.DynMap{
+		"message": "Data source not deleted, dry run",
+		"id":      ds.Id,
+	}
+	if warning != "" {
+		result["warning"] = warning
+	}
+
+	return response.JSON(200, result)
 }
 
 func validateURL(tp string, u string) response.Response {
@@ -529,14 +644,24 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) response.Respo
 		return response.Error(http.StatusInternalServerError, "Unable to load datasource metadata", err)
 	}
 
+	resp, errResp := hs.checkDatasourceHealth(c, ds)
+	if errResp != nil {
+		return errResp
+	}
+
+	return healthCheckResponse(resp)
+}
+
+// checkDatasourceHealth calls the health check of the plugin of a data source.
+func (hs *HTTPServer) checkDatasourceHealth(c *models.ReqContext, ds *models.DataSource) (*backend.CheckHealthResult, response.Response) {
 	plugin, exists := hs.pluginStore.Plugin(c.Req.Context(), ds.Type)
 	if !exists {
-		return response.Error(http.StatusInternalServerError, "Unable to find datasource plugin", err)
+		return nil, response.Error(http.StatusInternalServerError, "Unable to find datasource plugin", nil)
 	}
 
 	dsInstanceSettings, err := adapters.ModelToInstanceSettings(ds, hs.decryptSecureJsonDataFn())
 	if err != nil {
-		return response.Error(http.StatusInternalServerError, "Unable to get datasource model", err)
+		return nil, response.Error(http.StatusInternalServerError, "Unable to get datasource model", err)
 	}
 	req := &backend.CheckHealthRequest{
 		PluginContext: backend.PluginContext{
@@ -554,14 +679,18 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) response.Respo
 
 	err = hs.PluginRequestValidator.Validate(dsURL, c.Req)
 	if err != nil {
-		return response.Error(http.StatusForbidden, "Access denied", err)
+		return nil, response.Error(http.StatusForbidden, "Access denied", err)
 	}
 
 	resp, err := hs.pluginClient.CheckHealth(c.Req.Context(), req)
 	if err != nil {
-		return translatePluginRequestErrorToAPIError(err)
+		return nil, translatePluginRequestErrorToAPIError(err)
 	}
 
+	return resp, nil
+}
+
+func healthCheckResponse(resp *backend.CheckHealthResult) response.Response {
 	payload := map[string]interface{}{
 		"status":  resp.Status.String(),
 		"message": resp.Message,
@@ -570,7 +699,7 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) response.Respo
 	// Unmarshal JSONDetails if it's not empty.
 	if len(resp.JSONDetails) > 0 {
 		var jsonDetails map[string]interface{}
-		err = json.Unmarshal(resp.JSONDetails, &jsonDetails)
+		err := json.Unmarshal(resp.JSONDetails, &jsonDetails)
 		if err != nil {
 			return response.Error(http.StatusInternalServerError, "Failed to unmarshal detailed response from backend plugin", err)
 		}
@@ -13,8 +13,12 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboardrefs"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -568,3 +572,76 @@ func TestAPI_Datasources_AccessControl(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteDataSource_UsageWarning(t *testing.T) {
+	setup := func(t *testing.T) (*HTTPServer, *int) {
+		sqlStore := sqlstore.InitTestDB(t)
+		refs := dashboardrefs.ProvideService(setting.NewCfg(), sqlStore, bus.GetBus(), routing.NewRouteRegister())
+		_, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId: testOrgID,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"title":  "Uses Prometheus",
+				"panels": []interface{}{map[string]interface{}{"datasource": "Prometheus"}},
+			}),
+		})
+		require.NoError(t, err)
+
+		bus.AddHandler("test", func(ctx context.Context, query *models.GetDataSourceQuery) error {
+			query.Result = &models.DataSource{Id: 1, Uid: "ds", OrgId: testOrgID, Name: query.Name}
+			return nil
+		})
+		deleted := 0
+		bus.AddHandler("test", func(ctx context.Context, cmd *models.DeleteDataSourceCommand) error {
+			deleted++
+			return nil
+		})
+
+		return &HTTPServer{
+			Bus:                  bus.GetBus(),
+			Cfg:                  setting.NewCfg(),
+			Live:                 &live.GrafanaLive{},
+			DashboardRefsService: refs,
+		}, &deleted
+	}
+
+	loggedInUserScenarioWithRole(t, "Should warn about dashboards using the data source without deleting it when calling DELETE with dryRun on", "DELETE",
+		"/api/datasources/name/Prometheus", "/api/datasources/name/:name", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			hs, deleted := setup(sc.t)
+			sc.handlerFunc = hs.DeleteDataSourceByName
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{"dryRun": "true"}).exec()
+			require.Equal(t, 200, sc.resp.Code)
+
+			var res map[string]interface{}
+			require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&res))
+			assert.Equal(t, "Data source not deleted, dry run", res["message"])
+			assert.Equal(t, "Data source is still used by 1 dashboard(s)", res["warning"])
+			assert.Zero(t, *deleted)
+		})
+
+	loggedInUserScenarioWithRole(t, "Should not warn when the data source is unused when calling DELETE with dryRun on", "DELETE",
+		"/api/datasources/name/Loki", "/api/datasources/name/:name", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			hs, deleted := setup(sc.t)
+			sc.handlerFunc = hs.DeleteDataSourceByName
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{"dryRun": "true"}).exec()
+			require.Equal(t, 200, sc.resp.Code)
+
+			var res map[string]interface{}
+			require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&res))
+			assert.NotContains(t, res, "warning")
+			assert.Zero(t, *deleted)
+		})
+
+	loggedInUserScenarioWithRole(t, "Should delete the data source and warn about dashboards using it when calling DELETE on", "DELETE",
+		"/api/datasources/name/Prometheus", "/api/datasources/name/:name", models.ROLE_ADMIN, func(sc *scenarioContext) {
+			hs, deleted := setup(sc.t)
+			sc.handlerFunc = hs.DeleteDataSourceByName
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+			require.Equal(t, 200, sc.resp.Code)
+
+			var res map[string]interface{}
+			require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&res))
+			assert.Equal(t, "Data source deleted", res["message"])
+			assert.Equal(t, "Data source is still used by 1 dashboard(s)", res["warning"])
+			assert.Equal(t, 1, *deleted)
+		})
+}
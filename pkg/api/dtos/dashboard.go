@@ -62,3 +62,10 @@ type CalculateDiffTarget struct {
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
 }
+
+type MergeDashboardVersionsCommand struct {
+	BaseVersion      int              `json:"baseVersion" binding:"Required"`
+	TheirsVersion    int              `json:"theirsVersion"`
+	OursVersion      int              `json:"oursVersion"`
+	UnsavedDashboard *simplejson.Json `json:"unsavedDashboard"`
+}
@@ -2,6 +2,7 @@ package dtos
 
 import (
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
@@ -33,22 +34,35 @@ type DataSource struct {
 }
 
 type DataSourceListItemDTO struct {
-	Id          int64            `json:"id"`
-	UID         string           `json:"uid"`
-	OrgId       int64            `json:"orgId"`
-	Name        string           `json:"name"`
-	Type        string           `json:"type"`
-	TypeName    string           `json:"typeName"`
-	TypeLogoUrl string           `json:"typeLogoUrl"`
-	Access      models.DsAccess  `json:"access"`
-	Url         string           `json:"url"`
-	Password    string           `json:"password"`
-	User        string           `json:"user"`
-	Database    string           `json:"database"`
-	BasicAuth   bool             `json:"basicAuth"`
-	IsDefault   bool             `json:"isDefault"`
-	JsonData    *simplejson.Json `json:"jsonData,omitempty"`
-	ReadOnly    bool             `json:"readOnly"`
+	Id          int64                `json:"id"`
+	UID         string               `json:"uid"`
+	OrgId       int64                `json:"orgId"`
+	Name        string               `json:"name"`
+	Type        string               `json:"type"`
+	TypeName    string               `json:"typeName"`
+	TypeLogoUrl string               `json:"typeLogoUrl"`
+	Access      models.DsAccess      `json:"access"`
+	Url         string               `json:"url"`
+	Password    string               `json:"password"`
+	User        string               `json:"user"`
+	Database    string               `json:"database"`
+	BasicAuth   bool                 `json:"basicAuth"`
+	IsDefault   bool                 `json:"isDefault"`
+	JsonData    *simplejson.Json     `json:"jsonData,omitempty"`
+	ReadOnly    bool                 `json:"readOnly"`
+	Health      *DataSourceHealthDTO `json:"health,omitempty"`
+}
+
+// StageDataSourceSecretsCommand holds the next secrets of a data source.
+type StageDataSourceSecretsCommand struct {
+	SecureJsonData map[string]string `json:"secureJsonData"`
+}
+
+// DataSourceHealthDTO is the latest scheduled health check of a data source.
+type DataSourceHealthDTO struct {
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	Checked time.Time `json:"checked"`
 }
 
 type DataSourceList []DataSourceListItemDTO
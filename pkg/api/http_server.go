@@ -33,6 +33,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/cleanup"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/dashboardrefs"
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/encryption"
@@ -126,6 +128,8 @@ type HTTPServer struct {
 	serviceAccountsService    serviceaccounts.Service
 	authInfoService           authinfoservice.Service
 	TeamPermissionsService    *resourcepermissions.Service
+	DashboardRefsService      *dashboardrefs.Service
+	DataSourceHealthService   *datasourcehealth.Service
 }
 
 type ServerOptions struct {
@@ -151,7 +155,8 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 	encryptionService encryption.Internal, updateChecker *updatechecker.Service, searchUsersService searchusers.Service,
 	dataSourcesService *datasources.Service, secretsService secrets.Service, queryDataService *query.Service,
 	teamGuardian teamguardian.TeamGuardian, serviceaccountsService serviceaccounts.Service,
-	authInfoService authinfoservice.Service, resourcePermissionServices *resourceservices.ResourceServices) (*HTTPServer, error) {
+	authInfoService authinfoservice.Service, resourcePermissionServices *resourceservices.ResourceServices,
+	dashboardRefsService *dashboardrefs.Service, dataSourceHealthService *datasourcehealth.Service) (*HTTPServer, error) {
 	web.Env = cfg.Env
 	m := web.New()
 
@@ -209,6 +214,8 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 		serviceAccountsService:    serviceaccountsService,
 		authInfoService:           authInfoService,
 		TeamPermissionsService:    resourcePermissionServices.GetTeamService(),
+		DashboardRefsService:      dashboardRefsService,
+		DataSourceHealthService:   dataSourceHealthService,
 	}
 	if hs.Listener != nil {
 		hs.log.Debug("Using provided listener")
@@ -18,6 +18,10 @@ func (hs *HTTPServer) handleQueryMetricsError(err error) *response.NormalRespons
 	if errors.Is(err, models.ErrDataSourceAccessDenied) {
 		return response.Error(http.StatusForbidden, "Access denied to data source", err)
 	}
+	var throttled query.ErrQueryThrottled
+	if errors.As(err, &throttled) {
+		return response.Error(http.StatusTooManyRequests, util.Capitalize(throttled.Error()), err)
+	}
 	var badQuery *query.ErrBadQuery
 	if errors.As(err, &badQuery) {
 		return response.Error(http.StatusBadRequest, util.Capitalize(badQuery.Message), err)
@@ -90,6 +94,11 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext) response.Response {
 func toJsonStreamingResponse(qdr *backend.QueryDataResponse) response.Response {
 	statusCode := http.StatusOK
 	for _, res := range qdr.Responses {
+		var throttled query.ErrQueryThrottled
+		if errors.As(res.Error, &throttled) {
+			statusCode = http.StatusTooManyRequests
+			break
+		}
 		if res.Error != nil {
 			statusCode = http.StatusBadRequest
 		}
@@ -0,0 +1,297 @@
+package dashdiffs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// mergeManagedKeys are dashboard properties owned by the server. They are
+// always taken from "theirs" so that the merged dashboard can be saved on top
+// of the latest version without a version mismatch.
+var mergeManagedKeys = map[string]bool{
+	"id":      true,
+	"uid":     true,
+	"version": true,
+}
+
+type MergeOptions struct {
+	OrgId  int64
+	Base   DiffTarget
+	Theirs DiffTarget
+	Ours   DiffTarget
+}
+
+// Conflict describes a value that was changed differently in "theirs" and
+// "ours". A missing value means the property was removed on that side.
+type Conflict struct {
+	Path   string      `json:"path"`
+	Base   interface{} `json:"base,omitempty"`
+	Theirs interface{} `json:"theirs,omitempty"`
+	Ours   interface{} `json:"ours,omitempty"`
+}
+
+type MergeResult struct {
+	// Dashboard holds the merged dashboard. Conflicting values are resolved
+	// in favour of "theirs" and listed in Conflicts.
+	Dashboard *simplejson.Json `json:"dashboard"`
+	Conflicts []Conflict       `json:"conflicts"`
+}
+
+// MergeDashboardVersions performs a three-way merge of two dashboard versions
+// ("theirs" and "ours") that were both derived from a common base version.
+func MergeDashboardVersions(ctx context.Context, options *MergeOptions) (*MergeResult, error) {
+	base, err := loadMergeTarget(ctx, options.OrgId, options.Base)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := loadMergeTarget(ctx, options.OrgId, options.Theirs)
+	if err != nil {
+		return nil, err
+	}
+	ours, err := loadMergeTarget(ctx, options.OrgId, options.Ours)
+	if err != nil {
+		return nil, err
+	}
+
+	return Merge(base, theirs, ours)
+}
+
+// Merge performs a three-way merge of dashboard models. Panels are matched by
+// id so that concurrent edits of different panels merge cleanly.
+func Merge(base, theirs, ours *simplejson.Json) (*MergeResult, error) {
+	baseMap, err := toMap(base)
+	if err != nil {
+		return nil, err
+	}
+	theirsMap, err := toMap(theirs)
+	if err != nil {
+		return nil, err
+	}
+	oursMap, err := toMap(ours)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &merger{conflicts: []Conflict{}}
+	merged := m.mergeObjects("", baseMap, theirsMap, oursMap)
+	for key := range mergeManagedKeys {
+		if v, ok := theirsMap[key]; ok {
+			merged[key] = v
+		}
+	}
+
+	return &MergeResult{
+		Dashboard: simplejson.NewFromAny(merged),
+		Conflicts: m.conflicts,
+	}, nil
+}
+
+func loadMergeTarget(ctx context.Context, orgID int64, target DiffTarget) (*simplejson.Json, error) {
+	if target.UnsavedDashboard != nil {
+		return target.UnsavedDashboard, nil
+	}
+
+	query := models.GetDashboardVersionQuery{
+		DashboardId: target.DashboardId,
+		Version:     target.Version,
+		OrgId:       orgID,
+	}
+	if err := bus.Dispatch(ctx, &query); err != nil {
+		return nil, err
+	}
+
+	return query.Result.Data, nil
+}
+
+// toMap normalizes a dashboard model into plain JSON values, so that numbers
+// compare equal regardless of how the model was constructed.
+func toMap(data *simplejson.Json) (map[string]interface{}, error) {
+	if data == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	raw, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type merger struct {
+	conflicts []Conflict
+}
+
+// value is a JSON value that may be absent.
+type value struct {
+	v       interface{}
+	present bool
+}
+
+func lookup(m map[string]interface{}, key string) value {
+	v, ok := m[key]
+	return value{v: v, present: ok}
+}
+
+func (a value) equal(b value) bool {
+	return a.present == b.present && reflect.DeepEqual(a.v, b.v)
+}
+
+func (m *merger) mergeObjects(path string, base, theirs, ours map[string]interface{}) map[string]interface{} {
+	keys := map[string]bool{}
+	for _, obj := range []map[string]interface{}{base, theirs, ours} {
+		for k := range obj {
+			keys[k] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	result := make(map[string]interface{}, len(keys))
+	for _, key := range sorted {
+		if path == "" && mergeManagedKeys[key] {
+			continue
+		}
+		merged := m.mergeValues(joinPath(path, key), key, lookup(base, key), lookup(theirs, key), lookup(ours, key))
+		if merged.present {
+			result[key] = merged.v
+		}
+	}
+
+	return result
+}
+
+func (m *merger) mergeValues(path, key string, base, theirs, ours value) value {
+	switch {
+	case theirs.equal(ours):
+		return theirs
+	case base.equal(theirs):
+		return ours
+	case base.equal(ours):
+		return theirs
+	}
+
+	// Both sides changed the value. Try to merge structurally before
+	// reporting a conflict.
+	if theirs.present && ours.present {
+		baseObj, _ := base.v.(map[string]interface{})
+		theirsObj, theirsIsObj := theirs.v.(map[string]interface{})
+		oursObj, oursIsObj := ours.v.(map[string]interface{})
+		if theirsIsObj && oursIsObj {
+			return value{v: m.mergeObjects(path, baseObj, theirsObj, oursObj), present: true}
+		}
+
+		if key == "panels" {
+			baseList, _ := base.v.([]interface{})
+			theirsList, theirsIsList := theirs.v.([]interface{})
+			oursList, oursIsList := ours.v.([]interface{})
+			if theirsIsList && oursIsList {
+				if merged, ok := m.mergePanels(path, baseList, theirsList, oursList); ok {
+					return value{v: merged, present: true}
+				}
+			}
+		}
+	}
+
+	m.addConflict(path, base, theirs, ours)
+	return theirs
+}
+
+// mergePanels merges panel lists by panel id. Panels keep the order they have
+// in "theirs", followed by panels that were only added in "ours". It returns
+// false when panels cannot be matched by id.
+func (m *merger) mergePanels(path string, base, theirs, ours []interface{}) ([]interface{}, bool) {
+	baseByID, ok := panelsByID(base)
+	if !ok {
+		return nil, false
+	}
+	theirsByID, ok := panelsByID(theirs)
+	if !ok {
+		return nil, false
+	}
+	oursByID, ok := panelsByID(ours)
+	if !ok {
+		return nil, false
+	}
+
+	order := make([]string, 0, len(theirs)+len(ours))
+	seen := map[string]bool{}
+	for _, list := range [][]interface{}{theirs, ours} {
+		for _, p := range list {
+			id := panelID(p)
+			if !seen[id] {
+				seen[id] = true
+				order = append(order, id)
+			}
+		}
+	}
+
+	result := make([]interface{}, 0, len(order))
+	for _, id := range order {
+		merged := m.mergeValues(fmt.Sprintf("%s[id=%s]", path, id), "", lookup(baseByID, id), lookup(theirsByID, id), lookup(oursByID, id))
+		if merged.present {
+			result = append(result, merged.v)
+		}
+	}
+
+	return result, true
+}
+
+func panelsByID(panels []interface{}) (map[string]interface{}, bool) {
+	result := make(map[string]interface{}, len(panels))
+	for _, p := range panels {
+		id := panelID(p)
+		if id == "" {
+			return nil, false
+		}
+		if _, exists := result[id]; exists {
+			return nil, false
+		}
+		result[id] = p
+	}
+	return result, true
+}
+
+func panelID(panel interface{}) string {
+	obj, ok := panel.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	id, ok := obj["id"]
+	if !ok || id == nil {
+		return ""
+	}
+	return fmt.Sprint(id)
+}
+
+func (m *merger) addConflict(path string, base, theirs, ours value) {
+	m.conflicts = append(m.conflicts, Conflict{
+		Path:   path,
+		Base:   base.v,
+		Theirs: theirs.v,
+		Ours:   ours.v,
+	})
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
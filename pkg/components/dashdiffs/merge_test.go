@@ -0,0 +1,126 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestMerge(t *testing.T) {
+	const baseJSON = `{
+		"uid": "abc",
+		"version": 3,
+		"title": "Service",
+		"tags": ["a"],
+		"panels": [
+			{"id": 1, "title": "CPU", "type": "graph"},
+			{"id": 2, "title": "Memory", "type": "graph"},
+			{"id": 3, "title": "Disk", "type": "graph"}
+		]
+	}`
+
+	parse := func(t *testing.T, s string) *simplejson.Json {
+		t.Helper()
+		js, err := simplejson.NewJson([]byte(s))
+		require.NoError(t, err)
+		return js
+	}
+
+	t.Run("Non-conflicting panel edits are merged", func(t *testing.T) {
+		theirs := parse(t, `{
+			"uid": "abc",
+			"version": 4,
+			"title": "Service",
+			"tags": ["a"],
+			"panels": [
+				{"id": 1, "title": "CPU usage", "type": "graph"},
+				{"id": 2, "title": "Memory", "type": "graph"},
+				{"id": 3, "title": "Disk", "type": "graph"}
+			]
+		}`)
+		ours := parse(t, `{
+			"uid": "abc",
+			"version": 3,
+			"title": "Service overview",
+			"tags": ["a"],
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "graph"},
+				{"id": 2, "title": "Memory", "type": "timeseries"},
+				{"id": 4, "title": "Network", "type": "graph"}
+			]
+		}`)
+
+		result, err := Merge(parse(t, baseJSON), theirs, ours)
+		require.NoError(t, err)
+		assert.Empty(t, result.Conflicts)
+
+		dash := result.Dashboard
+		assert.Equal(t, "Service overview", dash.Get("title").MustString())
+		assert.Equal(t, 4, dash.Get("version").MustInt())
+
+		panels := dash.Get("panels").MustArray()
+		require.Len(t, panels, 3)
+		assert.Equal(t, "CPU usage", dash.Get("panels").GetIndex(0).Get("title").MustString())
+		assert.Equal(t, "timeseries", dash.Get("panels").GetIndex(1).Get("type").MustString())
+		assert.Equal(t, 4, dash.Get("panels").GetIndex(2).Get("id").MustInt())
+	})
+
+	t.Run("Concurrent edits of the same property are reported as conflicts", func(t *testing.T) {
+		theirs := parse(t, `{
+			"uid": "abc",
+			"version": 4,
+			"title": "Service",
+			"tags": ["a"],
+			"panels": [
+				{"id": 1, "title": "CPU usage", "type": "graph"},
+				{"id": 2, "title": "Memory", "type": "graph"}
+			]
+		}`)
+		ours := parse(t, `{
+			"uid": "abc",
+			"version": 3,
+			"title": "Service",
+			"tags": ["a"],
+			"panels": [
+				{"id": 1, "title": "CPU seconds", "type": "graph"},
+				{"id": 2, "title": "Memory", "type": "graph"},
+				{"id": 3, "title": "Disk IO", "type": "graph"}
+			]
+		}`)
+
+		result, err := Merge(parse(t, baseJSON), theirs, ours)
+		require.NoError(t, err)
+		require.Len(t, result.Conflicts, 2)
+
+		assert.Equal(t, Conflict{
+			Path:   "panels[id=1].title",
+			Base:   "CPU",
+			Theirs: "CPU usage",
+			Ours:   "CPU seconds",
+		}, result.Conflicts[0])
+
+		deleted := result.Conflicts[1]
+		assert.Equal(t, "panels[id=3]", deleted.Path)
+		assert.Nil(t, deleted.Theirs)
+		assert.NotNil(t, deleted.Ours)
+
+		// Conflicts resolve to theirs.
+		dash := result.Dashboard
+		assert.Equal(t, "CPU usage", dash.Get("panels").GetIndex(0).Get("title").MustString())
+		assert.Len(t, dash.Get("panels").MustArray(), 2)
+	})
+
+	t.Run("Panels without ids are merged as a whole", func(t *testing.T) {
+		base := parse(t, `{"panels": [{"title": "A"}]}`)
+		theirs := parse(t, `{"panels": [{"title": "B"}]}`)
+		ours := parse(t, `{"panels": [{"title": "C"}]}`)
+
+		result, err := Merge(base, theirs, ours)
+		require.NoError(t, err)
+		require.Len(t, result.Conflicts, 1)
+		assert.Equal(t, "panels", result.Conflicts[0].Path)
+	})
+}
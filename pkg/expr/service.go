@@ -49,6 +49,15 @@ func ProvideService(cfg *setting.Cfg, pluginClient plugins.Client, secretsServic
 	}
 }
 
+// WithDataService returns a copy of the service that sends the queries of
+// data sources to dataService, e.g. to apply the query limits of the data
+// sources of a request.
+func (s *Service) WithDataService(dataService backend.QueryDataHandler) *Service {
+	withDataService := *s
+	withDataService.dataService = dataService
+	return &withDataService
+}
+
 func (s *Service) isDisabled() bool {
 	if s.cfg == nil {
 		return true
@@ -1,11 +1,21 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
+var (
+	ErrDashboardSnapshotPasswordRequired = errors.New("dashboard snapshot is password protected")
+	ErrDashboardSnapshotInvalidPassword  = errors.New("invalid dashboard snapshot password")
+	ErrDashboardSnapshotTooManyAttempts  = errors.New("too many invalid dashboard snapshot passwords, try again later")
+	ErrDashboardSnapshotViewLimitReached = errors.New("dashboard snapshot view limit reached")
+	ErrDashboardSnapshotRevoked          = errors.New("dashboard snapshot has been revoked")
+	ErrDashboardSnapshotInvalidMaxViews  = errors.New("dashboard snapshot max views cannot be negative")
+)
+
 // DashboardSnapshot model
 type DashboardSnapshot struct {
 	Id                int64
@@ -24,6 +34,16 @@ type DashboardSnapshot struct {
 
 	Dashboard          *simplejson.Json
 	DashboardEncrypted []byte
+
+	PasswordEncrypted []byte
+	MaxViews          int64
+	ViewCount         int64
+	Revoked           bool
+}
+
+// IsPasswordProtected returns true if a password is required to view the snapshot.
+func (s *DashboardSnapshot) IsPasswordProtected() bool {
+	return len(s.PasswordEncrypted) > 0
 }
 
 // DashboardSnapshotDTO without dashboard map
@@ -36,11 +56,31 @@ type DashboardSnapshotDTO struct {
 	External    bool   `json:"external"`
 	ExternalUrl string `json:"externalUrl"`
 
+	PasswordEncrypted []byte `json:"-"`
+	PasswordProtected bool   `json:"passwordProtected" xorm:"-"`
+	MaxViews          int64  `json:"maxViews"`
+	ViewCount         int64  `json:"viewCount"`
+	Revoked           bool   `json:"revoked"`
+
 	Expires time.Time `json:"expires"`
 	Created time.Time `json:"created"`
 	Updated time.Time `json:"updated"`
 }
 
+// DashboardSnapshotAccess is an entry in the access log of a snapshot.
+type DashboardSnapshotAccess struct {
+	Id         int64     `json:"id"`
+	SnapshotId int64     `json:"snapshotId"`
+	OrgId      int64     `json:"orgId"`
+	UserId     int64     `json:"userId"`
+	Login      string    `json:"login"`
+	ClientIp   string    `json:"clientIp"`
+	UserAgent  string    `json:"userAgent"`
+	Granted    bool      `json:"granted"`
+	Reason     string    `json:"reason"`
+	Created    time.Time `json:"created"`
+}
+
 // -----------------
 // COMMANDS
 
@@ -57,10 +97,16 @@ type CreateDashboardSnapshotCommand struct {
 	Key       string `json:"key"`
 	DeleteKey string `json:"deleteKey"`
 
+	// Password optionally protects the snapshot. It is stored encrypted.
+	Password string `json:"password"`
+	// MaxViews limits how many times the snapshot can be viewed. Zero means unlimited.
+	MaxViews int64 `json:"maxViews"`
+
 	OrgId  int64 `json:"-"`
 	UserId int64 `json:"-"`
 
 	DashboardEncrypted []byte `json:"-"`
+	PasswordEncrypted  []byte `json:"-"`
 
 	Result *DashboardSnapshot
 }
@@ -73,6 +119,30 @@ type DeleteExpiredSnapshotsCommand struct {
 	DeletedRows int64
 }
 
+// AccessDashboardSnapshotCommand checks that a snapshot can be viewed and
+// records the attempt in the access log. A granted access counts as a view.
+type AccessDashboardSnapshotCommand struct {
+	Snapshot  *DashboardSnapshot
+	Password  string
+	UserId    int64
+	Login     string
+	ClientIp  string
+	UserAgent string
+}
+
+type RevokeUserDashboardSnapshotsCommand struct {
+	UserId int64
+
+	RevokedRows int64
+}
+
+type GetDashboardSnapshotAccessLogQuery struct {
+	SnapshotId int64
+	Limit      int
+
+	Result []*DashboardSnapshotAccess
+}
+
 type GetDashboardSnapshotQuery struct {
 	Key       string
 	DeleteKey string
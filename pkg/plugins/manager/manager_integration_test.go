@@ -24,6 +24,7 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/cloudmonitoring"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch"
 	"github.com/grafana/grafana/pkg/tsdb/elasticsearch"
+	"github.com/grafana/grafana/pkg/tsdb/genericsql"
 	"github.com/grafana/grafana/pkg/tsdb/grafanads"
 	"github.com/grafana/grafana/pkg/tsdb/graphite"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb"
@@ -81,14 +82,15 @@ func TestPluginManager_int_init(t *testing.T) {
 	lk := loki.ProvideService(hcp, tracer)
 	otsdb := opentsdb.ProvideService(hcp)
 	pr := prometheus.ProvideService(hcp, tracer)
-	tmpo := tempo.ProvideService(hcp)
+	tmpo := tempo.ProvideService(hcp, features, nil, nil, nil)
 	td := testdatasource.ProvideService(cfg, features)
 	pg := postgres.ProvideService(cfg)
 	my := mysql.ProvideService(cfg, hcp)
 	ms := mssql.ProvideService(cfg)
+	gs := genericsql.ProvideService(cfg)
 	graf := grafanads.ProvideService(cfg)
 
-	coreRegistry := coreplugin.ProvideCoreRegistry(am, cw, cm, es, grap, idb, lk, otsdb, pr, tmpo, td, pg, my, ms, graf)
+	coreRegistry := coreplugin.ProvideCoreRegistry(am, cw, cm, es, grap, idb, lk, otsdb, pr, tmpo, td, pg, my, ms, gs, graf)
 
 	pmCfg := plugins.FromGrafanaCfg(cfg)
 	pm, err := ProvideService(cfg, loader.New(pmCfg, license, signature.NewUnsignedAuthorizer(pmCfg),
@@ -152,6 +154,7 @@ func verifyCorePluginCatalogue(t *testing.T, pm *PluginManager) {
 		"postgres":                         {},
 		"mysql":                            {},
 		"mssql":                            {},
+		"genericsql":                       {},
 		"grafana":                          {},
 		"alertmanager":                     {},
 		"dashboard":                        {},
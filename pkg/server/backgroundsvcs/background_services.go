@@ -11,7 +11,9 @@ import (
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/cleanup"
+	"github.com/grafana/grafana/pkg/services/dashboardrefs"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/pushhttp"
 	"github.com/grafana/grafana/pkg/services/ngalert"
@@ -20,6 +22,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/pluginsettings"
 	"github.com/grafana/grafana/pkg/services/provisioning"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/services/search/fulltext"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/updatechecker"
@@ -32,6 +36,8 @@ func ProvideBackgroundServiceRegistry(
 	provisioning *provisioning.ProvisioningServiceImpl, alerting *alerting.AlertEngine, pm *manager.PluginManager,
 	metrics *metrics.InternalMetricsService, usageStats *uss.UsageStats, updateChecker *updatechecker.Service,
 	tracing tracing.Tracer, remoteCache *remotecache.RemoteCache, secretsService *secretsManager.SecretsService,
+	searchIndex *fulltext.Service, dashboardRefs *dashboardrefs.Service, reports *reports.Service,
+	dataSourceHealth *datasourcehealth.Service,
 	// Need to make sure these are initialized, is there a better place to put them?
 	_ *plugindashboards.Service, _ *dashboardsnapshots.Service, _ *pluginsettings.Service,
 	_ *alerting.AlertNotificationService, _ serviceaccounts.Service,
@@ -53,7 +59,11 @@ func ProvideBackgroundServiceRegistry(
 		usageStats,
 		tracing,
 		remoteCache,
-		secretsService)
+		secretsService,
+		searchIndex,
+		dashboardRefs,
+		reports,
+		dataSourceHealth)
 }
 
 // BackgroundServiceRegistry provides background services.
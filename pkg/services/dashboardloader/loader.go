@@ -0,0 +1,84 @@
+// Package dashboardloader fills in-memory views of the stored dashboards,
+// such as search indexes, when the server starts.
+package dashboardloader
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// loadBatchSize is the number of dashboards read per query.
+const loadBatchSize = 200
+
+// minRetryInterval and maxRetryInterval bound the delay between attempts to
+// load the dashboards when the database can't be read.
+const (
+	minRetryInterval = 5 * time.Second
+	maxRetryInterval = 5 * time.Minute
+)
+
+// Load calls fn for every stored dashboard and returns how many there were.
+func Load(ctx context.Context, sqlStore *sqlstore.SQLStore, fn func(dash *models.Dashboard)) (int, error) {
+	var lastID int64
+	count := 0
+
+	for {
+		var dashboards []*models.Dashboard
+		err := sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return sess.Where("id > ?", lastID).Asc("id").Limit(loadBatchSize).Find(&dashboards)
+		})
+		if err != nil {
+			return count, err
+		}
+
+		for _, dash := range dashboards {
+			fn(dash)
+			lastID = dash.Id
+		}
+		count += len(dashboards)
+
+		if len(dashboards) < loadBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// Run loads every stored dashboard with fn and then blocks until the context
+// is cancelled, which suits background services that keep their view up to
+// date through dashboard events. A failed load is retried with a growing
+// delay rather than stopping the server.
+func Run(ctx context.Context, sqlStore *sqlstore.SQLStore, logger log.Logger, fn func(dash *models.Dashboard)) error {
+	load := func(ctx context.Context) (int, error) {
+		return Load(ctx, sqlStore, fn)
+	}
+	return run(ctx, logger, load, minRetryInterval)
+}
+
+func run(ctx context.Context, logger log.Logger, load func(ctx context.Context) (int, error), retryInterval time.Duration) error {
+	for {
+		count, err := load(ctx)
+		if err == nil {
+			logger.Info("Dashboards loaded", "dashboards", count)
+			break
+		}
+		logger.Error("Failed to load dashboards", "error", err, "retryIn", retryInterval)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryInterval):
+		}
+
+		retryInterval *= 2
+		if retryInterval > maxRetryInterval {
+			retryInterval = maxRetryInterval
+		}
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,69 @@
+package dashboardloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	for i := 0; i < loadBatchSize+1; i++ {
+		_, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": fmt.Sprintf("Dashboard %d", i)}),
+		})
+		require.NoError(t, err)
+	}
+
+	var ids []int64
+	count, err := Load(context.Background(), sqlStore, func(dash *models.Dashboard) {
+		ids = append(ids, dash.Id)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, loadBatchSize+1, count)
+	assert.Len(t, ids, loadBatchSize+1)
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	loaded := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("database is locked")
+		}
+		close(loaded)
+		return 1, nil
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- run(ctx, log.New("test"), load, time.Millisecond)
+	}()
+
+	select {
+	case <-loaded:
+	case err := <-done:
+		t.Fatalf("run stopped before loading the dashboards: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("dashboards were not loaded")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, 3, attempts)
+}
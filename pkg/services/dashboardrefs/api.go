@@ -0,0 +1,110 @@
+package dashboardrefs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// UsageResponse lists the dashboards using an object that the signed in
+// user is allowed to see.
+type UsageResponse struct {
+	Dashboards search.HitList `json:"dashboards"`
+}
+
+// ReferencesResponse lists everything a dashboard refers to.
+type ReferencesResponse struct {
+	References []Reference `json:"references"`
+}
+
+func (s *Service) registerAPIEndpoints() {
+	s.routeRegister.Group("/api/usage", func(usage routing.RouteRegister) {
+		usage.Get("/datasources/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.getDataSourceUsageHandler))
+		usage.Get("/library-elements/:uid", middleware.ReqSignedIn, routing.Wrap(s.getLibraryElementUsageHandler))
+		usage.Get("/dashboards/:uid", middleware.ReqSignedIn, routing.Wrap(s.getDashboardUsageHandler))
+		usage.Get("/dashboards/:uid/references", middleware.ReqSignedIn, routing.Wrap(s.getDashboardReferencesHandler))
+	})
+}
+
+// getDataSourceUsageHandler handles GET /api/usage/datasources/:uid.
+func (s *Service) getDataSourceUsageHandler(c *models.ReqContext) response.Response {
+	query := &models.GetDataSourceQuery{Uid: web.Params(c.Req)[":uid"], OrgId: c.OrgId}
+	if err := bus.Dispatch(c.Req.Context(), query); err != nil {
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return response.Error(http.StatusNotFound, "Data source not found", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to query data source", err)
+	}
+
+	return s.usageResponse(c, s.DashboardsUsingDataSource(query.Result))
+}
+
+// getLibraryElementUsageHandler handles GET /api/usage/library-elements/:uid.
+func (s *Service) getLibraryElementUsageHandler(c *models.ReqContext) response.Response {
+	return s.usageResponse(c, s.DashboardsUsingLibraryElement(c.OrgId, web.Params(c.Req)[":uid"]))
+}
+
+// getDashboardUsageHandler handles GET /api/usage/dashboards/:uid.
+func (s *Service) getDashboardUsageHandler(c *models.ReqContext) response.Response {
+	return s.usageResponse(c, s.DashboardsLinkingTo(c.OrgId, web.Params(c.Req)[":uid"]))
+}
+
+// getDashboardReferencesHandler handles GET /api/usage/dashboards/:uid/references.
+func (s *Service) getDashboardReferencesHandler(c *models.ReqContext) response.Response {
+	dash, err := s.sqlStore.GetDashboard(0, c.OrgId, web.Params(c.Req)[":uid"], "")
+	if err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return response.Error(http.StatusNotFound, "Dashboard not found", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard", err)
+	}
+
+	// the permission filtered search only returns the dashboard if the user can view it.
+	hits, err := s.findDashboards(c.Req.Context(), c.SignedInUser, []int64{dash.Id})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search dashboards", err)
+	}
+	if len(hits) == 0 {
+		return response.Error(http.StatusNotFound, "Dashboard not found", nil)
+	}
+
+	return response.JSON(http.StatusOK, ReferencesResponse{References: s.References(c.OrgId, dash.Id)})
+}
+
+func (s *Service) usageResponse(c *models.ReqContext, ids []int64) response.Response {
+	hits := search.HitList{}
+	if len(ids) > 0 {
+		var err error
+		hits, err = s.findDashboards(c.Req.Context(), c.SignedInUser, ids)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to search dashboards", err)
+		}
+	}
+
+	return response.JSON(http.StatusOK, UsageResponse{Dashboards: hits})
+}
+
+// findDashboards returns the dashboards with the given IDs that the user is
+// allowed to view.
+func (s *Service) findDashboards(ctx context.Context, user *models.SignedInUser, ids []int64) (search.HitList, error) {
+	query := &search.FindPersistedDashboardsQuery{
+		SignedInUser: user,
+		OrgId:        user.OrgId,
+		DashboardIds: ids,
+		Permission:   models.PERMISSION_VIEW,
+		Limit:        int64(len(ids)),
+	}
+	if err := bus.Dispatch(ctx, query); err != nil {
+		return nil, err
+	}
+
+	return query.Result, nil
+}
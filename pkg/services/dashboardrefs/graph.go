@@ -0,0 +1,96 @@
+package dashboardrefs
+
+import (
+	"sort"
+	"sync"
+)
+
+// graph keeps the references of every dashboard together with the reverse
+// lookup from a referenced object to the dashboards using it.
+type graph struct {
+	mu     sync.RWMutex
+	refs   map[int64]map[int64][]Reference
+	usedBy map[int64]map[Reference]map[int64]struct{}
+}
+
+func newGraph() *graph {
+	return &graph{
+		refs:   map[int64]map[int64][]Reference{},
+		usedBy: map[int64]map[Reference]map[int64]struct{}{},
+	}
+}
+
+// set replaces the references of the dashboard.
+func (g *graph) set(orgID, dashboardID int64, refs []Reference) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.removeLocked(orgID, dashboardID)
+
+	if g.refs[orgID] == nil {
+		g.refs[orgID] = map[int64][]Reference{}
+		g.usedBy[orgID] = map[Reference]map[int64]struct{}{}
+	}
+	g.refs[orgID][dashboardID] = refs
+
+	usedBy := g.usedBy[orgID]
+	for _, ref := range refs {
+		if usedBy[ref] == nil {
+			usedBy[ref] = map[int64]struct{}{}
+		}
+		usedBy[ref][dashboardID] = struct{}{}
+	}
+}
+
+func (g *graph) remove(orgID, dashboardID int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.removeLocked(orgID, dashboardID)
+}
+
+func (g *graph) removeLocked(orgID, dashboardID int64) {
+	refs, ok := g.refs[orgID][dashboardID]
+	if !ok {
+		return
+	}
+
+	usedBy := g.usedBy[orgID]
+	for _, ref := range refs {
+		delete(usedBy[ref], dashboardID)
+		if len(usedBy[ref]) == 0 {
+			delete(usedBy, ref)
+		}
+	}
+	delete(g.refs[orgID], dashboardID)
+}
+
+// references returns the references of the dashboard.
+func (g *graph) references(orgID, dashboardID int64) []Reference {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return append([]Reference{}, g.refs[orgID][dashboardID]...)
+}
+
+// dashboards returns the sorted IDs of the dashboards using any of the
+// given references.
+func (g *graph) dashboards(orgID int64, refs ...Reference) []int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	ids := map[int64]struct{}{}
+	for _, ref := range refs {
+		for id := range g.usedBy[orgID][ref] {
+			ids[id] = struct{}{}
+		}
+	}
+
+	res := make([]int64, 0, len(ids))
+	for id := range ids {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+
+	return res
+}
@@ -0,0 +1,149 @@
+package dashboardrefs
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Kind is the type of object a dashboard references.
+type Kind string
+
+const (
+	KindDataSource     Kind = "datasource"
+	KindLibraryElement Kind = "library-element"
+	KindDashboard      Kind = "dashboard"
+)
+
+// Reference points from a dashboard to another object. Data sources
+// referenced by name in older dashboards have no UID set, and queries
+// without a data source refer to the default data source of the organization.
+type Reference struct {
+	Kind    Kind   `json:"kind"`
+	UID     string `json:"uid,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Default bool   `json:"default,omitempty"`
+}
+
+var defaultDataSource = Reference{Kind: KindDataSource, Default: true}
+
+var dashboardURLPattern = regexp.MustCompile(`^(?:.*/)?d/([a-zA-Z0-9_-]+)(?:/|$)`)
+
+// ExtractReferences returns the unique references found in the dashboard
+// JSON model. Links to dashboards are only followed when they are relative
+// or start with appURL, since other Grafana instances may use the same UIDs.
+func ExtractReferences(data *simplejson.Json, appURL string) []Reference {
+	e := &extractor{appURL: appURL, seen: map[Reference]bool{}}
+	if data == nil {
+		return e.refs
+	}
+
+	for _, panel := range data.Get("panels").MustArray() {
+		e.panel(simplejson.NewFromAny(panel))
+	}
+	for _, row := range data.Get("rows").MustArray() {
+		for _, panel := range simplejson.NewFromAny(row).Get("panels").MustArray() {
+			e.panel(simplejson.NewFromAny(panel))
+		}
+	}
+	for _, variable := range data.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(variable)
+		e.queryDataSource(variable.Get("datasource"), variable.Get("type").MustString() == "query")
+	}
+	for _, annotation := range data.GetPath("annotations", "list").MustArray() {
+		e.dataSource(simplejson.NewFromAny(annotation).Get("datasource"))
+	}
+	e.links(data.Get("links"))
+
+	return e.refs
+}
+
+type extractor struct {
+	appURL string
+	refs   []Reference
+	seen   map[Reference]bool
+}
+
+func (e *extractor) add(ref Reference) {
+	if e.seen[ref] {
+		return
+	}
+	e.seen[ref] = true
+	e.refs = append(e.refs, ref)
+}
+
+func (e *extractor) panel(panel *simplejson.Json) {
+	e.queryDataSource(panel.Get("datasource"), len(panel.Get("targets").MustArray()) > 0)
+	for _, target := range panel.Get("targets").MustArray() {
+		e.dataSource(simplejson.NewFromAny(target).Get("datasource"))
+	}
+
+	if uid := panel.GetPath("libraryPanel", "uid").MustString(); uid != "" {
+		e.add(Reference{Kind: KindLibraryElement, UID: uid})
+	}
+
+	e.links(panel.Get("links"))
+	e.links(panel.GetPath("fieldConfig", "defaults", "links"))
+
+	for _, nested := range panel.Get("panels").MustArray() {
+		e.panel(simplejson.NewFromAny(nested))
+	}
+}
+
+// queryDataSource adds the data source of a panel or variable, which falls
+// back to the default data source when it runs queries without one.
+func (e *extractor) queryDataSource(ref *simplejson.Json, hasQueries bool) {
+	if ref.Interface() == nil {
+		if hasQueries {
+			e.add(defaultDataSource)
+		}
+		return
+	}
+	e.dataSource(ref)
+}
+
+// dataSource adds a data source reference, which is either a name or an
+// object holding the data source UID. Template variables are skipped since
+// they are resolved when the dashboard is rendered.
+func (e *extractor) dataSource(ref *simplejson.Json) {
+	if name, err := ref.String(); err == nil {
+		if name == "default" {
+			e.add(defaultDataSource)
+		} else if name != "" && !strings.HasPrefix(name, "$") {
+			e.add(Reference{Kind: KindDataSource, Name: name})
+		}
+		return
+	}
+
+	if uid := ref.Get("uid").MustString(); uid != "" && !strings.HasPrefix(uid, "$") {
+		e.add(Reference{Kind: KindDataSource, UID: uid})
+	}
+}
+
+func (e *extractor) links(links *simplejson.Json) {
+	for _, link := range links.MustArray() {
+		if uid := e.dashboardUID(simplejson.NewFromAny(link).Get("url").MustString()); uid != "" {
+			e.add(Reference{Kind: KindDashboard, UID: uid})
+		}
+	}
+}
+
+// dashboardUID returns the UID of the dashboard the link points to, if it
+// points to a dashboard of this Grafana instance.
+func (e *extractor) dashboardUID(link string) string {
+	if e.appURL != "" && strings.HasPrefix(link, e.appURL) {
+		link = "/" + strings.TrimPrefix(strings.TrimPrefix(link, e.appURL), "/")
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+
+	if match := dashboardURLPattern.FindStringSubmatch(u.Path); match != nil {
+		return match[1]
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package dashboardrefs
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractReferences(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"links": [{"type": "link", "url": "/d/overview/overview?orgId=1"}],
+		"templating": {
+			"list": [
+				{"type": "query", "datasource": {"type": "prometheus", "uid": "prom"}},
+				{"type": "query", "datasource": "${ds}"}
+			]
+		},
+		"annotations": {"list": [{"datasource": "-- Grafana --"}]},
+		"panels": [
+			{
+				"datasource": {"type": "prometheus", "uid": "prom"},
+				"targets": [{"datasource": {"type": "loki", "uid": "loki"}}],
+				"links": [
+					{"url": "https://grafana.example.com/d/details"},
+					{"url": "https://other.example.com/d/external"}
+				]
+			},
+			{"datasource": null, "targets": [{"expr": "up"}]},
+			{"type": "text", "options": {"content": "No queries"}},
+			{"libraryPanel": {"uid": "lib-1", "name": "Shared"}},
+			{"type": "row", "panels": [{"datasource": "MySQL"}]}
+		],
+		"rows": [{"panels": [{"datasource": "Graphite"}]}]
+	}`))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []Reference{
+		{Kind: KindDataSource, UID: "prom"},
+		{Kind: KindDataSource, UID: "loki"},
+		{Kind: KindDataSource, Name: "MySQL"},
+		{Kind: KindDataSource, Name: "Graphite"},
+		{Kind: KindDataSource, Name: "-- Grafana --"},
+		{Kind: KindDataSource, Default: true},
+		{Kind: KindLibraryElement, UID: "lib-1"},
+		{Kind: KindDashboard, UID: "details"},
+		{Kind: KindDashboard, UID: "overview"},
+	}, ExtractReferences(data, "https://grafana.example.com/"))
+
+	assert.Empty(t, ExtractReferences(nil, ""))
+}
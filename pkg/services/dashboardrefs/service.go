@@ -0,0 +1,98 @@
+package dashboardrefs
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboardloader"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Service tracks which data sources, library elements and dashboards are
+// referenced by each dashboard.
+type Service struct {
+	cfg           *setting.Cfg
+	sqlStore      *sqlstore.SQLStore
+	routeRegister routing.RouteRegister
+	graph         *graph
+	log           log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, bus bus.Bus, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		cfg:           cfg,
+		sqlStore:      sqlStore,
+		routeRegister: routeRegister,
+		graph:         newGraph(),
+		log:           log.New("dashboardrefs"),
+	}
+
+	bus.AddEventListener(s.handleDashboardSaved)
+	bus.AddEventListener(s.handleDashboardDeleted)
+	s.registerAPIEndpoints()
+
+	return s
+}
+
+// Run builds the reference graph from the stored dashboards. Later changes
+// are applied through dashboard events.
+func (s *Service) Run(ctx context.Context) error {
+	return dashboardloader.Run(ctx, s.sqlStore, s.log, s.set)
+}
+
+// References returns everything the dashboard refers to.
+func (s *Service) References(orgID, dashboardID int64) []Reference {
+	return s.graph.references(orgID, dashboardID)
+}
+
+// DashboardsUsingDataSource returns the IDs of the dashboards referencing the
+// data source either by UID or by name, or implicitly when it's the default
+// data source of the organization.
+func (s *Service) DashboardsUsingDataSource(ds *models.DataSource) []int64 {
+	refs := []Reference{
+		{Kind: KindDataSource, UID: ds.Uid},
+		{Kind: KindDataSource, Name: ds.Name},
+	}
+	if ds.IsDefault {
+		refs = append(refs, defaultDataSource)
+	}
+	return s.graph.dashboards(ds.OrgId, refs...)
+}
+
+// DashboardsUsingLibraryElement returns the IDs of the dashboards containing
+// the library element.
+func (s *Service) DashboardsUsingLibraryElement(orgID int64, uid string) []int64 {
+	return s.graph.dashboards(orgID, Reference{Kind: KindLibraryElement, UID: uid})
+}
+
+// DashboardsLinkingTo returns the IDs of the dashboards with a link to the
+// dashboard.
+func (s *Service) DashboardsLinkingTo(orgID int64, uid string) []int64 {
+	return s.graph.dashboards(orgID, Reference{Kind: KindDashboard, UID: uid})
+}
+
+// set replaces the references of the dashboard in the graph.
+func (s *Service) set(dash *models.Dashboard) {
+	s.graph.set(dash.OrgId, dash.Id, ExtractReferences(dash.Data, s.cfg.AppURL))
+}
+
+func (s *Service) handleDashboardSaved(_ context.Context, evt *events.DashboardSaved) error {
+	dash, err := s.sqlStore.GetDashboard(evt.ID, evt.OrgID, "", "")
+	if err != nil {
+		s.log.Warn("Failed to load saved dashboard", "id", evt.ID, "error", err)
+		return nil
+	}
+
+	s.set(dash)
+	return nil
+}
+
+func (s *Service) handleDashboardDeleted(_ context.Context, evt *events.DashboardDeleted) error {
+	s.graph.remove(evt.OrgID, evt.ID)
+	return nil
+}
@@ -0,0 +1,74 @@
+package dashboardrefs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboardloader"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	sqlStore := sqlstore.InitTestDB(t)
+	saveDashboard := func(title string, panels ...interface{}) *models.Dashboard {
+		dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId: 1,
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"title":  title,
+				"panels": panels,
+			}),
+		})
+		require.NoError(t, err)
+		return dash
+	}
+
+	byUID := saveDashboard("By UID", map[string]interface{}{
+		"datasource": map[string]interface{}{"uid": "prom"},
+	})
+
+	s := ProvideService(setting.NewCfg(), sqlStore, bus.GetBus(), routing.NewRouteRegister())
+	_, err := dashboardloader.Load(context.Background(), sqlStore, s.set)
+	require.NoError(t, err)
+
+	ds := &models.DataSource{OrgId: 1, Uid: "prom", Name: "Prometheus"}
+	assert.Equal(t, []int64{byUID.Id}, s.DashboardsUsingDataSource(ds))
+
+	t.Run("saved dashboards are tracked", func(t *testing.T) {
+		byName := saveDashboard("By name",
+			map[string]interface{}{"datasource": "Prometheus"},
+			map[string]interface{}{"libraryPanel": map[string]interface{}{"uid": "lib"}},
+		)
+
+		assert.Equal(t, []int64{byUID.Id, byName.Id}, s.DashboardsUsingDataSource(ds))
+		assert.Equal(t, []int64{byName.Id}, s.DashboardsUsingLibraryElement(1, "lib"))
+		assert.Empty(t, s.DashboardsUsingLibraryElement(2, "lib"))
+	})
+
+	t.Run("queries without a data source use the default one", func(t *testing.T) {
+		usesDefault := saveDashboard("Default", map[string]interface{}{
+			"targets": []interface{}{map[string]interface{}{"refId": "A"}},
+		})
+
+		defaultDS := &models.DataSource{OrgId: 1, Uid: "loki", Name: "Loki", IsDefault: true}
+		assert.Equal(t, []int64{usesDefault.Id}, s.DashboardsUsingDataSource(defaultDS))
+		assert.NotContains(t, s.DashboardsUsingDataSource(ds), usesDefault.Id)
+	})
+
+	t.Run("deleted dashboards are removed", func(t *testing.T) {
+		err := sqlStore.DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: byUID.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		assert.Len(t, s.DashboardsUsingDataSource(ds), 1)
+		assert.Empty(t, s.References(1, byUID.Id))
+	})
+}
@@ -2,22 +2,47 @@ package dashboardsnapshots
 
 import (
 	"context"
+	"crypto/subtle"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var logger = log.New("dashboardsnapshots")
+
+// Reasons recorded in the snapshot access log.
+const (
+	accessReasonRevoked          = "revoked"
+	accessReasonPasswordRequired = "password required"
+	accessReasonInvalidPassword  = "invalid password"
+	accessReasonTooManyAttempts  = "too many attempts"
+	accessReasonViewLimitReached = "view limit reached"
+)
+
+// Like login, a client gets maxInvalidPasswordAttempts password guesses per
+// snapshot within passwordAttemptsWindow.
+const (
+	maxInvalidPasswordAttempts int64 = 5
+	passwordAttemptsWindow           = 5 * time.Minute
 )
 
 type Service struct {
+	Cfg            *setting.Cfg
 	Bus            bus.Bus
 	SQLStore       *sqlstore.SQLStore
 	SecretsService secrets.Service
 }
 
-func ProvideService(bus bus.Bus, store *sqlstore.SQLStore, secretsService secrets.Service) *Service {
+func ProvideService(cfg *setting.Cfg, bus bus.Bus, store *sqlstore.SQLStore, secretsService secrets.Service) *Service {
 	s := &Service{
+		Cfg:            cfg,
 		Bus:            bus,
 		SQLStore:       store,
 		SecretsService: secretsService,
@@ -28,6 +53,10 @@ func ProvideService(bus bus.Bus, store *sqlstore.SQLStore, secretsService secret
 	s.Bus.AddHandler(s.DeleteDashboardSnapshot)
 	s.Bus.AddHandler(s.SearchDashboardSnapshots)
 	s.Bus.AddHandler(s.DeleteExpiredSnapshots)
+	s.Bus.AddHandler(s.AccessDashboardSnapshot)
+	s.Bus.AddHandler(s.GetDashboardSnapshotAccessLog)
+	s.Bus.AddHandler(s.RevokeUserDashboardSnapshots)
+	s.Bus.AddEventListener(s.handleUserDisabled)
 
 	return s
 }
@@ -45,6 +74,14 @@ func (s *Service) CreateDashboardSnapshot(ctx context.Context, cmd *models.Creat
 
 	cmd.DashboardEncrypted = encryptedDashboard
 
+	if cmd.Password != "" {
+		encryptedPassword, err := s.SecretsService.Encrypt(ctx, []byte(cmd.Password), secrets.WithoutScope())
+		if err != nil {
+			return err
+		}
+		cmd.PasswordEncrypted = encryptedPassword
+	}
+
 	return s.SQLStore.CreateDashboardSnapshot(ctx, cmd)
 }
 
@@ -82,3 +119,103 @@ func (s *Service) SearchDashboardSnapshots(_ context.Context, query *models.GetD
 func (s *Service) DeleteExpiredSnapshots(ctx context.Context, cmd *models.DeleteExpiredSnapshotsCommand) error {
 	return s.SQLStore.DeleteExpiredSnapshots(ctx, cmd)
 }
+
+// AccessDashboardSnapshot checks whether the snapshot can be viewed, counts
+// the view and records the attempt in the access log.
+func (s *Service) AccessDashboardSnapshot(ctx context.Context, cmd *models.AccessDashboardSnapshotCommand) error {
+	reason, err := s.checkSnapshotAccess(ctx, cmd)
+	if err != nil && reason == "" {
+		return err
+	}
+
+	entry := &models.DashboardSnapshotAccess{
+		SnapshotId: cmd.Snapshot.Id,
+		OrgId:      cmd.Snapshot.OrgId,
+		UserId:     cmd.UserId,
+		Login:      cmd.Login,
+		ClientIp:   cmd.ClientIp,
+		UserAgent:  truncate(cmd.UserAgent, 255),
+		Granted:    err == nil,
+		Reason:     reason,
+		Created:    time.Now(),
+	}
+	if logErr := s.SQLStore.AddDashboardSnapshotAccess(ctx, entry); logErr != nil {
+		logger.Error("Failed to record snapshot access", "snapshotId", cmd.Snapshot.Id, "error", logErr)
+	}
+
+	return err
+}
+
+// checkSnapshotAccess returns the reason and error for a denied access. An
+// error without a reason is an internal error that is not logged as access.
+func (s *Service) checkSnapshotAccess(ctx context.Context, cmd *models.AccessDashboardSnapshotCommand) (string, error) {
+	snapshot := cmd.Snapshot
+
+	if snapshot.Revoked {
+		return accessReasonRevoked, models.ErrDashboardSnapshotRevoked
+	}
+
+	if snapshot.IsPasswordProtected() {
+		if cmd.Password == "" {
+			return accessReasonPasswordRequired, models.ErrDashboardSnapshotPasswordRequired
+		}
+
+		if !s.Cfg.DisableBruteForceLoginProtection {
+			attempts, err := s.SQLStore.CountDashboardSnapshotAccessDenials(ctx, snapshot.Id, cmd.ClientIp,
+				accessReasonInvalidPassword, time.Now().Add(-passwordAttemptsWindow))
+			if err != nil {
+				return "", err
+			}
+			if attempts >= maxInvalidPasswordAttempts {
+				return accessReasonTooManyAttempts, models.ErrDashboardSnapshotTooManyAttempts
+			}
+		}
+
+		password, err := s.SecretsService.Decrypt(ctx, snapshot.PasswordEncrypted)
+		if err != nil {
+			return "", err
+		}
+
+		if subtle.ConstantTimeCompare(password, []byte(cmd.Password)) != 1 {
+			return accessReasonInvalidPassword, models.ErrDashboardSnapshotInvalidPassword
+		}
+	}
+
+	counted, err := s.SQLStore.IncrementDashboardSnapshotViewCount(ctx, snapshot.Id)
+	if err != nil {
+		return "", err
+	}
+	if !counted {
+		return accessReasonViewLimitReached, models.ErrDashboardSnapshotViewLimitReached
+	}
+
+	return "", nil
+}
+
+func (s *Service) GetDashboardSnapshotAccessLog(ctx context.Context, query *models.GetDashboardSnapshotAccessLogQuery) error {
+	return s.SQLStore.GetDashboardSnapshotAccessLog(ctx, query)
+}
+
+func (s *Service) RevokeUserDashboardSnapshots(ctx context.Context, cmd *models.RevokeUserDashboardSnapshotsCommand) error {
+	return s.SQLStore.RevokeUserDashboardSnapshots(ctx, cmd)
+}
+
+func (s *Service) handleUserDisabled(ctx context.Context, evt *events.UserDisabled) error {
+	cmd := &models.RevokeUserDashboardSnapshotsCommand{UserId: evt.Id}
+	if err := s.RevokeUserDashboardSnapshots(ctx, cmd); err != nil {
+		return err
+	}
+
+	if cmd.RevokedRows > 0 {
+		logger.Info("Revoked snapshots of disabled user", "userId", evt.Id, "count", cmd.RevokedRows)
+	}
+
+	return nil
+}
+
+func truncate(s string, length int) string {
+	if len(s) > length {
+		return s[:length]
+	}
+	return s
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/secrets/database"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -19,6 +20,7 @@ func TestDashboardSnapshotsService(t *testing.T) {
 	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
 
 	s := &Service{
+		Cfg:            setting.NewCfg(),
 		SQLStore:       sqlStore,
 		SecretsService: secretsService,
 	}
@@ -69,4 +71,113 @@ func TestDashboardSnapshotsService(t *testing.T) {
 
 		require.Equal(t, rawDashboard, decrypted)
 	})
+
+	t.Run("password protected snapshot requires the password", func(t *testing.T) {
+		ctx := context.Background()
+
+		cmd := models.CreateDashboardSnapshotCommand{
+			Key:       "protected",
+			DeleteKey: "protected-delete",
+			Dashboard: dashboard,
+			Password:  "secret",
+			OrgId:     1,
+		}
+		require.NoError(t, s.CreateDashboardSnapshot(ctx, &cmd))
+		require.NotEqual(t, []byte("secret"), cmd.Result.PasswordEncrypted)
+
+		query := models.GetDashboardSnapshotQuery{Key: "protected"}
+		require.NoError(t, s.GetDashboardSnapshot(ctx, &query))
+		require.True(t, query.Result.IsPasswordProtected())
+
+		access := &models.AccessDashboardSnapshotCommand{Snapshot: query.Result, ClientIp: "10.0.0.1"}
+		require.ErrorIs(t, s.AccessDashboardSnapshot(ctx, access), models.ErrDashboardSnapshotPasswordRequired)
+
+		access.Password = "wrong"
+		require.ErrorIs(t, s.AccessDashboardSnapshot(ctx, access), models.ErrDashboardSnapshotInvalidPassword)
+
+		access.Password = "secret"
+		access.UserId = 7
+		access.Login = "vendor"
+		require.NoError(t, s.AccessDashboardSnapshot(ctx, access))
+
+		logQuery := models.GetDashboardSnapshotAccessLogQuery{SnapshotId: query.Result.Id}
+		require.NoError(t, s.GetDashboardSnapshotAccessLog(ctx, &logQuery))
+		require.Len(t, logQuery.Result, 3)
+
+		granted := 0
+		for _, entry := range logQuery.Result {
+			require.Equal(t, "10.0.0.1", entry.ClientIp)
+			if entry.Granted {
+				granted++
+				require.Equal(t, "vendor", entry.Login)
+				require.Equal(t, int64(7), entry.UserId)
+			}
+		}
+		require.Equal(t, 1, granted)
+	})
+
+	t.Run("password guesses are limited per client", func(t *testing.T) {
+		ctx := context.Background()
+
+		cmd := models.CreateDashboardSnapshotCommand{
+			Key:       "guessed",
+			DeleteKey: "guessed-delete",
+			Dashboard: dashboard,
+			Password:  "secret",
+		}
+		require.NoError(t, s.CreateDashboardSnapshot(ctx, &cmd))
+
+		access := &models.AccessDashboardSnapshotCommand{Snapshot: cmd.Result, ClientIp: "10.0.0.2", Password: "wrong"}
+		for i := int64(0); i < maxInvalidPasswordAttempts; i++ {
+			require.ErrorIs(t, s.AccessDashboardSnapshot(ctx, access), models.ErrDashboardSnapshotInvalidPassword)
+		}
+
+		access.Password = "secret"
+		require.ErrorIs(t, s.AccessDashboardSnapshot(ctx, access), models.ErrDashboardSnapshotTooManyAttempts)
+
+		other := &models.AccessDashboardSnapshotCommand{Snapshot: cmd.Result, ClientIp: "10.0.0.3", Password: "secret"}
+		require.NoError(t, s.AccessDashboardSnapshot(ctx, other))
+	})
+
+	t.Run("snapshot cannot be viewed more than max views", func(t *testing.T) {
+		ctx := context.Background()
+
+		cmd := models.CreateDashboardSnapshotCommand{
+			Key:       "limited",
+			DeleteKey: "limited-delete",
+			Dashboard: dashboard,
+			MaxViews:  2,
+		}
+		require.NoError(t, s.CreateDashboardSnapshot(ctx, &cmd))
+
+		access := &models.AccessDashboardSnapshotCommand{Snapshot: cmd.Result}
+		require.NoError(t, s.AccessDashboardSnapshot(ctx, access))
+		require.NoError(t, s.AccessDashboardSnapshot(ctx, access))
+		require.ErrorIs(t, s.AccessDashboardSnapshot(ctx, access), models.ErrDashboardSnapshotViewLimitReached)
+
+		query := models.GetDashboardSnapshotQuery{Key: "limited"}
+		require.NoError(t, s.GetDashboardSnapshot(ctx, &query))
+		require.Equal(t, int64(2), query.Result.ViewCount)
+	})
+
+	t.Run("disabling a user revokes their snapshots", func(t *testing.T) {
+		ctx := context.Background()
+
+		cmd := models.CreateDashboardSnapshotCommand{
+			Key:       "owned",
+			DeleteKey: "owned-delete",
+			Dashboard: dashboard,
+			UserId:    42,
+		}
+		require.NoError(t, s.CreateDashboardSnapshot(ctx, &cmd))
+
+		require.NoError(t, s.handleUserDisabled(ctx, &events.UserDisabled{Id: 42}))
+
+		query := models.GetDashboardSnapshotQuery{Key: "owned"}
+		require.NoError(t, s.GetDashboardSnapshot(ctx, &query))
+		require.True(t, query.Result.Revoked)
+
+		access := &models.AccessDashboardSnapshotCommand{Snapshot: query.Result}
+		require.ErrorIs(t, s.AccessDashboardSnapshot(ctx, access), models.ErrDashboardSnapshotRevoked)
+	})
 }
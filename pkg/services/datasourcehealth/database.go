@@ -0,0 +1,66 @@
+package datasourcehealth
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func (s *Service) getAllDataSources(ctx context.Context) ([]*models.DataSource, error) {
+	dataSources := make([]*models.DataSource, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Find(&dataSources)
+	})
+	return dataSources, err
+}
+
+func (s *Service) saveChecks(ctx context.Context, checks []*DataSourceHealthCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	return s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.InsertMulti(checks)
+		return err
+	})
+}
+
+func (s *Service) deleteChecksBefore(ctx context.Context, before time.Time) error {
+	return s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Where("created < ?", before).Delete(&DataSourceHealthCheck{})
+		return err
+	})
+}
+
+func (s *Service) getChecks(ctx context.Context, orgID, dataSourceID int64, limit int) ([]*DataSourceHealthCheck, error) {
+	checks := make([]*DataSourceHealthCheck, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id=? AND data_source_id=?", orgID, dataSourceID).Desc("created").Limit(limit).Find(&checks)
+	})
+	return checks, err
+}
+
+// getLatestChecks returns the latest health check of every data source by ID,
+// in an organization or in all of them when orgID is 0.
+func (s *Service) getLatestChecks(ctx context.Context, orgID int64) (map[int64]*DataSourceHealthCheck, error) {
+	checks := make([]*DataSourceHealthCheck, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		latest := "SELECT data_source_id, MAX(created) AS created FROM data_source_health_check GROUP BY data_source_id"
+		sess.Join("INNER", "("+latest+") latest",
+			"data_source_health_check.data_source_id = latest.data_source_id AND data_source_health_check.created = latest.created")
+		if orgID != 0 {
+			sess.Where("data_source_health_check.org_id=?", orgID)
+		}
+		return sess.Find(&checks)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*DataSourceHealthCheck, len(checks))
+	for _, check := range checks {
+		byID[check.DataSourceId] = check
+	}
+	return byID, nil
+}
@@ -0,0 +1,76 @@
+package datasourcehealth
+
+import (
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	healthStatus        *prometheus.GaugeVec
+	healthCheckDuration *prometheus.GaugeVec
+	healthCheckTime     *prometheus.GaugeVec
+)
+
+func init() {
+	labels := []string{"org_id", "datasource_uid", "datasource_type"}
+
+	healthStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "datasource_health_status",
+		Help:      "Whether the latest health check of a data source succeeded (1) or not (0)",
+		Namespace: "grafana",
+	}, labels)
+
+	healthCheckDuration = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "datasource_health_check_duration_seconds",
+		Help:      "Duration of the latest health check of a data source",
+		Namespace: "grafana",
+	}, labels)
+
+	healthCheckTime = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "datasource_health_check_timestamp_seconds",
+		Help:      "Time of the latest health check of a data source",
+		Namespace: "grafana",
+	}, labels)
+}
+
+// updateHealthMetrics sets the metrics of the data sources to their latest
+// health check. The series of the previous update that are not set again,
+// e.g. those of deleted data sources, are removed. It returns the label sets
+// of the series that were set, for the next update.
+func updateHealthMetrics(previous map[string]prometheus.Labels, dataSources []*models.DataSource, latest map[int64]*DataSourceHealthCheck) map[string]prometheus.Labels {
+	current := make(map[string]prometheus.Labels, len(latest))
+	for _, ds := range dataSources {
+		check, ok := latest[ds.Id]
+		if !ok {
+			continue
+		}
+
+		labels := prometheus.Labels{
+			"org_id":          strconv.FormatInt(ds.OrgId, 10),
+			"datasource_uid":  ds.Uid,
+			"datasource_type": ds.Type,
+		}
+		status := 0.0
+		if check.IsOK() {
+			status = 1
+		}
+		healthStatus.With(labels).Set(status)
+		healthCheckDuration.With(labels).Set(float64(check.DurationMs) / 1000)
+		healthCheckTime.With(labels).Set(float64(check.Created.Unix()))
+		current[labels["org_id"]+"/"+ds.Uid+"/"+ds.Type] = labels
+	}
+
+	for key, labels := range previous {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		healthStatus.Delete(labels)
+		healthCheckDuration.Delete(labels)
+		healthCheckTime.Delete(labels)
+	}
+
+	return current
+}
@@ -0,0 +1,23 @@
+package datasourcehealth
+
+import (
+	"time"
+)
+
+// DataSourceHealthCheck is the outcome of a scheduled health check of a data
+// source.
+type DataSourceHealthCheck struct {
+	Id            int64     `json:"-"`
+	OrgId         int64     `json:"orgId"`
+	DataSourceId  int64     `json:"dataSourceId"`
+	DataSourceUid string    `json:"dataSourceUid"`
+	Status        string    `json:"status"`
+	Message       string    `json:"message"`
+	DurationMs    int64     `json:"durationMs"`
+	Created       time.Time `json:"created"`
+}
+
+// IsOK checks whether the data source was healthy.
+func (c *DataSourceHealthCheck) IsOK() bool {
+	return c.Status == statusOK
+}
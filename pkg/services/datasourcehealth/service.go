@@ -0,0 +1,224 @@
+package datasourcehealth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	statusOK    = "OK"
+	statusError = "ERROR"
+
+	// checkTimeout bounds a single health check, so that an unreachable
+	// data source does not hold up the others.
+	checkTimeout = 30 * time.Second
+	// maxConcurrentChecks is how many data sources are checked at once.
+	maxConcurrentChecks = 10
+)
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, pluginStore plugins.Store,
+	pluginClient plugins.Client, secretsService secrets.Service, serverLock *serverlock.ServerLockService,
+	pluginRequestValidator models.PluginRequestValidator) *Service {
+	return &Service{
+		Cfg:                    cfg,
+		SQLStore:               sqlStore,
+		pluginStore:            pluginStore,
+		pluginClient:           pluginClient,
+		SecretsService:         secretsService,
+		ServerLock:             serverLock,
+		PluginRequestValidator: pluginRequestValidator,
+		log:                    log.New("datasource.health"),
+	}
+}
+
+// Service periodically checks the health of every data source with a backend
+// plugin, keeps a history of the results and exposes them as metrics.
+type Service struct {
+	Cfg            *setting.Cfg
+	SQLStore       *sqlstore.SQLStore
+	pluginStore    plugins.Store
+	pluginClient   plugins.Client
+	SecretsService secrets.Service
+	ServerLock     *serverlock.ServerLockService
+	// PluginRequestValidator denies checks of the data sources whose URL
+	// can't be queried, as it does for queries.
+	PluginRequestValidator models.PluginRequestValidator
+	log                    log.Logger
+
+	// metricLabels are the label sets of the exposed metrics, so that the
+	// metrics of deleted data sources can be removed.
+	metricLabels map[string]prometheus.Labels
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.Cfg.DataSourceHealthCheckInterval > 0
+}
+
+// IsDisabled is used by the background service registry.
+func (s *Service) IsDisabled() bool {
+	return !s.IsEnabled()
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	interval := s.Cfg.DataSourceHealthCheckInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Only one instance checks the data sources, but all of them
+			// expose the results.
+			lockInterval := interval - interval/10
+			err := s.ServerLock.LockAndExecute(ctx, "datasource-health-checks", lockInterval, func(ctx context.Context) {
+				s.checkAll(ctx, time.Now())
+			})
+			if err != nil {
+				s.log.Error("Failed to lock health checks", "error", err)
+			}
+			s.updateMetrics(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// checkAll checks all data sources and stores the results, dropping the ones
+// older than the history retention.
+func (s *Service) checkAll(ctx context.Context, now time.Time) {
+	dataSources, err := s.getAllDataSources(ctx)
+	if err != nil {
+		s.log.Error("Failed to get data sources", "error", err)
+		return
+	}
+
+	results := make(chan *DataSourceHealthCheck, len(dataSources))
+	sem := make(chan struct{}, maxConcurrentChecks)
+	checked := 0
+	for _, ds := range dataSources {
+		plugin, exists := s.pluginStore.Plugin(ctx, ds.Type)
+		if !exists || !plugin.Backend {
+			continue
+		}
+
+		checked++
+		ds := ds
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			results <- s.check(ctx, ds, plugin.ID, now)
+		}()
+	}
+
+	checks := make([]*DataSourceHealthCheck, 0, checked)
+	for i := 0; i < checked; i++ {
+		check := <-results
+		if !check.IsOK() {
+			s.log.Warn("Data source is unhealthy", "uid", check.DataSourceUid, "orgId", check.OrgId, "message", check.Message)
+		}
+		checks = append(checks, check)
+	}
+
+	if err := s.saveChecks(ctx, checks); err != nil {
+		s.log.Error("Failed to save health checks", "error", err)
+	}
+	if err := s.deleteChecksBefore(ctx, now.Add(-s.Cfg.DataSourceHealthCheckRetention)); err != nil {
+		s.log.Error("Failed to delete old health checks", "error", err)
+	}
+}
+
+// check calls the health check of the plugin of a data source.
+func (s *Service) check(ctx context.Context, ds *models.DataSource, pluginID string, now time.Time) *DataSourceHealthCheck {
+	check := &DataSourceHealthCheck{
+		OrgId:         ds.OrgId,
+		DataSourceId:  ds.Id,
+		DataSourceUid: ds.Uid,
+		Status:        statusError,
+		Created:       now,
+	}
+
+	if err := s.PluginRequestValidator.Validate(ds.Url, nil); err != nil {
+		check.Message = fmt.Sprintf("access denied: %v", err)
+		return check
+	}
+
+	instanceSettings, err := adapters.ModelToInstanceSettings(ds, s.decryptSecureJsonData(ctx))
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to get data source settings: %v", err)
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := s.pluginClient.CheckHealth(ctx, &backend.CheckHealthRequest{
+		PluginContext: backend.PluginContext{
+			OrgID:                      ds.OrgId,
+			PluginID:                   pluginID,
+			DataSourceInstanceSettings: instanceSettings,
+		},
+	})
+	check.DurationMs = time.Since(start).Milliseconds()
+
+	switch {
+	case err != nil:
+		check.Message = err.Error()
+	case resp == nil:
+		check.Message = "empty health check response"
+	default:
+		check.Status = resp.Status.String()
+		check.Message = resp.Message
+	}
+	return check
+}
+
+func (s *Service) decryptSecureJsonData(ctx context.Context) func(map[string][]byte) map[string]string {
+	return func(m map[string][]byte) map[string]string {
+		decrypted, err := s.SecretsService.DecryptJsonData(ctx, m)
+		if err != nil {
+			s.log.Error("Failed to decrypt secure json data", "error", err)
+		}
+		return decrypted
+	}
+}
+
+// updateMetrics exposes the latest result of every data source.
+func (s *Service) updateMetrics(ctx context.Context) {
+	dataSources, err := s.getAllDataSources(ctx)
+	if err != nil {
+		s.log.Error("Failed to get data sources", "error", err)
+		return
+	}
+	latest, err := s.getLatestChecks(ctx, 0)
+	if err != nil {
+		s.log.Error("Failed to get health checks", "error", err)
+		return
+	}
+
+	s.metricLabels = updateHealthMetrics(s.metricLabels, dataSources, latest)
+}
+
+// LatestChecks returns the latest health check of the data sources of an
+// organization, by data source ID.
+func (s *Service) LatestChecks(ctx context.Context, orgID int64) (map[int64]*DataSourceHealthCheck, error) {
+	return s.getLatestChecks(ctx, orgID)
+}
+
+// History returns the most recent health checks of a data source, the latest
+// first.
+func (s *Service) History(ctx context.Context, orgID, dataSourceID int64, limit int) ([]*DataSourceHealthCheck, error) {
+	return s.getChecks(ctx, orgID, dataSourceID, limit)
+}
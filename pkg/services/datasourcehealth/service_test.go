@@ -0,0 +1,165 @@
+package datasourcehealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	client := &fakePluginClient{results: map[string]error{
+		"healthy":   nil,
+		"unhealthy": errors.New("401 Unauthorized"),
+	}}
+
+	s := &Service{
+		Cfg: &setting.Cfg{
+			DataSourceHealthCheckInterval:  time.Minute,
+			DataSourceHealthCheckRetention: time.Hour,
+		},
+		SQLStore: sqlStore,
+		pluginStore: &fakePluginStore{plugins: map[string]plugins.PluginDTO{
+			"prometheus": {JSONData: plugins.JSONData{ID: "prometheus", Backend: true}},
+			"frontend":   {JSONData: plugins.JSONData{ID: "frontend"}},
+		}},
+		pluginClient:           client,
+		SecretsService:         fakes.NewFakeSecretsService(),
+		PluginRequestValidator: &fakePluginRequestValidator{deniedURL: "http://denied"},
+		log:                    log.New("datasource.health.test"),
+	}
+
+	addDataSource := func(orgID int64, name, dsType string) *models.DataSource {
+		cmd := &models.AddDataSourceCommand{
+			OrgId: orgID, Name: name, Uid: name, Type: dsType, Access: models.DS_ACCESS_PROXY, Url: "http://" + name,
+		}
+		require.NoError(t, sqlStore.AddDataSource(context.Background(), cmd))
+		return cmd.Result
+	}
+	healthy := addDataSource(1, "healthy", "prometheus")
+	unhealthy := addDataSource(1, "unhealthy", "prometheus")
+	addDataSource(1, "frontend", "frontend")
+	denied := addDataSource(1, "denied", "prometheus")
+	other := addDataSource(2, "other", "prometheus")
+
+	now := time.Now().Truncate(time.Second)
+	s.checkAll(context.Background(), now.Add(-2*time.Hour))
+	s.checkAll(context.Background(), now.Add(-time.Minute))
+	s.checkAll(context.Background(), now)
+
+	t.Run("checks the data sources with a backend plugin", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"healthy", "unhealthy", "other"}, client.checked[:3])
+		assert.Len(t, client.checked, 9)
+	})
+
+	t.Run("returns the latest check of the data sources of an organization", func(t *testing.T) {
+		latest, err := s.LatestChecks(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, latest, 3)
+
+		assert.Equal(t, statusOK, latest[healthy.Id].Status)
+		assert.Equal(t, now, latest[healthy.Id].Created.Local())
+		assert.Equal(t, statusError, latest[unhealthy.Id].Status)
+		assert.Equal(t, "401 Unauthorized", latest[unhealthy.Id].Message)
+	})
+
+	t.Run("records a failed check without checking data sources whose URL is denied", func(t *testing.T) {
+		assert.NotContains(t, client.checked, "denied")
+
+		latest, err := s.LatestChecks(context.Background(), 1)
+		require.NoError(t, err)
+		require.Contains(t, latest, denied.Id)
+		assert.Equal(t, statusError, latest[denied.Id].Status)
+		assert.Equal(t, "access denied: URL not allowed", latest[denied.Id].Message)
+	})
+
+	t.Run("keeps the history within the retention", func(t *testing.T) {
+		history, err := s.History(context.Background(), 1, unhealthy.Id, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, now, history[0].Created.Local())
+		assert.Equal(t, now.Add(-time.Minute), history[1].Created.Local())
+
+		history, err = s.History(context.Background(), 1, other.Id, 10)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("exposes the latest checks as metrics", func(t *testing.T) {
+		s.updateMetrics(context.Background())
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(healthStatus.WithLabelValues("1", "healthy", "prometheus")))
+		assert.Equal(t, 0.0, testutil.ToFloat64(healthStatus.WithLabelValues("1", "unhealthy", "prometheus")))
+		assert.Equal(t, float64(now.Unix()), testutil.ToFloat64(healthCheckTime.WithLabelValues("2", "other", "prometheus")))
+	})
+
+	t.Run("removes the checks and metrics of deleted data sources", func(t *testing.T) {
+		require.Equal(t, 4, testutil.CollectAndCount(healthStatus))
+
+		err := sqlStore.DeleteDataSource(context.Background(), &models.DeleteDataSourceCommand{OrgID: 2, UID: other.Uid})
+		require.NoError(t, err)
+		s.updateMetrics(context.Background())
+
+		count, err := sqlStore.NewSession(context.Background()).Where("data_source_id = ?", other.Id).Count(&DataSourceHealthCheck{})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		assert.Equal(t, 3, testutil.CollectAndCount(healthStatus))
+		assert.Equal(t, 1.0, testutil.ToFloat64(healthStatus.WithLabelValues("1", "healthy", "prometheus")))
+	})
+}
+
+type fakePluginStore struct {
+	plugins.Store
+
+	plugins map[string]plugins.PluginDTO
+}
+
+func (s *fakePluginStore) Plugin(_ context.Context, pluginID string) (plugins.PluginDTO, bool) {
+	p, exists := s.plugins[pluginID]
+	return p, exists
+}
+
+type fakePluginRequestValidator struct {
+	deniedURL string
+}
+
+func (v *fakePluginRequestValidator) Validate(dsURL string, _ *http.Request) error {
+	if dsURL == v.deniedURL {
+		return errors.New("URL not allowed")
+	}
+	return nil
+}
+
+type fakePluginClient struct {
+	plugins.Client
+
+	mu      sync.Mutex
+	results map[string]error
+	checked []string
+}
+
+func (c *fakePluginClient) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	uid := req.PluginContext.DataSourceInstanceSettings.UID
+	c.checked = append(c.checked, uid)
+	if err := c.results[uid]; err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: err.Error()}, nil
+	}
+	return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Data source is working"}, nil
+}
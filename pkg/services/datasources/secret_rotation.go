@@ -0,0 +1,405 @@
+package datasources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var (
+	ErrNoStagedSecrets   = errors.New("data source has no staged secrets")
+	ErrNoPreviousSecrets = errors.New("data source has no previous secrets to roll back to")
+	ErrEmptySecrets      = errors.New("no secrets to stage")
+	ErrSecretsChanged    = errors.New("data source secrets changed since they were tested")
+)
+
+const (
+	// SecretSetStaged is the next set of secrets of a data source, which
+	// replaces the current one once promoted.
+	SecretSetStaged = "staged"
+	// SecretSetPrevious is the set of secrets replaced by the last promotion
+	// or rollback, kept to roll back to.
+	SecretSetPrevious = "previous"
+)
+
+// Secret rotation actions recorded in the audit trail.
+const (
+	SecretActionStage    = "stage"
+	SecretActionDiscard  = "discard"
+	SecretActionTest     = "test"
+	SecretActionPromote  = "promote"
+	SecretActionRollback = "rollback"
+)
+
+// DataSourceSecretSet is a staged or previous set of secrets of a data
+// source, encrypted like the secure JSON data of the data source.
+type DataSourceSecretSet struct {
+	Id             int64
+	OrgId          int64
+	DataSourceId   int64
+	State          string
+	SecureJsonData map[string][]byte
+	UserId         int64
+	Created        time.Time
+}
+
+// DataSourceSecretAudit is an entry of the audit trail of the secret
+// rotations of a data source. It never holds the secrets themselves.
+type DataSourceSecretAudit struct {
+	Id            int64     `json:"id"`
+	OrgId         int64     `json:"orgId"`
+	DataSourceId  int64     `json:"dataSourceId"`
+	DataSourceUid string    `json:"dataSourceUid"`
+	Action        string    `json:"action"`
+	UserId        int64     `json:"userId"`
+	UserLogin     string    `json:"userLogin"`
+	Success       bool      `json:"success"`
+	Message       string    `json:"message"`
+	Created       time.Time `json:"created"`
+}
+
+// SecretSetInfo describes a set of secrets without revealing them.
+type SecretSetInfo struct {
+	Fields  []string  `json:"fields"`
+	UserId  int64     `json:"userId"`
+	Created time.Time `json:"created"`
+}
+
+// SecretRotationStatus lists the staged and previous secrets of a data
+// source.
+type SecretRotationStatus struct {
+	Staged   *SecretSetInfo `json:"staged"`
+	Previous *SecretSetInfo `json:"previous"`
+}
+
+// StageSecrets stores the next secrets of a data source, replacing any that
+// were staged before. Fields that are not staged keep their current value
+// once promoted.
+func (s *Service) StageSecrets(ctx context.Context, user *models.SignedInUser, ds *models.DataSource, secureJsonData map[string]string) error {
+	if ds.ReadOnly {
+		return models.ErrDatasourceIsReadOnly
+	}
+	if len(secureJsonData) == 0 {
+		return ErrEmptySecrets
+	}
+
+	encrypted, err := s.SecretsService.EncryptJsonData(ctx, secureJsonData, secrets.WithoutScope())
+	if err != nil {
+		return err
+	}
+
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		set := &DataSourceSecretSet{
+			OrgId:          ds.OrgId,
+			DataSourceId:   ds.Id,
+			State:          SecretSetStaged,
+			SecureJsonData: encrypted,
+			UserId:         user.UserId,
+			Created:        time.Now(),
+		}
+		if err := replaceSecretSet(sess, set); err != nil {
+			return err
+		}
+
+		return insertSecretAudit(sess, user, ds, SecretActionStage, true, "staged "+fieldList(encrypted))
+	})
+}
+
+// DiscardStagedSecrets deletes the staged secrets of a data source.
+func (s *Service) DiscardStagedSecrets(ctx context.Context, user *models.SignedInUser, ds *models.DataSource) error {
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("data_source_id=? AND state=?", ds.Id, SecretSetStaged).Delete(&DataSourceSecretSet{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrNoStagedSecrets
+		}
+
+		return insertSecretAudit(sess, user, ds, SecretActionDiscard, true, "")
+	})
+}
+
+// WithStagedSecrets returns a copy of the data source with its staged
+// secrets, as it would be once they are promoted. The copy has the negated ID
+// of the data source, so that plugins create a separate instance for it and
+// keep the one of the data source, and it is updated now, so that the
+// instance is not reused for other staged secrets.
+func (s *Service) WithStagedSecrets(ctx context.Context, ds *models.DataSource) (*models.DataSource, error) {
+	var staged *DataSourceSecretSet
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		staged, err = getSecretSet(sess, ds.Id, SecretSetStaged)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if staged == nil {
+		return nil, ErrNoStagedSecrets
+	}
+
+	withStaged := *ds
+	withStaged.Id = -ds.Id
+	withStaged.SecureJsonData = mergeSecrets(ds.SecureJsonData, staged.SecureJsonData)
+	withStaged.Updated = time.Now()
+	return &withStaged, nil
+}
+
+// RecordSecretTest adds the outcome of a health check of the staged secrets of
+// a data source to the audit trail.
+func (s *Service) RecordSecretTest(ctx context.Context, user *models.SignedInUser, ds *models.DataSource, success bool, message string) error {
+	return s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return insertSecretAudit(sess, user, ds, SecretActionTest, success, message)
+	})
+}
+
+// PromoteStagedSecrets replaces the secrets of a data source with the staged
+// ones in a single transaction, keeping the replaced secrets for a rollback.
+// The secrets must still be the ones of tested, as returned by
+// WithStagedSecrets, so that only checked secrets are promoted.
+func (s *Service) PromoteStagedSecrets(ctx context.Context, user *models.SignedInUser, ds *models.DataSource, tested *models.DataSource) error {
+	err := s.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
+		current, staged, err := s.getDataSourceAndSecretSet(ctx, ds, SecretSetStaged)
+		if err != nil {
+			return err
+		}
+		if staged == nil {
+			return ErrNoStagedSecrets
+		}
+
+		merged := mergeSecrets(current.SecureJsonData, staged.SecureJsonData)
+		if !reflect.DeepEqual(merged, tested.SecureJsonData) {
+			return ErrSecretsChanged
+		}
+
+		if err := s.swapSecrets(ctx, user, current, merged); err != nil {
+			return err
+		}
+
+		return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			if _, err := sess.ID(staged.Id).Delete(&DataSourceSecretSet{}); err != nil {
+				return err
+			}
+
+			return insertSecretAudit(sess, user, ds, SecretActionPromote, true, "promoted "+fieldList(staged.SecureJsonData))
+		})
+	})
+	if err != nil {
+		s.recordFailedRotation(ctx, user, ds, SecretActionPromote, err)
+	}
+	return err
+}
+
+// RollbackSecrets restores the secrets of a data source replaced by the last
+// promotion or rollback.
+func (s *Service) RollbackSecrets(ctx context.Context, user *models.SignedInUser, ds *models.DataSource) error {
+	err := s.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
+		current, previous, err := s.getDataSourceAndSecretSet(ctx, ds, SecretSetPrevious)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			return ErrNoPreviousSecrets
+		}
+
+		if err := s.swapSecrets(ctx, user, current, previous.SecureJsonData); err != nil {
+			return err
+		}
+
+		return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			return insertSecretAudit(sess, user, ds, SecretActionRollback, true, "restored secrets of "+previous.Created.UTC().Format(time.RFC3339))
+		})
+	})
+	if err != nil {
+		s.recordFailedRotation(ctx, user, ds, SecretActionRollback, err)
+	}
+	return err
+}
+
+// recordFailedRotation adds a failed promotion or rollback to the audit trail,
+// once its transaction was rolled back.
+func (s *Service) recordFailedRotation(ctx context.Context, user *models.SignedInUser, ds *models.DataSource, action string, rotationErr error) {
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return insertSecretAudit(sess, user, ds, action, false, rotationErr.Error())
+	})
+	if err != nil {
+		plog.Error("Failed to record failed secret rotation", "datasource", ds.Uid, "action", action, "error", err)
+	}
+}
+
+// getDataSourceAndSecretSet returns the data source as stored and its set of
+// secrets in the given state, if any.
+func (s *Service) getDataSourceAndSecretSet(ctx context.Context, ds *models.DataSource, state string) (*models.DataSource, *DataSourceSecretSet, error) {
+	var current *models.DataSource
+	var set *DataSourceSecretSet
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		if current, err = getDataSourceForUpdate(sess, ds); err != nil {
+			return err
+		}
+		set, err = getSecretSet(sess, ds.Id, state)
+		return err
+	})
+	return current, set, err
+}
+
+// swapSecrets sets the secrets of a data source, keeping the current ones as
+// the previous set. The data source is updated like any other update, failing
+// if it was updated since it was read. It must be called in a transaction.
+func (s *Service) swapSecrets(ctx context.Context, user *models.SignedInUser, ds *models.DataSource, secureJsonData map[string][]byte) error {
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return replaceSecretSet(sess, &DataSourceSecretSet{
+			OrgId:          ds.OrgId,
+			DataSourceId:   ds.Id,
+			State:          SecretSetPrevious,
+			SecureJsonData: ds.SecureJsonData,
+			UserId:         user.UserId,
+			Created:        time.Now(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := s.SecretsService.DecryptJsonData(ctx, secureJsonData)
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateDataSource(ctx, &models.UpdateDataSourceCommand{
+		Name:              ds.Name,
+		Type:              ds.Type,
+		Access:            ds.Access,
+		Url:               ds.Url,
+		Password:          ds.Password,
+		User:              ds.User,
+		Database:          ds.Database,
+		BasicAuth:         ds.BasicAuth,
+		BasicAuthUser:     ds.BasicAuthUser,
+		BasicAuthPassword: ds.BasicAuthPassword,
+		WithCredentials:   ds.WithCredentials,
+		IsDefault:         ds.IsDefault,
+		JsonData:          ds.JsonData,
+		SecureJsonData:    decrypted,
+		Version:           ds.Version,
+		Uid:               ds.Uid,
+		OrgId:             ds.OrgId,
+		Id:                ds.Id,
+		ReadOnly:          ds.ReadOnly,
+	})
+}
+
+// SecretRotationStatus returns which secrets of a data source are staged and
+// can be rolled back to.
+func (s *Service) SecretRotationStatus(ctx context.Context, ds *models.DataSource) (*SecretRotationStatus, error) {
+	status := &SecretRotationStatus{}
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		sets := make([]*DataSourceSecretSet, 0)
+		if err := sess.Where("data_source_id=?", ds.Id).Find(&sets); err != nil {
+			return err
+		}
+
+		for _, set := range sets {
+			info := &SecretSetInfo{Fields: fieldNames(set.SecureJsonData), UserId: set.UserId, Created: set.Created}
+			switch set.State {
+			case SecretSetStaged:
+				status.Staged = info
+			case SecretSetPrevious:
+				status.Previous = info
+			}
+		}
+		return nil
+	})
+	return status, err
+}
+
+// SecretAuditTrail returns the secret rotation audit trail of a data source,
+// the latest first.
+func (s *Service) SecretAuditTrail(ctx context.Context, ds *models.DataSource, limit int) ([]*DataSourceSecretAudit, error) {
+	entries := make([]*DataSourceSecretAudit, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id=? AND data_source_id=?", ds.OrgId, ds.Id).Desc("id").Limit(limit).Find(&entries)
+	})
+	return entries, err
+}
+
+func getDataSourceForUpdate(sess *sqlstore.DBSession, ds *models.DataSource) (*models.DataSource, error) {
+	current := &models.DataSource{}
+	exists, err := sess.Where("id=? AND org_id=?", ds.Id, ds.OrgId).Get(current)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrDataSourceNotFound
+	}
+	if current.ReadOnly {
+		return nil, models.ErrDatasourceIsReadOnly
+	}
+	return current, nil
+}
+
+func getSecretSet(sess *sqlstore.DBSession, dataSourceID int64, state string) (*DataSourceSecretSet, error) {
+	set := &DataSourceSecretSet{}
+	exists, err := sess.Where("data_source_id=? AND state=?", dataSourceID, state).Get(set)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return set, nil
+}
+
+func replaceSecretSet(sess *sqlstore.DBSession, set *DataSourceSecretSet) error {
+	if _, err := sess.Where("data_source_id=? AND state=?", set.DataSourceId, set.State).Delete(&DataSourceSecretSet{}); err != nil {
+		return err
+	}
+	_, err := sess.Insert(set)
+	return err
+}
+
+func insertSecretAudit(sess *sqlstore.DBSession, user *models.SignedInUser, ds *models.DataSource, action string, success bool, message string) error {
+	_, err := sess.Insert(&DataSourceSecretAudit{
+		OrgId:         ds.OrgId,
+		DataSourceId:  ds.Id,
+		DataSourceUid: ds.Uid,
+		Action:        action,
+		UserId:        user.UserId,
+		UserLogin:     user.Login,
+		Success:       success,
+		Message:       message,
+		Created:       time.Now(),
+	})
+	return err
+}
+
+// mergeSecrets returns the current secrets overridden by the staged ones.
+func mergeSecrets(current, staged map[string][]byte) map[string][]byte {
+	merged := make(map[string][]byte, len(current)+len(staged))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range staged {
+		merged[k] = v
+	}
+	return merged
+}
+
+func fieldNames(secureJsonData map[string][]byte) []string {
+	fields := make([]string, 0, len(secureJsonData))
+	for k := range secureJsonData {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func fieldList(secureJsonData map[string][]byte) string {
+	return fmt.Sprintf("fields: %s", strings.Join(fieldNames(secureJsonData), ", "))
+}
@@ -0,0 +1,153 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretRotation(t *testing.T) {
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+	s := ProvideService(bus.New(), sqlStore, secretsService, &acmock.Mock{})
+	user := &models.SignedInUser{OrgId: 1, UserId: 2, Login: "admin"}
+
+	cmd := models.AddDataSourceCommand{
+		OrgId:          1,
+		Name:           "postgres",
+		Uid:            "postgres",
+		Type:           "postgres",
+		SecureJsonData: map[string]string{"password": "old", "tlsClientKey": "key"},
+	}
+	require.NoError(t, s.AddDataSource(ctx, &cmd))
+
+	getDataSource := func() *models.DataSource {
+		query := &models.GetDataSourceQuery{OrgId: 1, Uid: "postgres"}
+		require.NoError(t, sqlStore.GetDataSource(ctx, query))
+		return query.Result
+	}
+	secureJsonData := func(ds *models.DataSource) map[string]string {
+		decrypted, err := secretsService.DecryptJsonData(ctx, ds.SecureJsonData)
+		require.NoError(t, err)
+		return decrypted
+	}
+
+	t.Run("cannot promote or roll back without staged or previous secrets", func(t *testing.T) {
+		ds := getDataSource()
+		_, err := s.WithStagedSecrets(ctx, ds)
+		require.ErrorIs(t, err, ErrNoStagedSecrets)
+		require.ErrorIs(t, s.RollbackSecrets(ctx, user, ds), ErrNoPreviousSecrets)
+		require.ErrorIs(t, s.StageSecrets(ctx, user, ds, nil), ErrEmptySecrets)
+	})
+
+	t.Run("stages secrets without changing the current ones", func(t *testing.T) {
+		ds := getDataSource()
+		require.NoError(t, s.StageSecrets(ctx, user, ds, map[string]string{"password": "new"}))
+
+		assert.Equal(t, map[string]string{"password": "old", "tlsClientKey": "key"}, secureJsonData(getDataSource()))
+
+		staged, err := s.WithStagedSecrets(ctx, ds)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"password": "new", "tlsClientKey": "key"}, secureJsonData(staged))
+		// The plugin instance of the data source is not replaced by the one of the test.
+		assert.Equal(t, -ds.Id, staged.Id)
+
+		status, err := s.SecretRotationStatus(ctx, ds)
+		require.NoError(t, err)
+		require.NotNil(t, status.Staged)
+		assert.Equal(t, []string{"password"}, status.Staged.Fields)
+		assert.Nil(t, status.Previous)
+	})
+
+	t.Run("refuses to promote secrets that changed since they were tested", func(t *testing.T) {
+		ds := getDataSource()
+		tested, err := s.WithStagedSecrets(ctx, ds)
+		require.NoError(t, err)
+
+		require.NoError(t, s.StageSecrets(ctx, user, ds, map[string]string{"password": "newer"}))
+		require.ErrorIs(t, s.PromoteStagedSecrets(ctx, user, ds, tested), ErrSecretsChanged)
+		assert.Equal(t, "old", secureJsonData(getDataSource())["password"])
+	})
+
+	t.Run("promotes the tested secrets and keeps the current ones", func(t *testing.T) {
+		ds := getDataSource()
+		tested, err := s.WithStagedSecrets(ctx, ds)
+		require.NoError(t, err)
+		require.NoError(t, s.RecordSecretTest(ctx, user, ds, true, "Database Connection OK"))
+		require.NoError(t, s.PromoteStagedSecrets(ctx, user, ds, tested))
+
+		promoted := getDataSource()
+		assert.Equal(t, map[string]string{"password": "newer", "tlsClientKey": "key"}, secureJsonData(promoted))
+		assert.Equal(t, ds.Version+1, promoted.Version)
+
+		status, err := s.SecretRotationStatus(ctx, ds)
+		require.NoError(t, err)
+		assert.Nil(t, status.Staged)
+		require.NotNil(t, status.Previous)
+		assert.Equal(t, []string{"password", "tlsClientKey"}, status.Previous.Fields)
+	})
+
+	t.Run("rolls back to the previous secrets", func(t *testing.T) {
+		require.NoError(t, s.RollbackSecrets(ctx, user, getDataSource()))
+		assert.Equal(t, "old", secureJsonData(getDataSource())["password"])
+
+		// The rolled back secrets can be restored again.
+		require.NoError(t, s.RollbackSecrets(ctx, user, getDataSource()))
+		assert.Equal(t, "newer", secureJsonData(getDataSource())["password"])
+	})
+
+	t.Run("keeps an audit trail without the secrets", func(t *testing.T) {
+		entries, err := s.SecretAuditTrail(ctx, getDataSource(), 100)
+		require.NoError(t, err)
+
+		actions := make([]string, 0, len(entries))
+		failed := make([]string, 0)
+		for _, entry := range entries {
+			actions = append(actions, entry.Action)
+			if !entry.Success {
+				failed = append(failed, entry.Message)
+			}
+			assert.Equal(t, "admin", entry.UserLogin)
+			assert.Equal(t, "postgres", entry.DataSourceUid)
+			assert.NotContains(t, entry.Message, "newer")
+		}
+		assert.Equal(t, []string{
+			SecretActionRollback, SecretActionRollback, SecretActionPromote, SecretActionTest, SecretActionPromote,
+			SecretActionStage, SecretActionStage, SecretActionRollback,
+		}, actions)
+		assert.Equal(t, []string{ErrSecretsChanged.Error(), ErrNoPreviousSecrets.Error()}, failed)
+	})
+
+	t.Run("refuses to rotate the secrets of read-only data sources", func(t *testing.T) {
+		ds := getDataSource()
+		ds.ReadOnly = true
+		require.ErrorIs(t, s.StageSecrets(ctx, user, ds, map[string]string{"password": "new"}), models.ErrDatasourceIsReadOnly)
+	})
+
+	t.Run("deletes the secrets but keeps the audit trail with the data source", func(t *testing.T) {
+		ds := getDataSource()
+		require.NoError(t, s.StageSecrets(ctx, user, ds, map[string]string{"password": "next"}))
+		require.NoError(t, s.DeleteDataSource(ctx, &models.DeleteDataSourceCommand{OrgID: 1, UID: ds.Uid}))
+
+		err := sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			sets, err := sess.Where("data_source_id=?", ds.Id).Count(&DataSourceSecretSet{})
+			require.NoError(t, err)
+			assert.Zero(t, sets)
+
+			entries, err := sess.Where("data_source_id=?", ds.Id).Count(&DataSourceSecretAudit{})
+			require.NoError(t, err)
+			assert.Equal(t, int64(9), entries)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
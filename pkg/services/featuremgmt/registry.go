@@ -59,10 +59,9 @@ var (
 			FrontendOnly: true,
 		},
 		{
-			Name:         "tempoSearch",
-			Description:  "Enable searching in tempo datasources",
-			State:        FeatureStateBeta,
-			FrontendOnly: true,
+			Name:        "tempoSearch",
+			Description: "Enable searching in tempo datasources",
+			State:       FeatureStateBeta,
 		},
 		{
 			Name:        "tempoBackendSearch",
@@ -70,10 +69,9 @@ var (
 			State:       FeatureStateBeta,
 		},
 		{
-			Name:         "tempoServiceGraph",
-			Description:  "show service",
-			State:        FeatureStateBeta,
-			FrontendOnly: true,
+			Name:        "tempoServiceGraph",
+			Description: "show service",
+			State:       FeatureStateBeta,
 		},
 		{
 			Name:         "fullRangeLogsVolume",
@@ -113,5 +111,15 @@ var (
 			State:           FeatureStateAlpha,
 			RequiresDevMode: true,
 		},
+		{
+			Name:        "fullTextDashboardSearch",
+			Description: "Search dashboards by panel titles, queries and datasources using an in-memory index",
+			State:       FeatureStateAlpha,
+		},
+		{
+			Name:        "scheduledReports",
+			Description: "Email dashboards as PDF or PNG reports on a schedule",
+			State:       FeatureStateAlpha,
+		},
 	}
 )
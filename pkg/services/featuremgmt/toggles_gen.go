@@ -82,4 +82,12 @@ const (
 	// FlagValidatedQueries
 	// only execute the query saved in a panel
 	FlagValidatedQueries = "validatedQueries"
+
+	// FlagFullTextDashboardSearch
+	// Search dashboards by panel titles, queries and datasources using an in-memory index
+	FlagFullTextDashboardSearch = "fullTextDashboardSearch"
+
+	// FlagScheduledReports
+	// Email dashboards as PDF or PNG reports on a schedule
+	FlagScheduledReports = "scheduledReports"
 )
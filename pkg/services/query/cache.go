@@ -0,0 +1,250 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// cacheStatusHeader tells the client whether the response was served from the cache.
+	cacheStatusHeader = "X-Cache"
+
+	cacheStatusHit    = "HIT"
+	cacheStatusMiss   = "MISS"
+	cacheStatusBypass = "BYPASS"
+
+	// queryCachingTTLKey is the data source json data setting that overrides
+	// the default TTL, either as a duration or in milliseconds.
+	queryCachingTTLKey = "queryCachingTTL"
+
+	cacheKeyPrefix = "query-response-"
+)
+
+type queryDataFunc func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error)
+
+// responseCache caches data source query responses. Responses are keyed by the
+// data source, the normalized query models and the time range aligned to the
+// query interval, so that refreshes within the same interval share a response.
+type responseCache struct {
+	storage    remotecache.CacheStorage
+	defaultTTL time.Duration
+	log        log.Logger
+}
+
+func newResponseCache(cfg *setting.Cfg, remoteCache *remotecache.RemoteCache) *responseCache {
+	if cfg == nil || !cfg.QueryCaching.Enabled {
+		return nil
+	}
+
+	var storage remotecache.CacheStorage = &memoryCacheStorage{
+		cache: localcache.New(cfg.QueryCaching.TTL, 2*cfg.QueryCaching.TTL),
+	}
+	if cfg.QueryCaching.Backend == setting.QueryCachingBackendRemote && remoteCache != nil {
+		storage = remoteCache
+	}
+
+	return &responseCache{
+		storage:    storage,
+		defaultTTL: cfg.QueryCaching.TTL,
+		log:        log.New("query_cache"),
+	}
+}
+
+func (c *responseCache) queryData(ctx context.Context, ds *models.DataSource, req *backend.QueryDataRequest, skipCache bool,
+	queryData queryDataFunc) (*backend.QueryDataResponse, error) {
+	ttl := c.ttl(ds)
+	if ttl <= 0 {
+		return queryData(ctx, req)
+	}
+
+	key, err := cacheKey(ds, req.Queries)
+	if err != nil {
+		c.log.Warn("Failed to create query cache key", "error", err)
+		setCacheStatus(ctx, ds, cacheStatusBypass)
+		return queryData(ctx, req)
+	}
+
+	if skipCache {
+		setCacheStatus(ctx, ds, cacheStatusBypass)
+	} else if resp, ok := c.get(ctx, key); ok {
+		setCacheStatus(ctx, ds, cacheStatusHit)
+		return resp, nil
+	} else {
+		setCacheStatus(ctx, ds, cacheStatusMiss)
+	}
+
+	resp, err := queryData(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	// Errors are often transient, so only complete responses are cached.
+	for _, res := range resp.Responses {
+		if res.Error != nil {
+			return resp, nil
+		}
+	}
+
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		c.log.Warn("Failed to encode query response", "error", err)
+		return resp, nil
+	}
+	if err := c.storage.Set(ctx, key, encoded, ttl); err != nil {
+		c.log.Warn("Failed to cache query response", "error", err)
+	}
+
+	return resp, nil
+}
+
+func (c *responseCache) get(ctx context.Context, key string) (*backend.QueryDataResponse, bool) {
+	cached, err := c.storage.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			c.log.Warn("Failed to read cached query response", "error", err)
+		}
+		return nil, false
+	}
+
+	encoded, ok := cached.([]byte)
+	if !ok {
+		return nil, false
+	}
+
+	resp := &backend.QueryDataResponse{}
+	if err := json.Unmarshal(encoded, resp); err != nil {
+		c.log.Warn("Failed to decode cached query response", "error", err)
+		return nil, false
+	}
+	return resp, true
+}
+
+// ttl returns how long responses of the data source are cached for. A zero
+// TTL disables caching.
+func (c *responseCache) ttl(ds *models.DataSource) time.Duration {
+	ttl, ok, err := jsonDataDuration(ds.JsonData, queryCachingTTLKey)
+	if err != nil {
+		c.log.Warn("Invalid query caching TTL", "datasource", ds.Uid, "error", err)
+		return c.defaultTTL
+	}
+	if !ok {
+		return c.defaultTTL
+	}
+	return ttl
+}
+
+// jsonDataDuration reads a duration from the json data of a data source,
+// either as a duration string or in milliseconds.
+func jsonDataDuration(jsonData *simplejson.Json, key string) (time.Duration, bool, error) {
+	if jsonData == nil {
+		return 0, false, nil
+	}
+
+	value, ok := jsonData.CheckGet(key)
+	if !ok {
+		return 0, false, nil
+	}
+
+	if ms, err := value.Int64(); err == nil {
+		return time.Duration(ms) * time.Millisecond, true, nil
+	}
+
+	if s, err := value.String(); err == nil {
+		d, err := gtime.ParseDuration(s)
+		if err != nil {
+			return 0, false, err
+		}
+		return d, true, nil
+	}
+
+	return 0, false, fmt.Errorf("invalid duration %v for %s", value.Interface(), key)
+}
+
+// cacheKey hashes everything that determines the response of a query. The
+// data source version is included so that changing the data source settings
+// invalidates its cached responses.
+func cacheKey(ds *models.DataSource, queries []backend.DataQuery) (string, error) {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%d/%s/%d\n", ds.OrgId, ds.Uid, ds.Version)
+
+	for _, q := range queries {
+		model, err := normalizeQueryJSON(q.JSON)
+		if err != nil {
+			return "", err
+		}
+
+		interval := q.Interval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		from := q.TimeRange.From.Truncate(interval).UnixNano()
+		to := q.TimeRange.To.Truncate(interval).UnixNano()
+
+		_, _ = fmt.Fprintf(h, "%s/%s/%d/%d/%d/%d\n", q.RefID, q.QueryType, q.MaxDataPoints, interval, from, to)
+		_, _ = h.Write(model)
+		_, _ = h.Write([]byte("\n"))
+	}
+
+	return cacheKeyPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// normalizeQueryJSON re-encodes a query model with sorted keys and without
+// the properties that change on every request.
+func normalizeQueryJSON(raw json.RawMessage) ([]byte, error) {
+	var model map[string]interface{}
+	if err := json.Unmarshal(raw, &model); err != nil {
+		return nil, err
+	}
+
+	delete(model, "requestId")
+	delete(model, "key")
+
+	return json.Marshal(model)
+}
+
+func setCacheStatus(ctx context.Context, ds *models.DataSource, status string) {
+	queryCacheRequests.WithLabelValues(ds.Type, status).Inc()
+
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil {
+		reqCtx.Resp.Header().Set(cacheStatusHeader, status)
+	}
+}
+
+// memoryCacheStorage stores cached responses in the memory of the local instance.
+type memoryCacheStorage struct {
+	cache *localcache.CacheService
+}
+
+func (s *memoryCacheStorage) Get(ctx context.Context, key string) (interface{}, error) {
+	value, ok := s.cache.Get(key)
+	if !ok {
+		return nil, remotecache.ErrCacheItemNotFound
+	}
+	return value, nil
+}
+
+func (s *memoryCacheStorage) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	s.cache.Set(key, value, expire)
+	return nil
+}
+
+func (s *memoryCacheStorage) Delete(ctx context.Context, key string) error {
+	s.cache.Delete(key)
+	return nil
+}
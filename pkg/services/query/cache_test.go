@@ -0,0 +1,72 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestCacheKey(t *testing.T) {
+	ds := &models.DataSource{OrgId: 1, Uid: "abc", Version: 1}
+	query := func(model string, from time.Time) backend.DataQuery {
+		return backend.DataQuery{
+			RefID:     "A",
+			JSON:      []byte(model),
+			Interval:  time.Minute,
+			TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)},
+		}
+	}
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	key, err := cacheKey(ds, []backend.DataQuery{query(`{"expr": "up", "refId": "A", "requestId": "1"}`, now)})
+	require.NoError(t, err)
+
+	t.Run("ignores key order, request ids and times within the interval", func(t *testing.T) {
+		other, err := cacheKey(ds, []backend.DataQuery{query(`{"refId": "A", "expr": "up", "requestId": "2"}`, now.Add(30*time.Second))})
+		require.NoError(t, err)
+		require.Equal(t, key, other)
+	})
+
+	t.Run("changes with the query, the time range and the data source", func(t *testing.T) {
+		other, err := cacheKey(ds, []backend.DataQuery{query(`{"expr": "down", "refId": "A"}`, now)})
+		require.NoError(t, err)
+		require.NotEqual(t, key, other)
+
+		other, err = cacheKey(ds, []backend.DataQuery{query(`{"expr": "up", "refId": "A"}`, now.Add(time.Minute))})
+		require.NoError(t, err)
+		require.NotEqual(t, key, other)
+
+		updated := &models.DataSource{OrgId: 1, Uid: "abc", Version: 2}
+		other, err = cacheKey(updated, []backend.DataQuery{query(`{"expr": "up", "refId": "A"}`, now)})
+		require.NoError(t, err)
+		require.NotEqual(t, key, other)
+	})
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	c := &responseCache{defaultTTL: time.Minute, log: log.New("query_cache.test")}
+
+	tests := map[string]struct {
+		jsonData map[string]interface{}
+		expected time.Duration
+	}{
+		"default":      {jsonData: map[string]interface{}{}, expected: time.Minute},
+		"milliseconds": {jsonData: map[string]interface{}{"queryCachingTTL": 5000}, expected: 5 * time.Second},
+		"duration":     {jsonData: map[string]interface{}{"queryCachingTTL": "5m"}, expected: 5 * time.Minute},
+		"disabled":     {jsonData: map[string]interface{}{"queryCachingTTL": 0}, expected: 0},
+		"invalid":      {jsonData: map[string]interface{}{"queryCachingTTL": "soon"}, expected: time.Minute},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ds := &models.DataSource{JsonData: simplejson.NewFromAny(tc.jsonData)}
+			require.Equal(t, tc.expected, c.ttl(ds))
+		})
+	}
+}
@@ -15,3 +15,14 @@ func NewErrBadQuery(msg string) *ErrBadQuery {
 func (e ErrBadQuery) Error() string {
 	return fmt.Sprintf("bad query: %s", e.Message)
 }
+
+// ErrQueryThrottled is returned for queries that waited too long for the
+// query limits of their data source.
+type ErrQueryThrottled struct {
+	DataSource string
+	Limit      string
+}
+
+func (e ErrQueryThrottled) Error() string {
+	return fmt.Sprintf("too many requests: the %s query limit of data source %q was reached, try again later", e.Limit, e.DataSource)
+}
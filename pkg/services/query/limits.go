@@ -0,0 +1,276 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Data source json data settings that limit the queries sent to a data
+// source, in total and for every user.
+const (
+	queryConcurrencyLimitKey     = "queryConcurrencyLimit"
+	queryRateLimitKey            = "queryRateLimit"
+	userQueryConcurrencyLimitKey = "userQueryConcurrencyLimit"
+	userQueryRateLimitKey        = "userQueryRateLimit"
+	queryQueueTimeoutKey         = "queryQueueTimeout"
+
+	defaultQueryQueueTimeout = 10 * time.Second
+)
+
+// idleLimiterTimeout is how long the limiter of a data source or user is kept
+// after its last query.
+const idleLimiterTimeout = 10 * time.Minute
+
+type queryLimits struct {
+	concurrency     int
+	rate            float64
+	userConcurrency int
+	userRate        float64
+	queueTimeout    time.Duration
+}
+
+func (l queryLimits) enabled() bool {
+	return l.concurrency > 0 || l.rate > 0 || l.userConcurrency > 0 || l.userRate > 0
+}
+
+// queryLimiter limits the number of concurrent queries and queries per
+// second of every data source and of every user of a data source. Requests
+// above a limit wait until the queue timeout of the data source.
+type queryLimiter struct {
+	mu        sync.Mutex
+	limiters  map[string]*limiter
+	lastSweep time.Time
+	now       func() time.Time
+	log       log.Logger
+}
+
+func newQueryLimiter() *queryLimiter {
+	return &queryLimiter{
+		limiters: map[string]*limiter{},
+		now:      time.Now,
+		log:      log.New("query_data.limits"),
+	}
+}
+
+// limitsOf returns the query limits of a data source. Invalid settings are
+// ignored, so that they do not break the queries of the data source.
+func (ql *queryLimiter) limitsOf(ds *models.DataSource) queryLimits {
+	limits := queryLimits{queueTimeout: defaultQueryQueueTimeout}
+	if ds.JsonData == nil {
+		return limits
+	}
+
+	limits.concurrency = int(ql.jsonDataNumber(ds, queryConcurrencyLimitKey))
+	limits.rate = ql.jsonDataNumber(ds, queryRateLimitKey)
+	limits.userConcurrency = int(ql.jsonDataNumber(ds, userQueryConcurrencyLimitKey))
+	limits.userRate = ql.jsonDataNumber(ds, userQueryRateLimitKey)
+
+	timeout, ok, err := jsonDataDuration(ds.JsonData, queryQueueTimeoutKey)
+	switch {
+	case err != nil:
+		ql.log.Warn("Invalid query queue timeout, using the default", "datasource", ds.Uid, "error", err, "default", defaultQueryQueueTimeout)
+	case ok:
+		limits.queueTimeout = timeout
+	}
+
+	return limits
+}
+
+// jsonDataNumber reads a limit from the json data of a data source. Limits
+// saved from a text input are strings, so numbers in strings are accepted.
+func (ql *queryLimiter) jsonDataNumber(ds *models.DataSource, key string) float64 {
+	value, ok := ds.JsonData.CheckGet(key)
+	if !ok {
+		return 0
+	}
+
+	if n, err := value.Float64(); err == nil {
+		return n
+	}
+	if s, err := value.String(); err == nil {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return 0
+		}
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	}
+
+	ql.log.Warn("Invalid query limit, ignoring it", "datasource", ds.Uid, "setting", key, "value", value.Interface())
+	return 0
+}
+
+// limitQueryData returns queryData, wrapped with the query limits of the data
+// source if it has any.
+func (ql *queryLimiter) limitQueryData(ds *models.DataSource, user *models.SignedInUser, queryData queryDataFunc) queryDataFunc {
+	limits := ql.limitsOf(ds)
+	if !limits.enabled() {
+		return queryData
+	}
+	return ql.limit(ds, user, limits, queryData)
+}
+
+// limit wraps queryData, so that it waits for the query limits of the data
+// source before sending the request. Anonymous users have no user ID, so all
+// anonymous users of an organization share one user limit.
+func (ql *queryLimiter) limit(ds *models.DataSource, user *models.SignedInUser, limits queryLimits, queryData queryDataFunc) queryDataFunc {
+	return func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		start := time.Now()
+		queueCtx, cancel := context.WithTimeout(ctx, limits.queueTimeout)
+		defer cancel()
+
+		dsKey := fmt.Sprintf("%d/%s", ds.OrgId, ds.Uid)
+		if limits.concurrency > 0 || limits.rate > 0 {
+			release, err := ql.acquire(queueCtx, dsKey, limits.concurrency, limits.rate)
+			if err != nil {
+				return throttled(ctx, ds, req, "datasource")
+			}
+			defer release()
+		}
+
+		if user != nil && (limits.userConcurrency > 0 || limits.userRate > 0) {
+			userKey := fmt.Sprintf("%s/%d", dsKey, user.UserId)
+			releaseUser, err := ql.acquire(queueCtx, userKey, limits.userConcurrency, limits.userRate)
+			if err != nil {
+				return throttled(ctx, ds, req, "user")
+			}
+			defer releaseUser()
+		}
+
+		queryQueueDuration.WithLabelValues(ds.Type).Observe(time.Since(start).Seconds())
+		return queryData(ctx, req)
+	}
+}
+
+// acquire waits for the limiter of the key. The returned function frees the
+// slot again.
+func (ql *queryLimiter) acquire(ctx context.Context, key string, concurrency int, qps float64) (func(), error) {
+	l := ql.get(key, concurrency, qps)
+
+	release, err := l.acquire(ctx)
+	if err != nil {
+		ql.done(l)
+		return nil, err
+	}
+
+	return func() {
+		release()
+		ql.done(l)
+	}, nil
+}
+
+// get returns the limiter for the key, replacing it when the limits of the
+// data source have changed. Limiters that have not been used for a while are
+// removed, so that the limiters of deleted data sources and users who left do
+// not pile up.
+func (ql *queryLimiter) get(key string, concurrency int, qps float64) *limiter {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+
+	now := ql.now()
+	if now.Sub(ql.lastSweep) > idleLimiterTimeout {
+		for k, l := range ql.limiters {
+			if l.users == 0 && now.Sub(l.lastUsed) > idleLimiterTimeout {
+				delete(ql.limiters, k)
+			}
+		}
+		ql.lastSweep = now
+	}
+
+	l, ok := ql.limiters[key]
+	if !ok || l.concurrency != concurrency || l.qps != qps {
+		l = newLimiter(concurrency, qps)
+		ql.limiters[key] = l
+	}
+	l.users++
+	l.lastUsed = now
+	return l
+}
+
+// done marks the end of a use of a limiter returned by get.
+func (ql *queryLimiter) done(l *limiter) {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+
+	l.users--
+	l.lastUsed = ql.now()
+}
+
+// throttled returns a response with an error for every query, unless the
+// request itself was canceled.
+func throttled(ctx context.Context, ds *models.DataSource, req *backend.QueryDataRequest, limit string) (*backend.QueryDataResponse, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	queryThrottled.WithLabelValues(ds.Type, limit).Inc()
+
+	resp := backend.NewQueryDataResponse()
+	for _, q := range req.Queries {
+		resp.Responses[q.RefID] = backend.DataResponse{
+			Error: ErrQueryThrottled{DataSource: ds.Name, Limit: limit},
+		}
+	}
+	return resp, nil
+}
+
+type limiter struct {
+	concurrency int
+	qps         float64
+	slots       chan struct{}
+	rate        *rate.Limiter
+
+	// users and lastUsed are guarded by the mutex of the queryLimiter.
+	users    int
+	lastUsed time.Time
+}
+
+func newLimiter(concurrency int, qps float64) *limiter {
+	l := &limiter{concurrency: concurrency, qps: qps}
+	if concurrency > 0 {
+		l.slots = make(chan struct{}, concurrency)
+	}
+	if qps > 0 {
+		burst := int(qps)
+		if burst < 1 {
+			burst = 1
+		}
+		l.rate = rate.NewLimiter(rate.Limit(qps), burst)
+	}
+	return l
+}
+
+// acquire waits for a free slot and then for the rate limit, so that requests
+// that time out waiting for a slot do not use up the rate of the others. The
+// returned function frees the slot again.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
@@ -0,0 +1,85 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestQueryLimitsOf(t *testing.T) {
+	ql := newQueryLimiter()
+
+	t.Run("reads limits saved as numbers or strings", func(t *testing.T) {
+		limits := ql.limitsOf(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryConcurrencyLimit":     5,
+			"queryRateLimit":            "2.5",
+			"userQueryConcurrencyLimit": "2",
+			"userQueryRateLimit":        "",
+			"queryQueueTimeout":         "30s",
+		})})
+
+		require.Equal(t, queryLimits{
+			concurrency:     5,
+			rate:            2.5,
+			userConcurrency: 2,
+			queueTimeout:    30 * time.Second,
+		}, limits)
+	})
+
+	t.Run("ignores invalid limits and queue timeouts", func(t *testing.T) {
+		limits := ql.limitsOf(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"queryConcurrencyLimit": "many",
+			"queryRateLimit":        1,
+			"queryQueueTimeout":     "soon",
+		})})
+
+		require.Equal(t, queryLimits{rate: 1, queueTimeout: defaultQueryQueueTimeout}, limits)
+	})
+}
+
+func TestQueryLimiterRemovesIdleLimiters(t *testing.T) {
+	now := time.Now()
+	ql := newQueryLimiter()
+	ql.now = func() time.Time { return now }
+
+	release, err := ql.acquire(context.Background(), "1/busy", 1, 0)
+	require.NoError(t, err)
+	idleRelease, err := ql.acquire(context.Background(), "1/idle", 1, 0)
+	require.NoError(t, err)
+	idleRelease()
+
+	now = now.Add(idleLimiterTimeout + time.Second)
+	otherRelease, err := ql.acquire(context.Background(), "2/other", 1, 0)
+	require.NoError(t, err)
+	otherRelease()
+
+	require.Contains(t, ql.limiters, "1/busy")
+	require.Contains(t, ql.limiters, "2/other")
+	require.NotContains(t, ql.limiters, "1/idle")
+
+	release()
+}
+
+func TestLimiterKeepsRateOfRequestsWithoutSlot(t *testing.T) {
+	l := newLimiter(1, 2)
+
+	release, err := l.acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	release, err = l.acquire(ctx)
+	require.NoError(t, err)
+	release()
+}
@@ -0,0 +1,44 @@
+package query
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+var (
+	queryCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "query_cache",
+			Name:      "requests_total",
+			Help:      "Number of data source query requests by cache status",
+		},
+		[]string{"datasource_type", "status"},
+	)
+
+	queryThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "query",
+			Name:      "throttled_total",
+			Help:      "Number of data source query requests rejected because a query limit was reached",
+		},
+		[]string{"datasource_type", "limit"},
+	)
+
+	queryQueueDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "query",
+			Name:      "queue_duration_seconds",
+			Help:      "Time data source query requests waited for the query limits of the data source",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30},
+		},
+		[]string{"datasource_type"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(queryCacheRequests, queryThrottled, queryQueueDuration)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
@@ -36,6 +37,7 @@ func ProvideService(
 	SecretsService secrets.Service,
 	pluginClient plugins.Client,
 	oAuthTokenService oauthtoken.OAuthTokenService,
+	remoteCache *remotecache.RemoteCache,
 ) *Service {
 	g := &Service{
 		cfg:                    cfg,
@@ -45,6 +47,8 @@ func ProvideService(
 		secretsService:         SecretsService,
 		pluginClient:           pluginClient,
 		oAuthTokenService:      oAuthTokenService,
+		responseCache:          newResponseCache(cfg, remoteCache),
+		queryLimiter:           newQueryLimiter(),
 		log:                    log.New("query_data"),
 	}
 	g.log.Info("Query Service initialization")
@@ -59,6 +63,8 @@ type Service struct {
 	secretsService         secrets.Service
 	pluginClient           plugins.Client
 	oAuthTokenService      oauthtoken.OAuthTokenService
+	responseCache          *responseCache
+	queryLimiter           *queryLimiter
 	log                    log.Logger
 }
 
@@ -77,7 +83,7 @@ func (s *Service) QueryData(ctx context.Context, user *models.SignedInUser, skip
 	if handleExpressions && parsedReq.hasExpression {
 		return s.handleExpressions(ctx, user, parsedReq)
 	}
-	return s.handleQueryData(ctx, user, parsedReq)
+	return s.handleQueryData(ctx, user, skipCache, parsedReq)
 }
 
 // handleExpressions handles POST /api/ds/query when there is an expression.
@@ -87,10 +93,12 @@ func (s *Service) handleExpressions(ctx context.Context, user *models.SignedInUs
 		Queries: []expr.Query{},
 	}
 
+	dataSources := map[string]*models.DataSource{}
 	for _, pq := range parsedReq.parsedQueries {
 		if pq.datasource == nil {
 			return nil, NewErrBadQuery(fmt.Sprintf("query mising datasource info: %s", pq.query.RefID))
 		}
+		dataSources[pq.datasource.Uid] = pq.datasource
 
 		exprReq.Queries = append(exprReq.Queries, expr.Query{
 			JSON:          pq.query.JSON,
@@ -106,14 +114,25 @@ func (s *Service) handleExpressions(ctx context.Context, user *models.SignedInUs
 		})
 	}
 
-	qdr, err := s.expressionService.TransformData(ctx, &exprReq)
+	// The queries of the data sources go through the query limits like any
+	// other query.
+	queryData := backend.QueryDataHandlerFunc(func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		if settings := req.PluginContext.DataSourceInstanceSettings; settings != nil {
+			if ds, ok := dataSources[settings.UID]; ok {
+				return s.queryLimiter.limitQueryData(ds, user, s.pluginClient.QueryData)(ctx, req)
+			}
+		}
+		return s.pluginClient.QueryData(ctx, req)
+	})
+
+	qdr, err := s.expressionService.WithDataService(queryData).TransformData(ctx, &exprReq)
 	if err != nil {
 		return nil, fmt.Errorf("expression request error: %w", err)
 	}
 	return qdr, nil
 }
 
-func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
+func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser, skipCache bool, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
 	ds := parsedReq.parsedQueries[0].datasource
 	if err := s.pluginRequestValidator.Validate(ds.Url, nil); err != nil {
 		return nil, models.ErrDataSourceAccessDenied
@@ -135,7 +154,12 @@ func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser
 		Queries: []backend.DataQuery{},
 	}
 
+	// Responses of data sources that forward the user's token depend on the
+	// user, so they are never cached.
+	cacheable := s.responseCache != nil
+
 	if s.oAuthTokenService.IsOAuthPassThruEnabled(ds) {
+		cacheable = false
 		if token := s.oAuthTokenService.GetCurrentOAuthToken(ctx, user); token != nil {
 			req.Headers["Authorization"] = fmt.Sprintf("%s %s", token.Type(), token.AccessToken)
 
@@ -154,7 +178,11 @@ func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser
 		req.Queries = append(req.Queries, q.query)
 	}
 
-	return s.pluginClient.QueryData(ctx, req)
+	queryData := s.queryLimiter.limitQueryData(ds, user, s.pluginClient.QueryData)
+	if cacheable {
+		return s.responseCache.queryData(ctx, ds, req, skipCache, queryData)
+	}
+	return queryData(ctx, req)
 }
 
 type parsedQuery struct {
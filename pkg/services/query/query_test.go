@@ -2,18 +2,23 @@ package query_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"golang.org/x/oauth2"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,9 +54,176 @@ func TestQueryData(t *testing.T) {
 		}
 		require.Equal(t, expected, tc.pluginContext.req.Headers)
 	})
+
+	t.Run("it caches responses when query caching is enabled", func(t *testing.T) {
+		tc := setupWithCfg(queryCachingCfg())
+		tc.pluginContext.resp = &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("test", data.NewField("value", nil, []float64{1}))}},
+		}}
+
+		first, err := tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+		require.NoError(t, err)
+		second, err := tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, tc.pluginContext.calls)
+		require.Equal(t, "test", second.Responses["A"].Frames[0].Name)
+		require.Equal(t, first.Responses["A"].Frames[0].Fields[0].At(0), second.Responses["A"].Frames[0].Fields[0].At(0))
+
+		_, err = tc.queryService.QueryData(context.Background(), nil, true, metricRequest(), false)
+		require.NoError(t, err)
+		require.Equal(t, 2, tc.pluginContext.calls, "skipping the cache should query the data source")
+	})
+
+	t.Run("it does not cache responses with errors", func(t *testing.T) {
+		tc := setupWithCfg(queryCachingCfg())
+		tc.pluginContext.resp = &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": backend.DataResponse{Error: errors.New("boom")},
+		}}
+
+		for i := 0; i < 2; i++ {
+			_, err := tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, tc.pluginContext.calls)
+	})
+
+	t.Run("it does not cache responses when the data source disables caching", func(t *testing.T) {
+		tc := setupWithCfg(queryCachingCfg())
+		tc.dataSourceCache.ds.JsonData = simplejson.NewFromAny(map[string]interface{}{"queryCachingTTL": 0})
+
+		for i := 0; i < 2; i++ {
+			_, err := tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, tc.pluginContext.calls)
+	})
+
+	t.Run("it does not cache responses of data sources with OAuth pass-through", func(t *testing.T) {
+		tc := setupWithCfg(queryCachingCfg())
+		tc.oauthTokenService.passThruEnabled = true
+
+		for i := 0; i < 2; i++ {
+			_, err := tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, tc.pluginContext.calls)
+	})
+}
+
+func TestQueryDataLimits(t *testing.T) {
+	t.Run("it throttles queries above the concurrency limit of the data source", func(t *testing.T) {
+		tc := setup()
+		tc.dataSourceCache.ds.Name = "Elasticsearch"
+		tc.dataSourceCache.ds.JsonData = simplejson.NewFromAny(map[string]interface{}{
+			"queryConcurrencyLimit": 1,
+			"queryQueueTimeout":     "50ms",
+		})
+		tc.pluginContext.started = make(chan struct{})
+		tc.pluginContext.block = make(chan struct{})
+
+		done := make(chan error)
+		go func() {
+			_, err := tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+			done <- err
+		}()
+		<-tc.pluginContext.started
+
+		resp, err := tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+		require.NoError(t, err)
+
+		var throttled query.ErrQueryThrottled
+		require.ErrorAs(t, resp.Responses["A"].Error, &throttled)
+		require.Equal(t, "datasource", throttled.Limit)
+		require.Contains(t, throttled.Error(), `"Elasticsearch"`)
+
+		close(tc.pluginContext.block)
+		require.NoError(t, <-done)
+
+		tc.pluginContext.started = nil
+		_, err = tc.queryService.QueryData(context.Background(), nil, false, metricRequest(), false)
+		require.NoError(t, err)
+		require.Equal(t, 2, tc.pluginContext.calls)
+	})
+
+	t.Run("it throttles queries above the rate limit of a user", func(t *testing.T) {
+		tc := setup()
+		tc.dataSourceCache.ds.JsonData = simplejson.NewFromAny(map[string]interface{}{
+			"userQueryRateLimit": 1,
+			"queryQueueTimeout":  10,
+		})
+		user := &models.SignedInUser{UserId: 1}
+
+		resp, err := tc.queryService.QueryData(context.Background(), user, false, metricRequest(), false)
+		require.NoError(t, err)
+		require.Nil(t, resp)
+
+		resp, err = tc.queryService.QueryData(context.Background(), user, false, metricRequest(), false)
+		require.NoError(t, err)
+		var throttled query.ErrQueryThrottled
+		require.ErrorAs(t, resp.Responses["A"].Error, &throttled)
+		require.Equal(t, "user", throttled.Limit)
+
+		// other users have their own limit
+		_, err = tc.queryService.QueryData(context.Background(), &models.SignedInUser{UserId: 2}, false, metricRequest(), false)
+		require.NoError(t, err)
+		require.Equal(t, 2, tc.pluginContext.calls)
+	})
+
+	t.Run("it throttles the queries of requests with expressions", func(t *testing.T) {
+		tc := setup()
+		cfg := setting.NewCfg()
+		cfg.ExpressionsEnabled = true
+		tc.queryService = query.ProvideService(nil, tc.dataSourceCache, expr.ProvideService(cfg, tc.pluginContext, tc.secretService),
+			tc.pluginRequestValidator, tc.secretService, tc.pluginContext, tc.oauthTokenService, nil)
+		tc.dataSourceCache.ds.Uid = "prometheus"
+		tc.dataSourceCache.ds.JsonData = simplejson.NewFromAny(map[string]interface{}{
+			"userQueryRateLimit": 1,
+			"queryQueueTimeout":  10,
+		})
+		tc.pluginContext.resp = &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": {Frames: data.Frames{data.NewFrame("",
+				data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+				data.NewField("value", nil, []float64{1}),
+			)}},
+		}}
+		user := &models.SignedInUser{UserId: 1, OrgId: 1}
+
+		resp, err := tc.queryService.QueryData(context.Background(), user, false, expressionRequest(), true)
+		require.NoError(t, err)
+		require.Contains(t, resp.Responses, "B")
+
+		_, err = tc.queryService.QueryData(context.Background(), user, false, expressionRequest(), true)
+		var throttled query.ErrQueryThrottled
+		require.ErrorAs(t, err, &throttled)
+		require.Equal(t, "user", throttled.Limit)
+		require.Equal(t, 1, tc.pluginContext.calls)
+	})
+}
+
+func expressionRequest() dtos.MetricRequest {
+	q, _ := simplejson.NewJson([]byte(`{"refId":"A","datasource":{"uid":"prometheus"}}`))
+	e, _ := simplejson.NewJson([]byte(`{"refId":"B","datasource":{"uid":"__expr__","type":"__expr__"},"type":"math","expression":"$A * 2"}`))
+	return dtos.MetricRequest{
+		Queries: []*simplejson.Json{q, e},
+	}
+}
+
+func queryCachingCfg() *setting.Cfg {
+	cfg := setting.NewCfg()
+	cfg.QueryCaching = setting.QueryCachingSettings{
+		Enabled: true,
+		Backend: setting.QueryCachingBackendMemory,
+		TTL:     time.Minute,
+	}
+	return cfg
 }
 
 func setup() *testContext {
+	return setupWithCfg(nil)
+}
+
+func setupWithCfg(cfg *setting.Cfg) *testContext {
 	pc := &fakePluginClient{}
 	sc := &fakeSecretsService{}
 	dc := &fakeDataSourceCache{ds: &models.DataSource{}}
@@ -64,7 +236,7 @@ func setup() *testContext {
 		dataSourceCache:        dc,
 		oauthTokenService:      tc,
 		pluginRequestValidator: rv,
-		queryService:           query.ProvideService(nil, dc, nil, rv, sc, pc, tc),
+		queryService:           query.ProvideService(cfg, dc, nil, rv, sc, pc, tc, nil),
 	}
 }
 
@@ -133,10 +305,21 @@ func (c *fakeDataSourceCache) GetDatasourceByUID(ctx context.Context, datasource
 type fakePluginClient struct {
 	plugins.Client
 
-	req *backend.QueryDataRequest
+	req   *backend.QueryDataRequest
+	resp  *backend.QueryDataResponse
+	calls int
+
+	// started is notified when a query is sent, which then waits until block is closed.
+	started chan struct{}
+	block   chan struct{}
 }
 
 func (c *fakePluginClient) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	c.req = req
-	return nil, nil
+	c.calls++
+	if c.started != nil {
+		c.started <- struct{}{}
+		<-c.block
+	}
+	return c.resp, nil
 }
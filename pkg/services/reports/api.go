@@ -0,0 +1,117 @@
+package reports
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.RouteRegister.Group("/api/reports", func(reports routing.RouteRegister) {
+		reports.Get("/", routing.Wrap(s.getAllHandler))
+		reports.Post("/", routing.Wrap(s.createHandler))
+		reports.Get("/:uid", routing.Wrap(s.getHandler))
+		reports.Put("/:uid", routing.Wrap(s.updateHandler))
+		reports.Delete("/:uid", routing.Wrap(s.deleteHandler))
+		reports.Post("/:uid/send", routing.Wrap(s.sendHandler))
+	}, middleware.ReqOrgAdmin)
+}
+
+// getAllHandler handles GET /api/reports.
+func (s *Service) getAllHandler(c *models.ReqContext) response.Response {
+	reports, err := s.getReports(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get reports", err)
+	}
+
+	return response.JSON(http.StatusOK, reports)
+}
+
+// createHandler handles POST /api/reports.
+func (s *Service) createHandler(c *models.ReqContext) response.Response {
+	cmd := SaveReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	report, err := s.createReport(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return toReportError(err, "Failed to create report")
+	}
+
+	return response.JSON(http.StatusOK, report)
+}
+
+// getHandler handles GET /api/reports/:uid.
+func (s *Service) getHandler(c *models.ReqContext) response.Response {
+	report, err := s.getReport(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toReportError(err, "Failed to get report")
+	}
+
+	return response.JSON(http.StatusOK, report)
+}
+
+// updateHandler handles PUT /api/reports/:uid.
+func (s *Service) updateHandler(c *models.ReqContext) response.Response {
+	cmd := SaveReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	report, err := s.updateReport(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], cmd)
+	if err != nil {
+		return toReportError(err, "Failed to update report")
+	}
+
+	return response.JSON(http.StatusOK, report)
+}
+
+// deleteHandler handles DELETE /api/reports/:uid.
+func (s *Service) deleteHandler(c *models.ReqContext) response.Response {
+	if err := s.deleteReport(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"]); err != nil {
+		return toReportError(err, "Failed to delete report")
+	}
+
+	return response.Success("Report deleted")
+}
+
+// sendHandler handles POST /api/reports/:uid/send and emails the report
+// right away without changing its schedule.
+func (s *Service) sendHandler(c *models.ReqContext) response.Response {
+	report, err := s.getReport(c.Req.Context(), c.OrgId, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return toReportError(err, "Failed to get report")
+	}
+
+	if err := s.run(c.Req.Context(), report); err != nil {
+		return toReportError(err, "Failed to send report")
+	}
+
+	report.LastRunAt = time.Now()
+	if err := s.updateRunState(c.Req.Context(), report); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to update report", err)
+	}
+
+	return response.Success("Report sent")
+}
+
+func toReportError(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, ErrReportNotFound), errors.Is(err, models.ErrDashboardNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, ErrReportInvalidFormat), errors.Is(err, ErrReportNoRecipients),
+		errors.Is(err, ErrReportMissingName), errors.Is(err, ErrReportMissingDash),
+		errors.Is(err, ErrReportInvalidTimezone), errors.Is(err, ErrReportInvalidSchedule):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, ErrReportCreatorNotInOrg), errors.Is(err, ErrReportAccessDenied):
+		return response.Error(http.StatusForbidden, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,158 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (s *Service) createReport(ctx context.Context, user *models.SignedInUser, cmd SaveReportCommand) (*Report, error) {
+	if err := cmd.validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &Report{
+		Uid:     util.GenerateShortUID(),
+		OrgId:   user.OrgId,
+		UserId:  user.UserId,
+		Created: now,
+	}
+	if err := report.apply(cmd, now); err != nil {
+		return nil, err
+	}
+
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(report)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *Service) updateReport(ctx context.Context, user *models.SignedInUser, uid string, cmd SaveReportCommand) (*Report, error) {
+	if err := cmd.validate(); err != nil {
+		return nil, err
+	}
+
+	var report *Report
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		report, err = getReport(sess, user.OrgId, uid)
+		if err != nil {
+			return err
+		}
+
+		if err := report.apply(cmd, time.Now()); err != nil {
+			return err
+		}
+
+		_, err = sess.ID(report.Id).AllCols().Update(report)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *Service) deleteReport(ctx context.Context, user *models.SignedInUser, uid string) error {
+	return s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id=? AND uid=?", user.OrgId, uid).Delete(&Report{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrReportNotFound
+		}
+		return nil
+	})
+}
+
+func (s *Service) getReport(ctx context.Context, orgID int64, uid string) (*Report, error) {
+	var report *Report
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		report, err = getReport(sess, orgID, uid)
+		return err
+	})
+	return report, err
+}
+
+func (s *Service) getReports(ctx context.Context, orgID int64) ([]*Report, error) {
+	reports := make([]*Report, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id=?", orgID).Asc("name").Find(&reports)
+	})
+	return reports, err
+}
+
+// getDueReports returns the enabled reports that should have run by now.
+func (s *Service) getDueReports(ctx context.Context, now time.Time) ([]*Report, error) {
+	reports := make([]*Report, 0)
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("enabled=? AND next_run_at <= ?", s.SQLStore.Dialect.BooleanStr(true), now).Find(&reports)
+	})
+	return reports, err
+}
+
+// claimRun stores the next run of a report that is still due. It returns
+// false when another instance has already moved the report past now.
+func (s *Service) claimRun(ctx context.Context, report *Report, now time.Time) (bool, error) {
+	var claimed bool
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.ID(report.Id).Where("next_run_at <= ?", now).Cols("next_run_at", "last_run_at").Update(report)
+		claimed = affected > 0
+		return err
+	})
+	return claimed, err
+}
+
+// updateRunState records the outcome of a report run and schedules the next one.
+func (s *Service) updateRunState(ctx context.Context, report *Report) error {
+	return s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.ID(report.Id).Cols("next_run_at", "last_run_at", "last_error").Update(report)
+		return err
+	})
+}
+
+func getReport(sess *sqlstore.DBSession, orgID int64, uid string) (*Report, error) {
+	report := &Report{}
+	exists, err := sess.Where("org_id=? AND uid=?", orgID, uid).Get(report)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+// apply copies the command onto the report and computes the next run.
+func (r *Report) apply(cmd SaveReportCommand, now time.Time) error {
+	r.Name = cmd.Name
+	r.DashboardUid = cmd.DashboardUid
+	r.Recipients = cmd.Recipients
+	r.Schedule = cmd.Schedule
+	r.Timezone = cmd.Timezone
+	r.TimeFrom = cmd.TimeFrom
+	r.TimeTo = cmd.TimeTo
+	r.Variables = cmd.Variables
+	r.Format = cmd.Format
+	r.Enabled = cmd.Enabled
+	r.Updated = now
+
+	next, err := nextRun(r.Schedule, r.Timezone, now)
+	if err != nil {
+		return err
+	}
+	r.NextRunAt = next
+	return nil
+}
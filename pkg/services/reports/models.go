@@ -0,0 +1,111 @@
+package reports
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	ErrReportNotFound        = errors.New("report not found")
+	ErrReportInvalidFormat   = errors.New("report format must be pdf or png")
+	ErrReportNoRecipients    = errors.New("report needs at least one recipient")
+	ErrReportMissingName     = errors.New("report name is required")
+	ErrReportMissingDash     = errors.New("report dashboard is required")
+	ErrReportInvalidTimezone = errors.New("report time zone is invalid")
+	ErrReportInvalidSchedule = errors.New("report schedule is invalid")
+	ErrReportCreatorNotInOrg = errors.New("report creator is no longer a member of the organization")
+	ErrReportAccessDenied    = errors.New("report creator can no longer view the dashboard")
+)
+
+// Format is the file type of the rendered report.
+type Format string
+
+const (
+	FormatPDF Format = "pdf"
+	FormatPNG Format = "png"
+)
+
+// Report is a dashboard that is rendered and emailed on a schedule.
+type Report struct {
+	Id           int64            `json:"id"`
+	Uid          string           `json:"uid"`
+	OrgId        int64            `json:"orgId"`
+	UserId       int64            `json:"userId"`
+	Name         string           `json:"name"`
+	DashboardUid string           `json:"dashboardUid"`
+	Recipients   string           `json:"recipients"`
+	Schedule     string           `json:"schedule"`
+	Timezone     string           `json:"timezone"`
+	TimeFrom     string           `json:"timeFrom"`
+	TimeTo       string           `json:"timeTo"`
+	Variables    *simplejson.Json `json:"variables"`
+	Format       Format           `json:"format"`
+	Enabled      bool             `json:"enabled"`
+	Created      time.Time        `json:"created"`
+	Updated      time.Time        `json:"updated"`
+	NextRunAt    time.Time        `json:"nextRunAt"`
+	LastRunAt    time.Time        `json:"lastRunAt"`
+	LastError    string           `json:"lastError"`
+}
+
+// SaveReportCommand holds the user editable fields of a report.
+type SaveReportCommand struct {
+	Name         string `json:"name"`
+	DashboardUid string `json:"dashboardUid"`
+	// Recipients is a list of email addresses separated by commas, semicolons or new lines.
+	Recipients string `json:"recipients"`
+	// Schedule is a standard five field cron expression.
+	Schedule  string           `json:"schedule"`
+	Timezone  string           `json:"timezone"`
+	TimeFrom  string           `json:"timeFrom"`
+	TimeTo    string           `json:"timeTo"`
+	Variables *simplejson.Json `json:"variables"`
+	Format    Format           `json:"format"`
+	Enabled   bool             `json:"enabled"`
+}
+
+// validate checks the command and fills in defaults.
+func (cmd *SaveReportCommand) validate() error {
+	if cmd.Name == "" {
+		return ErrReportMissingName
+	}
+	if cmd.DashboardUid == "" {
+		return ErrReportMissingDash
+	}
+	if len(splitRecipients(cmd.Recipients)) == 0 {
+		return ErrReportNoRecipients
+	}
+
+	switch cmd.Format {
+	case "":
+		cmd.Format = FormatPDF
+	case FormatPDF, FormatPNG:
+	default:
+		return ErrReportInvalidFormat
+	}
+
+	if cmd.TimeFrom == "" {
+		cmd.TimeFrom = "now-7d"
+	}
+	if cmd.TimeTo == "" {
+		cmd.TimeTo = "now"
+	}
+
+	_, err := nextRun(cmd.Schedule, cmd.Timezone, time.Now())
+	return err
+}
+
+// splitRecipients returns the non-empty email addresses in the list.
+func splitRecipients(recipients string) []string {
+	var res []string
+	for _, addr := range util.SplitEmails(recipients) {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
@@ -0,0 +1,65 @@
+package reports
+
+import (
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// page is a rendered panel that is placed on its own PDF page.
+type page struct {
+	title     string
+	imagePath string
+}
+
+// writePDF assembles a landscape A4 document with one page per rendered
+// panel, each scaled to fit below a header with the report title.
+func writePDF(w io.Writer, title, subtitle string, pages []page) error {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("Grafana", true)
+	pdf.SetAutoPageBreak(false, 0)
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	contentWidth := pageWidth - left - right
+
+	for _, p := range pages {
+		pdf.AddPage()
+
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(contentWidth, 8, title, "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(contentWidth, 5, subtitle, "", 1, "L", false, 0, "")
+		if p.title != "" {
+			pdf.SetFont("Helvetica", "B", 11)
+			pdf.CellFormat(contentWidth, 8, p.title, "", 1, "L", false, 0, "")
+		}
+
+		y := pdf.GetY() + 2
+		maxHeight := pageHeight - y - bottom
+		info := pdf.RegisterImageOptions(p.imagePath, gofpdf.ImageOptions{ImageType: "PNG"})
+		if err := pdf.Error(); err != nil {
+			return err
+		}
+
+		width, height := fitImage(info.Width(), info.Height(), contentWidth, maxHeight)
+		pdf.ImageOptions(p.imagePath, left+(contentWidth-width)/2, y, width, height, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// fitImage scales the image dimensions down to fit the box while keeping the
+// aspect ratio.
+func fitImage(width, height, maxWidth, maxHeight float64) (float64, float64) {
+	if width <= 0 || height <= 0 {
+		return maxWidth, maxHeight
+	}
+
+	scale := maxWidth / width
+	if s := maxHeight / height; s < scale {
+		scale = s
+	}
+	return width * scale, height * scale
+}
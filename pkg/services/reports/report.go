@@ -0,0 +1,228 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+const (
+	reportTemplate = "report"
+
+	renderTimeout  = 60 * time.Second
+	panelWidth     = 1200
+	panelHeight    = 600
+	dashboardWidth = 1600
+	// fullPageHeight makes the image renderer capture the whole dashboard.
+	fullPageHeight = -1
+)
+
+// run renders the report's dashboard and emails it to the recipients.
+func (s *Service) run(ctx context.Context, report *Report) error {
+	if !s.RenderService.IsAvailable() {
+		return rendering.ErrRenderUnavailable
+	}
+
+	dash, err := s.SQLStore.GetDashboard(0, report.OrgId, report.DashboardUid, "")
+	if err != nil {
+		return err
+	}
+
+	user, err := s.reportUser(ctx, report, dash)
+	if err != nil {
+		return err
+	}
+
+	authOpts := rendering.AuthOpts{
+		OrgID:   report.OrgId,
+		UserID:  user.UserId,
+		OrgRole: user.OrgRole,
+	}
+	var attachment *models.SendEmailAttachFile
+	switch report.Format {
+	case FormatPNG:
+		attachment, err = s.renderPNG(ctx, report, dash, authOpts)
+	default:
+		attachment, err = s.renderPDF(ctx, report, dash, authOpts)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.EmailSender.SendEmailCommandHandlerSync(ctx, &models.SendEmailCommandSync{
+		SendEmailCommand: models.SendEmailCommand{
+			To:       splitRecipients(report.Recipients),
+			Template: reportTemplate,
+			Data: map[string]interface{}{
+				"ReportName":     report.Name,
+				"DashboardTitle": dash.Title,
+				"DashboardUrl":   s.Cfg.AppURL + dashboardPath(report, dash),
+				"TimeRange":      fmt.Sprintf("%s to %s", report.TimeFrom, report.TimeTo),
+			},
+			AttachedFiles: []*models.SendEmailAttachFile{attachment},
+		},
+	})
+}
+
+// reportUser returns the creator of the report with their current role in the
+// organization. Reports are rendered as the creator, so they stop once the
+// creator leaves the organization or loses access to the dashboard.
+func (s *Service) reportUser(ctx context.Context, report *Report, dash *models.Dashboard) (*models.SignedInUser, error) {
+	query := &models.GetSignedInUserQuery{UserId: report.UserId, OrgId: report.OrgId}
+	if err := s.SQLStore.GetSignedInUser(ctx, query); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, ErrReportCreatorNotInOrg
+		}
+		return nil, err
+	}
+
+	user := query.Result
+	if user.OrgId != report.OrgId {
+		return nil, ErrReportCreatorNotInOrg
+	}
+
+	canView, err := guardian.New(ctx, dash.Id, report.OrgId, user).CanView()
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrReportAccessDenied
+	}
+
+	return user, nil
+}
+
+func (s *Service) renderPNG(ctx context.Context, report *Report, dash *models.Dashboard, authOpts rendering.AuthOpts) (*models.SendEmailAttachFile, error) {
+	result, err := s.RenderService.Render(ctx, s.renderOpts(report, authOpts, dashboardPath(report, dash)+"&kiosk", dashboardWidth, fullPageHeight), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// The path is returned by the rendering service and not user input.
+	content, err := ioutil.ReadFile(result.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SendEmailAttachFile{Name: dash.Slug + ".png", Content: content}, nil
+}
+
+func (s *Service) renderPDF(ctx context.Context, report *Report, dash *models.Dashboard, authOpts rendering.AuthOpts) (*models.SendEmailAttachFile, error) {
+	var pages []page
+	for _, panel := range reportPanels(dash.Data) {
+		path := fmt.Sprintf("d-solo/%s/%s?%s&panelId=%d", dash.Uid, dash.Slug, reportParams(report).Encode(), panel.id)
+		result, err := s.RenderService.Render(ctx, s.renderOpts(report, authOpts, path, panelWidth, panelHeight), nil)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page{title: panel.title, imagePath: result.FilePath})
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("dashboard %s has no panels to render", dash.Uid)
+	}
+
+	var buf bytes.Buffer
+	subtitle := fmt.Sprintf("%s, %s to %s", dash.Title, report.TimeFrom, report.TimeTo)
+	if err := writePDF(&buf, report.Name, subtitle, pages); err != nil {
+		return nil, err
+	}
+
+	return &models.SendEmailAttachFile{Name: dash.Slug + ".pdf", Content: buf.Bytes()}, nil
+}
+
+func (s *Service) renderOpts(report *Report, authOpts rendering.AuthOpts, path string, width, height int) rendering.Opts {
+	return rendering.Opts{
+		TimeoutOpts: rendering.TimeoutOpts{
+			Timeout: renderTimeout,
+		},
+		AuthOpts:        authOpts,
+		Width:           width,
+		Height:          height,
+		Path:            path,
+		Timezone:        report.Timezone,
+		ConcurrentLimit: s.Cfg.RendererConcurrentRequestLimit,
+		Theme:           rendering.ThemeLight,
+	}
+}
+
+type reportPanel struct {
+	id    int64
+	title string
+}
+
+// reportPanels returns the panels of the dashboard in display order,
+// including the panels of collapsed rows and of the rows used by dashboards
+// from before schema version 16.
+func reportPanels(data *simplejson.Json) []reportPanel {
+	var panels []reportPanel
+	var walk func(list []interface{})
+	walk = func(list []interface{}) {
+		for _, p := range list {
+			panel := simplejson.NewFromAny(p)
+			if panel.Get("type").MustString() == "row" {
+				walk(panel.Get("panels").MustArray())
+				continue
+			}
+			panels = append(panels, reportPanel{
+				id:    panel.Get("id").MustInt64(),
+				title: panel.Get("title").MustString(),
+			})
+		}
+	}
+	walk(data.Get("panels").MustArray())
+	for _, row := range data.Get("rows").MustArray() {
+		walk(simplejson.NewFromAny(row).Get("panels").MustArray())
+	}
+
+	return panels
+}
+
+// reportParams returns the URL query selecting the report's time range and
+// template variable values.
+func reportParams(report *Report) url.Values {
+	params := url.Values{}
+	params.Set("orgId", fmt.Sprint(report.OrgId))
+	params.Set("from", report.TimeFrom)
+	params.Set("to", report.TimeTo)
+	if report.Timezone != "" {
+		params.Set("tz", report.Timezone)
+	}
+
+	if report.Variables != nil {
+		vars := report.Variables.MustMap()
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			switch v := vars[name].(type) {
+			case []interface{}:
+				for _, item := range v {
+					params.Add("var-"+name, fmt.Sprint(item))
+				}
+			default:
+				params.Add("var-"+name, fmt.Sprint(v))
+			}
+		}
+	}
+
+	return params
+}
+
+func dashboardPath(report *Report, dash *models.Dashboard) string {
+	return fmt.Sprintf("d/%s/%s?%s", dash.Uid, dash.Slug, reportParams(report).Encode())
+}
@@ -0,0 +1,36 @@
+package reports
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// nextRun returns the first time after `after` at which the schedule fires,
+// evaluated in the report's time zone.
+func nextRun(schedule, timezone string, after time.Time) (time.Time, error) {
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q: %s", ErrReportInvalidSchedule, schedule, err)
+	}
+
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return sched.Next(after.In(loc)), nil
+}
+
+func loadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, ErrReportInvalidTimezone
+	}
+	return loc, nil
+}
@@ -0,0 +1,61 @@
+package reports
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextRun(t *testing.T) {
+	after := time.Date(2022, 1, 3, 7, 0, 0, 0, time.UTC) // Monday
+
+	t.Run("uses UTC by default", func(t *testing.T) {
+		next, err := nextRun("0 8 * * 1", "", after)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 1, 3, 8, 0, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("evaluates the schedule in the time zone", func(t *testing.T) {
+		next, err := nextRun("0 8 * * 1", "Europe/Stockholm", after)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 1, 10, 7, 0, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("rejects invalid schedules", func(t *testing.T) {
+		_, err := nextRun("every monday", "", after)
+		assert.True(t, errors.Is(err, ErrReportInvalidSchedule))
+	})
+
+	t.Run("rejects invalid time zones", func(t *testing.T) {
+		_, err := nextRun("0 8 * * 1", "Mars/Olympus", after)
+		assert.True(t, errors.Is(err, ErrReportInvalidTimezone))
+	})
+}
+
+func TestSaveReportCommandValidate(t *testing.T) {
+	valid := func() SaveReportCommand {
+		return SaveReportCommand{
+			Name:         "Weekly",
+			DashboardUid: "abc",
+			Recipients:   "a@example.com;b@example.com",
+			Schedule:     "0 8 * * 1",
+		}
+	}
+
+	cmd := valid()
+	require.NoError(t, cmd.validate())
+	assert.Equal(t, FormatPDF, cmd.Format)
+	assert.Equal(t, "now-7d", cmd.TimeFrom)
+	assert.Equal(t, "now", cmd.TimeTo)
+
+	cmd = valid()
+	cmd.Recipients = " ; "
+	assert.Equal(t, ErrReportNoRecipients, cmd.validate())
+
+	cmd = valid()
+	cmd.Format = "docx"
+	assert.Equal(t, ErrReportInvalidFormat, cmd.validate())
+}
@@ -0,0 +1,133 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// checkInterval is how often the scheduler looks for due reports.
+	checkInterval = 30 * time.Second
+	// lockInterval keeps other instances from claiming a due report at the
+	// same time. Claiming moves the report to its next run before it's
+	// rendered, so the lock doesn't have to outlast the rendering.
+	lockInterval = 50 * time.Second
+)
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, routeRegister routing.RouteRegister,
+	renderService rendering.Service, emailSender notifications.EmailSender, serverLock *serverlock.ServerLockService,
+	features featuremgmt.FeatureToggles) *Service {
+	s := &Service{
+		Cfg:           cfg,
+		SQLStore:      sqlStore,
+		RouteRegister: routeRegister,
+		RenderService: renderService,
+		EmailSender:   emailSender,
+		ServerLock:    serverLock,
+		features:      features,
+		log:           log.New("reports"),
+	}
+
+	if s.IsEnabled() {
+		s.registerAPIEndpoints()
+	}
+
+	return s
+}
+
+// Service renders dashboards on a schedule and emails them as PDF or PNG
+// attachments.
+type Service struct {
+	Cfg           *setting.Cfg
+	SQLStore      *sqlstore.SQLStore
+	RouteRegister routing.RouteRegister
+	RenderService rendering.Service
+	EmailSender   notifications.EmailSender
+	ServerLock    *serverlock.ServerLockService
+	features      featuremgmt.FeatureToggles
+	log           log.Logger
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.features.IsEnabled(featuremgmt.FlagScheduledReports)
+}
+
+// IsDisabled is used by the background service registry.
+func (s *Service) IsDisabled() bool {
+	return !s.IsEnabled()
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runDueReports(ctx, time.Now())
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) runDueReports(ctx context.Context, now time.Time) {
+	reports, err := s.getDueReports(ctx, now)
+	if err != nil {
+		s.log.Error("Failed to get due reports", "error", err)
+		return
+	}
+
+	for _, report := range reports {
+		report := report
+		err := s.ServerLock.LockAndExecute(ctx, "report-"+report.Uid, lockInterval, func(ctx context.Context) {
+			s.runAndRecord(ctx, report, now)
+		})
+		if err != nil {
+			s.log.Error("Failed to lock report", "uid", report.Uid, "error", err)
+		}
+	}
+}
+
+// runAndRecord claims the due run of the report, sends it and stores the
+// outcome.
+func (s *Service) runAndRecord(ctx context.Context, report *Report, now time.Time) {
+	next, err := nextRun(report.Schedule, report.Timezone, now)
+	if err != nil {
+		s.log.Error("Failed to schedule report", "uid", report.Uid, "error", err)
+		return
+	}
+	report.LastRunAt = now
+	report.NextRunAt = next
+
+	claimed, err := s.claimRun(ctx, report, now)
+	if err != nil {
+		s.log.Error("Failed to update report", "uid", report.Uid, "error", err)
+		return
+	}
+	if !claimed {
+		s.log.Debug("Report was already sent", "uid", report.Uid)
+		return
+	}
+
+	s.log.Info("Sending report", "uid", report.Uid, "orgId", report.OrgId)
+
+	report.LastError = ""
+	if err := s.run(ctx, report); err != nil {
+		s.log.Error("Failed to send report", "uid", report.Uid, "error", err)
+		report.LastError = err.Error()
+	}
+
+	if err := s.updateRunState(ctx, report); err != nil {
+		s.log.Error("Failed to update report", "uid", report.Uid, "error", err)
+	}
+}
@@ -0,0 +1,200 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	renderer := &fakeRenderer{imagePath: writeTestPNG(t)}
+	sender := &fakeEmailSender{}
+
+	s := &Service{
+		Cfg:           &setting.Cfg{AppURL: "http://grafana.example.com/"},
+		SQLStore:      sqlStore,
+		RenderService: renderer,
+		EmailSender:   sender,
+		ServerLock:    serverlock.ProvideService(sqlStore),
+		log:           log.New("reports.test"),
+	}
+
+	dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+		OrgId: 1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"title": "Weekly overview",
+			"panels": []interface{}{
+				map[string]interface{}{"id": 1, "title": "Requests"},
+				map[string]interface{}{"id": 2, "type": "row", "panels": []interface{}{
+					map[string]interface{}{"id": 3, "title": "Errors"},
+				}},
+			},
+			"rows": []interface{}{
+				map[string]interface{}{"panels": []interface{}{
+					map[string]interface{}{"id": 4, "title": "Latency"},
+				}},
+			},
+		}),
+	})
+	require.NoError(t, err)
+
+	_, err = sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "admin"})
+	require.NoError(t, err)
+	creator, err := sqlStore.CreateUser(context.Background(), models.CreateUserCommand{Login: "manager"})
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.AddOrgUser(context.Background(), &models.AddOrgUserCommand{
+		OrgId: 1, UserId: creator.Id, Role: models.ROLE_VIEWER,
+	}))
+
+	origNewGuardian := guardian.New
+	t.Cleanup(func() { guardian.New = origNewGuardian })
+	dashGuardian := &guardian.FakeDashboardGuardian{CanViewValue: true}
+	guardian.MockDashboardGuardian(dashGuardian)
+
+	user := &models.SignedInUser{OrgId: 1, UserId: creator.Id}
+	report, err := s.createReport(context.Background(), user, SaveReportCommand{
+		Name:         "Weekly report",
+		DashboardUid: dash.Uid,
+		Recipients:   "manager@example.com",
+		Schedule:     "0 8 * * 1",
+		Variables:    simplejson.NewFromAny(map[string]interface{}{"env": "prod"}),
+		Enabled:      true,
+	})
+	require.NoError(t, err)
+
+	t.Run("reports are not sent before they are due", func(t *testing.T) {
+		s.runDueReports(context.Background(), report.NextRunAt.Add(-time.Minute))
+		assert.Nil(t, sender.cmd)
+	})
+
+	t.Run("due reports are rendered and emailed", func(t *testing.T) {
+		runAt := report.NextRunAt.Add(time.Second)
+		s.runDueReports(context.Background(), runAt)
+
+		require.NotNil(t, sender.cmd)
+		assert.Equal(t, []string{"manager@example.com"}, sender.cmd.To)
+		require.Len(t, sender.cmd.AttachedFiles, 1)
+		assert.Equal(t, "weekly-overview.pdf", sender.cmd.AttachedFiles[0].Name)
+		assert.True(t, bytes.HasPrefix(sender.cmd.AttachedFiles[0].Content, []byte("%PDF")))
+
+		require.Len(t, renderer.paths, 3)
+		assert.Contains(t, renderer.paths[0], "panelId=1")
+		assert.Contains(t, renderer.paths[0], "var-env=prod")
+		assert.Contains(t, renderer.paths[1], "panelId=3")
+		assert.Contains(t, renderer.paths[2], "panelId=4")
+
+		assert.Equal(t, creator.Id, renderer.authOpts.UserID)
+		assert.Equal(t, models.ROLE_VIEWER, renderer.authOpts.OrgRole)
+		assert.Equal(t, dash.Id, dashGuardian.DashId)
+
+		stored, err := s.getReport(context.Background(), 1, report.Uid)
+		require.NoError(t, err)
+		assert.Empty(t, stored.LastError)
+		assert.Equal(t, runAt.Unix(), stored.LastRunAt.Unix())
+		assert.Equal(t, report.NextRunAt.Add(7*24*time.Hour).Unix(), stored.NextRunAt.Unix())
+	})
+
+	t.Run("runs claimed by another instance are not sent again", func(t *testing.T) {
+		sender.cmd = nil
+		renderer.paths = nil
+
+		// report still holds the run that was sent above.
+		stale := *report
+		s.runAndRecord(context.Background(), &stale, stale.NextRunAt.Add(2*time.Second))
+
+		assert.Nil(t, sender.cmd)
+		assert.Empty(t, renderer.paths)
+	})
+
+	t.Run("reports fail once the creator cannot view the dashboard", func(t *testing.T) {
+		dashGuardian.CanViewValue = false
+		t.Cleanup(func() { dashGuardian.CanViewValue = true })
+		sender.cmd = nil
+
+		stored, err := s.getReport(context.Background(), 1, report.Uid)
+		require.NoError(t, err)
+		s.runAndRecord(context.Background(), stored, stored.NextRunAt.Add(time.Second))
+
+		assert.Nil(t, sender.cmd)
+		stored, err = s.getReport(context.Background(), 1, report.Uid)
+		require.NoError(t, err)
+		assert.Equal(t, ErrReportAccessDenied.Error(), stored.LastError)
+	})
+
+	t.Run("updating a report changes its schedule", func(t *testing.T) {
+		updated, err := s.updateReport(context.Background(), user, report.Uid, SaveReportCommand{
+			Name:         "Daily report",
+			DashboardUid: dash.Uid,
+			Recipients:   "manager@example.com",
+			Schedule:     "0 8 * * *",
+			Format:       FormatPNG,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Daily report", updated.Name)
+		assert.False(t, updated.Enabled)
+
+		reports, err := s.getReports(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, FormatPNG, reports[0].Format)
+	})
+
+	t.Run("deleting a report", func(t *testing.T) {
+		require.NoError(t, s.deleteReport(context.Background(), user, report.Uid))
+		_, err := s.getReport(context.Background(), 1, report.Uid)
+		assert.Equal(t, ErrReportNotFound, err)
+	})
+}
+
+func writeTestPNG(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "panel.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, 40, 20))))
+	return path
+}
+
+type fakeRenderer struct {
+	rendering.Service
+	imagePath string
+	paths     []string
+	authOpts  rendering.AuthOpts
+}
+
+func (r *fakeRenderer) IsAvailable() bool { return true }
+
+func (r *fakeRenderer) Render(_ context.Context, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+	r.paths = append(r.paths, opts.Path)
+	r.authOpts = opts.AuthOpts
+	return &rendering.RenderResult{FilePath: r.imagePath}, nil
+}
+
+type fakeEmailSender struct {
+	cmd *models.SendEmailCommandSync
+}
+
+func (s *fakeEmailSender) SendEmailCommandHandlerSync(_ context.Context, cmd *models.SendEmailCommandSync) error {
+	s.cmd = cmd
+	return nil
+}
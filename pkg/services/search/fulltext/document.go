@@ -0,0 +1,116 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Fields that can be used as filters in a full-text query, e.g. `ds:prometheus`.
+const (
+	FieldTitle       = "title"
+	FieldTag         = "tag"
+	FieldPanel       = "panel"
+	FieldDescription = "desc"
+	FieldQuery       = "query"
+	FieldDatasource  = "ds"
+)
+
+// fieldWeights controls how much a match in a given field contributes to the
+// score of a document.
+var fieldWeights = map[string]float64{
+	FieldTitle:       10,
+	FieldTag:         5,
+	FieldPanel:       4,
+	FieldDescription: 2,
+	FieldDatasource:  2,
+	FieldQuery:       1,
+}
+
+// queryKeys are the target properties that hold query expressions for the
+// core datasources.
+var queryKeys = []string{"expr", "query", "rawSql", "target", "rawQuery", "queryText", "expression"}
+
+// document is the indexed representation of a single dashboard.
+type document struct {
+	id     int64
+	orgID  int64
+	fields map[string][]string
+}
+
+func newDocument(dash *models.Dashboard) *document {
+	doc := &document{
+		id:     dash.Id,
+		orgID:  dash.OrgId,
+		fields: map[string][]string{},
+	}
+
+	doc.add(FieldTitle, dash.Title)
+	if dash.Data == nil {
+		return doc
+	}
+
+	for _, tag := range dash.Data.Get("tags").MustStringArray() {
+		doc.add(FieldTag, tag)
+	}
+	doc.add(FieldDescription, dash.Data.Get("description").MustString())
+
+	for _, panel := range dash.Data.Get("panels").MustArray() {
+		doc.addPanel(simplejson.NewFromAny(panel))
+	}
+
+	// dashboards that have not been migrated to the flat panel list yet.
+	for _, row := range dash.Data.Get("rows").MustArray() {
+		for _, panel := range simplejson.NewFromAny(row).Get("panels").MustArray() {
+			doc.addPanel(simplejson.NewFromAny(panel))
+		}
+	}
+
+	return doc
+}
+
+func (d *document) addPanel(panel *simplejson.Json) {
+	d.add(FieldPanel, panel.Get("title").MustString())
+	d.add(FieldDescription, panel.Get("description").MustString())
+	d.addDatasource(panel.Get("datasource"))
+
+	for _, target := range panel.Get("targets").MustArray() {
+		t := simplejson.NewFromAny(target)
+		d.addDatasource(t.Get("datasource"))
+		for _, key := range queryKeys {
+			if v, err := t.Get(key).String(); err == nil {
+				d.add(FieldQuery, v)
+			}
+		}
+	}
+
+	// collapsed rows keep their panels nested.
+	for _, nested := range panel.Get("panels").MustArray() {
+		d.addPanel(simplejson.NewFromAny(nested))
+	}
+}
+
+// addDatasource indexes a datasource reference, which is either a datasource
+// name or an object holding the datasource uid and type.
+func (d *document) addDatasource(ref *simplejson.Json) {
+	if name, err := ref.String(); err == nil {
+		d.add(FieldDatasource, name)
+		return
+	}
+	d.add(FieldDatasource, ref.Get("uid").MustString())
+	d.add(FieldDatasource, ref.Get("type").MustString())
+}
+
+func (d *document) add(field, text string) {
+	d.fields[field] = append(d.fields[field], tokenize(text)...)
+}
+
+// tokenize lower-cases the text and splits it into words. Underscores are
+// kept so that metric names such as `http_requests_total` stay intact.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
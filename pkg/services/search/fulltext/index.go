@@ -0,0 +1,169 @@
+package fulltext
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// prefixMatchWeight is applied to the score of terms that only match the
+// beginning of an indexed word.
+const prefixMatchWeight = 0.5
+
+// postings maps an indexed word to the documents containing it and the number
+// of occurrences.
+type postings map[string]map[int64]int
+
+type orgIndex struct {
+	docs   map[int64]*document
+	fields map[string]postings
+}
+
+func newOrgIndex() *orgIndex {
+	return &orgIndex{
+		docs:   map[int64]*document{},
+		fields: map[string]postings{},
+	}
+}
+
+// Index is an in-memory inverted index over dashboard content, partitioned
+// by organization.
+type Index struct {
+	mu   sync.RWMutex
+	orgs map[int64]*orgIndex
+}
+
+func newIndex() *Index {
+	return &Index{orgs: map[int64]*orgIndex{}}
+}
+
+// Update adds the dashboard to the index, replacing any earlier version.
+func (i *Index) Update(dash *models.Dashboard) {
+	doc := newDocument(dash)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	org, ok := i.orgs[doc.orgID]
+	if !ok {
+		org = newOrgIndex()
+		i.orgs[doc.orgID] = org
+	}
+
+	org.remove(doc.id)
+	org.docs[doc.id] = doc
+	for field, words := range doc.fields {
+		p, ok := org.fields[field]
+		if !ok {
+			p = postings{}
+			org.fields[field] = p
+		}
+		for _, word := range words {
+			if p[word] == nil {
+				p[word] = map[int64]int{}
+			}
+			p[word][doc.id]++
+		}
+	}
+}
+
+// Remove drops the dashboard from the index.
+func (i *Index) Remove(orgID, dashboardID int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if org, ok := i.orgs[orgID]; ok {
+		org.remove(dashboardID)
+	}
+}
+
+// Search returns the IDs of the dashboards in the organization matching every
+// term of the query, ordered by descending relevance.
+func (i *Index) Search(orgID int64, query string) []int64 {
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	org, ok := i.orgs[orgID]
+	if !ok {
+		return nil
+	}
+
+	var scores map[int64]float64
+	for _, t := range terms {
+		termScores := org.score(t)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id, score := range scores {
+			if s, ok := termScores[id]; ok {
+				scores[id] = score + s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	ids := make([]int64, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if scores[ids[a]] != scores[ids[b]] {
+			return scores[ids[a]] > scores[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+
+	return ids
+}
+
+func (o *orgIndex) remove(id int64) {
+	doc, ok := o.docs[id]
+	if !ok {
+		return
+	}
+
+	for field, words := range doc.fields {
+		p := o.fields[field]
+		for _, word := range words {
+			delete(p[word], id)
+			if len(p[word]) == 0 {
+				delete(p, word)
+			}
+		}
+	}
+	delete(o.docs, id)
+}
+
+// score returns the documents matching the term with their weighted score.
+func (o *orgIndex) score(t term) map[int64]float64 {
+	scores := map[int64]float64{}
+	for field, p := range o.fields {
+		if t.field != "" && t.field != field {
+			continue
+		}
+
+		weight := fieldWeights[field]
+		for word, docs := range p {
+			w := weight
+			if word != t.value {
+				if !strings.HasPrefix(word, t.value) {
+					continue
+				}
+				w *= prefixMatchWeight
+			}
+			for id, count := range docs {
+				scores[id] += w * float64(count)
+			}
+		}
+	}
+	return scores
+}
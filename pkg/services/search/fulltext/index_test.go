@@ -0,0 +1,104 @@
+package fulltext
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDashboard(t *testing.T, id, orgID int64, title, data string) *models.Dashboard {
+	t.Helper()
+
+	json, err := simplejson.NewJson([]byte(data))
+	require.NoError(t, err)
+
+	return &models.Dashboard{Id: id, OrgId: orgID, Title: title, Data: json}
+}
+
+func TestIndex(t *testing.T) {
+	index := newIndex()
+	index.Update(testDashboard(t, 1, 1, "API latency", `{
+		"tags": ["backend"],
+		"panels": [
+			{
+				"title": "Request latency",
+				"datasource": {"type": "prometheus", "uid": "prom-1"},
+				"targets": [{"expr": "histogram_quantile(0.99, rate(http_request_duration_seconds_bucket[5m]))"}]
+			}
+		]
+	}`))
+	index.Update(testDashboard(t, 2, 1, "Database overview", `{
+		"description": "Shows request latency of the primary database",
+		"rows": [
+			{"panels": [{"title": "Connections", "datasource": "MySQL", "targets": [{"rawSql": "SELECT count(*) FROM connections"}]}]}
+		]
+	}`))
+	index.Update(testDashboard(t, 3, 1, "Logs", `{
+		"panels": [
+			{"type": "row", "panels": [{"title": "Errors", "datasource": {"type": "loki", "uid": "loki-1"}}]}
+		]
+	}`))
+	index.Update(testDashboard(t, 4, 2, "API latency", `{}`))
+
+	t.Run("ranks title matches above description matches", func(t *testing.T) {
+		assert.Equal(t, []int64{1, 2}, index.Search(1, "latency"))
+	})
+
+	t.Run("requires all terms to match", func(t *testing.T) {
+		assert.Equal(t, []int64{2}, index.Search(1, "latency database"))
+	})
+
+	t.Run("matches word prefixes", func(t *testing.T) {
+		assert.Equal(t, []int64{2}, index.Search(1, "connect"))
+	})
+
+	t.Run("filters by datasource", func(t *testing.T) {
+		assert.Equal(t, []int64{1}, index.Search(1, "ds:prometheus"))
+		assert.Equal(t, []int64{1}, index.Search(1, "ds:prom-1"))
+		assert.Equal(t, []int64{2}, index.Search(1, "ds:mysql"))
+		assert.Equal(t, []int64{3}, index.Search(1, "ds:loki"))
+	})
+
+	t.Run("filters by panel title", func(t *testing.T) {
+		assert.Equal(t, []int64{1}, index.Search(1, "panel:latency"))
+		assert.Equal(t, []int64{3}, index.Search(1, "panel:errors"))
+		assert.Empty(t, index.Search(1, "panel:database"))
+	})
+
+	t.Run("filters by query and tag", func(t *testing.T) {
+		assert.Equal(t, []int64{1}, index.Search(1, "query:http_request_duration_seconds_bucket"))
+		assert.Equal(t, []int64{1}, index.Search(1, "tag:backend"))
+	})
+
+	t.Run("keeps organizations apart", func(t *testing.T) {
+		assert.Equal(t, []int64{4}, index.Search(2, "latency"))
+		assert.Empty(t, index.Search(3, "latency"))
+	})
+
+	t.Run("replaces updated dashboards", func(t *testing.T) {
+		index.Update(testDashboard(t, 1, 1, "API throughput", `{}`))
+		assert.Equal(t, []int64{2}, index.Search(1, "latency"))
+		assert.Equal(t, []int64{1}, index.Search(1, "throughput"))
+	})
+
+	t.Run("removes deleted dashboards", func(t *testing.T) {
+		index.Remove(1, 2)
+		assert.Empty(t, index.Search(1, "latency"))
+	})
+}
+
+func TestParseQuery(t *testing.T) {
+	assert.Equal(t, []term{
+		{value: "api"},
+		{field: FieldDatasource, value: "prometheus"},
+		{field: FieldPanel, value: "p99"},
+		{field: FieldPanel, value: "latency"},
+		{value: "foo"},
+		{value: "bar"},
+	}, parseQuery(`API ds:Prometheus panel:"p99 latency" foo:bar`))
+
+	assert.Empty(t, parseQuery("  "))
+}
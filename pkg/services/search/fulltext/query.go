@@ -0,0 +1,59 @@
+package fulltext
+
+import (
+	"strings"
+)
+
+// term is a single word of a parsed query. Terms without a field match any
+// indexed field.
+type term struct {
+	field string
+	value string
+}
+
+// parseQuery splits a query such as `latency ds:prometheus panel:"p99 latency"`
+// into terms. Unknown field prefixes are treated as free text.
+func parseQuery(query string) []term {
+	var terms []term
+	for _, word := range splitQuery(query) {
+		field := ""
+		if i := strings.Index(word, ":"); i > 0 {
+			if _, ok := fieldWeights[strings.ToLower(word[:i])]; ok {
+				field = strings.ToLower(word[:i])
+				word = word[i+1:]
+			}
+		}
+
+		for _, token := range tokenize(word) {
+			terms = append(terms, term{field: field, value: token})
+		}
+	}
+	return terms
+}
+
+// splitQuery splits the query on whitespace, keeping double-quoted phrases
+// together.
+func splitQuery(query string) []string {
+	var words []string
+	var current strings.Builder
+	quoted := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+
+	return words
+}
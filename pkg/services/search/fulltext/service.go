@@ -0,0 +1,81 @@
+package fulltext
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboardloader"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// Service keeps a full-text index of all dashboards up to date and answers
+// search queries against it.
+type Service struct {
+	sqlStore *sqlstore.SQLStore
+	features featuremgmt.FeatureToggles
+	index    *Index
+	log      log.Logger
+}
+
+func ProvideService(sqlStore *sqlstore.SQLStore, bus bus.Bus, features featuremgmt.FeatureToggles) *Service {
+	s := &Service{
+		sqlStore: sqlStore,
+		features: features,
+		index:    newIndex(),
+		log:      log.New("search.fulltext"),
+	}
+
+	if s.IsEnabled() {
+		bus.AddEventListener(s.handleDashboardSaved)
+		bus.AddEventListener(s.handleDashboardDeleted)
+	}
+
+	return s
+}
+
+// IsEnabled returns true when full-text search is switched on.
+func (s *Service) IsEnabled() bool {
+	return s.features.IsEnabled(featuremgmt.FlagFullTextDashboardSearch)
+}
+
+// IsDisabled is used by the background service registry.
+func (s *Service) IsDisabled() bool {
+	return !s.IsEnabled()
+}
+
+// Run builds the index from the dashboards stored in the database. Changes
+// made afterwards are applied through dashboard events.
+func (s *Service) Run(ctx context.Context) error {
+	return dashboardloader.Run(ctx, s.sqlStore, s.log, s.index.Update)
+}
+
+// IsSearchable returns false when the query has no words the index can match,
+// for example when it's made only of punctuation.
+func (s *Service) IsSearchable(query string) bool {
+	return len(parseQuery(query)) > 0
+}
+
+// Search returns the IDs of the dashboards matching the query, most relevant
+// first.
+func (s *Service) Search(_ context.Context, orgID int64, query string) ([]int64, error) {
+	return s.index.Search(orgID, query), nil
+}
+
+func (s *Service) handleDashboardSaved(_ context.Context, evt *events.DashboardSaved) error {
+	dash, err := s.sqlStore.GetDashboard(evt.ID, evt.OrgID, "", "")
+	if err != nil {
+		s.log.Warn("Failed to load saved dashboard for indexing", "id", evt.ID, "error", err)
+		return nil
+	}
+
+	s.index.Update(dash)
+	return nil
+}
+
+func (s *Service) handleDashboardDeleted(_ context.Context, evt *events.DashboardDeleted) error {
+	s.index.Remove(evt.OrgID, evt.ID)
+	return nil
+}
@@ -0,0 +1,68 @@
+package fulltext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboardloader"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	sqlStore := sqlstore.InitTestDB(t)
+	saveDashboard := func(title string, data map[string]interface{}) *models.Dashboard {
+		data["title"] = title
+		dash, err := sqlStore.SaveDashboard(models.SaveDashboardCommand{
+			OrgId:     1,
+			Dashboard: simplejson.NewFromAny(data),
+		})
+		require.NoError(t, err)
+		return dash
+	}
+
+	existing := saveDashboard("Existing", map[string]interface{}{
+		"panels": []interface{}{map[string]interface{}{"title": "CPU usage"}},
+	})
+
+	s := ProvideService(sqlStore, bus.GetBus(), featuremgmt.WithFeatures(featuremgmt.FlagFullTextDashboardSearch))
+	_, err := dashboardloader.Load(context.Background(), sqlStore, s.index.Update)
+	require.NoError(t, err)
+
+	ids, err := s.Search(context.Background(), 1, "panel:cpu")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{existing.Id}, ids)
+
+	t.Run("queries without words aren't searchable", func(t *testing.T) {
+		assert.True(t, s.IsSearchable("cpu"))
+		assert.False(t, s.IsSearchable("!?"))
+		assert.False(t, s.IsSearchable(" - "))
+	})
+
+	t.Run("saved dashboards are indexed", func(t *testing.T) {
+		dash := saveDashboard("New", map[string]interface{}{
+			"panels": []interface{}{map[string]interface{}{"title": "Memory usage"}},
+		})
+
+		ids, err := s.Search(context.Background(), 1, "panel:memory")
+		require.NoError(t, err)
+		assert.Equal(t, []int64{dash.Id}, ids)
+	})
+
+	t.Run("deleted dashboards are removed", func(t *testing.T) {
+		err := sqlStore.DeleteDashboard(context.Background(), &models.DeleteDashboardCommand{Id: existing.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		ids, err := s.Search(context.Background(), 1, "panel:cpu")
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+}
@@ -10,10 +10,19 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 )
 
-func ProvideService(cfg *setting.Cfg, bus bus.Bus) *SearchService {
+// maxFullTextCandidates caps the number of dashboards the user is allowed to
+// see that a full-text search returns.
+const maxFullTextCandidates = 1000
+
+// fullTextBatchSize is the number of dashboards from the full-text index that
+// are checked against the database per query.
+const fullTextBatchSize = 500
+
+func ProvideService(cfg *setting.Cfg, bus bus.Bus, index FullTextIndex) *SearchService {
 	s := &SearchService{
-		Cfg: cfg,
-		Bus: bus,
+		Cfg:   cfg,
+		Bus:   bus,
+		Index: index,
 		sortOptions: map[string]SortOption{
 			SortAlphaAsc.Name:  SortAlphaAsc,
 			SortAlphaDesc.Name: SortAlphaDesc,
@@ -59,9 +68,21 @@ type FindPersistedDashboardsQuery struct {
 	Result HitList
 }
 
+// FullTextIndex ranks dashboards by matching a query against their content,
+// such as panel titles, queries and datasources.
+type FullTextIndex interface {
+	IsEnabled() bool
+	// IsSearchable returns false when the query has no words the index can
+	// match, in which case the regular title search is used instead.
+	IsSearchable(query string) bool
+	// Search returns the IDs of the matching dashboards, most relevant first.
+	Search(ctx context.Context, orgID int64, query string) ([]int64, error)
+}
+
 type SearchService struct {
-	Bus bus.Bus
-	Cfg *setting.Cfg
+	Bus   bus.Bus
+	Cfg   *setting.Cfg
+	Index FullTextIndex
 
 	sortOptions map[string]SortOption
 }
@@ -84,6 +105,10 @@ func (s *SearchService) SearchHandler(ctx context.Context, query *Query) error {
 		dashboardQuery.Sort = sortOpt
 	}
 
+	if s.useFullTextIndex(query) {
+		return s.fullTextSearch(ctx, query, dashboardQuery)
+	}
+
 	if err := bus.Dispatch(ctx, &dashboardQuery); err != nil {
 		return err
 	}
@@ -102,6 +127,143 @@ func (s *SearchService) SearchHandler(ctx context.Context, query *Query) error {
 	return nil
 }
 
+func (s *SearchService) useFullTextIndex(query *Query) bool {
+	return query.Title != "" && s.Index != nil && s.Index.IsEnabled() && s.Index.IsSearchable(query.Title)
+}
+
+// fullTextSearch looks up the dashboards matching the query in the full-text
+// index. The candidates are then passed through the persisted dashboard search
+// so that the remaining filters and the user's permissions still apply.
+func (s *SearchService) fullTextSearch(ctx context.Context, query *Query, dashboardQuery FindPersistedDashboardsQuery) error {
+	ids, err := s.Index.Search(ctx, query.SignedInUser.OrgId, query.Title)
+	if err != nil {
+		return err
+	}
+
+	if len(query.DashboardIds) > 0 {
+		ids = intersectIDs(ids, query.DashboardIds)
+	}
+
+	dashboardQuery.Title = ""
+	hits, err := permittedHits(ctx, dashboardQuery, ids)
+	if err != nil {
+		return err
+	}
+
+	if query.Sort != "" && len(hits) > 0 {
+		// the batches are sorted separately, so sort the permitted hits again.
+		dashboardQuery.DashboardIds = hitIDs(hits)
+		dashboardQuery.Limit = int64(len(hits))
+		dashboardQuery.Page = 1
+		if err := bus.Dispatch(ctx, &dashboardQuery); err != nil {
+			return err
+		}
+		hits = dashboardQuery.Result
+	}
+	hits = pageHits(hits, query.Limit, query.Page)
+
+	if err := setStarredDashboards(ctx, query.SignedInUser.UserId, hits); err != nil {
+		return err
+	}
+
+	query.Result = hits
+
+	return nil
+}
+
+// permittedHits checks the ranked IDs against the database in batches and
+// returns the hits the user is allowed to see, up to maxFullTextCandidates.
+// Filtering before applying the cap keeps dashboards the user can't see from
+// pushing the ones they can out of the results.
+func permittedHits(ctx context.Context, dashboardQuery FindPersistedDashboardsQuery, ids []int64) (HitList, error) {
+	hits := HitList{}
+	for start := 0; start < len(ids) && len(hits) < maxFullTextCandidates; start += fullTextBatchSize {
+		end := start + fullTextBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		dashboardQuery.DashboardIds = ids[start:end]
+		dashboardQuery.Limit = int64(end - start)
+		dashboardQuery.Page = 1
+		if err := bus.Dispatch(ctx, &dashboardQuery); err != nil {
+			return nil, err
+		}
+
+		hits = append(hits, rankedHits(dashboardQuery.Result, ids[start:end])...)
+	}
+
+	if len(hits) > maxFullTextCandidates {
+		hits = hits[:maxFullTextCandidates]
+	}
+	return hits, nil
+}
+
+func hitIDs(hits HitList) []int64 {
+	ids := make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+// rankedHits orders the hits in the same order as the ranked IDs.
+func rankedHits(unsorted HitList, ranked []int64) HitList {
+	rank := make(map[int64]int, len(ranked))
+	for i, id := range ranked {
+		rank[id] = i
+	}
+
+	hits := make(HitList, 0, len(unsorted))
+	hits = append(hits, unsorted...)
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return rank[hits[i].ID] < rank[hits[j].ID]
+	})
+
+	for _, hit := range hits {
+		sort.Strings(hit.Tags)
+	}
+
+	return hits
+}
+
+func pageHits(hits HitList, limit, page int64) HitList {
+	if limit < 1 {
+		limit = 1000
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * limit
+	if start >= int64(len(hits)) {
+		return HitList{}
+	}
+
+	end := start + limit
+	if end > int64(len(hits)) {
+		end = int64(len(hits))
+	}
+
+	return hits[start:end]
+}
+
+func intersectIDs(ids []int64, allowed []int64) []int64 {
+	set := make(map[int64]struct{}, len(allowed))
+	for _, id := range allowed {
+		set[id] = struct{}{}
+	}
+
+	res := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := set[id]; ok {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
 func sortedHits(unsorted HitList) HitList {
 	hits := make(HitList, 0)
 	hits = append(hits, unsorted...)
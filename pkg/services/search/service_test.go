@@ -56,3 +56,121 @@ func TestSearch_SortedResults(t *testing.T) {
 	assert.Equal(t, "BB", query.Result[3].Tags[1])
 	assert.Equal(t, "EE", query.Result[3].Tags[2])
 }
+
+type fakeFullTextIndex struct {
+	ids []int64
+}
+
+func (f *fakeFullTextIndex) IsEnabled() bool { return true }
+
+func (f *fakeFullTextIndex) IsSearchable(query string) bool { return query != "!!" }
+
+func (f *fakeFullTextIndex) Search(_ context.Context, _ int64, _ string) ([]int64, error) {
+	return f.ids, nil
+}
+
+func TestSearch_FullTextIndex(t *testing.T) {
+	var dashboardQuery *FindPersistedDashboardsQuery
+	bus.AddHandler("test", func(_ context.Context, query *FindPersistedDashboardsQuery) error {
+		dashboardQuery = query
+		// the database only returns the dashboards the user is allowed to see.
+		query.Result = HitList{
+			&Hit{ID: 10, Title: "AA", Type: "dash-db"},
+			&Hit{ID: 20, Title: "BB", Type: "dash-db"},
+			&Hit{ID: 30, Title: "CC", Type: "dash-db"},
+		}
+		return nil
+	})
+
+	bus.AddHandler("test", func(_ context.Context, query *models.GetUserStarsQuery) error {
+		query.Result = map[int64]bool{20: true}
+		return nil
+	})
+
+	svc := &SearchService{Index: &fakeFullTextIndex{ids: []int64{30, 40, 20, 10}}}
+
+	query := &Query{
+		Title:        "latency",
+		Limit:        2,
+		SignedInUser: &models.SignedInUser{OrgId: 1},
+	}
+
+	err := svc.SearchHandler(context.Background(), query)
+	require.NoError(t, err)
+
+	assert.Empty(t, dashboardQuery.Title)
+	assert.Equal(t, []int64{30, 40, 20, 10}, dashboardQuery.DashboardIds)
+
+	require.Len(t, query.Result, 2)
+	assert.Equal(t, int64(30), query.Result[0].ID)
+	assert.Equal(t, int64(20), query.Result[1].ID)
+	assert.True(t, query.Result[1].IsStarred)
+}
+
+func TestSearch_FullTextIndexPermissions(t *testing.T) {
+	// the user may only see the dashboards ranked after the candidate limit.
+	ids := make([]int64, 0, maxFullTextCandidates+100)
+	for id := int64(1); id <= maxFullTextCandidates+100; id++ {
+		ids = append(ids, id)
+	}
+
+	queries := 0
+	bus.AddHandler("test", func(_ context.Context, query *FindPersistedDashboardsQuery) error {
+		queries++
+		query.Result = HitList{}
+		for _, id := range query.DashboardIds {
+			if id > maxFullTextCandidates {
+				query.Result = append(query.Result, &Hit{ID: id, Type: "dash-db"})
+			}
+		}
+		return nil
+	})
+
+	bus.AddHandler("test", func(_ context.Context, query *models.GetUserStarsQuery) error {
+		query.Result = map[int64]bool{}
+		return nil
+	})
+
+	svc := &SearchService{Index: &fakeFullTextIndex{ids: ids}}
+
+	query := &Query{
+		Title:        "latency",
+		Limit:        10,
+		SignedInUser: &models.SignedInUser{OrgId: 1},
+	}
+
+	err := svc.SearchHandler(context.Background(), query)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, queries)
+	require.Len(t, query.Result, 10)
+	assert.Equal(t, int64(maxFullTextCandidates+1), query.Result[0].ID)
+}
+
+func TestSearch_FullTextIndexPunctuation(t *testing.T) {
+	var dashboardQuery *FindPersistedDashboardsQuery
+	bus.AddHandler("test", func(_ context.Context, query *FindPersistedDashboardsQuery) error {
+		dashboardQuery = query
+		query.Result = HitList{&Hit{ID: 10, Title: "!!", Type: "dash-db"}}
+		return nil
+	})
+
+	bus.AddHandler("test", func(_ context.Context, query *models.GetUserStarsQuery) error {
+		query.Result = map[int64]bool{}
+		return nil
+	})
+
+	svc := &SearchService{Index: &fakeFullTextIndex{}}
+
+	query := &Query{
+		Title:        "!!",
+		SignedInUser: &models.SignedInUser{OrgId: 1},
+	}
+
+	err := svc.SearchHandler(context.Background(), query)
+	require.NoError(t, err)
+
+	assert.Equal(t, "!!", dashboardQuery.Title)
+	require.Len(t, query.Result, 1)
+	assert.Equal(t, int64(10), query.Result[0].ID)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/search"
@@ -167,6 +168,14 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 
 	cmd.Result = dash
 
+	sess.publishAfterCommit(&events.DashboardSaved{
+		Timestamp: dash.Updated,
+		ID:        dash.Id,
+		UID:       dash.Uid,
+		OrgID:     dash.OrgId,
+		Version:   dash.Version,
+	})
+
 	return nil
 }
 
@@ -440,9 +449,10 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
 
 		dashIds := []struct {
-			Id int64
+			Id  int64
+			Uid string
 		}{}
-		err := sess.SQL("SELECT id FROM dashboard WHERE folder_id = ?", dashboard.Id).Find(&dashIds)
+		err := sess.SQL("SELECT id, uid FROM dashboard WHERE folder_id = ?", dashboard.Id).Find(&dashIds)
 		if err != nil {
 			return err
 		}
@@ -451,6 +461,12 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 			if err := deleteAlertDefinition(id.Id, sess); err != nil {
 				return err
 			}
+			sess.publishAfterCommit(&events.DashboardDeleted{
+				Timestamp: time.Now(),
+				ID:        id.Id,
+				UID:       id.Uid,
+				OrgID:     dashboard.OrgId,
+			})
 		}
 
 		if len(dashIds) > 0 {
@@ -506,6 +522,13 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 		}
 	}
 
+	sess.publishAfterCommit(&events.DashboardDeleted{
+		Timestamp: time.Now(),
+		ID:        dashboard.Id,
+		UID:       dashboard.Uid,
+		OrgID:     dashboard.OrgId,
+	})
+
 	return nil
 }
 
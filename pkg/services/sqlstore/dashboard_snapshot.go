@@ -19,8 +19,14 @@ func (ss *SQLStore) DeleteExpiredSnapshots(ctx context.Context, cmd *models.Dele
 			return nil
 		}
 
+		now := time.Now()
+		deleteAccessSQL := "DELETE FROM dashboard_snapshot_access WHERE snapshot_id IN (SELECT id FROM dashboard_snapshot WHERE expires < ?)"
+		if _, err := sess.Exec(deleteAccessSQL, now); err != nil {
+			return err
+		}
+
 		deleteExpiredSQL := "DELETE FROM dashboard_snapshot WHERE expires < ?"
-		expiredResponse, err := sess.Exec(deleteExpiredSQL, time.Now())
+		expiredResponse, err := sess.Exec(deleteExpiredSQL, now)
 		if err != nil {
 			return err
 		}
@@ -49,6 +55,8 @@ func (ss *SQLStore) CreateDashboardSnapshot(ctx context.Context, cmd *models.Cre
 			ExternalDeleteUrl:  cmd.ExternalDeleteUrl,
 			Dashboard:          simplejson.New(),
 			DashboardEncrypted: cmd.DashboardEncrypted,
+			PasswordEncrypted:  cmd.PasswordEncrypted,
+			MaxViews:           cmd.MaxViews,
 			Expires:            expires,
 			Created:            time.Now(),
 			Updated:            time.Now(),
@@ -62,6 +70,11 @@ func (ss *SQLStore) CreateDashboardSnapshot(ctx context.Context, cmd *models.Cre
 
 func (ss *SQLStore) DeleteDashboardSnapshot(ctx context.Context, cmd *models.DeleteDashboardSnapshotCommand) error {
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		deleteAccessSQL := "DELETE FROM dashboard_snapshot_access WHERE snapshot_id IN (SELECT id FROM dashboard_snapshot WHERE delete_key=?)"
+		if _, err := sess.Exec(deleteAccessSQL, cmd.DeleteKey); err != nil {
+			return err
+		}
+
 		var rawSQL = "DELETE FROM dashboard_snapshot WHERE delete_key=?"
 		_, err := sess.Exec(rawSQL, cmd.DeleteKey)
 		return err
@@ -82,6 +95,74 @@ func (ss *SQLStore) GetDashboardSnapshot(query *models.GetDashboardSnapshotQuery
 	return nil
 }
 
+// IncrementDashboardSnapshotViewCount counts a view of the snapshot. It returns
+// false without counting the view if the snapshot reached its view limit.
+func (ss *SQLStore) IncrementDashboardSnapshotViewCount(ctx context.Context, snapshotID int64) (bool, error) {
+	counted := false
+	err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		rawSQL := "UPDATE dashboard_snapshot SET view_count = view_count + 1 WHERE id = ? AND (max_views = 0 OR view_count < max_views)"
+		res, err := sess.Exec(rawSQL, snapshotID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		counted = affected > 0
+		return nil
+	})
+	return counted, err
+}
+
+func (ss *SQLStore) AddDashboardSnapshotAccess(ctx context.Context, entry *models.DashboardSnapshotAccess) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		_, err := sess.Insert(entry)
+		return err
+	})
+}
+
+// CountDashboardSnapshotAccessDenials returns how many times the client was
+// denied access to the snapshot for the reason since the given time.
+func (ss *SQLStore) CountDashboardSnapshotAccessDenials(ctx context.Context, snapshotID int64, clientIP, reason string, since time.Time) (int64, error) {
+	var count int64
+	err := ss.WithDbSession(ctx, func(sess *DBSession) error {
+		var err error
+		count, err = sess.Where("snapshot_id = ? AND client_ip = ? AND granted = ? AND reason = ? AND created >= ?",
+			snapshotID, clientIP, false, reason, since).Count(&models.DashboardSnapshotAccess{})
+		return err
+	})
+	return count, err
+}
+
+func (ss *SQLStore) GetDashboardSnapshotAccessLog(ctx context.Context, query *models.GetDashboardSnapshotAccessLogQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		entries := make([]*models.DashboardSnapshotAccess, 0)
+		sess.Where("snapshot_id = ?", query.SnapshotId).Desc("created").Desc("id")
+		if query.Limit > 0 {
+			sess.Limit(query.Limit)
+		}
+		if err := sess.Find(&entries); err != nil {
+			return err
+		}
+		query.Result = entries
+		return nil
+	})
+}
+
+// RevokeUserDashboardSnapshots revokes all snapshots created by a user.
+func (ss *SQLStore) RevokeUserDashboardSnapshots(ctx context.Context, cmd *models.RevokeUserDashboardSnapshotsCommand) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		rawSQL := "UPDATE dashboard_snapshot SET revoked = ?, updated = ? WHERE user_id = ? AND revoked = ?"
+		res, err := sess.Exec(rawSQL, true, time.Now(), cmd.UserId, false)
+		if err != nil {
+			return err
+		}
+		cmd.RevokedRows, _ = res.RowsAffected()
+		return nil
+	})
+}
+
 // SearchDashboardSnapshots returns a list of all snapshots for admins
 // for other roles, it returns snapshots created by the user
 func (ss *SQLStore) SearchDashboardSnapshots(query *models.GetDashboardSnapshotsQuery) error {
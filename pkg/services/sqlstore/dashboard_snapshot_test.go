@@ -151,8 +151,10 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 		setting.SnapShotRemoveExpired = true
 
 		nonExpiredSnapshot := createTestSnapshot(t, sqlstore, "key1", 48000)
-		createTestSnapshot(t, sqlstore, "key2", -1200)
+		expiredSnapshot := createTestSnapshot(t, sqlstore, "key2", -1200)
 		createTestSnapshot(t, sqlstore, "key3", -1200)
+		addTestSnapshotAccess(t, sqlstore, nonExpiredSnapshot)
+		addTestSnapshotAccess(t, sqlstore, expiredSnapshot)
 
 		err := sqlstore.DeleteExpiredSnapshots(context.Background(), &models.DeleteExpiredSnapshotsCommand{})
 		require.NoError(t, err)
@@ -166,6 +168,8 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 
 		assert.Len(t, query.Result, 1)
 		assert.Equal(t, nonExpiredSnapshot.Key, query.Result[0].Key)
+		assert.Len(t, getTestSnapshotAccessLog(t, sqlstore, nonExpiredSnapshot), 1)
+		assert.Empty(t, getTestSnapshotAccessLog(t, sqlstore, expiredSnapshot))
 
 		err = sqlstore.DeleteExpiredSnapshots(context.Background(), &models.DeleteExpiredSnapshotsCommand{})
 		require.NoError(t, err)
@@ -182,6 +186,39 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 	})
 }
 
+func TestDeleteDashboardSnapshot(t *testing.T) {
+	sqlstore := InitTestDB(t)
+
+	t.Run("Deleting a snapshot deletes its access log", func(t *testing.T) {
+		snapshot := createTestSnapshot(t, sqlstore, "key1", 48000)
+		other := createTestSnapshot(t, sqlstore, "key2", 48000)
+		addTestSnapshotAccess(t, sqlstore, snapshot)
+		addTestSnapshotAccess(t, sqlstore, other)
+
+		err := sqlstore.DeleteDashboardSnapshot(context.Background(), &models.DeleteDashboardSnapshotCommand{DeleteKey: snapshot.DeleteKey})
+		require.NoError(t, err)
+
+		assert.Empty(t, getTestSnapshotAccessLog(t, sqlstore, snapshot))
+		assert.Len(t, getTestSnapshotAccessLog(t, sqlstore, other), 1)
+	})
+}
+
+func addTestSnapshotAccess(t *testing.T, sqlstore *SQLStore, snapshot *models.DashboardSnapshot) {
+	err := sqlstore.AddDashboardSnapshotAccess(context.Background(), &models.DashboardSnapshotAccess{
+		SnapshotId: snapshot.Id,
+		OrgId:      snapshot.OrgId,
+		Granted:    true,
+		Created:    time.Now(),
+	})
+	require.NoError(t, err)
+}
+
+func getTestSnapshotAccessLog(t *testing.T, sqlstore *SQLStore, snapshot *models.DashboardSnapshot) []*models.DashboardSnapshotAccess {
+	query := &models.GetDashboardSnapshotAccessLogQuery{SnapshotId: snapshot.Id}
+	require.NoError(t, sqlstore.GetDashboardSnapshotAccessLog(context.Background(), query))
+	return query.Result
+}
+
 func createTestSnapshot(t *testing.T, sqlstore *SQLStore, key string, expires int64) *models.DashboardSnapshot {
 	cmd := models.CreateDashboardSnapshotCommand{
 		Key:       key,
@@ -84,28 +84,33 @@ func (ss *SQLStore) GetDefaultDataSource(ctx context.Context, query *models.GetD
 // DeleteDataSource removes a datasource by org_id as well as either uid (preferred), id, or name
 // and is added to the bus.
 func (ss *SQLStore) DeleteDataSource(ctx context.Context, cmd *models.DeleteDataSourceCommand) error {
-	params := make([]interface{}, 0)
-
-	makeQuery := func(sql string, p ...interface{}) {
-		params = append(params, sql)
-		params = append(params, p...)
-	}
+	var where string
+	var args []interface{}
 
 	switch {
 	case cmd.OrgID == 0:
 		return models.ErrDataSourceIdentifierNotSet
 	case cmd.UID != "":
-		makeQuery("DELETE FROM data_source WHERE uid=? and org_id=?", cmd.UID, cmd.OrgID)
+		where, args = "uid=? and org_id=?", []interface{}{cmd.UID, cmd.OrgID}
 	case cmd.ID != 0:
-		makeQuery("DELETE FROM data_source WHERE id=? and org_id=?", cmd.ID, cmd.OrgID)
+		where, args = "id=? and org_id=?", []interface{}{cmd.ID, cmd.OrgID}
 	case cmd.Name != "":
-		makeQuery("DELETE FROM data_source WHERE name=? and org_id=?", cmd.Name, cmd.OrgID)
+		where, args = "name=? and org_id=?", []interface{}{cmd.Name, cmd.OrgID}
 	default:
 		return models.ErrDataSourceIdentifierNotSet
 	}
 
 	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
-		result, err := sess.Exec(params...)
+		// The staged and previous secrets and the health checks of the data
+		// source go with it. The audit trail of its secret rotations is kept.
+		for _, table := range []string{"data_source_secret_set", "data_source_health_check"} {
+			deleteSQL := "DELETE FROM " + table + " WHERE data_source_id IN (SELECT id FROM data_source WHERE " + where + ")"
+			if _, err := sess.Exec(append([]interface{}{deleteSQL}, args...)...); err != nil {
+				return err
+			}
+		}
+
+		result, err := sess.Exec(append([]interface{}{"DELETE FROM data_source WHERE " + where}, args...)...)
 		cmd.DeletedDatasourcesCount, _ = result.RowsAffected()
 
 		sess.publishAfterCommit(&events.DataSourceDeleted{
@@ -71,4 +71,42 @@ func addDashboardSnapshotMigrations(mg *Migrator) {
 
 	mg.AddMigration("Change dashboard_encrypted column to MEDIUMBLOB", NewRawSQLMigration("").
 		Mysql("ALTER TABLE dashboard_snapshot MODIFY dashboard_encrypted MEDIUMBLOB;"))
+
+	mg.AddMigration("Add column password_encrypted to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "password_encrypted", Type: DB_Blob, Nullable: true,
+	}))
+
+	mg.AddMigration("Add column max_views to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "max_views", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("Add column view_count to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "view_count", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("Add column revoked to dashboard_snapshot", NewAddColumnMigration(snapshotV5, &Column{
+		Name: "revoked", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	snapshotAccessV1 := Table{
+		Name: "dashboard_snapshot_access",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "snapshot_id", Type: DB_BigInt, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "login", Type: DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "client_ip", Type: DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "user_agent", Type: DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "granted", Type: DB_Bool, Nullable: false},
+			{Name: "reason", Type: DB_NVarchar, Length: 64, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"snapshot_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_snapshot_access table v1", NewAddTableMigration(snapshotAccessV1))
+	addTableIndicesMigrations(mg, "v1", snapshotAccessV1)
 }
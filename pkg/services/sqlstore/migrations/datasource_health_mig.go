@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDataSourceHealthCheckMigrations(mg *Migrator) {
+	healthCheckV1 := Table{
+		Name: "data_source_health_check",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "data_source_id", Type: DB_BigInt, Nullable: false},
+			{Name: "data_source_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "status", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "message", Type: DB_Text, Nullable: true},
+			{Name: "duration_ms", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "data_source_id", "created"}},
+			{Cols: []string{"created"}},
+		},
+	}
+
+	mg.AddMigration("create data_source_health_check table v1", NewAddTableMigration(healthCheckV1))
+
+	mg.AddMigration("add index data_source_health_check.org_id-data_source_id-created", NewAddIndexMigration(healthCheckV1, healthCheckV1.Indices[0]))
+	mg.AddMigration("add index data_source_health_check.created", NewAddIndexMigration(healthCheckV1, healthCheckV1.Indices[1]))
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDataSourceSecretRotationMigrations(mg *Migrator) {
+	secretSetV1 := Table{
+		Name: "data_source_secret_set",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "data_source_id", Type: DB_BigInt, Nullable: false},
+			{Name: "state", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "secure_json_data", Type: DB_Text, Nullable: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"data_source_id", "state"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create data_source_secret_set table v1", NewAddTableMigration(secretSetV1))
+	mg.AddMigration("add unique index data_source_secret_set.data_source_id-state", NewAddIndexMigration(secretSetV1, secretSetV1.Indices[0]))
+
+	secretAuditV1 := Table{
+		Name: "data_source_secret_audit",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "data_source_id", Type: DB_BigInt, Nullable: false},
+			{Name: "data_source_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_login", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "success", Type: DB_Bool, Nullable: false},
+			{Name: "message", Type: DB_Text, Nullable: true},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "data_source_id"}},
+		},
+	}
+
+	mg.AddMigration("create data_source_secret_audit table v1", NewAddTableMigration(secretAuditV1))
+	mg.AddMigration("add index data_source_secret_audit.org_id-data_source_id", NewAddIndexMigration(secretAuditV1, secretAuditV1.Indices[0]))
+}
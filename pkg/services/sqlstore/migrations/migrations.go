@@ -69,6 +69,9 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	ualert.AddDashboardUIDPanelIDMigration(mg)
 	accesscontrol.AddMigration(mg)
 	addQueryHistoryMigrations(mg)
+	addReportMigrations(mg)
+	addDataSourceHealthCheckMigrations(mg)
+	addDataSourceSecretRotationMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
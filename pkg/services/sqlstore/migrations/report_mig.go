@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addReportMigrations(mg *Migrator) {
+	reportV1 := Table{
+		Name: "report",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "recipients", Type: DB_Text, Nullable: false},
+			{Name: "schedule", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "timezone", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "time_from", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "time_to", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "variables", Type: DB_Text, Nullable: true},
+			{Name: "format", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "enabled", Type: DB_Bool, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "next_run_at", Type: DB_DateTime, Nullable: true},
+			{Name: "last_run_at", Type: DB_DateTime, Nullable: true},
+			{Name: "last_error", Type: DB_Text, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"enabled", "next_run_at"}},
+		},
+	}
+
+	mg.AddMigration("create report table v1", NewAddTableMigration(reportV1))
+
+	mg.AddMigration("add unique index report.org_id-uid", NewAddIndexMigration(reportV1, reportV1.Indices[0]))
+	mg.AddMigration("add index report.enabled-next_run_at", NewAddIndexMigration(reportV1, reportV1.Indices[1]))
+}
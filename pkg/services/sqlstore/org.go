@@ -237,6 +237,9 @@ func (ss *SQLStore) DeleteOrg(ctx context.Context, cmd *models.DeleteOrgCommand)
 			"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
 			"DELETE FROM dashboard WHERE org_id = ?",
 			"DELETE FROM api_key WHERE org_id = ?",
+			"DELETE FROM data_source_secret_set WHERE org_id = ?",
+			"DELETE FROM data_source_secret_audit WHERE org_id = ?",
+			"DELETE FROM data_source_health_check WHERE org_id = ?",
 			"DELETE FROM data_source WHERE org_id = ?",
 			"DELETE FROM org_user WHERE org_id = ?",
 			"DELETE FROM org WHERE id = ?",
@@ -32,26 +32,27 @@ func newSession(ctx context.Context) *DBSession {
 	return sess
 }
 
-func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DBSession, error) {
+// startSession returns the session of the transaction of ctx if any, or a
+// new session otherwise. isNew tells whether the caller owns the session.
+func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (sess *DBSession, isNew bool, err error) {
 	value := ctx.Value(ContextSessionKey{})
-	var sess *DBSession
 	sess, ok := value.(*DBSession)
 
 	if ok {
 		sess.Session = sess.Session.Context(ctx)
-		return sess, nil
+		return sess, false, nil
 	}
 
 	newSess := &DBSession{Session: engine.NewSession()}
 	if beginTran {
 		err := newSess.Begin()
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 	}
 
 	newSess.Session = newSess.Session.Context(ctx)
-	return newSess, nil
+	return newSess, true, nil
 }
 
 // WithDbSession calls the callback with a session.
@@ -8,7 +8,10 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,4 +56,76 @@ func TestTransaction(t *testing.T) {
 		require.Nil(t, err)
 		require.Equal(t, cmd.Result.Id, query.Result.Id)
 	})
+
+	t.Run("rolls back nested transactions if the outer one fails", func(t *testing.T) {
+		nestedCmd := &models.AddApiKeyCommand{Key: "nested-secret-key", Name: "nested key", OrgId: 1}
+		err := ss.AddAPIKey(context.Background(), nestedCmd)
+		require.Nil(t, err)
+
+		err = ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			err := ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+				return deleteAPIKey(sess, nestedCmd.Result.Id, 1)
+			})
+			if err != nil {
+				return err
+			}
+
+			return ErrProvokedError
+		})
+
+		require.Equal(t, ErrProvokedError, err)
+
+		query := &models.GetApiKeyByIdQuery{ApiKeyId: nestedCmd.Result.Id}
+		err = ss.GetApiKeyById(context.Background(), query)
+		require.Nil(t, err)
+		require.Equal(t, nestedCmd.Result.Id, query.Result.Id)
+	})
+
+	t.Run("publishes the events of nested transactions after the outer one commits", func(t *testing.T) {
+		var published []string
+		bus.AddEventListener(func(ctx context.Context, e *events.DataSourceDeleted) error {
+			published = append(published, e.UID)
+			return nil
+		})
+
+		deleteDataSource := func(ctx context.Context, uid string) error {
+			return ss.DeleteDataSource(ctx, &models.DeleteDataSourceCommand{OrgID: 1, UID: uid})
+		}
+
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			if err := deleteDataSource(ctx, "committed"); err != nil {
+				return err
+			}
+			require.Empty(t, published)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"committed"}, published)
+
+		err = ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			if err := deleteDataSource(ctx, "rolled-back"); err != nil {
+				return err
+			}
+			return ErrProvokedError
+		})
+		require.Equal(t, ErrProvokedError, err)
+		require.Equal(t, []string{"committed"}, published)
+	})
+
+	t.Run("retries locked sqlite transactions in the outer scope", func(t *testing.T) {
+		outerCalls, innerCalls := 0, 0
+		err := ss.InTransaction(context.Background(), func(ctx context.Context) error {
+			outerCalls++
+			return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+				innerCalls++
+				if innerCalls == 1 {
+					return sqlite3.Error{Code: sqlite3.ErrBusy}
+				}
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, outerCalls)
+		require.Equal(t, 2, innerCalls)
+	})
 }
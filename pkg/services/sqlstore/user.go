@@ -722,20 +722,32 @@ func SearchUsers(ctx context.Context, query *models.SearchUsersQuery) error {
 }
 
 func DisableUser(ctx context.Context, cmd *models.DisableUserCommand) error {
-	user := models.User{}
-	sess := x.Table("user")
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		user := models.User{}
 
-	if has, err := sess.ID(cmd.UserId).Get(&user); err != nil {
-		return err
-	} else if !has {
-		return models.ErrUserNotFound
-	}
+		if has, err := sess.Table("user").ID(cmd.UserId).Get(&user); err != nil {
+			return err
+		} else if !has {
+			return models.ErrUserNotFound
+		}
 
-	user.IsDisabled = cmd.IsDisabled
-	sess.UseBool("is_disabled")
+		user.IsDisabled = cmd.IsDisabled
+		sess.UseBool("is_disabled")
 
-	_, err := sess.ID(cmd.UserId).Update(&user)
-	return err
+		if _, err := sess.Table("user").ID(cmd.UserId).Update(&user); err != nil {
+			return err
+		}
+
+		if cmd.IsDisabled {
+			sess.publishAfterCommit(&events.UserDisabled{
+				Timestamp: time.Now(),
+				Id:        user.Id,
+				Login:     user.Login,
+			})
+		}
+
+		return nil
+	})
 }
 
 func (ss *SQLStore) BatchDisableUsers(ctx context.Context, cmd *models.BatchDisableUsersCommand) error {
@@ -759,6 +771,15 @@ func (ss *SQLStore) BatchDisableUsers(ctx context.Context, cmd *models.BatchDisa
 			return err
 		}
 
+		if cmd.IsDisabled {
+			for _, id := range userIds {
+				sess.publishAfterCommit(&events.UserDisabled{
+					Timestamp: time.Now(),
+					Id:        id,
+				})
+			}
+		}
+
 		return nil
 	})
 }
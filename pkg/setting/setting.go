@@ -338,6 +338,9 @@ type Cfg struct {
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheOptions
 
+	// Query caching
+	QueryCaching QueryCachingSettings
+
 	EditorsCanAdmin bool
 
 	ApiKeyMaxSecondsToLive int64
@@ -367,7 +370,9 @@ type Cfg struct {
 	Sentry Sentry
 
 	// Data sources
-	DataSourceLimit int
+	DataSourceLimit                int
+	DataSourceHealthCheckInterval  time.Duration
+	DataSourceHealthCheckRetention time.Duration
 
 	// Snapshots
 	SnapshotPublicMode bool
@@ -889,6 +894,10 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 		return err
 	}
 
+	if err := readQueryCachingSettings(iniFile, cfg); err != nil {
+		return err
+	}
+
 	if err := readSecuritySettings(iniFile, cfg); err != nil {
 		return err
 	}
@@ -1501,6 +1510,8 @@ func (cfg *Cfg) GetContentDeliveryURL(prefix string) string {
 func (cfg *Cfg) readDataSourcesSettings() {
 	datasources := cfg.Raw.Section("datasources")
 	cfg.DataSourceLimit = datasources.Key("datasource_limit").MustInt(5000)
+	cfg.DataSourceHealthCheckInterval = datasources.Key("health_check_interval").MustDuration(0)
+	cfg.DataSourceHealthCheckRetention = datasources.Key("health_check_retention").MustDuration(7 * 24 * time.Hour)
 }
 
 func GetAllowedOriginGlobs(originPatterns []string) ([]glob.Glob, error) {
@@ -0,0 +1,47 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"gopkg.in/ini.v1"
+)
+
+const (
+	QueryCachingBackendMemory = "memory"
+	QueryCachingBackendRemote = "remote"
+
+	defaultQueryCachingTTL = time.Minute
+)
+
+type QueryCachingSettings struct {
+	Enabled bool
+	// Backend is either "memory" or "remote", in which case the storage
+	// configured in the [remote_cache] section is used.
+	Backend string
+	// TTL is the default time a response is cached for. It can be overridden
+	// for every data source.
+	TTL time.Duration
+}
+
+func readQueryCachingSettings(iniFile *ini.File, cfg *Cfg) error {
+	section := iniFile.Section("query_caching")
+	cfg.QueryCaching.Enabled = section.Key("enabled").MustBool(false)
+	cfg.QueryCaching.Backend = valueAsString(section, "backend", QueryCachingBackendMemory)
+
+	switch cfg.QueryCaching.Backend {
+	case QueryCachingBackendMemory, QueryCachingBackendRemote:
+	default:
+		return fmt.Errorf("invalid query caching backend %q, must be %q or %q",
+			cfg.QueryCaching.Backend, QueryCachingBackendMemory, QueryCachingBackendRemote)
+	}
+
+	ttl, err := gtime.ParseDuration(valueAsString(section, "ttl", defaultQueryCachingTTL.String()))
+	if err != nil {
+		return fmt.Errorf("invalid query caching ttl: %w", err)
+	}
+	cfg.QueryCaching.TTL = ttl
+
+	return nil
+}
@@ -18,7 +18,6 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"go.opentelemetry.io/otel/attribute"
-	"golang.org/x/net/context/ctxhttp"
 )
 
 // AzureMonitorDatasource calls the Azure Monitor API - one of the four API's supported
@@ -54,6 +53,10 @@ func (e *AzureMonitorDatasource) executeTimeSeriesQuery(ctx context.Context, ori
 	}
 
 	for _, query := range queries {
+		if query.Scope != "" {
+			result.Responses[query.RefID] = e.executeMultiResourceQuery(ctx, query, dsInfo, client, url, tracer)
+			continue
+		}
 		result.Responses[query.RefID] = e.executeQuery(ctx, query, dsInfo, client, url, tracer)
 	}
 
@@ -88,6 +91,17 @@ func (e *AzureMonitorDatasource) buildQueries(queries []backend.DataQuery, dsInf
 		}
 		azureURL := ub.Build()
 
+		// Queries of several resources use the metrics API of the resource group or subscription.
+		if azJSONModel.Scope != "" {
+			if azJSONModel.Scope != scopeResourceGroup && azJSONModel.Scope != scopeSubscription {
+				return nil, fmt.Errorf("unsupported Azure Monitor query scope %q", azJSONModel.Scope)
+			}
+			if urlComponents["subscription"] == "" {
+				urlComponents["subscription"] = dsInfo.Settings.SubscriptionId
+			}
+			azureURL = ub.BuildScope(azJSONModel.Scope)
+		}
+
 		alias := azJSONModel.Alias
 
 		timeGrain := azJSONModel.TimeGrain
@@ -143,6 +157,7 @@ func (e *AzureMonitorDatasource) buildQueries(queries []backend.DataQuery, dsInf
 			RefID:         query.RefID,
 			Alias:         alias,
 			TimeRange:     query.TimeRange,
+			Scope:         azJSONModel.Scope,
 		})
 	}
 
@@ -174,7 +189,7 @@ func (e *AzureMonitorDatasource) executeQuery(ctx context.Context, query *AzureM
 
 	azlog.Debug("AzureMonitor", "Request ApiURL", req.URL.String())
 	azlog.Debug("AzureMonitor", "Target", query.Target)
-	res, err := ctxhttp.Do(ctx, cli, req)
+	res, err := doRequestWithRetry(ctx, cli, req)
 	if err != nil {
 		dataResponse.Error = err
 		return dataResponse
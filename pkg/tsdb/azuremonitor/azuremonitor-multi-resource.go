@@ -0,0 +1,441 @@
+package azuremonitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/setting"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// Scopes of Azure Monitor queries. By default a query targets a single
+// resource, otherwise all the resources of its metric definition (resource
+// type) in a resource group or subscription.
+const (
+	scopeResourceGroup = "resourceGroup"
+	scopeSubscription  = "subscription"
+)
+
+const (
+	// multiResourceAPIVersion is the first version of the metrics API
+	// supporting queries scoped to a resource group or subscription.
+	multiResourceAPIVersion = "2021-05-01"
+	// multiResourceBatchSize is the number of resources whose metrics are
+	// fetched in one request, keeping the request URL reasonably short.
+	multiResourceBatchSize = 20
+	// multiResourceConcurrency is the number of batches fetched concurrently.
+	multiResourceConcurrency = 4
+	// maxMultiResourceResources is the maximum number of resources a query
+	// can target.
+	maxMultiResourceResources = 1000
+	// resourceIDDimension is the dimension metrics are split by per resource.
+	resourceIDDimension = "Microsoft.ResourceId"
+)
+
+var (
+	resourceTypePattern  = regexp.MustCompile(`^[A-Za-z0-9.]+(/[A-Za-z0-9.]+)+$`)
+	resourceGroupPattern = regexp.MustCompile(`^[-\w.()]+$`)
+)
+
+// azureResource is a resource listed by Azure Resource Graph.
+type azureResource struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Location      string `json:"location"`
+	ResourceGroup string `json:"resourceGroup"`
+}
+
+// azureResourcesResponse is a page of resources listed by Azure Resource
+// Graph with the objectArray result format.
+type azureResourcesResponse struct {
+	Data      []azureResource `json:"data"`
+	SkipToken string          `json:"$skipToken"`
+}
+
+// executeMultiResourceQuery runs a query scoped to a resource group or a
+// subscription. The matching resources are listed via Azure Resource Graph and
+// their metrics fetched in batches of resources of the same location, as the
+// metrics API requires, each series being labelled with its resource.
+func (e *AzureMonitorDatasource) executeMultiResourceQuery(ctx context.Context, query *AzureMonitorQuery, dsInfo datasourceInfo, cli *http.Client,
+	url string, tracer tracing.Tracer) backend.DataResponse {
+	dataResponse := backend.DataResponse{}
+
+	ctx, span := tracer.Start(ctx, "azuremonitor multi-resource query")
+	span.SetAttributes("target", query.Target, attribute.Key("target").String(query.Target))
+	span.SetAttributes("scope", query.Scope, attribute.Key("scope").String(query.Scope))
+	span.SetAttributes("datasource_id", dsInfo.DatasourceID, attribute.Key("datasource_id").Int64(dsInfo.DatasourceID))
+	span.SetAttributes("org_id", dsInfo.OrgID, attribute.Key("org_id").Int64(dsInfo.OrgID))
+	defer span.End()
+
+	resources, err := e.listResources(ctx, query, cli, url)
+	if err != nil {
+		dataResponse.Error = fmt.Errorf("failed to list resources: %w", err)
+		return dataResponse
+	}
+
+	azurePortalUrl, err := getAzurePortalUrl(dsInfo.Cloud)
+	if err != nil {
+		dataResponse.Error = err
+		return dataResponse
+	}
+
+	batches := batchResources(resources)
+	results := make([]backend.DataResponse, len(batches))
+	semaphore := make(chan struct{}, multiResourceConcurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		i, batch := i, batch
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = e.executeBatch(ctx, query, batch, cli, url, azurePortalUrl)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		dataResponse.Frames = append(dataResponse.Frames, result.Frames...)
+		if dataResponse.Error == nil {
+			dataResponse.Error = result.Error
+		}
+	}
+	return dataResponse
+}
+
+// listResources lists the resources of the query metric definition in its scope.
+func (e *AzureMonitorDatasource) listResources(ctx context.Context, query *AzureMonitorQuery, cli *http.Client, dsURL string) ([]azureResource, error) {
+	resourceType := query.UrlComponents["metricDefinition"]
+	if !resourceTypePattern.MatchString(resourceType) {
+		return nil, fmt.Errorf("invalid metric definition %q", resourceType)
+	}
+	kql := fmt.Sprintf("Resources | where type =~ '%s'", resourceType)
+	if query.Scope == scopeResourceGroup {
+		resourceGroup := query.UrlComponents["resourceGroup"]
+		if !resourceGroupPattern.MatchString(resourceGroup) {
+			return nil, fmt.Errorf("invalid resource group %q", resourceGroup)
+		}
+		kql += fmt.Sprintf(" | where resourceGroup =~ '%s'", resourceGroup)
+	}
+	kql += " | project id, name, location, resourceGroup | order by id asc"
+
+	params := url.Values{}
+	params.Add("api-version", argAPIVersion)
+
+	var resources []azureResource
+	skipToken := ""
+	for {
+		options := map[string]interface{}{"resultFormat": "objectArray"}
+		if skipToken != "" {
+			options["$skipToken"] = skipToken
+		}
+		reqBody, err := json.Marshal(map[string]interface{}{
+			"subscriptions": []string{query.UrlComponents["subscription"]},
+			"query":         kql,
+			"options":       options,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, dsURL, bytes.NewBuffer(reqBody))
+		if err != nil {
+			return nil, err
+		}
+		req.URL.Path = path.Join("/", argQueryProviderName)
+		req.URL.RawQuery = params.Encode()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", fmt.Sprintf("Grafana/%s", setting.BuildVersion))
+
+		res, err := doRequestWithRetry(ctx, cli, req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := readResponseBody(res)
+		if err != nil {
+			return nil, err
+		}
+
+		var page azureResourcesResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Data...)
+		if len(resources) > maxMultiResourceResources {
+			return nil, fmt.Errorf("query matches more than %d resources", maxMultiResourceResources)
+		}
+
+		if page.SkipToken == "" {
+			return resources, nil
+		}
+		skipToken = page.SkipToken
+	}
+}
+
+// batchResources splits resources into batches of resources of the same location.
+func batchResources(resources []azureResource) [][]azureResource {
+	byLocation := map[string][]azureResource{}
+	locations := []string{}
+	for _, r := range resources {
+		location := strings.ToLower(r.Location)
+		if _, ok := byLocation[location]; !ok {
+			locations = append(locations, location)
+		}
+		byLocation[location] = append(byLocation[location], r)
+	}
+	sort.Strings(locations)
+
+	batches := [][]azureResource{}
+	for _, location := range locations {
+		locationResources := byLocation[location]
+		for start := 0; start < len(locationResources); start += multiResourceBatchSize {
+			end := start + multiResourceBatchSize
+			if end > len(locationResources) {
+				end = len(locationResources)
+			}
+			batches = append(batches, locationResources[start:end])
+		}
+	}
+	return batches
+}
+
+// executeBatch fetches the metrics of a batch of resources of the same location.
+func (e *AzureMonitorDatasource) executeBatch(ctx context.Context, query *AzureMonitorQuery, batch []azureResource, cli *http.Client,
+	dsURL string, azurePortalUrl string) backend.DataResponse {
+	dataResponse := backend.DataResponse{}
+
+	req, err := e.createRequest(ctx, datasourceInfo{}, dsURL)
+	if err != nil {
+		dataResponse.Error = err
+		return dataResponse
+	}
+	req.URL.Path = path.Join(req.URL.Path, query.URL)
+	req.URL.RawQuery = batchParams(query, batch).Encode()
+
+	azlog.Debug("AzureMonitor", "Request ApiURL", req.URL.String())
+	res, err := doRequestWithRetry(ctx, cli, req)
+	if err != nil {
+		dataResponse.Error = err
+		return dataResponse
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			azlog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	amr, err := e.unmarshalResponse(res)
+	if err != nil {
+		dataResponse.Error = err
+		return dataResponse
+	}
+
+	dataResponse.Frames, err = e.parseMultiResourceResponse(amr, query, batch, azurePortalUrl)
+	if err != nil {
+		dataResponse.Error = err
+	}
+	return dataResponse
+}
+
+// batchParams returns the parameters of the request for a batch of resources.
+// The metrics are split by resource by filtering on the resource ID dimension,
+// with the dimension filters of the query if any.
+func batchParams(query *AzureMonitorQuery, batch []azureResource) url.Values {
+	params := url.Values{}
+	for k, v := range query.Params {
+		params[k] = v
+	}
+	params.Set("api-version", multiResourceAPIVersion)
+	params.Set("region", batch[0].Location)
+
+	resourceFilters := make([]string, 0, len(batch))
+	for _, r := range batch {
+		resourceFilters = append(resourceFilters, fmt.Sprintf("%s eq '%s'", resourceIDDimension, r.ID))
+	}
+	filter := strings.Join(resourceFilters, " or ")
+	if dimensionFilter := query.Params.Get("$filter"); dimensionFilter != "" {
+		// "and" takes precedence over "or", so both sides are parenthesized to
+		// apply the dimension filter to every resource.
+		filter = fmt.Sprintf("(%s) and (%s)", filter, dimensionFilter)
+	}
+	params.Set("$filter", filter)
+
+	// top limits the number of series, which are now split by resource too.
+	top, err := strconv.Atoi(query.Params.Get("top"))
+	if err != nil || top <= 0 {
+		top = 10
+	}
+	params.Set("top", strconv.Itoa(top*len(batch)))
+
+	return params
+}
+
+// parseMultiResourceResponse parses the response of a batch of resources. The
+// series of each resource are parsed as if they were the result of a query of
+// that resource, and labelled with its name and resource group.
+func (e *AzureMonitorDatasource) parseMultiResourceResponse(amr AzureMonitorResponse, query *AzureMonitorQuery, batch []azureResource,
+	azurePortalUrl string) (data.Frames, error) {
+	if len(amr.Value) == 0 {
+		return nil, nil
+	}
+
+	frames := data.Frames{}
+	for _, r := range batch {
+		resourceResponse := amr
+		resourceResponse.Value = append(resourceResponse.Value[:0:0], amr.Value[0])
+		resourceResponse.Value[0].Timeseries = nil
+		for _, series := range amr.Value[0].Timeseries {
+			metadata := series.Metadatavalues[:0:0]
+			resourceID := ""
+			for _, md := range series.Metadatavalues {
+				if strings.EqualFold(md.Name.Value, resourceIDDimension) {
+					resourceID = strings.ToLower(md.Value)
+					continue
+				}
+				metadata = append(metadata, md)
+			}
+			if resourceID != strings.ToLower(r.ID) {
+				continue
+			}
+			series.Metadatavalues = metadata
+			resourceResponse.Value[0].Timeseries = append(resourceResponse.Value[0].Timeseries, series)
+		}
+		if len(resourceResponse.Value[0].Timeseries) == 0 {
+			continue
+		}
+		// The ID of the metric is used to find the resource group in aliases.
+		resourceResponse.Value[0].ID = r.ID
+
+		resourceQuery := *query
+		resourceQuery.UrlComponents = map[string]string{
+			"subscription":     query.UrlComponents["subscription"],
+			"resourceGroup":    r.ResourceGroup,
+			"metricDefinition": query.UrlComponents["metricDefinition"],
+			"resourceName":     resourceNameFromID(r.ID),
+		}
+
+		resourceFrames, err := e.parseResponse(resourceResponse, &resourceQuery, azurePortalUrl)
+		if err != nil {
+			return nil, err
+		}
+		for _, frame := range resourceFrames {
+			labels := frame.Fields[1].Labels
+			labels["resourceName"] = r.Name
+			labels["resourceGroup"] = r.ResourceGroup
+		}
+		frames = append(frames, resourceFrames...)
+	}
+	return frames, nil
+}
+
+// resourceNameFromID returns the name of a resource as used in metrics API
+// URLs, such as server/database for a nested resource.
+func resourceNameFromID(resourceID string) string {
+	i := strings.LastIndex(strings.ToLower(resourceID), "/providers/")
+	if i < 0 {
+		return ""
+	}
+	// The path after the provider namespace alternates between types and names.
+	parts := strings.Split(resourceID[i+len("/providers/"):], "/")
+	names := []string{}
+	for j := 2; j < len(parts); j += 2 {
+		names = append(names, parts[j])
+	}
+	return strings.Join(names, "/")
+}
+
+// maxThrottlingRetries is the number of times a throttled request is retried.
+const maxThrottlingRetries = 3
+
+var (
+	// throttlingRetryDelay is the delay before retrying a throttled request
+	// without a Retry-After header, doubled at each attempt.
+	throttlingRetryDelay = time.Second
+	// maxThrottlingRetryDelay bounds the delay asked by Retry-After headers.
+	maxThrottlingRetryDelay = 30 * time.Second
+)
+
+// doRequestWithRetry sends a request, retrying it when Azure throttles it,
+// after the delay given by the Retry-After header of the response.
+func doRequestWithRetry(ctx context.Context, cli *http.Client, req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := ctxhttp.Do(ctx, cli, req)
+		if err != nil || attempt == maxThrottlingRetries ||
+			(res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable) {
+			return res, err
+		}
+
+		delay := retryDelay(res.Header.Get("Retry-After"), attempt)
+		azlog.Debug("Request throttled, retrying", "status", res.Status, "delay", delay, "attempt", attempt+1)
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		if err := res.Body.Close(); err != nil {
+			azlog.Warn("Failed to close response body", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	delay := throttlingRetryDelay << attempt
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(retryAfter); err == nil {
+		delay = time.Until(t)
+	}
+	if delay > maxThrottlingRetryDelay {
+		delay = maxThrottlingRetryDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+func readResponseBody(res *http.Response) ([]byte, error) {
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			azlog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		azlog.Debug("Request failed", "status", res.Status, "body", string(body))
+		return nil, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+	return body, nil
+}
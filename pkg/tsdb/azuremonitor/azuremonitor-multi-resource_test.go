@@ -0,0 +1,224 @@
+package azuremonitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureMonitorMultiResourceQuery(t *testing.T) {
+	origRetryDelay := throttlingRetryDelay
+	t.Cleanup(func() {
+		throttlingRetryDelay = origRetryDelay
+	})
+	throttlingRetryDelay = time.Millisecond
+
+	resourceID := func(rg, name string) string {
+		return fmt.Sprintf("/subscriptions/sub/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines/%s", rg, name)
+	}
+
+	var (
+		mu             sync.Mutex
+		graphQueries   []string
+		metricRequests []*http.Request
+		throttled      bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.URL.Path == argQueryProviderName {
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			var graphReq struct {
+				Query   string                 `json:"query"`
+				Options map[string]interface{} `json:"options"`
+			}
+			require.NoError(t, json.Unmarshal(body, &graphReq))
+			graphQueries = append(graphQueries, graphReq.Query)
+
+			// Resources are returned in two pages.
+			page := azureResourcesResponse{
+				Data: []azureResource{
+					{ID: resourceID("rg1", "vm1"), Name: "vm1", Location: "westeurope", ResourceGroup: "rg1"},
+					{ID: resourceID("rg1", "vm2"), Name: "vm2", Location: "eastus", ResourceGroup: "rg1"},
+				},
+				SkipToken: "next",
+			}
+			if graphReq.Options["$skipToken"] == "next" {
+				page = azureResourcesResponse{
+					Data: []azureResource{
+						{ID: resourceID("rg2", "vm3"), Name: "vm3", Location: "westeurope", ResourceGroup: "rg2"},
+					},
+				}
+			}
+			require.NoError(t, json.NewEncoder(rw).Encode(page))
+			return
+		}
+
+		// The first metrics request is throttled.
+		if !throttled {
+			throttled = true
+			rw.Header().Set("Retry-After", "0")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		metricRequests = append(metricRequests, req)
+
+		var timeseries []string
+		for _, id := range strings.Split(req.URL.Query().Get("$filter"), " or ") {
+			id = strings.TrimSuffix(strings.TrimPrefix(id, "Microsoft.ResourceId eq '"), "'")
+			timeseries = append(timeseries, fmt.Sprintf(`{
+				"metadatavalues": [{"name": {"value": "Microsoft.ResourceId", "localizedValue": "Microsoft.ResourceId"}, "value": %q}],
+				"data": [{"timeStamp": "2021-01-01T00:00:00Z", "average": 1.5}]
+			}`, strings.ToLower(id)))
+		}
+		_, err := fmt.Fprintf(rw, `{
+			"namespace": "Microsoft.Compute/virtualMachines",
+			"value": [{
+				"id": "/subscriptions/sub/providers/Microsoft.Insights/metrics/Percentage CPU",
+				"name": {"value": "Percentage CPU", "localizedValue": "Percentage CPU"},
+				"unit": "Percent",
+				"timeseries": [%s]
+			}]
+		}`, strings.Join(timeseries, ","))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	tracer, err := tracing.InitializeTracerForTest()
+	require.NoError(t, err)
+
+	dsInfo := datasourceInfo{
+		Cloud:    setting.AzurePublic,
+		Settings: azureMonitorSettings{SubscriptionId: "sub"},
+	}
+	query := func(scope string) []backend.DataQuery {
+		return []backend.DataQuery{
+			{
+				RefID: "A",
+				TimeRange: backend.TimeRange{
+					From: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC),
+				},
+				JSON: []byte(fmt.Sprintf(`{
+					"azureMonitor": {
+						"scope":            %q,
+						"resourceGroup":    "rg1",
+						"metricDefinition": "Microsoft.Compute/virtualMachines",
+						"metricNamespace":  "Microsoft.Compute/virtualMachines",
+						"metricName":       "Percentage CPU",
+						"aggregation":      "Average",
+						"timeGrain":        "PT1M",
+						"alias":            "{{resourcegroup}}/{{resourcename}}"
+					}
+				}`, scope)),
+			},
+		}
+	}
+
+	t.Run("fetches the metrics of every resource of a subscription in batches per location", func(t *testing.T) {
+		ds := &AzureMonitorDatasource{}
+		res, err := ds.executeTimeSeriesQuery(context.Background(), query(scopeSubscription), dsInfo, srv.Client(), srv.URL, tracer)
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+
+		require.Len(t, graphQueries, 2)
+		assert.Equal(t, "Resources | where type =~ 'Microsoft.Compute/virtualMachines' | project id, name, location, resourceGroup | order by id asc", graphQueries[0])
+
+		require.Len(t, metricRequests, 2)
+		regions := []string{}
+		for _, req := range metricRequests {
+			assert.Equal(t, "/subscriptions/sub/providers/microsoft.insights/metrics", req.URL.Path)
+			assert.Equal(t, multiResourceAPIVersion, req.URL.Query().Get("api-version"))
+			regions = append(regions, req.URL.Query().Get("region"))
+		}
+		assert.ElementsMatch(t, []string{"eastus", "westeurope"}, regions)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 3)
+		names := map[string]data.Labels{}
+		for _, frame := range frames {
+			names[frame.Fields[1].Config.DisplayName] = frame.Fields[1].Labels
+		}
+		assert.Equal(t, map[string]data.Labels{
+			"rg1/vm1": {"resourceName": "vm1", "resourceGroup": "rg1"},
+			"rg1/vm2": {"resourceName": "vm2", "resourceGroup": "rg1"},
+			"rg2/vm3": {"resourceName": "vm3", "resourceGroup": "rg2"},
+		}, names)
+	})
+
+	t.Run("filters the resources of a resource group", func(t *testing.T) {
+		graphQueries, metricRequests = nil, nil
+		ds := &AzureMonitorDatasource{}
+		res, err := ds.executeTimeSeriesQuery(context.Background(), query(scopeResourceGroup), dsInfo, srv.Client(), srv.URL, tracer)
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+
+		assert.Contains(t, graphQueries[0], "| where resourceGroup =~ 'rg1'")
+		for _, req := range metricRequests {
+			assert.Equal(t, "/subscriptions/sub/resourceGroups/rg1/providers/microsoft.insights/metrics", req.URL.Path)
+		}
+	})
+
+	t.Run("rejects unsupported scopes", func(t *testing.T) {
+		ds := &AzureMonitorDatasource{}
+		_, err := ds.executeTimeSeriesQuery(context.Background(), query("tenant"), dsInfo, srv.Client(), srv.URL, tracer)
+		require.EqualError(t, err, `unsupported Azure Monitor query scope "tenant"`)
+	})
+}
+
+func TestBatchResources(t *testing.T) {
+	resources := []azureResource{}
+	for i := 0; i < multiResourceBatchSize+1; i++ {
+		resources = append(resources, azureResource{ID: fmt.Sprintf("vm%d", i), Location: "westeurope"})
+	}
+	resources = append(resources, azureResource{ID: "vm", Location: "EastUS"})
+
+	batches := batchResources(resources)
+	require.Len(t, batches, 3)
+	assert.Equal(t, []azureResource{{ID: "vm", Location: "EastUS"}}, batches[0])
+	assert.Len(t, batches[1], multiResourceBatchSize)
+	assert.Len(t, batches[2], 1)
+}
+
+func TestBatchParams(t *testing.T) {
+	query := &AzureMonitorQuery{Params: map[string][]string{
+		"api-version": {azureMonitorAPIVersion},
+		"$filter":     {"blob eq '*'"},
+		"top":         {"5"},
+	}}
+	params := batchParams(query, []azureResource{{ID: "/a", Location: "westeurope"}, {ID: "/b", Location: "westeurope"}})
+
+	assert.Equal(t, multiResourceAPIVersion, params.Get("api-version"))
+	assert.Equal(t, "westeurope", params.Get("region"))
+	assert.Equal(t, "(Microsoft.ResourceId eq '/a' or Microsoft.ResourceId eq '/b') and (blob eq '*')", params.Get("$filter"))
+	assert.Equal(t, "10", params.Get("top"))
+	assert.Equal(t, azureMonitorAPIVersion, query.Params.Get("api-version"))
+}
+
+func TestResourceNameFromID(t *testing.T) {
+	assert.Equal(t, "vm", resourceNameFromID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"))
+	assert.Equal(t, "server/db", resourceNameFromID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Sql/servers/server/databases/db"))
+	assert.Equal(t, "", resourceNameFromID("/subscriptions/sub"))
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 2*time.Second, retryDelay("2", 0))
+	assert.Equal(t, maxThrottlingRetryDelay, retryDelay("3600", 0))
+	assert.Equal(t, 4*throttlingRetryDelay, retryDelay("", 2))
+}
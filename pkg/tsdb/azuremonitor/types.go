@@ -20,6 +20,9 @@ type AzureMonitorQuery struct {
 	RefID         string
 	Alias         string
 	TimeRange     backend.TimeRange
+	// Scope is set for Azure Monitor queries of all the resources of a type
+	// in a resource group or subscription.
+	Scope string
 }
 
 // AzureMonitorResponse is the json response from the Azure Monitor API
@@ -91,6 +94,7 @@ type azureMonitorJSONQuery struct {
 		MetricNamespace     string  `json:"metricNamespace"`
 		ResourceGroup       string  `json:"resourceGroup"`
 		ResourceName        string  `json:"resourceName"`
+		Scope               string  `json:"scope"`
 		TimeGrain           string  `json:"timeGrain"`
 		Top                 string  `json:"top"`
 
@@ -37,3 +37,18 @@ func (ub *urlBuilder) Build() string {
 
 	return fmt.Sprintf("%s/providers/microsoft.insights/metrics", strings.Join(urlArray[:], "/"))
 }
+
+// BuildScope returns the url of the metrics of all the resources in the
+// resource group or subscription of the builder, depending on scope
+func (ub *urlBuilder) BuildScope(scope string) string {
+	subscription := ub.Subscription
+
+	if ub.Subscription == "" {
+		subscription = ub.DefaultSubscription
+	}
+
+	if scope == scopeSubscription {
+		return fmt.Sprintf("%s/providers/microsoft.insights/metrics", subscription)
+	}
+	return fmt.Sprintf("%s/resourceGroups/%s/providers/microsoft.insights/metrics", subscription, ub.ResourceGroup)
+}
@@ -62,6 +62,7 @@ const (
 	jwtAuthentication         string = "jwt"
 	metricQueryType           string = "metrics"
 	sloQueryType              string = "slo"
+	promQLQueryType           string = "promQL"
 	mqlEditorMode             string = "mql"
 	crossSeriesReducerDefault string = "REDUCE_NONE"
 	perSeriesAlignerDefault   string = "ALIGN_MEAN"
@@ -246,7 +247,40 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 func (s *Service) executeTimeSeriesQuery(ctx context.Context, req *backend.QueryDataRequest, dsInfo datasourceInfo) (
 	*backend.QueryDataResponse, error) {
 	resp := backend.NewQueryDataResponse()
-	queryExecutors, err := s.buildQueryExecutors(req)
+
+	// PromQL queries don't go through the Cloud Monitoring API.
+	promQLQueries := map[string]promQLQuery{}
+	queries := make([]backend.DataQuery, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		q, err := queryModel(query)
+		if err != nil {
+			return resp, fmt.Errorf("could not unmarshal CloudMonitoringQuery json: %w", err)
+		}
+		if q.QueryType == promQLQueryType {
+			promQLQueries[query.RefID] = q.PromQLQuery
+			continue
+		}
+		queries = append(queries, query)
+	}
+
+	if len(promQLQueries) > 0 {
+		promQLResp, err := s.executePromQLQueries(ctx, req, promQLQueries, dsInfo)
+		if err != nil {
+			return resp, err
+		}
+		for refID, res := range promQLResp.Responses {
+			resp.Responses[refID] = res
+		}
+	}
+	if len(queries) == 0 {
+		return resp, nil
+	}
+
+	queryExecutors, err := s.buildQueryExecutors(&backend.QueryDataRequest{
+		PluginContext: req.PluginContext,
+		Headers:       req.Headers,
+		Queries:       queries,
+	})
 	if err != nil {
 		return resp, err
 	}
@@ -274,7 +308,7 @@ func queryModel(query backend.DataQuery) (grafanaQuery, error) {
 		return grafanaQuery{}, err
 	}
 
-	if rawQuery["metricQuery"] == nil {
+	if rawQuery["metricQuery"] == nil && rawQuery["promQLQuery"] == nil {
 		// migrate legacy query
 		var mq metricQuery
 		err = json.Unmarshal(query.JSON, &mq)
@@ -0,0 +1,96 @@
+package cloudmonitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus"
+	"github.com/prometheus/client_golang/api"
+	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// executePromQLQueries runs PromQL queries against the Prometheus-compatible
+// API of Managed Service for Prometheus. The queries are run by the
+// Prometheus data source, so the frames are the same as the ones of a
+// Prometheus data source querying the same metrics.
+func (s *Service) executePromQLQueries(ctx context.Context, req *backend.QueryDataRequest, queries map[string]promQLQuery,
+	dsInfo datasourceInfo) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, query := range req.Queries {
+		q, ok := queries[query.RefID]
+		if !ok {
+			continue
+		}
+
+		queryRes, err := s.executePromQLQuery(ctx, req, query, q, dsInfo)
+		if err != nil {
+			resp.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+		for refID, res := range queryRes.Responses {
+			resp.Responses[refID] = res
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *Service) executePromQLQuery(ctx context.Context, req *backend.QueryDataRequest, query backend.DataQuery,
+	q promQLQuery, dsInfo datasourceInfo) (*backend.QueryDataResponse, error) {
+	projectName := q.ProjectName
+	if projectName == "" {
+		var err error
+		projectName, err = s.getDefaultProject(ctx, dsInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := newPromQLClient(dsInfo, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := json.Marshal(map[string]interface{}{
+		"expr":         q.Expr,
+		"legendFormat": q.LegendFormat,
+		"interval":     q.Step,
+		"range":        q.Range,
+		"instant":      q.Instant,
+	})
+	if err != nil {
+		return nil, err
+	}
+	query.JSON = model
+
+	return prometheus.QueryWithClient(ctx, &backend.QueryDataRequest{
+		PluginContext: req.PluginContext,
+		Headers:       req.Headers,
+		Queries:       []backend.DataQuery{query},
+	}, client, "", s.tracer)
+}
+
+// newPromQLClient returns a Prometheus API client for the metrics of a
+// project, authenticated the same way as Cloud Monitoring API requests.
+func newPromQLClient(dsInfo datasourceInfo, projectName string) (apiv1.API, error) {
+	// The client cleans the path of the address, so escaped slashes would
+	// still let the project name point to another path.
+	if strings.Contains(projectName, "/") {
+		return nil, fmt.Errorf("invalid project name %q", projectName)
+	}
+
+	service := dsInfo.services[cloudMonitor]
+	client, err := api.NewClient(api.Config{
+		Address:      fmt.Sprintf("%s/v1/projects/%s/location/global/prometheus", service.url, url.PathEscape(projectName)),
+		RoundTripper: service.client.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apiv1.NewAPI(client), nil
+}
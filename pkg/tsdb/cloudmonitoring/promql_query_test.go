@@ -0,0 +1,152 @@
+package cloudmonitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPromQLClient(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path = req.URL.EscapedPath()
+		rw.Header().Set("Content-Type", "application/json")
+		_, err := fmt.Fprint(rw, `{"status": "success", "data": {"resultType": "vector", "result": []}}`)
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	dsInfo := datasourceInfo{
+		services: map[string]datasourceService{
+			cloudMonitor: {url: srv.URL, client: srv.Client()},
+		},
+	}
+	client, err := newPromQLClient(dsInfo, "test-project?x=1#y")
+	require.NoError(t, err)
+
+	_, _, err = client.Query(context.Background(), "up", time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "/v1/projects/test-project%3Fx=1%23y/location/global/prometheus/api/v1/query", path)
+
+	_, err = newPromQLClient(dsInfo, "test-project/../other")
+	require.EqualError(t, err, `invalid project name "test-project/../other"`)
+}
+
+func TestPromQLQuery(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		paths    []string
+		forms    []url.Values
+		from     = time.Unix(1600000000, 0)
+		to       = from.Add(time.Hour)
+		response = `{
+			"status": "success",
+			"data": {
+				"resultType": "matrix",
+				"result": [{
+					"metric": {"__name__": "up", "job": "node"},
+					"values": [[1600000000, "1"], [1600000060, "0"]]
+				}]
+			}
+		}`
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		require.NoError(t, req.ParseForm())
+		paths = append(paths, req.URL.Path)
+		forms = append(forms, req.Form)
+		rw.Header().Set("Content-Type", "application/json")
+		_, err := fmt.Fprint(rw, response)
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	tracer, err := tracing.InitializeTracerForTest()
+	require.NoError(t, err)
+	s := &Service{tracer: tracer}
+	dsInfo := datasourceInfo{
+		defaultProject: "default-project",
+		services: map[string]datasourceService{
+			cloudMonitor: {url: srv.URL, client: srv.Client()},
+		},
+	}
+
+	t.Run("runs range queries against the Prometheus API of the project", func(t *testing.T) {
+		paths, forms = nil, nil
+		res, err := s.executeTimeSeriesQuery(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: backend.TimeRange{From: from, To: to},
+					Interval:  time.Minute,
+					JSON: json.RawMessage(`{
+						"queryType": "promQL",
+						"promQLQuery": {
+							"projectName":  "test-project",
+							"expr":         "up",
+							"legendFormat": "{{job}}",
+							"step":         "5m"
+						}
+					}`),
+				},
+			},
+		}, dsInfo)
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+
+		require.Equal(t, []string{"/v1/projects/test-project/location/global/prometheus/api/v1/query_range"}, paths)
+		assert.Equal(t, "up", forms[0].Get("query"))
+		assert.Equal(t, "300", forms[0].Get("step"))
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		assert.Equal(t, data.TimeSeriesTypeWide, frames[0].TimeSeriesSchema().Type)
+		assert.Equal(t, "node", frames[0].Fields[1].Config.DisplayNameFromDS)
+		assert.Equal(t, data.Labels{"__name__": "up", "job": "node"}, frames[0].Fields[1].Labels)
+	})
+
+	t.Run("uses the default project", func(t *testing.T) {
+		paths, forms = nil, nil
+		res, err := s.executeTimeSeriesQuery(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: backend.TimeRange{From: from, To: to},
+					Interval:  time.Minute,
+					JSON:      json.RawMessage(`{"queryType": "promQL", "promQLQuery": {"expr": "up"}}`),
+				},
+			},
+		}, dsInfo)
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Equal(t, []string{"/v1/projects/default-project/location/global/prometheus/api/v1/query_range"}, paths)
+	})
+
+	t.Run("returns Prometheus API errors in the response of the query", func(t *testing.T) {
+		response = `{"status": "error", "errorType": "bad_data", "error": "parse error"}`
+		res, err := s.executeTimeSeriesQuery(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: backend.TimeRange{From: from, To: to},
+					JSON:      json.RawMessage(`{"queryType": "promQL", "promQLQuery": {"expr": "up{"}}`),
+				},
+			},
+		}, dsInfo)
+		require.NoError(t, err)
+		require.Error(t, res.Responses["A"].Error)
+	})
+}
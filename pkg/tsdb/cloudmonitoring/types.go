@@ -68,12 +68,23 @@ type (
 		SloId            string
 	}
 
+	// Used to run PromQL queries against Managed Service for Prometheus
+	promQLQuery struct {
+		ProjectName  string
+		Expr         string
+		Step         string
+		LegendFormat string
+		Range        bool
+		Instant      bool
+	}
+
 	grafanaQuery struct {
 		DatasourceId int
 		RefId        string
 		QueryType    string
 		MetricQuery  metricQuery
 		SloQuery     sloQuery
+		PromQLQuery  promQLQuery
 	}
 
 	cloudMonitoringBucketOptions struct {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -22,8 +23,8 @@ import (
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
-	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -44,6 +45,13 @@ type datasourceInfo struct {
 
 	datasourceID int64
 
+	// logsTimeout is how long a logs query run on the backend may take.
+	logsTimeout time.Duration
+
+	// allowedAssumeRoleARNs are the roles, besides assumeRoleARN, that
+	// metric queries may assume to query other accounts.
+	allowedAssumeRoleARNs []string
+
 	HTTPClient *http.Client
 }
 
@@ -95,6 +103,9 @@ func NewInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			Endpoint      string `json:"endpoint"`
 			Namespace     string `json:"customMetricsNamespaces"`
 			AuthType      string `json:"authType"`
+			LogsTimeout   string `json:"logsTimeout"`
+
+			AllowedAssumeRoleARNs string `json:"allowedAssumeRoleArns"`
 		}{}
 
 		err := json.Unmarshal(settings.JSONData, &jsonData)
@@ -115,7 +126,19 @@ func NewInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			endpoint:      jsonData.Endpoint,
 			namespace:     jsonData.Namespace,
 			datasourceID:  settings.ID,
+			logsTimeout:   defaultLogsTimeout,
 			HTTPClient:    httpClient,
+
+			allowedAssumeRoleARNs: uniqueStrings(strings.Split(jsonData.AllowedAssumeRoleARNs, ",")),
+		}
+
+		if jsonData.LogsTimeout != "" {
+			logsTimeout, err := gtime.ParseInterval(jsonData.LogsTimeout)
+			if err != nil || logsTimeout <= 0 {
+				plog.Warn("Invalid logs timeout, using the default", "logsTimeout", jsonData.LogsTimeout, "default", defaultLogsTimeout)
+			} else {
+				model.logsTimeout = logsTimeout
+			}
 		}
 
 		at := awsds.AuthTypeDefault
@@ -156,6 +179,13 @@ type cloudWatchExecutor struct {
 }
 
 func (e *cloudWatchExecutor) newSession(region string, pluginCtx backend.PluginContext) (*session.Session, error) {
+	return e.newSessionWithRole(region, "", pluginCtx)
+}
+
+// newSessionWithRole returns a session assuming the given role instead of the
+// one configured in the data source, unless assumeRoleARN is empty. Sessions
+// are cached by region and role.
+func (e *cloudWatchExecutor) newSessionWithRole(region string, assumeRoleARN string, pluginCtx backend.PluginContext) (*session.Session, error) {
 	dsInfo, err := e.getDSInfo(pluginCtx)
 	if err != nil {
 		return nil, err
@@ -164,6 +194,11 @@ func (e *cloudWatchExecutor) newSession(region string, pluginCtx backend.PluginC
 	if region == defaultRegion {
 		region = dsInfo.region
 	}
+	if assumeRoleARN == "" {
+		assumeRoleARN = dsInfo.assumeRoleARN
+	} else if !dsInfo.allowsAssumeRole(assumeRoleARN) {
+		return nil, fmt.Errorf("assume role ARN %q is not allowed by the data source", assumeRoleARN)
+	}
 
 	return e.sessions.GetSession(awsds.SessionConfig{
 		HTTPClient: dsInfo.HTTPClient,
@@ -171,7 +206,7 @@ func (e *cloudWatchExecutor) newSession(region string, pluginCtx backend.PluginC
 			Profile:       dsInfo.profile,
 			Region:        region,
 			AuthType:      dsInfo.authType,
-			AssumeRoleARN: dsInfo.assumeRoleARN,
+			AssumeRoleARN: assumeRoleARN,
 			ExternalID:    dsInfo.externalID,
 			Endpoint:      dsInfo.endpoint,
 			DefaultRegion: dsInfo.region,
@@ -190,6 +225,14 @@ func (e *cloudWatchExecutor) getCWClient(region string, pluginCtx backend.Plugin
 	return NewCWClient(sess), nil
 }
 
+func (e *cloudWatchExecutor) getCWClientForTarget(target queryTarget, pluginCtx backend.PluginContext) (cloudwatchiface.CloudWatchAPI, error) {
+	sess, err := e.newSessionWithRole(target.region, target.assumeRoleARN, pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	return NewCWClient(sess), nil
+}
+
 func (e *cloudWatchExecutor) getCWLogsClient(region string, pluginCtx backend.PluginContext) (cloudwatchlogsiface.CloudWatchLogsAPI, error) {
 	sess, err := e.newSession(region, pluginCtx)
 	if err != nil {
@@ -220,65 +263,21 @@ func (e *cloudWatchExecutor) getRGTAClient(region string, pluginCtx backend.Plug
 	return newRGTAClient(sess), nil
 }
 
-func (e *cloudWatchExecutor) alertQuery(ctx context.Context, logsClient cloudwatchlogsiface.CloudWatchLogsAPI,
-	queryContext backend.DataQuery, model *simplejson.Json) (*cloudwatchlogs.GetQueryResultsOutput, error) {
-	const maxAttempts = 8
-	const pollPeriod = 1000 * time.Millisecond
-
-	startQueryOutput, err := e.executeStartQuery(ctx, logsClient, model, queryContext.TimeRange)
-	if err != nil {
-		return nil, err
-	}
-
-	requestParams := simplejson.NewFromAny(map[string]interface{}{
-		"region":  model.Get("region").MustString(""),
-		"queryId": *startQueryOutput.QueryId,
-	})
-
-	ticker := time.NewTicker(pollPeriod)
-	defer ticker.Stop()
-
-	attemptCount := 1
-	for range ticker.C {
-		res, err := e.executeGetQueryResults(ctx, logsClient, requestParams)
-		if err != nil {
-			return nil, err
-		}
-		if isTerminated(*res.Status) {
-			return res, err
-		}
-		if attemptCount >= maxAttempts {
-			return res, fmt.Errorf("fetching of query results exceeded max number of attempts")
-		}
-
-		attemptCount++
-	}
-
-	return nil, nil
-}
-
 func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	/*
-		Unlike many other data sources, with Cloudwatch Logs query requests don't receive the results as the response
-		to the query, but rather an ID is first returned. Following this, a client is expected to send requests along
-		with the ID until the status of the query is complete, receiving (possibly partial) results each time. For
-		queries made via dashboards and Explore, the logic of making these repeated queries is handled on the
-		frontend, but because alerts are executed on the backend the logic needs to be reimplemented here.
-	*/
 	q := req.Queries[0]
 	model, err := simplejson.NewJson(q.JSON)
 	if err != nil {
 		return nil, err
 	}
+	queryType := model.Get("type").MustString("")
+
 	_, fromAlert := req.Headers["FromAlert"]
 	isLogAlertQuery := fromAlert && model.Get("queryMode").MustString("") == "Logs"
 
-	if isLogAlertQuery {
-		return e.executeLogAlertQuery(ctx, req)
+	if isLogAlertQuery && queryType != logSyncQueryType {
+		return e.executeSyncLogQuery(ctx, req, groupedLogsFrames)
 	}
 
-	queryType := model.Get("type").MustString("")
-
 	var result *backend.QueryDataResponse
 	switch queryType {
 	case "metricFindQuery":
@@ -287,6 +286,8 @@ func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDa
 		result, err = e.executeAnnotationQuery(ctx, model, q, req.PluginContext)
 	case "logAction":
 		result, err = e.executeLogActions(ctx, req)
+	case logSyncQueryType:
+		result, err = e.executeSyncLogQuery(ctx, req, logsResultToAlertFrames)
 	case "timeSeriesQuery":
 		fallthrough
 	default:
@@ -296,69 +297,6 @@ func (e *cloudWatchExecutor) QueryData(ctx context.Context, req *backend.QueryDa
 	return result, err
 }
 
-func (e *cloudWatchExecutor) executeLogAlertQuery(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	resp := backend.NewQueryDataResponse()
-
-	for _, q := range req.Queries {
-		model, err := simplejson.NewJson(q.JSON)
-		if err != nil {
-			continue
-		}
-
-		model.Set("subtype", "StartQuery")
-		model.Set("queryString", model.Get("expression").MustString(""))
-
-		region := model.Get("region").MustString(defaultRegion)
-		if region == defaultRegion {
-			dsInfo, err := e.getDSInfo(req.PluginContext)
-			if err != nil {
-				return nil, err
-			}
-			model.Set("region", dsInfo.region)
-		}
-
-		logsClient, err := e.getCWLogsClient(region, req.PluginContext)
-		if err != nil {
-			return nil, err
-		}
-
-		result, err := e.executeStartQuery(ctx, logsClient, model, q.TimeRange)
-		if err != nil {
-			return nil, err
-		}
-
-		model.Set("queryId", *result.QueryId)
-
-		getQueryResultsOutput, err := e.alertQuery(ctx, logsClient, q, model)
-		if err != nil {
-			return nil, err
-		}
-
-		dataframe, err := logsResultsToDataframes(getQueryResultsOutput)
-		if err != nil {
-			return nil, err
-		}
-
-		var frames []*data.Frame
-
-		statsGroups := model.Get("statsGroups").MustStringArray()
-		if len(statsGroups) > 0 && len(dataframe.Fields) > 0 {
-			frames, err = groupResults(dataframe, statsGroups)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			frames = data.Frames{dataframe}
-		}
-
-		respD := resp.Responses["A"]
-		respD.Frames = frames
-		resp.Responses["A"] = respD
-	}
-
-	return resp, nil
-}
-
 func (e *cloudWatchExecutor) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
 	i, err := e.im.Get(pluginCtx)
 	if err != nil {
@@ -370,6 +308,20 @@ func (e *cloudWatchExecutor) getDSInfo(pluginCtx backend.PluginContext) (*dataso
 	return &instance, nil
 }
 
+// allowsAssumeRole returns whether queries may assume the given role, which
+// is the case for the role of the data source and the roles allowed in it.
+func (d *datasourceInfo) allowsAssumeRole(roleARN string) bool {
+	if roleARN == d.assumeRoleARN {
+		return true
+	}
+	for _, allowed := range d.allowedAssumeRoleARNs {
+		if roleARN == allowed {
+			return true
+		}
+	}
+	return false
+}
+
 func isTerminated(queryStatus string) bool {
 	return queryStatus == "Complete" || queryStatus == "Cancelled" || queryStatus == "Failed" || queryStatus == "Timeout"
 }
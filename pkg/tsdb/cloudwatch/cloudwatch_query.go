@@ -25,6 +25,13 @@ type cloudWatchQuery struct {
 	UsedExpression   string
 	MetricQueryType  metricQueryType
 	MetricEditorMode metricEditorMode
+	// AssumeRoleARN overrides the role configured in the data source.
+	AssumeRoleARN string
+	// AccountId is the account of the role the query is run with, if known.
+	AccountId string
+	// IsFanOut is set when the query targets several regions or roles, in
+	// which case its series are labelled with their region and account.
+	IsFanOut bool
 }
 
 func (q *cloudWatchQuery) getGMDAPIMode() gmdApiMode {
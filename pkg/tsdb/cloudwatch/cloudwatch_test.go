@@ -2,8 +2,10 @@ package cloudwatch
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
@@ -44,6 +46,47 @@ func TestNewInstanceSettings(t *testing.T) {
 				authType:      awsds.AuthTypeKeys,
 				accessKey:     "A123",
 				secretKey:     "secret",
+				logsTimeout:   defaultLogsTimeout,
+			},
+			Err: require.NoError,
+		},
+		{
+			name: "reads the logs timeout",
+			settings: backend.DataSourceInstanceSettings{
+				JSONData: []byte(`{"authType": "default", "logsTimeout": "5m"}`),
+			},
+			expectedDS: datasourceInfo{
+				authType:    awsds.AuthTypeDefault,
+				logsTimeout: 5 * time.Minute,
+			},
+			Err: require.NoError,
+		},
+		{
+			name: "reads the allowed assume role ARNs",
+			settings: backend.DataSourceInstanceSettings{
+				JSONData: []byte(`{
+					"authType": "default",
+					"allowedAssumeRoleArns": "arn:aws:iam::222222222222:role/grafana, arn:aws:iam::333333333333:role/grafana,"
+				}`),
+			},
+			expectedDS: datasourceInfo{
+				authType:    awsds.AuthTypeDefault,
+				logsTimeout: defaultLogsTimeout,
+				allowedAssumeRoleARNs: []string{
+					"arn:aws:iam::222222222222:role/grafana",
+					"arn:aws:iam::333333333333:role/grafana",
+				},
+			},
+			Err: require.NoError,
+		},
+		{
+			name: "uses the default logs timeout when it is invalid",
+			settings: backend.DataSourceInstanceSettings{
+				JSONData: []byte(`{"authType": "default", "logsTimeout": "soon"}`),
+			},
+			expectedDS: datasourceInfo{
+				authType:    awsds.AuthTypeDefault,
+				logsTimeout: defaultLogsTimeout,
 			},
 			Err: require.NoError,
 		},
@@ -64,7 +107,9 @@ func TestNewInstanceSettings(t *testing.T) {
 					d1.endpoint == d2.endpoint &&
 					d1.accessKey == d2.accessKey &&
 					d1.secretKey == d2.secretKey &&
-					d1.datasourceID == d2.datasourceID
+					d1.datasourceID == d2.datasourceID &&
+					d1.logsTimeout == d2.logsTimeout &&
+					cmp.Equal(d1.allowedAssumeRoleARNs, d2.allowedAssumeRoleARNs, cmpopts.EquateEmpty())
 			})
 			if !cmp.Equal(model.(datasourceInfo), tt.expectedDS, datasourceComparer) {
 				t.Errorf("Unexpected result. Expecting\n%v \nGot:\n%v", model, tt.expectedDS)
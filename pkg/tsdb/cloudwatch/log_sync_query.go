@@ -0,0 +1,243 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"golang.org/x/sync/errgroup"
+)
+
+// logSyncQueryType is the type of queries that run a CloudWatch Logs Insights
+// query to completion on the backend, as needed by alert rules.
+const logSyncQueryType = "logSyncQuery"
+
+// defaultLogsTimeout matches the default of the logsTimeout setting in the
+// frontend, which is also the longest a Logs Insights query can run.
+const defaultLogsTimeout = 15 * time.Minute
+
+// The results of a running query are polled with an exponential backoff
+// between these intervals.
+var (
+	logsPollInitialInterval = 500 * time.Millisecond
+	logsPollMaxInterval     = 5 * time.Second
+)
+
+// stopQueryTimeout bounds the request stopping a query once the request that
+// started it has been cancelled.
+const stopQueryTimeout = 10 * time.Second
+
+// logsFramesFunc shapes the result of a logs query run to completion.
+type logsFramesFunc func(frame *data.Frame, refID string, statsGroups []string) (data.Frames, error)
+
+// executeSyncLogQuery runs logs queries to completion. Unlike many other data
+// sources, CloudWatch Logs doesn't return the results of a query in the
+// response starting it, but rather an ID. A client is then expected to send
+// requests with the ID until the status of the query is complete, receiving
+// (possibly partial) results each time. For queries made via dashboards and
+// Explore this is handled by the frontend, but alert rules are evaluated on the
+// backend, so the logic is reimplemented here. The results are shaped by
+// toFrames.
+func (e *cloudWatchExecutor) executeSyncLogQuery(ctx context.Context, req *backend.QueryDataRequest,
+	toFrames logsFramesFunc) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	dsInfo, err := e.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	resultChan := make(chan backend.Responses, len(req.Queries))
+	eg, ectx := errgroup.WithContext(ctx)
+
+	for _, query := range req.Queries {
+		model, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			return nil, err
+		}
+
+		query := query
+		eg.Go(func() error {
+			frames, err := e.syncLogQuery(ectx, model, query, dsInfo, req.PluginContext, toFrames)
+			resultChan <- backend.Responses{
+				query.RefID: backend.DataResponse{Frames: frames, Error: err},
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	close(resultChan)
+
+	for result := range resultChan {
+		for refID, response := range result {
+			resp.Responses[refID] = response
+		}
+	}
+
+	return resp, nil
+}
+
+func (e *cloudWatchExecutor) syncLogQuery(ctx context.Context, model *simplejson.Json, query backend.DataQuery,
+	dsInfo *datasourceInfo, pluginCtx backend.PluginContext, toFrames logsFramesFunc) (data.Frames, error) {
+	region := model.Get("region").MustString(defaultRegion)
+	if region == "" || region == defaultRegion {
+		region = dsInfo.region
+	}
+	// The query editor stores the query string as expression.
+	if _, ok := model.CheckGet("queryString"); !ok {
+		model.Set("queryString", model.Get("expression").MustString(""))
+	}
+
+	logsClient, err := e.getCWLogsClient(region, pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	startQueryOutput, err := e.executeStartQuery(ctx, logsClient, model, query.TimeRange)
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == LimitExceededException {
+			return nil, &AWSError{Code: LimitExceededException, Message: err.Error()}
+		}
+		return nil, err
+	}
+	queryID := *startQueryOutput.QueryId
+
+	logsTimeout := dsInfo.logsTimeout
+	if logsTimeout <= 0 {
+		logsTimeout = defaultLogsTimeout
+	}
+	res, err := pollLogQueryResults(ctx, logsClient, queryID, logsTimeout)
+	if err != nil {
+		// Don't leave the query running, it counts against the concurrent query limit.
+		e.stopLogQuery(logsClient, queryID)
+		return nil, err
+	}
+	if status := aws.StringValue(res.Status); status != cloudwatchlogs.QueryStatusComplete {
+		return nil, fmt.Errorf("logs query %s finished with status %s", queryID, status)
+	}
+
+	frame, err := logsResultsToDataframes(res)
+	if err != nil {
+		return nil, err
+	}
+	return toFrames(frame, query.RefID, model.Get("statsGroups").MustStringArray())
+}
+
+// pollLogQueryResults gets the results of a query until it is terminated, ctx is
+// cancelled or timeout expires.
+func pollLogQueryResults(ctx context.Context, logsClient cloudwatchlogsiface.CloudWatchLogsAPI, queryID string,
+	timeout time.Duration) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interval := logsPollInitialInterval
+	for {
+		res, err := logsClient.GetQueryResultsWithContext(pollCtx, &cloudwatchlogs.GetQueryResultsInput{
+			QueryId: aws.String(queryID),
+		})
+		if err != nil {
+			if pollCtx.Err() != nil {
+				return nil, pollError(ctx, queryID, timeout)
+			}
+			return nil, err
+		}
+		if res.Status != nil && isTerminated(*res.Status) {
+			return res, nil
+		}
+
+		select {
+		case <-pollCtx.Done():
+			return nil, pollError(ctx, queryID, timeout)
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > logsPollMaxInterval {
+			interval = logsPollMaxInterval
+		}
+	}
+}
+
+func pollError(ctx context.Context, queryID string, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("logs query %s did not complete within %s", queryID, timeout)
+}
+
+// stopLogQuery stops a query that is no longer needed. The request that
+// started the query may have been cancelled, so a new context is used.
+func (e *cloudWatchExecutor) stopLogQuery(logsClient cloudwatchlogsiface.CloudWatchLogsAPI, queryID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), stopQueryTimeout)
+	defer cancel()
+
+	params := simplejson.NewFromAny(map[string]interface{}{
+		"queryId": queryID,
+	})
+	if _, err := e.executeStopQuery(ctx, logsClient, params); err != nil {
+		plog.Warn("Failed to stop logs query", "queryId", queryID, "err", err)
+	}
+}
+
+// groupedLogsFrames shapes the result of a logs query the way logs queries
+// in alert rules always have been: one frame per group of the statsGroups,
+// without converting them to time series. It is kept for logs queries sent
+// without the logSyncQuery type, so that existing alert rules evaluate the
+// same frames as before.
+func groupedLogsFrames(frame *data.Frame, refID string, statsGroups []string) (data.Frames, error) {
+	if len(statsGroups) == 0 || len(frame.Fields) == 0 {
+		return data.Frames{frame}, nil
+	}
+	return groupResults(frame, statsGroups)
+}
+
+// logsResultToAlertFrames shapes the result of a logSyncQuery for alerting.
+// Results of "stats ... by bin(...)" queries become wide time series with one
+// value field per group, labelled with the group. Results without a time
+// field, such as "stats ... by ...", are returned as a table.
+func logsResultToAlertFrames(frame *data.Frame, refID string, statsGroups []string) (data.Frames, error) {
+	fields := make([]*data.Field, 0, len(frame.Fields))
+	for _, field := range frame.Fields {
+		// The log and log stream identifiers are added to every query.
+		if field.Name == logIdentifierInternal || field.Name == logStreamIdentifierInternal {
+			continue
+		}
+		// Numeric fields the results are grouped by are dimensions, not values.
+		for _, group := range statsGroups {
+			if field.Name == group && field.Type().Numeric() {
+				stringField, err := numericFieldToStringField(field)
+				if err != nil {
+					return nil, err
+				}
+				field = stringField
+			}
+		}
+		fields = append(fields, field)
+	}
+	frame.Fields = fields
+	frame.Name = refID
+	frame.RefID = refID
+
+	if frame.Rows() == 0 || frame.TimeSeriesSchema().Type != data.TimeSeriesTypeLong {
+		return data.Frames{frame}, nil
+	}
+
+	wideFrame, err := data.LongToWide(frame, nil)
+	if err != nil {
+		return nil, err
+	}
+	wideFrame.RefID = refID
+	return data.Frames{wideFrame}, nil
+}
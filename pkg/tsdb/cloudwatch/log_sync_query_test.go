@@ -0,0 +1,249 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSyncLogsClient returns the results of a query once it has been polled
+// pollsBeforeComplete times.
+type fakeSyncLogsClient struct {
+	cloudwatchlogsiface.CloudWatchLogsAPI
+
+	mu                  sync.Mutex
+	pollsBeforeComplete int
+	results             [][]*cloudwatchlogs.ResultField
+	startQueryInputs    []*cloudwatchlogs.StartQueryInput
+	polls               int
+	stoppedQueryIDs     []string
+}
+
+func (c *fakeSyncLogsClient) StartQueryWithContext(ctx context.Context, input *cloudwatchlogs.StartQueryInput, option ...request.Option) (*cloudwatchlogs.StartQueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startQueryInputs = append(c.startQueryInputs, input)
+	return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String("query-id")}, nil
+}
+
+func (c *fakeSyncLogsClient) GetQueryResultsWithContext(ctx context.Context, input *cloudwatchlogs.GetQueryResultsInput, option ...request.Option) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.polls++
+	if c.pollsBeforeComplete >= 0 && c.polls > c.pollsBeforeComplete {
+		return &cloudwatchlogs.GetQueryResultsOutput{
+			Status:  aws.String(cloudwatchlogs.QueryStatusComplete),
+			Results: c.results,
+		}, nil
+	}
+	return &cloudwatchlogs.GetQueryResultsOutput{Status: aws.String(cloudwatchlogs.QueryStatusRunning)}, nil
+}
+
+func (c *fakeSyncLogsClient) StopQueryWithContext(ctx context.Context, input *cloudwatchlogs.StopQueryInput, option ...request.Option) (*cloudwatchlogs.StopQueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stoppedQueryIDs = append(c.stoppedQueryIDs, *input.QueryId)
+	return &cloudwatchlogs.StopQueryOutput{Success: aws.Bool(true)}, nil
+}
+
+func resultRow(fields ...string) []*cloudwatchlogs.ResultField {
+	row := make([]*cloudwatchlogs.ResultField, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		row = append(row, &cloudwatchlogs.ResultField{Field: aws.String(fields[i]), Value: aws.String(fields[i+1])})
+	}
+	return row
+}
+
+func TestQuery_SyncLogQuery(t *testing.T) {
+	origNewCWLogsClient := NewCWLogsClient
+	origInitialInterval, origMaxInterval := logsPollInitialInterval, logsPollMaxInterval
+	t.Cleanup(func() {
+		NewCWLogsClient = origNewCWLogsClient
+		logsPollInitialInterval, logsPollMaxInterval = origInitialInterval, origMaxInterval
+	})
+	logsPollInitialInterval, logsPollMaxInterval = time.Millisecond, 2*time.Millisecond
+
+	var cli *fakeSyncLogsClient
+	NewCWLogsClient = func(sess *session.Session) cloudwatchlogsiface.CloudWatchLogsAPI {
+		return cli
+	}
+
+	newTestExecutor := func(logsTimeout time.Duration) *cloudWatchExecutor {
+		im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+			return datasourceInfo{region: "us-east-1", logsTimeout: logsTimeout}, nil
+		})
+		return newExecutor(im, newTestConfig(), fakeSessionCache{})
+	}
+
+	timeRange := backend.TimeRange{From: time.Unix(1600000000, 0), To: time.Unix(1600003600, 0)}
+
+	t.Run("Polls until the query completes and returns wide time series", func(t *testing.T) {
+		cli = &fakeSyncLogsClient{
+			pollsBeforeComplete: 2,
+			results: [][]*cloudwatchlogs.ResultField{
+				resultRow("bin(5m)", "2020-09-13 12:30:00.000", "host", "a", "count", "1"),
+				resultRow("bin(5m)", "2020-09-13 12:30:00.000", "host", "b", "count", "2"),
+				resultRow("bin(5m)", "2020-09-13 12:35:00.000", "host", "a", "count", "3"),
+				resultRow("bin(5m)", "2020-09-13 12:35:00.000", "host", "b", "count", "4"),
+			},
+		}
+
+		resp, err := newTestExecutor(time.Minute).QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "B",
+					TimeRange: timeRange,
+					JSON: json.RawMessage(`{
+						"type":          "logSyncQuery",
+						"region":        "default",
+						"expression":    "stats count(*) as count by bin(5m), host",
+						"logGroupNames": ["group"],
+						"statsGroups":   ["bin(5m)", "host"]
+					}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["B"].Error)
+		assert.Equal(t, 3, cli.polls)
+		assert.Empty(t, cli.stoppedQueryIDs)
+		require.Len(t, cli.startQueryInputs, 1)
+		assert.Contains(t, *cli.startQueryInputs[0].QueryString, "stats count(*) as count by bin(5m), host")
+
+		frames := resp.Responses["B"].Frames
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		assert.Equal(t, "B", frame.RefID)
+		assert.Equal(t, data.TimeSeriesTypeWide, frame.TimeSeriesSchema().Type)
+		require.Len(t, frame.Fields, 3)
+		assert.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+		assert.Equal(t, data.Labels{"host": "b"}, frame.Fields[2].Labels)
+		assert.Equal(t, 4.0, *frame.Fields[2].At(1).(*float64))
+	})
+
+	t.Run("Runs queries from alert rules to completion", func(t *testing.T) {
+		cli = &fakeSyncLogsClient{
+			results: [][]*cloudwatchlogs.ResultField{
+				resultRow("host", "a", "count", "1"),
+				resultRow("host", "b", "count", "2"),
+			},
+		}
+
+		resp, err := newTestExecutor(time.Minute).QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Headers:       map[string]string{"FromAlert": "true"},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: timeRange,
+					JSON: json.RawMessage(`{
+						"queryMode":     "Logs",
+						"expression":    "stats count(*) as count by host",
+						"logGroupNames": ["group"]
+					}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, cli.startQueryInputs, 1)
+
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		assert.Equal(t, data.TimeSeriesTypeNot, frames[0].TimeSeriesSchema().Type)
+		require.Len(t, frames[0].Fields, 2)
+		assert.Equal(t, "host", frames[0].Fields[0].Name)
+		assert.Equal(t, "count", frames[0].Fields[1].Name)
+	})
+
+	t.Run("Keeps the grouped frames of logs queries from alert rules", func(t *testing.T) {
+		cli = &fakeSyncLogsClient{
+			results: [][]*cloudwatchlogs.ResultField{
+				resultRow("bin(5m)", "2020-09-13 12:30:00.000", "host", "a", "count", "1"),
+				resultRow("bin(5m)", "2020-09-13 12:30:00.000", "host", "b", "count", "2"),
+				resultRow("bin(5m)", "2020-09-13 12:35:00.000", "host", "a", "count", "3"),
+			},
+		}
+
+		resp, err := newTestExecutor(time.Minute).QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Headers:       map[string]string{"FromAlert": "true"},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: timeRange,
+					JSON: json.RawMessage(`{
+						"queryMode":     "Logs",
+						"expression":    "stats count(*) as count by bin(5m), host",
+						"logGroupNames": ["group"],
+						"statsGroups":   ["host"]
+					}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 2)
+		rows := map[string]int{}
+		for _, frame := range frames {
+			require.Len(t, frame.Fields, 3)
+			rows[frame.Name] = frame.Rows()
+		}
+		assert.Equal(t, map[string]int{"a": 2, "b": 1}, rows)
+	})
+
+	t.Run("Stops the query when it times out", func(t *testing.T) {
+		cli = &fakeSyncLogsClient{pollsBeforeComplete: -1}
+
+		resp, err := newTestExecutor(20*time.Millisecond).QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: timeRange,
+					JSON:      json.RawMessage(`{"type": "logSyncQuery", "expression": "fields @message", "logGroupNames": ["group"]}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.EqualError(t, resp.Responses["A"].Error, "logs query query-id did not complete within 20ms")
+		assert.Equal(t, []string{"query-id"}, cli.stoppedQueryIDs)
+	})
+
+	t.Run("Stops the query when the request is cancelled", func(t *testing.T) {
+		cli = &fakeSyncLogsClient{pollsBeforeComplete: -1}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		resp, err := newTestExecutor(time.Minute).QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}},
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: timeRange,
+					JSON:      json.RawMessage(`{"type": "logSyncQuery", "expression": "fields @message", "logGroupNames": ["group"]}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, context.DeadlineExceeded)
+		assert.Equal(t, []string{"query-id"}, cli.stoppedQueryIDs)
+	})
+}
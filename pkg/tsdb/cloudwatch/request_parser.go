@@ -14,9 +14,15 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
-// parseQueries parses the json queries and returns a map of cloudWatchQueries by region. The cloudWatchQuery has a 1 to 1 mapping to a query editor row
-func (e *cloudWatchExecutor) parseQueries(queries []backend.DataQuery, startTime time.Time, endTime time.Time) (map[string][]*cloudWatchQuery, error) {
-	requestQueries := make(map[string][]*cloudWatchQuery)
+// maxQueryTargets limits the number of region and role combinations a single query can fan out to.
+const maxQueryTargets = 100
+
+// parseQueries parses the json queries and returns a map of cloudWatchQueries by target region and role. The cloudWatchQuery has
+// a 1 to 1 mapping to a query editor row, unless the query fans out to several regions or roles, in which case there's one per target.
+// Only the roles allowed by the data source can be assumed.
+func (e *cloudWatchExecutor) parseQueries(queries []backend.DataQuery, startTime time.Time, endTime time.Time,
+	dsInfo *datasourceInfo) (map[queryTarget][]*cloudWatchQuery, error) {
+	requestQueries := make(map[queryTarget][]*cloudWatchQuery)
 	migratedQueries, err := migrateLegacyQuery(queries, startTime, endTime)
 	if err != nil {
 		return nil, err
@@ -39,15 +45,91 @@ func (e *cloudWatchExecutor) parseQueries(queries []backend.DataQuery, startTime
 			return nil, &queryError{err: err, RefID: refID}
 		}
 
-		if _, exist := requestQueries[query.Region]; !exist {
-			requestQueries[query.Region] = []*cloudWatchQuery{}
+		targetQueries, err := fanOutQuery(query, model.Get("regions").MustStringArray(), model.Get("assumeRoleArns").MustStringArray(), dsInfo)
+		if err != nil {
+			return nil, &queryError{err: err, RefID: refID}
+		}
+		for _, q := range targetQueries {
+			target := queryTarget{region: q.Region, assumeRoleARN: q.AssumeRoleARN}
+			requestQueries[target] = append(requestQueries[target], q)
 		}
-		requestQueries[query.Region] = append(requestQueries[query.Region], query)
 	}
 
 	return requestQueries, nil
 }
 
+// fanOutQuery returns a copy of the query for each combination of the given regions and assume role ARNs. The query itself is
+// returned if neither are given, so that it runs in its own region with the role configured in the data source. Roles the data source
+// doesn't allow are rejected, since they would be assumed with its credentials and external ID.
+func fanOutQuery(query *cloudWatchQuery, regions []string, assumeRoleARNs []string, dsInfo *datasourceInfo) ([]*cloudWatchQuery, error) {
+	regions = uniqueStrings(regions)
+	assumeRoleARNs = uniqueStrings(assumeRoleARNs)
+	if len(regions) == 0 && len(assumeRoleARNs) == 0 {
+		return []*cloudWatchQuery{query}, nil
+	}
+
+	if len(regions) == 0 {
+		regions = []string{query.Region}
+	}
+	accountIds := []string{""}
+	if len(assumeRoleARNs) == 0 {
+		assumeRoleARNs = []string{""}
+	} else {
+		accountIds = make([]string, 0, len(assumeRoleARNs))
+		for _, arn := range assumeRoleARNs {
+			accountId, err := accountIdFromARN(arn)
+			if err != nil {
+				return nil, err
+			}
+			if !dsInfo.allowsAssumeRole(arn) {
+				return nil, fmt.Errorf("assume role ARN %q is not allowed by the data source", arn)
+			}
+			accountIds = append(accountIds, accountId)
+		}
+	}
+	if targets := len(regions) * len(assumeRoleARNs); targets > maxQueryTargets {
+		return nil, fmt.Errorf("query targets %d combinations of regions and roles, the maximum is %d", targets, maxQueryTargets)
+	}
+
+	queries := make([]*cloudWatchQuery, 0, len(regions)*len(assumeRoleARNs))
+	for _, region := range regions {
+		for i, arn := range assumeRoleARNs {
+			q := *query
+			q.Region = region
+			q.AssumeRoleARN = arn
+			q.AccountId = accountIds[i]
+			q.IsFanOut = true
+			queries = append(queries, &q)
+		}
+	}
+	return queries, nil
+}
+
+// accountIdFromARN returns the account ID of an IAM role ARN such as arn:aws:iam::123456789012:role/Name.
+func accountIdFromARN(roleARN string) (string, error) {
+	parts := strings.SplitN(roleARN, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || !reAccountId.MatchString(parts[4]) {
+		return "", fmt.Errorf("invalid assume role ARN %q", roleARN)
+	}
+	return parts[4], nil
+}
+
+var reAccountId = regexp.MustCompile(`^\d{12}$`)
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
+
 // migrateLegacyQuery migrates queries that has a `statistics` field to use the `statistic` field instead.
 // This migration is also done in the frontend, so this should only ever be needed for alerting queries
 // In case the query used more than one stat, the first stat in the slice will be used in the statistic field
@@ -141,7 +141,7 @@ func buildDataFrames(startTime time.Time, endTime time.Time, aggregatedResponse
 				timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, []*time.Time{})
 				valueField := data.NewField(data.TimeSeriesValueFieldName, labels, []*float64{})
 
-				frameName := formatAlias(query, query.Statistic, labels, label)
+				frameName := labelFanOutSeries(query, formatAlias(query, query.Statistic, labels, label), labels)
 				valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: frameName, Links: createDataLinks(deepLink)})
 
 				emptyFrame := data.Frame{
@@ -174,10 +174,11 @@ func buildDataFrames(startTime time.Time, endTime time.Time, aggregatedResponse
 			points = append(points, val)
 		}
 
+		frameName := labelFanOutSeries(query, formatAlias(query, query.Statistic, labels, label), labels)
+
 		timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, timestamps)
 		valueField := data.NewField(data.TimeSeriesValueFieldName, labels, points)
 
-		frameName := formatAlias(query, query.Statistic, labels, label)
 		valueField.SetConfig(&data.FieldConfig{DisplayNameFromDS: frameName, Links: createDataLinks(deepLink)})
 
 		frame := data.Frame{
@@ -241,6 +242,9 @@ func formatAlias(query *cloudWatchQuery, stat string, dimensions map[string]stri
 	if len(label) != 0 {
 		data["label"] = label
 	}
+	if len(query.AccountId) != 0 {
+		data["account"] = query.AccountId
+	}
 
 	// since the SQL query string is not (yet) parsed, we don't know what namespace, metric, statistic and labels it's using at this point
 	if query.MetricQueryType != MetricQueryTypeQuery {
@@ -270,6 +274,22 @@ func formatAlias(query *cloudWatchQuery, stat string, dimensions map[string]stri
 	return string(result)
 }
 
+// labelFanOutSeries labels a series of a query fanned out to several regions or accounts with the ones it comes from. Unless the
+// alias already tells them apart, they're also added to the series name, as the series would otherwise share names.
+func labelFanOutSeries(query *cloudWatchQuery, name string, labels data.Labels) string {
+	if !query.IsFanOut {
+		return name
+	}
+	labels["region"] = query.Region
+	if query.AccountId != "" {
+		labels["account"] = query.AccountId
+	}
+	if strings.Contains(query.Alias, "{{") {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.TrimSpace(query.AccountId+" "+query.Region))
+}
+
 func createDataLinks(link string) []data.DataLink {
 	dataLinks := []data.DataLink{}
 	if link != "" {
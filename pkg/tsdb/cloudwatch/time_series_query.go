@@ -3,10 +3,11 @@ package cloudwatch
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"golang.org/x/sync/errgroup"
 )
 
 type responseWrapper struct {
@@ -28,21 +29,34 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, req *ba
 		return nil, fmt.Errorf("invalid time range: start time must be before end time")
 	}
 
-	requestQueriesByRegion, err := e.parseQueries(req.Queries, startTime, endTime)
+	dsInfo, err := e.getDSInfo(req.PluginContext)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(requestQueriesByRegion) == 0 {
+	requestQueriesByTarget, err := e.parseQueries(req.Queries, startTime, endTime, dsInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requestQueriesByTarget) == 0 {
 		return backend.NewQueryDataResponse(), nil
 	}
 
+	for _, queries := range requestQueriesByTarget {
+		for _, query := range queries {
+			resolveFanOutQuery(query, dsInfo)
+		}
+	}
+
 	resultChan := make(chan *responseWrapper, len(req.Queries))
-	eg, ectx := errgroup.WithContext(ctx)
-	for r, q := range requestQueriesByRegion {
+	var wg sync.WaitGroup
+	for t, q := range requestQueriesByTarget {
 		requestQueries := q
-		region := r
-		eg.Go(func() error {
+		target := t
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 			defer func() {
 				if err := recover(); err != nil {
 					plog.Error("Execute Get Metric Data Query Panic", "error", err, "stack", log.Stack(1))
@@ -56,47 +70,79 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, req *ba
 				}
 			}()
 
-			client, err := e.getCWClient(region, req.PluginContext)
+			res, err := e.executeTargetQueries(ctx, target, startTime, endTime, requestQueries, req.PluginContext)
 			if err != nil {
-				return err
+				// A failing region or role shouldn't prevent the results of the others from being returned.
+				for _, query := range requestQueries {
+					queryErr := fmt.Errorf("metric request error: %q", err)
+					if query.IsFanOut {
+						queryErr = fmt.Errorf("metric request error in %s: %q", target, err)
+					}
+					resultChan <- &responseWrapper{
+						DataResponse: &backend.DataResponse{Error: queryErr},
+						RefId:        query.RefId,
+					}
+				}
+				return
 			}
 
-			metricDataInput, err := e.buildMetricDataInput(startTime, endTime, requestQueries)
-			if err != nil {
-				return err
+			for _, responseWrapper := range res {
+				resultChan <- responseWrapper
 			}
+		}()
+	}
 
-			mdo, err := e.executeRequest(ectx, client, metricDataInput)
-			if err != nil {
-				return err
-			}
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
 
-			res, err := e.parseResponse(startTime, endTime, mdo, requestQueries)
-			if err != nil {
-				return err
-			}
+	// Queries fanned out to several targets have one result per target, which are merged.
+	for result := range resultChan {
+		dataResponse := resp.Responses[result.RefId]
+		dataResponse.Frames = append(dataResponse.Frames, result.DataResponse.Frames...)
+		if dataResponse.Error == nil {
+			dataResponse.Error = result.DataResponse.Error
+		}
+		resp.Responses[result.RefId] = dataResponse
+	}
 
-			for _, responseWrapper := range res {
-				resultChan <- responseWrapper
-			}
+	return resp, nil
+}
 
-			return nil
-		})
+// executeTargetQueries runs the queries of a target region and role in one GetMetricData request.
+func (e *cloudWatchExecutor) executeTargetQueries(ctx context.Context, target queryTarget, startTime time.Time, endTime time.Time,
+	requestQueries []*cloudWatchQuery, pluginCtx backend.PluginContext) ([]*responseWrapper, error) {
+	client, err := e.getCWClientForTarget(target, pluginCtx)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := eg.Wait(); err != nil {
-		dataResponse := backend.DataResponse{
-			Error: fmt.Errorf("metric request error: %q", err),
-		}
-		resultChan <- &responseWrapper{
-			DataResponse: &dataResponse,
-		}
+	metricDataInput, err := e.buildMetricDataInput(startTime, endTime, requestQueries)
+	if err != nil {
+		return nil, err
 	}
-	close(resultChan)
 
-	for result := range resultChan {
-		resp.Responses[result.RefId] = *result.DataResponse
+	mdo, err := e.executeRequest(ctx, client, metricDataInput)
+	if err != nil {
+		return nil, err
 	}
 
-	return resp, nil
+	return e.parseResponse(startTime, endTime, mdo, requestQueries)
+}
+
+// resolveFanOutQuery replaces the default region and role of a query fanned out to several targets with the ones configured in
+// the data source, so that its series can be labelled with them.
+func resolveFanOutQuery(query *cloudWatchQuery, dsInfo *datasourceInfo) {
+	if !query.IsFanOut {
+		return
+	}
+	if query.Region == defaultRegion {
+		query.Region = dsInfo.region
+	}
+	if query.AccountId == "" && dsInfo.assumeRoleARN != "" {
+		if accountId, err := accountIdFromARN(dsInfo.assumeRoleARN); err == nil {
+			query.AccountId = accountId
+		}
+	}
 }
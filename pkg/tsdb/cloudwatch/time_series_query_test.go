@@ -3,6 +3,9 @@ package cloudwatch
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,9 +13,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -136,3 +141,167 @@ func TestTimeSeriesQuery(t *testing.T) {
 		assert.EqualError(t, err, "invalid time range: start time must be before end time")
 	})
 }
+
+// recordingSessionCache records the region and role of the sessions it is asked for, and fails for failingRoleARN.
+type recordingSessionCache struct {
+	mu             sync.Mutex
+	targets        []string
+	failingRoleARN string
+}
+
+func (s *recordingSessionCache) GetSession(c awsds.SessionConfig) (*session.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targets = append(s.targets, c.Settings.Region+" "+c.Settings.AssumeRoleARN)
+	if s.failingRoleARN != "" && c.Settings.AssumeRoleARN == s.failingRoleARN {
+		return nil, errors.New("access denied")
+	}
+	return &session.Session{Config: &aws.Config{}}, nil
+}
+
+func TestTimeSeriesQuery_FanOut(t *testing.T) {
+	now := time.Now()
+
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+	NewCWClient = func(sess *session.Session) cloudwatchiface.CloudWatchAPI {
+		return FakeCWClient{
+			GetMetricDataOutput: cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{
+					{
+						StatusCode: aws.String("Complete"), Id: aws.String("a"), Label: aws.String("CPUUtilization"), Values: []*float64{aws.Float64(1.0)}, Timestamps: []*time.Time{&now},
+					},
+				},
+			},
+		}
+	}
+
+	im := datasource.NewInstanceManager(func(s backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		return datasourceInfo{
+			region:        "us-east-1",
+			assumeRoleARN: "arn:aws:iam::111111111111:role/grafana",
+			allowedAssumeRoleARNs: []string{
+				"arn:aws:iam::222222222222:role/grafana",
+				"arn:aws:iam::333333333333:role/grafana",
+			},
+		}, nil
+	})
+
+	query := func(fanOut string) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+			},
+			Queries: []backend.DataQuery{
+				{
+					RefID: "A",
+					TimeRange: backend.TimeRange{
+						From: now.Add(time.Hour * -2),
+						To:   now.Add(time.Hour * -1),
+					},
+					JSON: json.RawMessage(`{
+						"type":       "timeSeriesQuery",
+						"namespace":  "AWS/EC2",
+						"metricName": "CPUUtilization",
+						"dimensions": {},
+						"region":     "default",
+						"id":         "a",
+						"statistic":  "Average",
+						"period":     "300",
+						` + fanOut + `
+					}`),
+				},
+			},
+		}
+	}
+
+	seriesNames := func(frames data.Frames) []string {
+		names := []string{}
+		for _, frame := range frames {
+			names = append(names, frame.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("Queries every combination of regions and roles and labels the series", func(t *testing.T) {
+		sessions := &recordingSessionCache{}
+		executor := newExecutor(im, newTestConfig(), sessions)
+
+		resp, err := executor.QueryData(context.Background(), query(`
+			"regions":        ["default", "eu-west-1"],
+			"assumeRoleArns": ["arn:aws:iam::222222222222:role/grafana", "arn:aws:iam::333333333333:role/grafana"]`))
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+
+		sort.Strings(sessions.targets)
+		assert.Equal(t, []string{
+			"eu-west-1 arn:aws:iam::222222222222:role/grafana",
+			"eu-west-1 arn:aws:iam::333333333333:role/grafana",
+			"us-east-1 arn:aws:iam::222222222222:role/grafana",
+			"us-east-1 arn:aws:iam::333333333333:role/grafana",
+		}, sessions.targets)
+
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 4)
+		assert.Equal(t, []string{
+			"CPUUtilization_Average (222222222222 eu-west-1)",
+			"CPUUtilization_Average (222222222222 us-east-1)",
+			"CPUUtilization_Average (333333333333 eu-west-1)",
+			"CPUUtilization_Average (333333333333 us-east-1)",
+		}, seriesNames(frames))
+		for _, frame := range frames {
+			labels := frame.Fields[1].Labels
+			assert.Contains(t, []string{"us-east-1", "eu-west-1"}, labels["region"])
+			assert.Contains(t, []string{"222222222222", "333333333333"}, labels["account"])
+		}
+	})
+
+	t.Run("Uses the role of the data source when only regions are given", func(t *testing.T) {
+		sessions := &recordingSessionCache{}
+		executor := newExecutor(im, newTestConfig(), sessions)
+
+		resp, err := executor.QueryData(context.Background(), query(`"regions": ["us-east-1", "eu-west-1"], "alias": "{{account}} {{region}}"`))
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+
+		sort.Strings(sessions.targets)
+		assert.Equal(t, []string{
+			"eu-west-1 arn:aws:iam::111111111111:role/grafana",
+			"us-east-1 arn:aws:iam::111111111111:role/grafana",
+		}, sessions.targets)
+		assert.Equal(t, []string{"111111111111 eu-west-1", "111111111111 us-east-1"}, seriesNames(resp.Responses["A"].Frames))
+	})
+
+	t.Run("Returns the series of the other targets when one fails", func(t *testing.T) {
+		sessions := &recordingSessionCache{failingRoleARN: "arn:aws:iam::333333333333:role/grafana"}
+		executor := newExecutor(im, newTestConfig(), sessions)
+
+		resp, err := executor.QueryData(context.Background(), query(`
+			"assumeRoleArns": ["arn:aws:iam::222222222222:role/grafana", "arn:aws:iam::333333333333:role/grafana"]`))
+		require.NoError(t, err)
+		require.EqualError(t, resp.Responses["A"].Error,
+			`metric request error in default as arn:aws:iam::333333333333:role/grafana: "access denied"`)
+		assert.Equal(t, []string{"CPUUtilization_Average (222222222222 us-east-1)"}, seriesNames(resp.Responses["A"].Frames))
+	})
+
+	t.Run("Rejects invalid role ARNs", func(t *testing.T) {
+		executor := newExecutor(im, newTestConfig(), &recordingSessionCache{})
+
+		_, err := executor.QueryData(context.Background(), query(`"assumeRoleArns": ["grafana"]`))
+		require.EqualError(t, err, `error parsing query "A", invalid assume role ARN "grafana"`)
+	})
+
+	t.Run("Rejects role ARNs the data source doesn't allow", func(t *testing.T) {
+		sessions := &recordingSessionCache{}
+		executor := newExecutor(im, newTestConfig(), sessions)
+
+		_, err := executor.QueryData(context.Background(), query(`
+			"assumeRoleArns": ["arn:aws:iam::222222222222:role/grafana", "arn:aws:iam::444444444444:role/admin"]`))
+		require.EqualError(t, err,
+			`error parsing query "A", assume role ARN "arn:aws:iam::444444444444:role/admin" is not allowed by the data source`)
+		assert.Empty(t, sessions.targets)
+	})
+}
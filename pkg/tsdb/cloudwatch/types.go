@@ -54,3 +54,17 @@ const (
 	GMDApiModeMathExpression
 	GMDApiModeSQLExpression
 )
+
+// queryTarget is a region and IAM role that metric queries are run with. An
+// empty assumeRoleARN means the role configured in the data source.
+type queryTarget struct {
+	region        string
+	assumeRoleARN string
+}
+
+func (t queryTarget) String() string {
+	if t.assumeRoleARN == "" {
+		return t.region
+	}
+	return fmt.Sprintf("%s as %s", t.region, t.assumeRoleARN)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -36,6 +37,16 @@ type DatasourceInfo struct {
 	MaxConcurrentShardRequests int64
 	IncludeFrozen              bool
 	XPack                      bool
+	LogMessageField            string
+	LogLevelField              string
+}
+
+// ConfiguredFields holds the fields configured for the data source that give
+// documents their meaning in logs.
+type ConfiguredFields struct {
+	TimeField       string
+	LogMessageField string
+	LogLevelField   string
 }
 
 const loggerName = "tsdb.elasticsearch.client"
@@ -52,8 +63,13 @@ var newDatasourceHttpClient = func(httpClientProvider httpclient.Provider, ds *D
 type Client interface {
 	GetVersion() *semver.Version
 	GetTimeField() string
+	GetConfiguredFields() ConfiguredFields
 	GetMinInterval(queryInterval string) (time.Duration, error)
 	ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error)
+	ExecuteSearch(r *SearchRequest) (*SearchResponse, error)
+	SupportsPointInTime() bool
+	OpenPointInTime() (*PointInTime, error)
+	ClosePointInTime(pit *PointInTime) error
 	MultiSearch() *MultiSearchRequestBuilder
 	EnableDebug()
 }
@@ -102,6 +118,14 @@ func (c *baseClientImpl) GetTimeField() string {
 	return c.timeField
 }
 
+func (c *baseClientImpl) GetConfiguredFields() ConfiguredFields {
+	return ConfiguredFields{
+		TimeField:       c.timeField,
+		LogMessageField: c.ds.LogMessageField,
+		LogLevelField:   c.ds.LogLevelField,
+	}
+}
+
 func (c *baseClientImpl) GetMinInterval(queryInterval string) (time.Duration, error) {
 	timeInterval := c.ds.TimeInterval
 	return intervalv2.GetIntervalFrom(queryInterval, timeInterval, 0, 5*time.Second)
@@ -118,7 +142,7 @@ func (c *baseClientImpl) executeBatchRequest(uriPath, uriQuery string, requests
 	if err != nil {
 		return nil, err
 	}
-	return c.executeRequest(http.MethodPost, uriPath, uriQuery, bytes)
+	return c.executeRequest(http.MethodPost, uriPath, uriQuery, "application/x-ndjson", bytes)
 }
 
 func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte, error) {
@@ -133,15 +157,11 @@ func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte,
 		}
 		payload.WriteString(string(reqHeader) + "\n")
 
-		reqBody, err := json.Marshal(r.body)
+		body, err := encodeRequestBody(r.body, r.interval)
 		if err != nil {
 			return nil, err
 		}
 
-		body := string(reqBody)
-		body = strings.ReplaceAll(body, "$__interval_ms", strconv.FormatInt(r.interval.Milliseconds(), 10))
-		body = strings.ReplaceAll(body, "$__interval", r.interval.Text)
-
 		payload.WriteString(body + "\n")
 	}
 
@@ -151,7 +171,20 @@ func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte,
 	return payload.Bytes(), nil
 }
 
-func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body []byte) (*response, error) {
+func encodeRequestBody(body interface{}, interval intervalv2.Interval) (string, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := string(reqBody)
+	encoded = strings.ReplaceAll(encoded, "$__interval_ms", strconv.FormatInt(interval.Milliseconds(), 10))
+	encoded = strings.ReplaceAll(encoded, "$__interval", interval.Text)
+
+	return encoded, nil
+}
+
+func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery, contentType string, body []byte) (*response, error) {
 	u, err := url.Parse(c.ds.URL)
 	if err != nil {
 		return nil, err
@@ -160,10 +193,10 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 	u.RawQuery = uriQuery
 
 	var req *http.Request
-	if method == http.MethodPost {
-		req, err = http.NewRequest(http.MethodPost, u.String(), bytes.NewBuffer(body))
-	} else {
+	if method == http.MethodGet {
 		req, err = http.NewRequest(http.MethodGet, u.String(), nil)
+	} else {
+		req, err = http.NewRequest(method, u.String(), bytes.NewBuffer(body))
 	}
 	if err != nil {
 		return nil, err
@@ -180,7 +213,7 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 		}
 	}
 
-	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Type", contentType)
 
 	httpClient, err := newDatasourceHttpClient(c.httpClientProvider, c.ds)
 	if err != nil {
@@ -269,6 +302,106 @@ func (c *baseClientImpl) ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearch
 	return &msr, nil
 }
 
+// pointInTimeKeepAlive is how long a point in time is kept open between two
+// searches of it.
+const pointInTimeKeepAlive = "1m"
+
+// SupportsPointInTime returns true if the version of Elasticsearch can open a
+// point in time to page through hits.
+func (c *baseClientImpl) SupportsPointInTime() bool {
+	pointInTimeVersionRange, _ := semver.NewConstraint(">=7.10.0")
+	return pointInTimeVersionRange.Check(c.version)
+}
+
+// OpenPointInTime opens a point in time of the indices of the client.
+func (c *baseClientImpl) OpenPointInTime() (*PointInTime, error) {
+	uriPath := strings.Join(c.indices, ",") + "/_pit"
+	uriQuery := "ignore_unavailable=true&keep_alive=" + pointInTimeKeepAlive
+	clientRes, err := c.executeRequest(http.MethodPost, uriPath, uriQuery, "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	res := clientRes.httpResponse
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			clientLog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("failed to open point in time: %s", res.Status)
+	}
+
+	var pit PointInTime
+	if err := json.NewDecoder(res.Body).Decode(&pit); err != nil {
+		return nil, err
+	}
+	pit.KeepAlive = pointInTimeKeepAlive
+
+	return &pit, nil
+}
+
+// ClosePointInTime frees the resources of a point in time before it expires.
+func (c *baseClientImpl) ClosePointInTime(pit *PointInTime) error {
+	body, err := json.Marshal(map[string]string{"id": pit.ID})
+	if err != nil {
+		return err
+	}
+	clientRes, err := c.executeRequest(http.MethodDelete, "_pit", "", "application/json", body)
+	if err != nil {
+		return err
+	}
+	res := clientRes.httpResponse
+	if err := res.Body.Close(); err != nil {
+		clientLog.Warn("Failed to close response body", "err", err)
+	}
+
+	if res.StatusCode/100 != 2 && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to close point in time: %s", res.Status)
+	}
+	return nil
+}
+
+// maxErrorBodySize limits how much of the body of a failed search is returned
+// in the error.
+const maxErrorBodySize = 4096
+
+// ExecuteSearch executes a single search request. It is used to search a
+// point in time, which the request names instead of the indices.
+func (c *baseClientImpl) ExecuteSearch(r *SearchRequest) (*SearchResponse, error) {
+	body, err := encodeRequestBody(r, r.Interval)
+	if err != nil {
+		return nil, err
+	}
+	clientRes, err := c.executeRequest(http.MethodPost, "_search", "", "application/json", []byte(body))
+	if err != nil {
+		return nil, err
+	}
+	res := clientRes.httpResponse
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			clientLog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	clientLog.Debug("Received search response", "code", res.StatusCode, "status", res.Status)
+
+	if res.StatusCode/100 != 2 {
+		body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %s", res.Status)
+		}
+		return nil, fmt.Errorf("search failed: %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+
+	var sr SearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&sr); err != nil {
+		return nil, err
+	}
+
+	return &sr, nil
+}
+
 func (c *baseClientImpl) createMultiSearchRequests(searchRequests []*SearchRequest) []*multiRequest {
 	multiRequests := []*multiRequest{}
 
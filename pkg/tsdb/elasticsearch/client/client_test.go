@@ -100,6 +100,38 @@ func TestNewClient(t *testing.T) {
 	})
 }
 
+func TestClient_ExecuteSearch(t *testing.T) {
+	version, err := semver.NewVersion("7.10.0")
+	require.NoError(t, err)
+	ds := &DatasourceInfo{
+		Database:  "[metrics-]YYYY.MM.DD",
+		ESVersion: version,
+		TimeField: "@timestamp",
+		Interval:  "Daily",
+	}
+
+	httpClientScenario(t, "Given a fake http client and a v7.10 client with a search response", ds, func(sc *scenarioContext) {
+		sc.responseBody = `{ "pit_id": "pit-2", "hits": { "hits": [{ "_id": "1" }] } }`
+
+		res, err := sc.client.ExecuteSearch(&SearchRequest{PointInTime: &PointInTime{ID: "pit-1", KeepAlive: "1m"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, "/_search", sc.request.URL.Path)
+		assert.Equal(t, "pit-2", res.PitID)
+		require.Len(t, res.Hits.Hits, 1)
+	})
+
+	httpClientScenario(t, "Given a fake http client and a v7.10 client with a failed search", ds, func(sc *scenarioContext) {
+		sc.responseStatus = 404
+		sc.responseBody = `{ "error": { "type": "search_context_missing_exception" } }`
+
+		_, err := sc.client.ExecuteSearch(&SearchRequest{PointInTime: &PointInTime{ID: "pit-1", KeepAlive: "1m"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404 Not Found")
+		assert.Contains(t, err.Error(), "search_context_missing_exception")
+	})
+}
+
 func TestClient_ExecuteMultisearch(t *testing.T) {
 	version, err := semver.NewVersion("2.0.0")
 	require.NoError(t, err)
@@ -356,9 +388,9 @@ func httpClientScenario(t *testing.T, desc string, ds *DatasourceInfo, fn scenar
 			sc.requestBody = bytes.NewBuffer(buf)
 
 			rw.Header().Set("Content-Type", "application/x-ndjson")
+			rw.WriteHeader(sc.responseStatus)
 			_, err = rw.Write([]byte(sc.responseBody))
 			require.NoError(t, err)
-			rw.WriteHeader(sc.responseStatus)
 		}))
 		ds.URL = ts.URL
 
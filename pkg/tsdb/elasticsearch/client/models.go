@@ -35,9 +35,15 @@ type SearchRequest struct {
 	Interval    intervalv2.Interval
 	Size        int
 	Sort        map[string]interface{}
+	SearchAfter []interface{}
+	PointInTime *PointInTime
 	Query       *Query
 	Aggs        AggArray
 	CustomProps map[string]interface{}
+
+	// sortKeys holds the keys of Sort in the order they were added, since
+	// the order of sort fields matters when sorting on multiple fields.
+	sortKeys []string
 }
 
 // MarshalJSON returns the JSON encoding of the request.
@@ -45,8 +51,22 @@ func (r *SearchRequest) MarshalJSON() ([]byte, error) {
 	root := make(map[string]interface{})
 
 	root["size"] = r.Size
-	if len(r.Sort) > 0 {
+	if len(r.Sort) == 1 {
 		root["sort"] = r.Sort
+	} else if len(r.Sort) > 1 {
+		sort := make([]map[string]interface{}, 0, len(r.Sort))
+		for _, key := range r.sortKeys {
+			sort = append(sort, map[string]interface{}{key: r.Sort[key]})
+		}
+		root["sort"] = sort
+	}
+
+	if len(r.SearchAfter) > 0 {
+		root["search_after"] = r.SearchAfter
+	}
+
+	if r.PointInTime != nil {
+		root["pit"] = r.PointInTime
 	}
 
 	for key, value := range r.CustomProps {
@@ -62,6 +82,41 @@ func (r *SearchRequest) MarshalJSON() ([]byte, error) {
 	return json.Marshal(root)
 }
 
+// WithSearchAfter returns a copy of the request that fetches the next page of
+// hits after the given sort values.
+func (r *SearchRequest) WithSearchAfter(size int, searchAfter []interface{}) *SearchRequest {
+	next := *r
+	next.Size = size
+	next.SearchAfter = searchAfter
+	return &next
+}
+
+// WithPointInTime returns a copy of the request that searches the given point
+// in time instead of the indices. The index order tiebreaker is replaced by
+// the _shard_doc order, which is unique across shards, so that paging with
+// search_after neither skips nor repeats hits.
+func (r *SearchRequest) WithPointInTime(pit *PointInTime) *SearchRequest {
+	next := *r
+	next.PointInTime = pit
+	next.Sort = make(map[string]interface{}, len(r.Sort))
+	next.sortKeys = make([]string, 0, len(r.sortKeys))
+	for _, key := range r.sortKeys {
+		sort := r.Sort[key]
+		if key == docTiebreaker {
+			key = shardDocTiebreaker
+		}
+		next.Sort[key] = sort
+		next.sortKeys = append(next.sortKeys, key)
+	}
+	return &next
+}
+
+// PointInTime is a view of the searched indices at the time it was opened.
+type PointInTime struct {
+	ID        string `json:"id"`
+	KeepAlive string `json:"keep_alive"`
+}
+
 // SearchResponseHits represents search response hits
 type SearchResponseHits struct {
 	Hits []map[string]interface{}
@@ -72,6 +127,7 @@ type SearchResponse struct {
 	Error        map[string]interface{} `json:"error"`
 	Aggregations map[string]interface{} `json:"aggregations"`
 	Hits         *SearchResponseHits    `json:"hits"`
+	PitID        string                 `json:"pit_id"`
 }
 
 // MultiSearchRequest represents a multi search request
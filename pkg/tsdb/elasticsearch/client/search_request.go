@@ -7,6 +7,11 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 )
 
+const (
+	docTiebreaker      = "_doc"
+	shardDocTiebreaker = "_shard_doc"
+)
+
 // SearchRequestBuilder represents a builder which can build a search request
 type SearchRequestBuilder struct {
 	version      *semver.Version
@@ -14,6 +19,8 @@ type SearchRequestBuilder struct {
 	index        string
 	size         int
 	sort         map[string]interface{}
+	sortKeys     []string
+	searchAfter  []interface{}
 	queryBuilder *QueryBuilder
 	aggBuilders  []AggBuilder
 	customProps  map[string]interface{}
@@ -38,7 +45,9 @@ func (b *SearchRequestBuilder) Build() (*SearchRequest, error) {
 		Interval:    b.interval,
 		Size:        b.size,
 		Sort:        b.sort,
+		SearchAfter: b.searchAfter,
 		CustomProps: b.customProps,
+		sortKeys:    b.sortKeys,
 	}
 
 	if b.queryBuilder != nil {
@@ -80,11 +89,33 @@ func (b *SearchRequestBuilder) SortDesc(field, unmappedType string) *SearchReque
 		props["unmapped_type"] = unmappedType
 	}
 
+	return b.addSort(field, props)
+}
+
+// SortDescTiebreaker adds a descending sort on the index order of documents. It
+// is used after other sorts, so that hits with equal sort values are returned
+// in the same order. The index order is only unique within a shard, so paging
+// through hits with search_after needs a point in time, which replaces it with
+// the unique _shard_doc order. See SearchRequest.WithPointInTime.
+func (b *SearchRequestBuilder) SortDescTiebreaker() *SearchRequestBuilder {
+	return b.addSort(docTiebreaker, map[string]string{"order": "desc"})
+}
+
+func (b *SearchRequestBuilder) addSort(field string, props map[string]string) *SearchRequestBuilder {
+	if _, exists := b.sort[field]; !exists {
+		b.sortKeys = append(b.sortKeys, field)
+	}
 	b.sort[field] = props
 
 	return b
 }
 
+// SearchAfter sets the sort values of the hit after which results should start
+func (b *SearchRequestBuilder) SearchAfter(values []interface{}) *SearchRequestBuilder {
+	b.searchAfter = values
+	return b
+}
+
 // AddDocValueField adds a doc value field to the search request
 func (b *SearchRequestBuilder) AddDocValueField(field string) *SearchRequestBuilder {
 	// fields field not supported on version >= 5
@@ -45,6 +45,45 @@ func TestSearchRequest(t *testing.T) {
 		})
 	})
 
+	t.Run("When sorting on multiple fields and paging", func(t *testing.T) {
+		b := setup()
+		b.SortDesc(timeField, "boolean")
+		b.SortDescTiebreaker()
+		b.SearchAfter([]interface{}{float64(1526406600000), float64(42)})
+
+		sr, err := b.Build()
+		require.Nil(t, err)
+
+		body, err := json.Marshal(sr)
+		require.Nil(t, err)
+		json, err := simplejson.NewJson(body)
+		require.Nil(t, err)
+
+		sort := json.Get("sort")
+		require.Len(t, sort.MustArray(), 2)
+		require.Equal(t, "desc", sort.GetIndex(0).GetPath(timeField, "order").MustString())
+		require.Equal(t, "desc", sort.GetIndex(1).GetPath("_doc", "order").MustString())
+		require.Len(t, json.Get("search_after").MustArray(), 2)
+		require.Equal(t, int64(42), json.Get("search_after").GetIndex(1).MustInt64())
+
+		next := sr.WithSearchAfter(10, []interface{}{float64(1)})
+		require.Equal(t, 10, next.Size)
+		require.Equal(t, []interface{}{float64(1)}, next.SearchAfter)
+		require.Len(t, sr.SearchAfter, 2)
+
+		pitBody, err := next.WithPointInTime(&PointInTime{ID: "pit", KeepAlive: "1m"}).MarshalJSON()
+		require.Nil(t, err)
+		pitJSON, err := simplejson.NewJson(pitBody)
+		require.Nil(t, err)
+
+		require.Equal(t, "pit", pitJSON.GetPath("pit", "id").MustString())
+		require.Equal(t, "1m", pitJSON.GetPath("pit", "keep_alive").MustString())
+		sort = pitJSON.Get("sort")
+		require.Equal(t, "desc", sort.GetIndex(0).GetPath(timeField, "order").MustString())
+		require.Equal(t, "desc", sort.GetIndex(1).GetPath("_shard_doc", "order").MustString())
+		require.Contains(t, sr.Sort, "_doc")
+	})
+
 	t.Run("When adding size, sort, filters", func(t *testing.T) {
 		b := setup()
 		b.Size(200)
@@ -0,0 +1,219 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+// logLevelFieldName is the name of the field that the logs visualization
+// reads the level of a log line from.
+const logLevelFieldName = "level"
+
+// processDocumentResponse converts the hits of a raw_document, raw_data or logs
+// query into a single frame. The _source of every hit is flattened, so that
+// nested objects become dot separated field names, and every field gets the
+// type shared by all its values.
+func processDocumentResponse(res *es.SearchResponse, target *Query, configuredFields es.ConfiguredFields) *data.Frame {
+	var hits []map[string]interface{}
+	if res.Hits != nil {
+		hits = res.Hits.Hits
+	}
+
+	timeField := target.TimeField
+	if timeField == "" {
+		timeField = configuredFields.TimeField
+	}
+
+	docs := make([]map[string]interface{}, 0, len(hits))
+	times := make([]*time.Time, 0, len(hits))
+	keys := map[string]bool{}
+
+	for _, hit := range hits {
+		doc := map[string]interface{}{}
+		if source, ok := hit["_source"].(map[string]interface{}); ok {
+			flatten("", source, doc)
+		}
+		for _, meta := range []string{"_id", "_index", "_type"} {
+			if v, ok := hit[meta]; ok {
+				doc[meta] = v
+			}
+		}
+
+		times = append(times, hitTime(hit, doc, timeField))
+		delete(doc, timeField)
+
+		for k := range doc {
+			keys[k] = true
+		}
+		docs = append(docs, doc)
+	}
+
+	isLogs := target.Metrics[0].Type == logsType
+	names := orderDocumentFields(keys, isLogs, configuredFields)
+
+	fields := make([]*data.Field, 0, len(names)+2)
+	fields = append(fields, data.NewField(timeField, nil, times))
+
+	for _, name := range names {
+		values := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			values[i] = doc[name]
+		}
+		fields = append(fields, newDocumentField(name, values))
+
+		if isLogs && name == configuredFields.LogLevelField && name != logLevelFieldName {
+			fields = append(fields, newStringField(logLevelFieldName, values))
+		}
+	}
+
+	frame := data.NewFrame("", fields...)
+	frame.RefID = target.RefID
+	return frame
+}
+
+// orderDocumentFields returns the names of all document fields. For logs the
+// message field goes first, since the first string field is used as the log
+// line, followed by the level field.
+func orderDocumentFields(keys map[string]bool, isLogs bool, configuredFields es.ConfiguredFields) []string {
+	names := make([]string, 0, len(keys))
+	var first []string
+
+	if isLogs {
+		for _, name := range []string{configuredFields.LogMessageField, configuredFields.LogLevelField} {
+			if name != "" && keys[name] {
+				first = append(first, name)
+				delete(keys, name)
+			}
+		}
+	}
+
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return append(first, names...)
+}
+
+func flatten(prefix string, source map[string]interface{}, target map[string]interface{}) {
+	for k, v := range source {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(key, nested, target)
+			continue
+		}
+		target[key] = v
+	}
+}
+
+// hitTime reads the timestamp of a hit, preferring the doc value field that is
+// requested for the time field over the value in _source.
+func hitTime(hit map[string]interface{}, doc map[string]interface{}, timeField string) *time.Time {
+	if fields, ok := hit["fields"].(map[string]interface{}); ok {
+		if values, ok := fields[timeField].([]interface{}); ok && len(values) > 0 {
+			if t, ok := parseTime(values[0]); ok {
+				return &t
+			}
+		}
+	}
+
+	if t, ok := parseTime(doc[timeField]); ok {
+		return &t
+	}
+
+	return nil
+}
+
+func parseTime(v interface{}) (time.Time, bool) {
+	switch value := v.(type) {
+	case float64:
+		return time.Unix(0, int64(value)*int64(time.Millisecond)).UTC(), true
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return t.UTC(), true
+		}
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(0, ms*int64(time.Millisecond)).UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// newDocumentField creates a field with the type shared by all non-null values.
+// Fields with mixed or structured values are converted to strings.
+func newDocumentField(name string, values []interface{}) *data.Field {
+	var isNumber, isBool, isString bool
+	for _, v := range values {
+		switch v.(type) {
+		case nil:
+		case float64:
+			isNumber = true
+		case bool:
+			isBool = true
+		case string:
+			isString = true
+		default:
+			return newStringField(name, values)
+		}
+	}
+
+	switch {
+	case isNumber && !isBool && !isString:
+		out := make([]*float64, len(values))
+		for i, v := range values {
+			if f, ok := v.(float64); ok {
+				out[i] = &f
+			}
+		}
+		return data.NewField(name, nil, out)
+	case isBool && !isNumber && !isString:
+		out := make([]*bool, len(values))
+		for i, v := range values {
+			if b, ok := v.(bool); ok {
+				out[i] = &b
+			}
+		}
+		return data.NewField(name, nil, out)
+	default:
+		return newStringField(name, values)
+	}
+}
+
+func newStringField(name string, values []interface{}) *data.Field {
+	out := make([]*string, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		s := valueToString(v)
+		out[i] = &s
+	}
+	return data.NewField(name, nil, out)
+}
+
+func valueToString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
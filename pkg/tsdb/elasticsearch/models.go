@@ -17,6 +17,12 @@ type Query struct {
 	MaxDataPoints int64
 }
 
+// isDocumentQuery returns true if the query fetches documents instead of
+// aggregating them.
+func (q *Query) isDocumentQuery() bool {
+	return len(q.BucketAggs) == 0 && len(q.Metrics) > 0 && isDocumentMetric(q.Metrics[0].Type)
+}
+
 // BucketAgg represents a bucket aggregation of the time series query model of the datasource
 type BucketAgg struct {
 	Field    string           `json:"field"`
@@ -54,6 +60,8 @@ var metricAggType = map[string]string{
 	"serial_diff":    "Serial Difference",
 	"bucket_script":  "Bucket Script",
 	"raw_document":   "Raw Document",
+	"raw_data":       "Raw Data",
+	"logs":           "Logs",
 	"rate":           "Rate",
 }
 
@@ -91,6 +99,14 @@ var pipelineAggWithMultipleBucketPathsType = map[string]string{
 	"bucket_script": "bucket_script",
 }
 
+func isDocumentMetric(metricType string) bool {
+	switch metricType {
+	case rawDocumentType, rawDataType, logsType:
+		return true
+	}
+	return false
+}
+
 func isPipelineAgg(metricType string) bool {
 	if _, ok := pipelineAggType[metricType]; ok {
 		return true
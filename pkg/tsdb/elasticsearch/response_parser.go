@@ -20,6 +20,9 @@ const (
 	percentilesType   = "percentiles"
 	extendedStatsType = "extended_stats"
 	topMetricsType    = "top_metrics"
+	rawDocumentType   = "raw_document"
+	rawDataType       = "raw_data"
+	logsType          = "logs"
 	// Bucket types
 	dateHistType    = "date_histogram"
 	histogramType   = "histogram"
@@ -29,16 +32,19 @@ const (
 )
 
 type responseParser struct {
-	Responses []*es.SearchResponse
-	Targets   []*Query
-	DebugInfo *es.SearchDebugInfo
+	Responses        []*es.SearchResponse
+	Targets          []*Query
+	DebugInfo        *es.SearchDebugInfo
+	ConfiguredFields es.ConfiguredFields
 }
 
-var newResponseParser = func(responses []*es.SearchResponse, targets []*Query, debugInfo *es.SearchDebugInfo) *responseParser {
+var newResponseParser = func(responses []*es.SearchResponse, targets []*Query, debugInfo *es.SearchDebugInfo,
+	configuredFields es.ConfiguredFields) *responseParser {
 	return &responseParser{
-		Responses: responses,
-		Targets:   targets,
-		DebugInfo: debugInfo,
+		Responses:        responses,
+		Targets:          targets,
+		DebugInfo:        debugInfo,
+		ConfiguredFields: configuredFields,
 	}
 }
 
@@ -72,6 +78,19 @@ func (rp *responseParser) getTimeSeries() (*backend.QueryDataResponse, error) {
 			continue
 		}
 
+		if target.isDocumentQuery() {
+			frame := processDocumentResponse(res, target, rp.ConfiguredFields)
+			frame.Meta = &data.FrameMeta{
+				Custom:                 debugInfo,
+				PreferredVisualization: data.VisTypeTable,
+			}
+			if target.Metrics[0].Type == logsType {
+				frame.Meta.PreferredVisualization = data.VisTypeLogs
+			}
+			result.Responses[target.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+			continue
+		}
+
 		queryRes := backend.DataResponse{}
 
 		props := make(map[string]string)
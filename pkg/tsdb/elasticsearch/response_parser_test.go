@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,6 +15,108 @@ import (
 
 func TestResponseParser(t *testing.T) {
 	t.Run("Elasticsearch response parser test", func(t *testing.T) {
+		t.Run("Logs query", func(t *testing.T) {
+			targets := map[string]string{
+				"A": `{
+					"timeField": "@timestamp",
+					"metrics": [{ "type": "logs", "id": "1" }]
+				}`,
+			}
+			response := `{
+				"responses": [
+					{
+						"hits": {
+							"hits": [
+								{
+									"_id": "1",
+									"_index": "logs-2018.05.15",
+									"_source": {
+										"@timestamp": "2018-05-15T17:50:00.000Z",
+										"msg": "hello",
+										"severity": "info",
+										"http": { "status": 200, "ok": true },
+										"tags": ["a", "b"]
+									},
+									"fields": { "@timestamp": ["2018-05-15T17:50:00.000Z"] },
+									"sort": [1526406600000, 1]
+								},
+								{
+									"_id": "2",
+									"_index": "logs-2018.05.15",
+									"_source": {
+										"@timestamp": "2018-05-15T17:49:00.000Z",
+										"msg": "world",
+										"severity": "error",
+										"http": { "status": "n/a" }
+									},
+									"sort": [1526406540000, 2]
+								}
+							]
+						}
+					}
+				]
+			}`
+			rp, err := newResponseParserForTest(targets, response)
+			require.NoError(t, err)
+			rp.ConfiguredFields.LogMessageField = "msg"
+			rp.ConfiguredFields.LogLevelField = "severity"
+
+			result, err := rp.getTimeSeries()
+			require.NoError(t, err)
+			frames := result.Responses["A"].Frames
+			require.Len(t, frames, 1)
+			frame := frames[0]
+			require.Equal(t, data.VisTypeLogs, string(frame.Meta.PreferredVisualization))
+			require.Equal(t, 2, frame.Rows())
+
+			names := make([]string, 0, len(frame.Fields))
+			for _, f := range frame.Fields {
+				names = append(names, f.Name)
+			}
+			require.Equal(t, []string{"@timestamp", "msg", "severity", "level", "_id", "_index", "http.ok", "http.status", "tags"}, names)
+
+			require.Equal(t, time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+			require.Equal(t, time.Date(2018, 5, 15, 17, 49, 0, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+			require.Equal(t, "hello", *frame.Fields[1].At(0).(*string))
+			require.Equal(t, "error", *frame.Fields[3].At(1).(*string))
+			require.Equal(t, data.FieldTypeNullableBool, frame.Fields[6].Type())
+			require.Nil(t, frame.Fields[6].At(1))
+			// mixed types become strings
+			require.Equal(t, data.FieldTypeNullableString, frame.Fields[7].Type())
+			require.Equal(t, "200", *frame.Fields[7].At(0).(*string))
+			require.Equal(t, `["a","b"]`, *frame.Fields[8].At(0).(*string))
+		})
+
+		t.Run("Raw data query", func(t *testing.T) {
+			targets := map[string]string{
+				"A": `{
+					"timeField": "@timestamp",
+					"metrics": [{ "type": "raw_data", "id": "1" }]
+				}`,
+			}
+			response := `{
+				"responses": [
+					{
+						"hits": {
+							"hits": [
+								{ "_id": "1", "_source": { "@timestamp": 1526406600000, "value": 1.5 } }
+							]
+						}
+					}
+				]
+			}`
+			rp, err := newResponseParserForTest(targets, response)
+			require.NoError(t, err)
+			result, err := rp.getTimeSeries()
+			require.NoError(t, err)
+
+			frame := result.Responses["A"].Frames[0]
+			require.Equal(t, data.VisTypeTable, string(frame.Meta.PreferredVisualization))
+			require.Equal(t, time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+			value, _ := frame.FieldByName("value")
+			require.Equal(t, 1.5, *value.At(0).(*float64))
+		})
+
 		t.Run("Simple query and count", func(t *testing.T) {
 			targets := map[string]string{
 				"A": `{
@@ -1092,5 +1195,5 @@ func newResponseParserForTest(tsdbQueries map[string]string, responseBody string
 		return nil, err
 	}
 
-	return newResponseParser(response.Responses, queries, nil), nil
+	return newResponseParser(response.Responses, queries, nil, es.ConfiguredFields{TimeField: "@timestamp"}), nil
 }
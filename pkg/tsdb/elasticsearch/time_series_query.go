@@ -8,6 +8,7 @@ import (
 
 	"github.com/Masterminds/semver"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
@@ -53,13 +54,203 @@ func (e *timeSeriesQuery) execute() (*backend.QueryDataResponse, error) {
 		return &backend.QueryDataResponse{}, err
 	}
 
+	pagedReqs := e.pagedDocumentRequests(queries, req)
+
 	res, err := e.client.ExecuteMultisearch(req)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
 	}
 
-	rp := newResponseParser(res.Responses, queries, res.DebugInfo)
-	return rp.getTimeSeries()
+	if err := e.fetchDocumentPages(queries, pagedReqs, res); err != nil {
+		return &backend.QueryDataResponse{}, err
+	}
+
+	rp := newResponseParser(res.Responses, queries, res.DebugInfo, e.client.GetConfiguredFields())
+	queryRes, err := rp.getTimeSeries()
+	if err != nil {
+		return queryRes, err
+	}
+
+	e.warnTruncatedDocumentQueries(queries, queryRes)
+	return queryRes, nil
+}
+
+// pagedDocumentRequests returns the search requests of the document queries
+// that ask for more documents than fit in a single page, by query index. They
+// are fetched page by page in a point in time, which keeps the order of hits
+// stable between the pages. Their requests in the multisearch are replaced by
+// requests without hits, so that the responses keep the order of the queries.
+// Versions of Elasticsearch without point in time return a single page.
+func (e *timeSeriesQuery) pagedDocumentRequests(queries []*Query, req *es.MultiSearchRequest) map[int]*es.SearchRequest {
+	if !e.client.SupportsPointInTime() {
+		return nil
+	}
+
+	pagedReqs := map[int]*es.SearchRequest{}
+	for i, q := range queries {
+		if !q.isDocumentQuery() || i >= len(req.Requests) || documentQueryLimit(q.Metrics[0]) <= maxDocumentPageSize {
+			continue
+		}
+
+		searchReq := req.Requests[i]
+		pagedReqs[i] = searchReq
+		req.Requests[i] = searchReq.WithSearchAfter(0, searchReq.SearchAfter)
+	}
+
+	return pagedReqs
+}
+
+// warnTruncatedDocumentQueries adds a warning to the responses of document
+// queries that ask for more documents than a single page, when the version of
+// Elasticsearch can't page through them.
+func (e *timeSeriesQuery) warnTruncatedDocumentQueries(queries []*Query, result *backend.QueryDataResponse) {
+	if e.client.SupportsPointInTime() {
+		return
+	}
+
+	for _, q := range queries {
+		if !q.isDocumentQuery() {
+			continue
+		}
+		limit := documentQueryLimit(q.Metrics[0])
+		if limit <= maxDocumentPageSize {
+			continue
+		}
+
+		res := result.Responses[q.RefID]
+		if res.Error != nil {
+			continue
+		}
+		if len(res.Frames) == 0 {
+			res.Frames = data.Frames{data.NewFrame("")}
+		}
+
+		notice := data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text: fmt.Sprintf("Only the first %d of the %d requested documents are returned. Returning more requires Elasticsearch 7.10 or later.",
+				maxDocumentPageSize, limit),
+		}
+		for _, frame := range res.Frames {
+			if frame.Meta == nil {
+				frame.Meta = &data.FrameMeta{}
+			}
+			frame.Meta.Notices = append(frame.Meta.Notices, notice)
+		}
+		result.Responses[q.RefID] = res
+	}
+}
+
+// fetchDocumentPages fetches the hits of the paged document queries, using
+// search_after with the sort values of the last hit of the previous page.
+func (e *timeSeriesQuery) fetchDocumentPages(queries []*Query, pagedReqs map[int]*es.SearchRequest, res *es.MultiSearchResponse) error {
+	for i, searchReq := range pagedReqs {
+		if i >= len(res.Responses) || res.Responses[i].Error != nil {
+			continue
+		}
+
+		searchRes, err := e.fetchDocumentsInPointInTime(searchReq, documentQueryLimit(queries[i].Metrics[0]))
+		if err != nil {
+			return err
+		}
+		res.Responses[i] = searchRes
+	}
+
+	return nil
+}
+
+func (e *timeSeriesQuery) fetchDocumentsInPointInTime(searchReq *es.SearchRequest, limit int) (*es.SearchResponse, error) {
+	pit, err := e.client.OpenPointInTime()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := e.client.ClosePointInTime(pit); err != nil {
+			eslog.Warn("Failed to close point in time", "error", err)
+		}
+	}()
+
+	hits := make([]map[string]interface{}, 0)
+	searchAfter := searchReq.SearchAfter
+	for len(hits) < limit {
+		pageSize := limit - len(hits)
+		if pageSize > maxDocumentPageSize {
+			pageSize = maxDocumentPageSize
+		}
+
+		page, err := e.client.ExecuteSearch(searchReq.WithSearchAfter(pageSize, searchAfter).WithPointInTime(pit))
+		if err != nil {
+			return nil, err
+		}
+		if page.Error != nil {
+			return page, nil
+		}
+		// the id of a point in time may change between searches
+		if page.PitID != "" {
+			pit = &es.PointInTime{ID: page.PitID, KeepAlive: pit.KeepAlive}
+		}
+		if page.Hits == nil || len(page.Hits.Hits) == 0 {
+			break
+		}
+
+		hits = append(hits, page.Hits.Hits...)
+		if len(page.Hits.Hits) < pageSize {
+			break
+		}
+
+		sortValues, ok := page.Hits.Hits[len(page.Hits.Hits)-1]["sort"].([]interface{})
+		if !ok || len(sortValues) == 0 {
+			break
+		}
+		searchAfter = sortValues
+	}
+
+	return &es.SearchResponse{Hits: &es.SearchResponseHits{Hits: hits}}, nil
+}
+
+const (
+	defaultDocumentLimit = 500
+	// maxDocumentPageSize is the default index.max_result_window of Elasticsearch
+	maxDocumentPageSize = 10000
+)
+
+// processDocumentQuery builds the search request of a raw_document, raw_data or
+// logs query. Hits are sorted by time, newest first, with a tiebreaker so that
+// they can be paged with search_after.
+func processDocumentQuery(q *Query, b *es.SearchRequestBuilder, timeField string) {
+	metric := q.Metrics[0]
+
+	size := documentQueryLimit(metric)
+	if size > maxDocumentPageSize {
+		size = maxDocumentPageSize
+	}
+
+	b.Size(size)
+	b.SortDesc(timeField, "boolean")
+	b.SortDescTiebreaker()
+	b.AddDocValueField(timeField)
+
+	if searchAfter := metric.Settings.Get("searchAfter").MustArray(); len(searchAfter) > 0 {
+		b.SearchAfter(searchAfter)
+	}
+}
+
+// documentQueryLimit returns how many documents a document query asks for. The
+// frontend stores the setting as a string, so numbers in strings are accepted.
+func documentQueryLimit(metric *MetricAgg) int {
+	key := "size"
+	if metric.Type == logsType {
+		key = "limit"
+	}
+
+	setting := metric.Settings.Get(key)
+	if limit, err := setting.Int(); err == nil && limit > 0 {
+		return limit
+	}
+	if limit, err := strconv.Atoi(setting.MustString()); err == nil && limit > 0 {
+		return limit
+	}
+
+	return defaultDocumentLimit
 }
 
 func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilder, from, to int64,
@@ -80,16 +271,13 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 	}
 
 	if len(q.BucketAggs) == 0 {
-		if len(q.Metrics) == 0 || q.Metrics[0].Type != "raw_document" {
+		if !q.isDocumentQuery() {
 			result.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("invalid query, missing metrics and aggregations"),
 			}
 			return nil
 		}
-		metric := q.Metrics[0]
-		b.Size(metric.Settings.Get("size").MustInt(500))
-		b.SortDesc("@timestamp", "boolean")
-		b.AddDocValueField("@timestamp")
+		processDocumentQuery(q, b, e.client.GetTimeField())
 		return nil
 	}
 
@@ -2,11 +2,13 @@ package elasticsearch
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	"github.com/stretchr/testify/assert"
@@ -377,6 +379,88 @@ func TestExecuteTimeSeriesQuery(t *testing.T) {
 			require.Equal(t, sr.Size, 1337)
 		})
 
+		t.Run("With logs metric", func(t *testing.T) {
+			c := newFakeClient("7.10.0")
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "logs", "settings": { "limit": "1000", "searchAfter": [1526406600000, 7] } }]
+			}`, from, to, 15*time.Second)
+			require.NoError(t, err)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			require.Equal(t, 1000, sr.Size)
+			require.Contains(t, sr.Sort, "@timestamp")
+			require.Contains(t, sr.Sort, "_doc")
+			require.Len(t, sr.SearchAfter, 2)
+			require.Equal(t, []string{"@timestamp"}, sr.CustomProps["docvalue_fields"])
+		})
+
+		t.Run("With raw data metric larger than a page", func(t *testing.T) {
+			hits := make([]map[string]interface{}, maxDocumentPageSize)
+			for i := range hits {
+				hits[i] = map[string]interface{}{
+					"_id":     fmt.Sprint(i),
+					"_source": map[string]interface{}{"message": "line"},
+					"sort":    []interface{}{float64(1526406600000 - i), float64(i)},
+				}
+			}
+
+			c := newFakeClient("7.10.0")
+			c.searchResponses = []*es.SearchResponse{
+				{Hits: &es.SearchResponseHits{Hits: hits}, PitID: "pit-2"},
+				{Hits: &es.SearchResponseHits{Hits: []map[string]interface{}{
+					{"_id": "last", "_source": map[string]interface{}{"message": "line"}},
+				}}},
+			}
+			c.multiSearchResponse = &es.MultiSearchResponse{Responses: []*es.SearchResponse{{}}}
+			result, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "raw_data", "settings": { "size": 10002 } }]
+			}`, from, to, 15*time.Second)
+			require.NoError(t, err)
+
+			require.Len(t, c.multisearchRequests, 1)
+			require.Equal(t, 0, c.multisearchRequests[0].Requests[0].Size)
+
+			require.Len(t, c.searchRequests, 2)
+			first := c.searchRequests[0]
+			require.Equal(t, maxDocumentPageSize, first.Size)
+			require.Equal(t, "pit-1", first.PointInTime.ID)
+			require.Contains(t, first.Sort, "_shard_doc")
+			require.NotContains(t, first.Sort, "_doc")
+			next := c.searchRequests[1]
+			require.Equal(t, 2, next.Size)
+			require.Equal(t, "pit-2", next.PointInTime.ID)
+			require.Equal(t, hits[len(hits)-1]["sort"], next.SearchAfter)
+			require.Equal(t, []string{"pit-2"}, c.closedPointsInTime)
+
+			frames := result.Responses[""].Frames
+			require.Len(t, frames, 1)
+			require.Equal(t, maxDocumentPageSize+1, frames[0].Rows())
+		})
+
+		t.Run("With raw data metric larger than a page without point in time support", func(t *testing.T) {
+			c := newFakeClient("7.9.0")
+			result, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "raw_data", "settings": { "size": 10002 } }]
+			}`, from, to, 15*time.Second)
+			require.NoError(t, err)
+
+			require.Len(t, c.multisearchRequests, 1)
+			require.Equal(t, maxDocumentPageSize, c.multisearchRequests[0].Requests[0].Size)
+			require.Empty(t, c.searchRequests)
+
+			frames := result.Responses[""].Frames
+			require.NotEmpty(t, frames)
+			require.Len(t, frames[0].Meta.Notices, 1)
+			require.Equal(t, data.NoticeSeverityWarning, frames[0].Meta.Notices[0].Severity)
+			require.Contains(t, frames[0].Meta.Notices[0].Text, "requires Elasticsearch 7.10 or later")
+		})
+
 		t.Run("With date histogram agg", func(t *testing.T) {
 			c := newFakeClient("5.0.0")
 			_, err := executeTsdbQuery(c, `{
@@ -1190,6 +1274,9 @@ type fakeClient struct {
 	multiSearchError    error
 	builder             *es.MultiSearchRequestBuilder
 	multisearchRequests []*es.MultiSearchRequest
+	searchResponses     []*es.SearchResponse
+	searchRequests      []*es.SearchRequest
+	closedPointsInTime  []string
 }
 
 func newFakeClient(versionString string) *fakeClient {
@@ -1212,6 +1299,10 @@ func (c *fakeClient) GetTimeField() string {
 	return c.timeField
 }
 
+func (c *fakeClient) GetConfiguredFields() es.ConfiguredFields {
+	return es.ConfiguredFields{TimeField: c.timeField}
+}
+
 func (c *fakeClient) GetMinInterval(queryInterval string) (time.Duration, error) {
 	return 15 * time.Second, nil
 }
@@ -1226,6 +1317,26 @@ func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	return c.builder
 }
 
+func (c *fakeClient) ExecuteSearch(r *es.SearchRequest) (*es.SearchResponse, error) {
+	c.searchRequests = append(c.searchRequests, r)
+	page := c.searchResponses[0]
+	c.searchResponses = c.searchResponses[1:]
+	return page, nil
+}
+
+func (c *fakeClient) SupportsPointInTime() bool {
+	return !c.version.LessThan(semver.MustParse("7.10.0"))
+}
+
+func (c *fakeClient) OpenPointInTime() (*es.PointInTime, error) {
+	return &es.PointInTime{ID: "pit-1", KeepAlive: "1m"}, nil
+}
+
+func (c *fakeClient) ClosePointInTime(pit *es.PointInTime) error {
+	c.closedPointsInTime = append(c.closedPointsInTime, pit.ID)
+	return nil
+}
+
 func newDataQuery(body string) (backend.QueryDataRequest, error) {
 	return backend.QueryDataRequest{
 		Queries: []backend.DataQuery{
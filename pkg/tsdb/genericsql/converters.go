@@ -0,0 +1,120 @@
+package genericsql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+type genericSQLQueryResultTransformer struct {
+	driver string
+	log    log.Logger
+}
+
+func (t *genericSQLQueryResultTransformer) TransformQueryError(err error) error {
+	return err
+}
+
+func (t *genericSQLQueryResultTransformer) GetConverterList() []sqlutil.StringConverter {
+	if t.driver == "sqlite3" {
+		return sqliteConverters()
+	}
+	return nil
+}
+
+// sqliteConverters maps SQLite declared column types to data frame fields.
+// The SQLite driver only knows the scan type of a column once a row has been
+// read, so every column is scanned as a string and converted based on its
+// declared type. Expressions have no declared type and are read as numbers.
+func sqliteConverters() []sqlutil.StringConverter {
+	types := []struct {
+		names     []string
+		fieldType data.FieldType
+		replace   func(in *string) (interface{}, error)
+	}{
+		{[]string{"INTEGER", "INT", "BIGINT", "SMALLINT", "TINYINT"}, data.FieldTypeNullableInt64, parseInt},
+		{[]string{"REAL", "DOUBLE", "FLOAT", "NUMERIC", "DECIMAL", ""}, data.FieldTypeNullableFloat64, parseFloat},
+		{[]string{"TEXT", "VARCHAR", "CHAR", "CLOB"}, data.FieldTypeNullableString, nil},
+		{[]string{"DATETIME", "TIMESTAMP", "DATE"}, data.FieldTypeNullableTime, parseTime},
+	}
+
+	var converters []sqlutil.StringConverter
+	for _, t := range types {
+		for _, name := range t.names {
+			converters = append(converters, sqliteConverter(name, t.fieldType, t.replace))
+			// Declared types keep the case they were written with.
+			if lower := strings.ToLower(name); lower != name {
+				converters = append(converters, sqliteConverter(lower, t.fieldType, t.replace))
+			}
+		}
+	}
+	return converters
+}
+
+func sqliteConverter(typeName string, fieldType data.FieldType, replace func(in *string) (interface{}, error)) sqlutil.StringConverter {
+	if replace == nil {
+		replace = func(in *string) (interface{}, error) {
+			return in, nil
+		}
+	}
+	return sqlutil.StringConverter{
+		Name:          "handle " + typeName,
+		InputScanKind: reflect.Interface,
+		InputTypeName: typeName,
+		Replacer: &sqlutil.StringFieldReplacer{
+			OutputFieldType: fieldType,
+			ReplaceFunc: func(in *string) (interface{}, error) {
+				if in == nil {
+					return nil, nil
+				}
+				return replace(in)
+			},
+		},
+	}
+}
+
+func parseInt(in *string) (interface{}, error) {
+	v, err := strconv.ParseInt(*in, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func parseFloat(in *string) (interface{}, error) {
+	v, err := strconv.ParseFloat(*in, 64)
+	if err != nil {
+		return nil, fmt.Errorf("value %q is not numeric", *in)
+	}
+	return &v, nil
+}
+
+// sqliteTimeFormats are the layouts the SQLite driver reads and writes
+// timestamps in.
+var sqliteTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func parseTime(in *string) (interface{}, error) {
+	for _, layout := range sqliteTimeFormats {
+		if v, err := time.ParseInLocation(layout, *in, time.UTC); err == nil {
+			return &v, nil
+		}
+	}
+	return nil, fmt.Errorf("value %q is not a timestamp", *in)
+}
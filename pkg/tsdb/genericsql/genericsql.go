@@ -0,0 +1,182 @@
+package genericsql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/grafana/grafana/pkg/util"
+	"xorm.io/core"
+)
+
+const pluginID = "genericsql"
+
+var logger = log.New("tsdb.genericsql")
+
+type Service struct {
+	im instancemgmt.InstanceManager
+}
+
+func ProvideService(cfg *setting.Cfg) *Service {
+	return &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(cfg)),
+	}
+}
+
+func (s *Service) getDataSourceHandler(pluginCtx backend.PluginContext) (*sqleng.DataSourceHandler, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	instance := i.(*sqleng.DataSourceHandler)
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsHandler, err := s.getDataSourceHandler(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	return dsHandler.QueryData(ctx, req)
+}
+
+// CheckHealth runs a trivial query against the configured database.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsHandler, err := s.getDataSourceHandler(req.PluginContext)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+
+	res, err := dsHandler.QueryData(ctx, &backend.QueryDataRequest{
+		PluginContext: req.PluginContext,
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"rawSql":"SELECT 1","format":"table"}`)},
+		},
+	})
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+	if qErr := res.Responses["A"].Error; qErr != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: qErr.Error(),
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: "Database Connection OK",
+	}, nil
+}
+
+// jsonData holds the settings specific to the generic SQL data source, on top
+// of the sqleng connection pool and query settings.
+type jsonData struct {
+	Driver  string `json:"driver"`
+	Dialect string `json:"dialect"`
+}
+
+func newInstanceSettings(cfg *setting.Cfg) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		sqlJsonData := sqleng.JsonData{
+			MaxOpenConns:    0,
+			MaxIdleConns:    2,
+			ConnMaxLifetime: 14400,
+		}
+		if err := json.Unmarshal(settings.JSONData, &sqlJsonData); err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+		model := jsonData{Dialect: dialectANSI}
+		if err := json.Unmarshal(settings.JSONData, &model); err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+
+		if err := validateDriver(model.Driver, allowedDrivers(cfg)); err != nil {
+			return nil, err
+		}
+		dialect, ok := dialects[model.Dialect]
+		if !ok {
+			return nil, fmt.Errorf("unsupported macro dialect %q", model.Dialect)
+		}
+
+		cnnstr := settings.DecryptedSecureJSONData["connectionString"]
+		if cnnstr == "" {
+			cnnstr = settings.URL
+		}
+		if cnnstr == "" {
+			return nil, errors.New("no connection string configured")
+		}
+
+		dsInfo := sqleng.DataSourceInfo{
+			JsonData:                sqlJsonData,
+			URL:                     settings.URL,
+			User:                    settings.User,
+			Database:                settings.Database,
+			ID:                      settings.ID,
+			Updated:                 settings.Updated,
+			UID:                     settings.UID,
+			DecryptedSecureJSONData: settings.DecryptedSecureJSONData,
+		}
+
+		config := sqleng.DataPluginConfiguration{
+			DriverName:        model.Driver,
+			ConnectionString:  cnnstr,
+			DSInfo:            dsInfo,
+			MetricColumnTypes: []string{"CHAR", "VARCHAR", "TEXT", "STRING", "NCHAR", "NVARCHAR", "BPCHAR", "char", "varchar", "text"},
+			RowLimit:          cfg.DataProxyRowLimit,
+		}
+
+		queryResultTransformer := genericSQLQueryResultTransformer{
+			driver: model.Driver,
+			log:    logger,
+		}
+
+		return sqleng.NewQueryDataHandler(config, &queryResultTransformer, newGenericSQLMacroEngine(dialect), logger)
+	}
+}
+
+// allowedDrivers returns the database/sql drivers the server administrator
+// allows data sources to use, from the allowed_drivers key of [plugin.genericsql].
+func allowedDrivers(cfg *setting.Cfg) []string {
+	return util.SplitString(cfg.PluginSettings[pluginID]["allowed_drivers"])
+}
+
+// validateDriver checks that driver is allowed and registered with both
+// database/sql and xorm, which sqleng uses for connection pooling.
+func validateDriver(driver string, allowed []string) error {
+	if driver == "" {
+		return errors.New("no driver configured")
+	}
+
+	isAllowed := false
+	for _, d := range allowed {
+		if d == driver {
+			isAllowed = true
+			break
+		}
+	}
+	if !isAllowed {
+		return fmt.Errorf("driver %q is not allowed, add it to allowed_drivers in the [plugin.%s] section of the configuration", driver, pluginID)
+	}
+
+	for _, d := range sql.Drivers() {
+		if d == driver && core.QueryDriver(driver) != nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("driver %q is not registered", driver)
+}
@@ -0,0 +1,144 @@
+package genericsql
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDriver(t *testing.T) {
+	t.Run("Should fail without a driver", func(t *testing.T) {
+		err := validateDriver("", []string{"sqlite3"})
+		require.EqualError(t, err, "no driver configured")
+	})
+
+	t.Run("Should fail when driver is not allowed", func(t *testing.T) {
+		err := validateDriver("sqlite3", []string{"mysql"})
+		require.EqualError(t, err, `driver "sqlite3" is not allowed, add it to allowed_drivers in the [plugin.genericsql] section of the configuration`)
+	})
+
+	t.Run("Should fail when driver is not registered", func(t *testing.T) {
+		err := validateDriver("nosuchdriver", []string{"nosuchdriver"})
+		require.EqualError(t, err, `driver "nosuchdriver" is not registered`)
+	})
+
+	t.Run("Should accept an allowed and registered driver", func(t *testing.T) {
+		err := validateDriver("sqlite3", []string{"mysql", "sqlite3"})
+		require.NoError(t, err)
+	})
+}
+
+func TestGenericSQL(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	_, err = db.Exec("CREATE TABLE metrics (time DATETIME, host TEXT, value REAL, count INTEGER)")
+	require.NoError(t, err)
+
+	from := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		ts := from.Add(time.Duration(i) * time.Minute).Format("2006-01-02 15:04:05")
+		_, err = db.Exec("INSERT INTO metrics VALUES (?, 'a', ?, ?), (?, 'b', ?, ?)", ts, float64(i)+0.5, i, ts, float64(i)*2, i*2)
+		require.NoError(t, err)
+	}
+
+	cfg := setting.NewCfg()
+	cfg.DataProxyRowLimit = 1000
+	cfg.PluginSettings = setting.PluginSettings{pluginID: {"allowed_drivers": "sqlite3"}}
+
+	dsSettings := backend.DataSourceInstanceSettings{
+		JSONData:                []byte(`{"driver":"sqlite3","dialect":"sqlite"}`),
+		DecryptedSecureJSONData: map[string]string{"connectionString": dbPath},
+	}
+	instance, err := newInstanceSettings(cfg)(dsSettings)
+	require.NoError(t, err)
+	handler := instance.(*sqleng.DataSourceHandler)
+	t.Cleanup(handler.Dispose)
+
+	timeRange := backend.TimeRange{From: from, To: from.Add(5 * time.Minute)}
+
+	t.Run("Should return table data", func(t *testing.T) {
+		resp, err := handler.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: timeRange,
+					JSON:      []byte(`{"rawSql":"SELECT time, host, value, count FROM metrics WHERE host = 'a' AND $__timeFilter(time) ORDER BY time","format":"table"}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		queryResult := resp.Responses["A"]
+		require.NoError(t, queryResult.Error)
+
+		frame := queryResult.Frames[0]
+		require.Equal(t, 6, frame.Rows())
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		require.Equal(t, from, *frame.Fields[0].At(0).(*time.Time))
+		require.Equal(t, "a", *frame.Fields[1].At(0).(*string))
+		require.Equal(t, 0.5, *frame.Fields[2].At(0).(*float64))
+		require.Equal(t, int64(5), *frame.Fields[3].At(5).(*int64))
+	})
+
+	t.Run("Should return time series grouped by interval", func(t *testing.T) {
+		resp, err := handler.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					RefID:     "A",
+					TimeRange: timeRange,
+					JSON: []byte(`{"rawSql":"SELECT $__timeGroupAlias(time,'2m'), host AS metric, SUM(count) AS value ` +
+						`FROM metrics WHERE $__timeFilter(time) GROUP BY 1, 2 ORDER BY 1","format":"time_series"}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		queryResult := resp.Responses["A"]
+		require.NoError(t, queryResult.Error)
+
+		frames := queryResult.Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, 3, frames[0].Rows())
+		require.True(t, from.Equal(frames[0].Fields[0].At(0).(time.Time)))
+		require.True(t, from.Add(4*time.Minute).Equal(frames[0].Fields[0].At(2).(time.Time)))
+		require.Equal(t, "a", frames[0].Fields[1].Name)
+		require.Equal(t, float64(9), *frames[0].Fields[1].At(2).(*float64))
+		require.Equal(t, "b", frames[0].Fields[2].Name)
+		require.Equal(t, float64(18), *frames[0].Fields[2].At(2).(*float64))
+	})
+
+	t.Run("Should fail with a disallowed driver", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		_, err := newInstanceSettings(cfg)(dsSettings)
+		require.Error(t, err)
+	})
+
+	t.Run("Should fail with an unknown dialect", func(t *testing.T) {
+		_, err := newInstanceSettings(cfg)(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"driver":"sqlite3","dialect":"oracle"}`),
+			URL:      dbPath,
+		})
+		require.EqualError(t, err, `unsupported macro dialect "oracle"`)
+	})
+
+	t.Run("Should report a healthy database", func(t *testing.T) {
+		s := ProvideService(cfg)
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &dsSettings},
+		})
+		require.NoError(t, err)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+	})
+}
@@ -0,0 +1,210 @@
+package genericsql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+const rsIdentifier = `([_a-zA-Z0-9]+)`
+const sExpr = `\$` + rsIdentifier + `\(([^\)]*)\)`
+
+// Macro dialects supported by the generic SQL data source.
+const (
+	dialectANSI       = "ansi"
+	dialectSQLite     = "sqlite"
+	dialectClickHouse = "clickhouse"
+)
+
+// sqlDialect describes how the time macros are expressed in a SQL dialect.
+type sqlDialect struct {
+	// epoch converts a time column to seconds since the Unix epoch.
+	epoch func(column string) string
+	// timeFilter restricts a time column to the given range.
+	timeFilter func(column string, from, to time.Time) string
+	// timeLiteral renders a point in time as a literal comparable with time columns.
+	timeLiteral func(t time.Time) string
+	// group rounds an epoch expression in seconds down to a multiple of interval.
+	group func(expr string, interval time.Duration) string
+}
+
+var dialects = map[string]sqlDialect{
+	dialectANSI: {
+		epoch: func(column string) string {
+			return fmt.Sprintf("EXTRACT(EPOCH FROM %s)", column)
+		},
+		timeFilter: func(column string, from, to time.Time) string {
+			return fmt.Sprintf("%s BETWEEN %s AND %s", column, ansiTimestamp(from), ansiTimestamp(to))
+		},
+		timeLiteral: ansiTimestamp,
+		group: func(expr string, interval time.Duration) string {
+			return fmt.Sprintf("FLOOR(%s/%v)*%v", expr, interval.Seconds(), interval.Seconds())
+		},
+	},
+	dialectSQLite: {
+		epoch: func(column string) string {
+			return fmt.Sprintf("CAST(strftime('%%s', %s) AS INTEGER)", column)
+		},
+		timeFilter: func(column string, from, to time.Time) string {
+			return fmt.Sprintf("CAST(strftime('%%s', %s) AS INTEGER) BETWEEN %d AND %d", column, from.UTC().Unix(), to.UTC().Unix())
+		},
+		timeLiteral: func(t time.Time) string {
+			return fmt.Sprintf("'%s'", t.UTC().Format("2006-01-02 15:04:05"))
+		},
+		// SQLite has no FLOOR unless built with math functions, integer
+		// division truncates instead.
+		group: func(expr string, interval time.Duration) string {
+			seconds := int64(interval.Seconds())
+			return fmt.Sprintf("(CAST(%s AS INTEGER)/%d)*%d", expr, seconds, seconds)
+		},
+	},
+	dialectClickHouse: {
+		epoch: func(column string) string {
+			return fmt.Sprintf("toUnixTimestamp(%s)", column)
+		},
+		timeFilter: func(column string, from, to time.Time) string {
+			return fmt.Sprintf("%s BETWEEN toDateTime(%d) AND toDateTime(%d)", column, from.UTC().Unix(), to.UTC().Unix())
+		},
+		timeLiteral: func(t time.Time) string {
+			return fmt.Sprintf("toDateTime(%d)", t.UTC().Unix())
+		},
+		group: func(expr string, interval time.Duration) string {
+			seconds := int64(interval.Seconds())
+			return fmt.Sprintf("intDiv(%s, %d)*%d", expr, seconds, seconds)
+		},
+	},
+}
+
+func ansiTimestamp(t time.Time) string {
+	return fmt.Sprintf("TIMESTAMP '%s'", t.UTC().Format("2006-01-02 15:04:05"))
+}
+
+type genericSQLMacroEngine struct {
+	*sqleng.SQLMacroEngineBase
+	dialect sqlDialect
+}
+
+func newGenericSQLMacroEngine(dialect sqlDialect) sqleng.SQLMacroEngine {
+	return &genericSQLMacroEngine{
+		SQLMacroEngineBase: sqleng.NewSQLMacroEngineBase(),
+		dialect:            dialect,
+	}
+}
+
+func (m *genericSQLMacroEngine) Interpolate(query *backend.DataQuery, timeRange backend.TimeRange, sql string) (string, error) {
+	rExp, err := regexp.Compile(sExpr)
+	if err != nil {
+		return "", err
+	}
+	var macroError error
+
+	sql = m.ReplaceAllStringSubmatchFunc(rExp, sql, func(groups []string) string {
+		args := strings.Split(groups[2], ",")
+		for i, arg := range args {
+			args[i] = strings.Trim(arg, " ")
+		}
+		res, err := m.evaluateMacro(timeRange, query, groups[1], args)
+		if err != nil && macroError == nil {
+			macroError = err
+			return "macro_error()"
+		}
+		return res
+	})
+
+	if macroError != nil {
+		return "", macroError
+	}
+
+	return sql, nil
+}
+
+func (m *genericSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *backend.DataQuery, name string, args []string) (string, error) {
+	switch name {
+	case "__time":
+		if len(args) == 0 {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s AS time", args[0]), nil
+	case "__timeEpoch":
+		if len(args) == 0 {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s AS time", m.dialect.epoch(args[0])), nil
+	case "__timeFilter":
+		if len(args) == 0 {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return m.dialect.timeFilter(args[0], timeRange.From, timeRange.To), nil
+	case "__timeFrom":
+		return m.dialect.timeLiteral(timeRange.From), nil
+	case "__timeTo":
+		return m.dialect.timeLiteral(timeRange.To), nil
+	case "__timeGroup":
+		if len(args) < 2 {
+			return "", fmt.Errorf("macro %v needs time column and interval and optional fill value", name)
+		}
+		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'"`))
+		if err != nil {
+			return "", fmt.Errorf("error parsing interval %v", args[1])
+		}
+		if len(args) == 3 {
+			err := sqleng.SetupFillmode(query, interval, args[2])
+			if err != nil {
+				return "", err
+			}
+		}
+		return m.dialect.group(m.dialect.epoch(args[0]), interval), nil
+	case "__timeGroupAlias":
+		tg, err := m.evaluateMacro(timeRange, query, "__timeGroup", args)
+		if err == nil {
+			return tg + " AS time", nil
+		}
+		return "", err
+	case "__unixEpochFilter":
+		if len(args) == 0 {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s >= %d AND %s <= %d", args[0], timeRange.From.UTC().Unix(), args[0], timeRange.To.UTC().Unix()), nil
+	case "__unixEpochFrom":
+		return fmt.Sprintf("%d", timeRange.From.UTC().Unix()), nil
+	case "__unixEpochTo":
+		return fmt.Sprintf("%d", timeRange.To.UTC().Unix()), nil
+	case "__unixEpochNanoFilter":
+		if len(args) == 0 {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s >= %d AND %s <= %d", args[0], timeRange.From.UTC().UnixNano(), args[0], timeRange.To.UTC().UnixNano()), nil
+	case "__unixEpochNanoFrom":
+		return fmt.Sprintf("%d", timeRange.From.UTC().UnixNano()), nil
+	case "__unixEpochNanoTo":
+		return fmt.Sprintf("%d", timeRange.To.UTC().UnixNano()), nil
+	case "__unixEpochGroup":
+		if len(args) < 2 {
+			return "", fmt.Errorf("macro %v needs time column and interval and optional fill value", name)
+		}
+		interval, err := gtime.ParseInterval(strings.Trim(args[1], `'`))
+		if err != nil {
+			return "", fmt.Errorf("error parsing interval %v", args[1])
+		}
+		if len(args) == 3 {
+			err := sqleng.SetupFillmode(query, interval, args[2])
+			if err != nil {
+				return "", err
+			}
+		}
+		return m.dialect.group(args[0], interval), nil
+	case "__unixEpochGroupAlias":
+		tg, err := m.evaluateMacro(timeRange, query, "__unixEpochGroup", args)
+		if err == nil {
+			return tg + " AS time", nil
+		}
+		return "", err
+	default:
+		return "", fmt.Errorf("unknown macro %q", name)
+	}
+}
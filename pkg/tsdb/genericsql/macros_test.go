@@ -0,0 +1,65 @@
+package genericsql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroEngine(t *testing.T) {
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := backend.TimeRange{From: from, To: to}
+
+	tests := []struct {
+		dialect  string
+		sql      string
+		expected string
+	}{
+		{dialectANSI, "select $__time(time_column)", "select time_column AS time"},
+		{dialectANSI, "select $__timeEpoch(time_column)", "select EXTRACT(EPOCH FROM time_column) AS time"},
+		{dialectANSI, "WHERE $__timeFilter(time_column)", "WHERE time_column BETWEEN TIMESTAMP '2018-04-12 18:00:00' AND TIMESTAMP '2018-04-12 18:05:00'"},
+		{dialectANSI, "select $__timeFrom(), $__timeTo()", "select TIMESTAMP '2018-04-12 18:00:00', TIMESTAMP '2018-04-12 18:05:00'"},
+		{dialectANSI, "GROUP BY $__timeGroup(time_column,'5m')", "GROUP BY FLOOR(EXTRACT(EPOCH FROM time_column)/300)*300"},
+		{dialectANSI, "select $__timeGroupAlias(time_column,'5m')", "select FLOOR(EXTRACT(EPOCH FROM time_column)/300)*300 AS time"},
+		{dialectANSI, "select $__unixEpochGroupAlias(time_column,'5m')", "select FLOOR(time_column/300)*300 AS time"},
+		{dialectANSI, "WHERE $__unixEpochFilter(time)", "WHERE time >= 1523556000 AND time <= 1523556300"},
+		{dialectSQLite, "select $__timeEpoch(time_column)", "select CAST(strftime('%s', time_column) AS INTEGER) AS time"},
+		{dialectSQLite, "WHERE $__timeFilter(time_column)", "WHERE CAST(strftime('%s', time_column) AS INTEGER) BETWEEN 1523556000 AND 1523556300"},
+		{dialectSQLite, "select $__timeFrom()", "select '2018-04-12 18:00:00'"},
+		{dialectSQLite, "GROUP BY $__timeGroup(time_column,'5m')", "GROUP BY (CAST(CAST(strftime('%s', time_column) AS INTEGER) AS INTEGER)/300)*300"},
+		{dialectSQLite, "select $__unixEpochGroup(time_column,'1h')", "select (CAST(time_column AS INTEGER)/3600)*3600"},
+		{dialectClickHouse, "select $__timeEpoch(time_column)", "select toUnixTimestamp(time_column) AS time"},
+		{dialectClickHouse, "WHERE $__timeFilter(time_column)", "WHERE time_column BETWEEN toDateTime(1523556000) AND toDateTime(1523556300)"},
+		{dialectClickHouse, "select $__timeTo()", "select toDateTime(1523556300)"},
+		{dialectClickHouse, "GROUP BY $__timeGroup(time_column,'5m')", "GROUP BY intDiv(toUnixTimestamp(time_column), 300)*300"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.sql, func(t *testing.T) {
+			engine := newGenericSQLMacroEngine(dialects[tt.dialect])
+			query := &backend.DataQuery{JSON: []byte("{}")}
+
+			sql, err := engine.Interpolate(query, timeRange, tt.sql)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, sql)
+		})
+	}
+
+	t.Run("unknown macro", func(t *testing.T) {
+		engine := newGenericSQLMacroEngine(dialects[dialectANSI])
+		_, err := engine.Interpolate(&backend.DataQuery{JSON: []byte("{}")}, timeRange, "select $__unknown(col)")
+		require.EqualError(t, err, `unknown macro "__unknown"`)
+	})
+
+	t.Run("time group with fill", func(t *testing.T) {
+		engine := newGenericSQLMacroEngine(dialects[dialectSQLite])
+		query := &backend.DataQuery{JSON: []byte("{}")}
+
+		_, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column,'5m', NULL)")
+		require.NoError(t, err)
+		require.Contains(t, string(query.JSON), `"fillMode":"null"`)
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -27,26 +28,53 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/setting"
-	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 )
 
+var logger = log.New("tsdb.graphite")
+
 type Service struct {
-	logger log.Logger
-	im     instancemgmt.InstanceManager
-	tracer tracing.Tracer
+	logger          log.Logger
+	im              instancemgmt.InstanceManager
+	tracer          tracing.Tracer
+	resourceHandler backend.CallResourceHandler
 }
 
 const (
-	TargetFullModelField = "targetFull"
-	TargetModelField     = "target"
+	TargetFullModelField    = "targetFull"
+	TargetModelField        = "target"
+	ConsolidateByModelField = "consolidateBy"
+
+	// defaultMaxDataPoints is used for queries that don't set maxDataPoints,
+	// such as alert rules.
+	defaultMaxDataPoints int64 = 500
 )
 
+// consolidationFunctions are the functions Graphite accepts in consolidateBy.
+var consolidationFunctions = map[string]bool{
+	"average": true,
+	"avg":     true,
+	"median":  true,
+	"sum":     true,
+	"min":     true,
+	"max":     true,
+	"first":   true,
+	"last":    true,
+}
+
 func ProvideService(httpClientProvider httpclient.Provider, tracer tracing.Tracer) *Service {
-	return &Service{
-		logger: log.New("tsdb.graphite"),
+	s := &Service{
+		logger: logger,
 		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
 		tracer: tracer,
 	}
+
+	s.resourceHandler = httpadapter.New(s.newResourceMux())
+
+	return s
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return s.resourceHandler.CallResource(ctx, req, sender)
 }
 
 type datasourceInfo struct {
@@ -97,91 +125,130 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		return nil, err
 	}
 
-	// take the first query in the request list, since all query should share the same timerange
-	q := req.Queries[0]
-
-	/*
-		graphite doc about from and until, with sdk we are getting absolute instead of relative time
-		https://graphite-api.readthedocs.io/en/latest/api.html#from-until
-	*/
-	from, until := epochMStoGraphiteTime(q.TimeRange)
-	formData := url.Values{
-		"from":          []string{from},
-		"until":         []string{until},
-		"format":        []string{"json"},
-		"maxDataPoints": []string{"500"},
-	}
-
-	// Calculate and get the last target of Graphite Request
-	var target string
+	queries := make([]graphiteQuery, 0, len(req.Queries))
 	emptyQueries := make([]string, 0)
 	for _, query := range req.Queries {
-		model, err := simplejson.NewJson(query.JSON)
+		q, err := parseQuery(query)
 		if err != nil {
 			return nil, err
 		}
-		s.logger.Debug("graphite", "query", model)
-		currTarget := ""
-		if fullTarget, err := model.Get(TargetFullModelField).String(); err == nil {
-			currTarget = fullTarget
-		} else {
-			currTarget = model.Get(TargetModelField).MustString()
+		if q.hide {
+			continue
 		}
-		if currTarget == "" {
-			s.logger.Debug("graphite", "empty query target", model)
-			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", model))
+		if q.target == "" {
+			s.logger.Debug("graphite", "empty query target", string(query.JSON))
+			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", string(query.JSON)))
 			continue
 		}
-		target = fixIntervalFormat(currTarget)
+		queries = append(queries, q)
 	}
 
 	var result = backend.QueryDataResponse{}
 
-	if target == "" {
+	if len(queries) == 0 {
 		s.logger.Error("No targets in query model", "models without targets", strings.Join(emptyQueries, "\n"))
 		return &result, errors.New("no query target found for the alert rule")
 	}
 
-	formData["target"] = []string{target}
+	result.Responses = make(backend.Responses, len(queries))
+	for _, q := range queries {
+		frames, err := s.executeQuery(ctx, dsInfo, req.PluginContext, q)
+		if err != nil {
+			result.Responses[q.refID] = backend.DataResponse{Error: err}
+			continue
+		}
+		for _, frame := range frames {
+			frame.RefID = q.refID
+			frame.Meta = &data.FrameMeta{ExecutedQueryString: q.target}
+		}
+		result.Responses[q.refID] = backend.DataResponse{Frames: frames}
+	}
+
+	return &result, nil
+}
+
+// executeQuery sends a single render request for q and converts the series
+// Graphite returns to data frames.
+func (s *Service) executeQuery(ctx context.Context, dsInfo *datasourceInfo, pluginCtx backend.PluginContext, q graphiteQuery) (data.Frames, error) {
+	/*
+		graphite doc about from and until, with sdk we are getting absolute instead of relative time
+		https://graphite-api.readthedocs.io/en/latest/api.html#from-until
+	*/
+	from, until := epochMStoGraphiteTime(q.timeRange)
+	formData := url.Values{
+		"from":          []string{from},
+		"until":         []string{until},
+		"format":        []string{"json"},
+		"maxDataPoints": []string{strconv.FormatInt(q.maxDataPoints, 10)},
+		"target":        []string{q.target},
+	}
 
 	if setting.Env == setting.Dev {
 		s.logger.Debug("Graphite request", "params", formData)
 	}
 
-	graphiteReq, err := s.createRequest(dsInfo, formData)
+	graphiteReq, err := s.createRequest(dsInfo, "render", formData)
 	if err != nil {
-		return &result, err
+		return nil, err
 	}
 
 	ctx, span := s.tracer.Start(ctx, "graphite query")
-	span.SetAttributes("target", target, attribute.Key("target").String(target))
+	span.SetAttributes("target", q.target, attribute.Key("target").String(q.target))
 	span.SetAttributes("from", from, attribute.Key("from").String(from))
 	span.SetAttributes("until", until, attribute.Key("until").String(until))
 	span.SetAttributes("datasource_id", dsInfo.Id, attribute.Key("datasource_id").Int64(dsInfo.Id))
-	span.SetAttributes("org_id", req.PluginContext.OrgID, attribute.Key("org_id").Int64(req.PluginContext.OrgID))
+	span.SetAttributes("org_id", pluginCtx.OrgID, attribute.Key("org_id").Int64(pluginCtx.OrgID))
 
 	defer span.End()
 	s.tracer.Inject(ctx, graphiteReq.Header, span)
 
 	res, err := ctxhttp.Do(ctx, dsInfo.HTTPClient, graphiteReq)
 	if err != nil {
-		return &result, err
+		return nil, err
 	}
 
-	frames, err := s.toDataFrames(res)
+	return s.toDataFrames(res)
+}
+
+// parseQuery reads the target of a query, applying the consolidation function
+// and falling back to the default number of data points when not set.
+func parseQuery(query backend.DataQuery) (graphiteQuery, error) {
+	model, err := simplejson.NewJson(query.JSON)
 	if err != nil {
-		return &result, err
+		return graphiteQuery{}, err
 	}
 
-	result = backend.QueryDataResponse{
-		Responses: make(backend.Responses),
+	target := ""
+	if fullTarget, err := model.Get(TargetFullModelField).String(); err == nil {
+		target = fullTarget
+	} else {
+		target = model.Get(TargetModelField).MustString()
 	}
 
-	result.Responses["A"] = backend.DataResponse{
-		Frames: frames,
+	q := graphiteQuery{
+		refID:         query.RefID,
+		hide:          model.Get("hide").MustBool(false),
+		maxDataPoints: query.MaxDataPoints,
+		timeRange:     query.TimeRange,
+	}
+	if q.maxDataPoints <= 0 {
+		q.maxDataPoints = defaultMaxDataPoints
+	}
+	if target == "" {
+		return q, nil
 	}
 
-	return &result, nil
+	q.target = fixIntervalFormat(target)
+	if fn := model.Get(ConsolidateByModelField).MustString(); fn != "" {
+		if !consolidationFunctions[fn] {
+			return graphiteQuery{}, fmt.Errorf("unsupported consolidation function %q", fn)
+		}
+		// A consolidateBy call in the target takes precedence.
+		if !strings.Contains(q.target, "consolidateBy(") {
+			q.target = fmt.Sprintf("consolidateBy(%s, '%s')", q.target, fn)
+		}
+	}
+	return q, nil
 }
 
 func (s *Service) parseResponse(res *http.Response) ([]TargetResponseDTO, error) {
@@ -223,7 +290,7 @@ func (s *Service) toDataFrames(response *http.Response) (frames data.Frames, err
 		name := series.Target
 
 		for _, dataPoint := range series.DataPoints {
-			var timestamp, value, err = parseDataTimePoint(dataPoint)
+			var timestamp, value, err = parseDataPoint(dataPoint)
 			if err != nil {
 				return nil, err
 			}
@@ -238,6 +305,8 @@ func (s *Service) toDataFrames(response *http.Response) (frames data.Frames, err
 				tags[name] = value
 			case float64:
 				tags[name] = strconv.FormatFloat(value, 'f', -1, 64)
+			case bool:
+				tags[name] = strconv.FormatBool(value)
 			}
 		}
 
@@ -252,12 +321,12 @@ func (s *Service) toDataFrames(response *http.Response) (frames data.Frames, err
 	return frames, nil
 }
 
-func (s *Service) createRequest(dsInfo *datasourceInfo, data url.Values) (*http.Request, error) {
+func (s *Service) createRequest(dsInfo *datasourceInfo, endpoint string, data url.Values) (*http.Request, error) {
 	u, err := url.Parse(dsInfo.URL)
 	if err != nil {
 		return nil, err
 	}
-	u.Path = path.Join(u.Path, "render")
+	u.Path = path.Join(u.Path, endpoint)
 
 	req, err := http.NewRequest(http.MethodPost, u.String(), strings.NewReader(data.Encode()))
 	if err != nil {
@@ -285,21 +354,13 @@ func epochMStoGraphiteTime(tr backend.TimeRange) (string, string) {
 	return fmt.Sprintf("%d", tr.From.UTC().Unix()), fmt.Sprintf("%d", tr.To.UTC().Unix())
 }
 
-/**
- * Graphite should always return timestamp as a number but values might be nil when data is missing
- */
-func parseDataTimePoint(dataTimePoint legacydata.DataTimePoint) (time.Time, *float64, error) {
-	if !dataTimePoint[1].Valid {
+// parseDataPoint converts a Graphite [value, timestamp] pair. Graphite should
+// always return the timestamp as a number, but the value is null when data is missing.
+func parseDataPoint(dataPoint dataPoint) (time.Time, *float64, error) {
+	if dataPoint[1] == nil {
 		return time.Time{}, nil, errors.New("failed to parse data point timestamp")
 	}
 
-	timestamp := time.Unix(int64(dataTimePoint[1].Float64), 0).UTC()
-
-	if dataTimePoint[0].Valid {
-		var value = new(float64)
-		*value = dataTimePoint[0].Float64
-		return timestamp, value, nil
-	} else {
-		return timestamp, nil, nil
-	}
+	timestamp := time.Unix(int64(*dataPoint[1]), 0).UTC()
+	return timestamp, dataPoint[0], nil
 }
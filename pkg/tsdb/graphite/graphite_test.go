@@ -1,16 +1,22 @@
 package graphite
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,3 +118,196 @@ func TestFixIntervalFormat(t *testing.T) {
 		}
 	})
 }
+
+func TestParseQuery(t *testing.T) {
+	t.Run("Should use the maxDataPoints of the query", func(t *testing.T) {
+		q, err := parseQuery(backend.DataQuery{RefID: "A", MaxDataPoints: 1200, JSON: []byte(`{"target":"app.cpu"}`)})
+		require.NoError(t, err)
+		require.Equal(t, "A", q.refID)
+		require.Equal(t, "app.cpu", q.target)
+		require.Equal(t, int64(1200), q.maxDataPoints)
+	})
+
+	t.Run("Should fall back to the default maxDataPoints", func(t *testing.T) {
+		q, err := parseQuery(backend.DataQuery{RefID: "A", JSON: []byte(`{"target":"app.cpu"}`)})
+		require.NoError(t, err)
+		require.Equal(t, defaultMaxDataPoints, q.maxDataPoints)
+	})
+
+	t.Run("Should prefer targetFull over target", func(t *testing.T) {
+		q, err := parseQuery(backend.DataQuery{RefID: "B", JSON: []byte(`{"target":"sumSeries(#A)","targetFull":"sumSeries(app.cpu)"}`)})
+		require.NoError(t, err)
+		require.Equal(t, "sumSeries(app.cpu)", q.target)
+	})
+
+	t.Run("Should wrap the target with consolidateBy", func(t *testing.T) {
+		q, err := parseQuery(backend.DataQuery{RefID: "A", JSON: []byte(`{"target":"app.cpu","consolidateBy":"max"}`)})
+		require.NoError(t, err)
+		require.Equal(t, "consolidateBy(app.cpu, 'max')", q.target)
+	})
+
+	t.Run("Should keep consolidateBy from the target", func(t *testing.T) {
+		q, err := parseQuery(backend.DataQuery{RefID: "A", JSON: []byte(`{"target":"consolidateBy(app.cpu, 'sum')","consolidateBy":"max"}`)})
+		require.NoError(t, err)
+		require.Equal(t, "consolidateBy(app.cpu, 'sum')", q.target)
+	})
+
+	t.Run("Should reject an unknown consolidation function", func(t *testing.T) {
+		_, err := parseQuery(backend.DataQuery{RefID: "A", JSON: []byte(`{"target":"app.cpu","consolidateBy":"p99"}`)})
+		require.EqualError(t, err, `unsupported consolidation function "p99"`)
+	})
+}
+
+func TestQueryData(t *testing.T) {
+	var requests []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/render", req.URL.Path)
+		require.NoError(t, req.ParseForm())
+		requests = append(requests, req.PostForm)
+
+		switch req.PostForm.Get("target") {
+		case "seriesByTag('name=cpu')":
+			_, _ = rw.Write([]byte(`[
+				{"target": "cpu;host=a", "tags": {"name": "cpu", "host": "a"}, "datapoints": [[1, 60], [2, 120]]},
+				{"target": "cpu;host=b", "tags": {"name": "cpu", "host": "b"}, "datapoints": [[3, 60], [null, 120]]}
+			]`))
+		case "app.missing":
+			rw.WriteHeader(http.StatusBadRequest)
+		default:
+			_, _ = rw.Write([]byte(`[{"target": "app.cpu", "datapoints": [[5, 60]]}]`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	s := newTestService(t)
+	pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL}}
+	timeRange := backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(180, 0)}
+
+	t.Run("Should send a request per query and key responses by refId", func(t *testing.T) {
+		requests = nil
+		resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, MaxDataPoints: 100, JSON: []byte(`{"target":"seriesByTag('name=cpu')"}`)},
+				{RefID: "B", TimeRange: timeRange, MaxDataPoints: 100, JSON: []byte(`{"target":"app.cpu","consolidateBy":"max"}`)},
+				{RefID: "C", TimeRange: timeRange, JSON: []byte(`{"target":"app.hidden","hide":true}`)},
+				{RefID: "D", TimeRange: timeRange, JSON: []byte(`{"target":"app.missing"}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, requests, 3)
+		require.Equal(t, "100", requests[0].Get("maxDataPoints"))
+		require.Equal(t, "0", requests[0].Get("from"))
+		require.Equal(t, "180", requests[0].Get("until"))
+		require.Equal(t, "consolidateBy(app.cpu, 'max')", requests[1].Get("target"))
+		require.Equal(t, "500", requests[2].Get("maxDataPoints"))
+
+		require.Len(t, resp.Responses, 3)
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 2)
+		require.Equal(t, "A", frames[0].RefID)
+		require.Equal(t, data.Labels{"name": "cpu", "host": "a"}, frames[0].Fields[1].Labels)
+		require.Equal(t, data.Labels{"name": "cpu", "host": "b"}, frames[1].Fields[1].Labels)
+		require.Nil(t, frames[1].Fields[1].At(1))
+		require.Equal(t, "seriesByTag('name=cpu')", frames[0].Meta.ExecutedQueryString)
+
+		require.Equal(t, "B", resp.Responses["B"].Frames[0].RefID)
+		require.Error(t, resp.Responses["D"].Error)
+	})
+
+	t.Run("Should fail when no query has a target", func(t *testing.T) {
+		_, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Queries:       []backend.DataQuery{{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"target":""}`)}},
+		})
+		require.EqualError(t, err, "no query target found for the alert rule")
+	})
+}
+
+func TestResources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/metrics/find":
+			require.Equal(t, "app.*", req.URL.Query().Get("query"))
+			require.Equal(t, "-1h", req.URL.Query().Get("from"))
+			_, _ = rw.Write([]byte(`[{"text": "cpu", "id": "app.cpu", "leaf": 0, "expandable": 1}, {"text": "up", "id": "app.up", "leaf": 1, "expandable": 0}]`))
+		case "/tags/autoComplete/tags":
+			require.Equal(t, []string{"name=cpu", "host=~a.*"}, req.URL.Query()["expr"])
+			require.Equal(t, "ho", req.URL.Query().Get("tagPrefix"))
+			_, _ = rw.Write([]byte(`["host"]`))
+		case "/tags/autoComplete/values":
+			require.Equal(t, "host", req.URL.Query().Get("tag"))
+			require.Equal(t, "10", req.URL.Query().Get("limit"))
+			_, _ = rw.Write([]byte(`["a", "b"]`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	s := newTestService(t)
+	pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL}}
+
+	callResource := func(t *testing.T, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+		t.Helper()
+		req.PluginContext = pluginCtx
+		sender := &fakeSender{}
+		err := s.CallResource(context.Background(), req, sender)
+		require.NoError(t, err)
+		require.NotNil(t, sender.resp)
+		return sender.resp
+	}
+
+	t.Run("Should find metrics with a form request", func(t *testing.T) {
+		resp := callResource(t, &backend.CallResourceRequest{
+			Method:  http.MethodPost,
+			Path:    "metrics/find",
+			URL:     "metrics/find?from=-1h",
+			Headers: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:    []byte("query=app.*"),
+		})
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `[{"text":"cpu","id":"app.cpu","expandable":true},{"text":"up","id":"app.up","expandable":false}]`, string(resp.Body))
+	})
+
+	t.Run("Should require a query to find metrics", func(t *testing.T) {
+		resp := callResource(t, &backend.CallResourceRequest{Method: http.MethodGet, Path: "metrics/find", URL: "metrics/find"})
+		require.Equal(t, http.StatusBadRequest, resp.Status)
+	})
+
+	t.Run("Should autocomplete tags", func(t *testing.T) {
+		resp := callResource(t, &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   "tags/autoComplete/tags",
+			URL:    "tags/autoComplete/tags?expr=name%3Dcpu&expr=host%3D~a.*&tagPrefix=ho",
+		})
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["host"]`, string(resp.Body))
+	})
+
+	t.Run("Should autocomplete tag values", func(t *testing.T) {
+		resp := callResource(t, &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   "tags/autoComplete/values",
+			URL:    "tags/autoComplete/values?tag=host&limit=10",
+		})
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["a","b"]`, string(resp.Body))
+	})
+}
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	tracer, err := tracing.InitializeTracerForTest()
+	require.NoError(t, err)
+	return ProvideService(httpclient.NewProvider(), tracer)
+}
+
+type fakeSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(resp *backend.CallResourceResponse) error {
+	s.resp = resp
+	return nil
+}
@@ -0,0 +1,167 @@
+package graphite
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+func (s *Service) newResourceMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics/find", s.handleMetricFind)
+	mux.HandleFunc("/tags/autoComplete/tags", s.handleTagsAutoComplete("tagPrefix"))
+	mux.HandleFunc("/tags/autoComplete/values", s.handleTagsAutoComplete("tag", "valuePrefix"))
+	return mux
+}
+
+// handleMetricFind returns the nodes matching the query parameter, as used
+// by template variables and the query editor.
+func (s *Service) handleMetricFind(rw http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("unexpected error %v", err))
+		return
+	}
+	query := req.Form.Get("query")
+	if query == "" {
+		writeResponse(rw, http.StatusBadRequest, "query parameter is required")
+		return
+	}
+
+	params := url.Values{"query": []string{query}}
+	copyParams(params, req.Form, "from", "until")
+
+	var nodes []metricFindNode
+	if code, err := s.doResourceRequest(req, "metrics/find", params, &nodes); err != nil {
+		writeResponse(rw, code, fmt.Sprintf("unexpected error %v", err))
+		return
+	}
+
+	values := make([]metricFindValue, 0, len(nodes))
+	for _, node := range nodes {
+		values = append(values, metricFindValue{
+			Text:       node.Text,
+			ID:         node.ID,
+			Expandable: isTruthy(node.Expandable),
+		})
+	}
+	writeJSONResponse(rw, values)
+}
+
+// handleTagsAutoComplete returns the tag names or values matching the expr
+// parameters, restricted by the given additional parameters.
+func (s *Service) handleTagsAutoComplete(extraParams ...string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("unexpected error %v", err))
+			return
+		}
+
+		params := url.Values{}
+		copyParams(params, req.Form, append([]string{"expr", "limit", "from", "until"}, extraParams...)...)
+
+		var values []string
+		if code, err := s.doResourceRequest(req, req.URL.Path, params, &values); err != nil {
+			writeResponse(rw, code, fmt.Sprintf("unexpected error %v", err))
+			return
+		}
+		if values == nil {
+			values = []string{}
+		}
+		writeJSONResponse(rw, values)
+	}
+}
+
+// doResourceRequest sends a GET request to the given Graphite endpoint and
+// decodes the JSON response into v. It returns the status code to reply with
+// on failure.
+func (s *Service) doResourceRequest(req *http.Request, endpoint string, params url.Values, v interface{}) (int, error) {
+	pluginCtx := httpadapter.PluginConfigFromContext(req.Context())
+	dsInfo, err := s.getDSInfo(pluginCtx)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	u.Path = path.Join(u.Path, endpoint)
+	u.RawQuery = params.Encode()
+
+	graphiteReq, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := ctxhttp.Do(req.Context(), dsInfo.HTTPClient, graphiteReq)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	if res.StatusCode/100 != 2 {
+		s.logger.Info("Request failed", "status", res.Status, "body", string(body))
+		return res.StatusCode, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return http.StatusBadGateway, fmt.Errorf("failed to unmarshal graphite response: %w", err)
+	}
+	return http.StatusOK, nil
+}
+
+func copyParams(dst, src url.Values, keys ...string) {
+	for _, key := range keys {
+		if values, ok := src[key]; ok {
+			dst[key] = values
+		}
+	}
+}
+
+func isTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != "" && v != "0" && v != "false"
+	default:
+		return false
+	}
+}
+
+func writeJSONResponse(rw http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("error formatting response %v", err))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	writeResponseBytes(rw, http.StatusOK, body)
+}
+
+func writeResponseBytes(rw http.ResponseWriter, code int, msg []byte) {
+	rw.WriteHeader(code)
+	_, err := rw.Write(msg)
+	if err != nil {
+		logger.Error("Unable to write HTTP response", "error", err)
+	}
+}
+
+func writeResponse(rw http.ResponseWriter, code int, msg string) {
+	writeResponseBytes(rw, code, []byte(msg))
+}
@@ -1,10 +1,37 @@
 package graphite
 
-import "github.com/grafana/grafana/pkg/tsdb/legacydata"
+import "github.com/grafana/grafana-plugin-sdk-go/backend"
 
 type TargetResponseDTO struct {
-	Target     string                          `json:"target"`
-	DataPoints legacydata.DataTimeSeriesPoints `json:"datapoints"`
+	Target     string      `json:"target"`
+	DataPoints []dataPoint `json:"datapoints"`
 	// Graphite <=1.1.7 may return some tags as numbers requiring extra conversion. See https://github.com/grafana/grafana/issues/37614
 	Tags map[string]interface{} `json:"tags"`
 }
+
+// dataPoint is a [value, timestamp] pair as returned by the render API.
+type dataPoint [2]*float64
+
+// graphiteQuery is a query from a data request, ready to be sent to the render API.
+type graphiteQuery struct {
+	refID         string
+	target        string
+	hide          bool
+	maxDataPoints int64
+	timeRange     backend.TimeRange
+}
+
+// metricFindNode is a node returned by the /metrics/find API. Graphite-web
+// returns expandable as 0 or 1, other implementations as a boolean.
+type metricFindNode struct {
+	Text       string      `json:"text"`
+	ID         string      `json:"id"`
+	Expandable interface{} `json:"expandable"`
+}
+
+// metricFindValue is a node returned by the metrics/find resource.
+type metricFindValue struct {
+	Text       string `json:"text"`
+	ID         string `json:"id,omitempty"`
+	Expandable bool   `json:"expandable"`
+}
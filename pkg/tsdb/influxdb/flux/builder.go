@@ -59,7 +59,11 @@ type frameBuilder struct {
 	labels              []string
 	maxPoints           int // max points in a series
 	maxSeries           int // max number of series
+	maxRows             int // max number of rows of all series, 0 for no limit
+	maxBytes            int // max estimated size of all series, 0 for no limit
 	totalSeries         int
+	totalRows           int
+	totalBytes          int
 	hasUsualStartStop   bool // has _start and _stop timestamp-labels
 }
 
@@ -254,6 +258,49 @@ func (e maxPointsExceededError) Error() string {
 	return fmt.Sprintf("max data points limit exceeded (count is %d)", e.Count)
 }
 
+// resultLimitExceededError is returned when appending a record would exceed
+// the rows or bytes limit. The record is not appended.
+type resultLimitExceededError struct {
+	Limit int
+	Unit  string
+}
+
+func (e resultLimitExceededError) Error() string {
+	return fmt.Sprintf("results are truncated, max %s reached (%d)", e.Unit, e.Limit)
+}
+
+// valueSize estimates the memory used by a value of a record.
+func valueSize(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case time.Time:
+		return 24
+	default:
+		return 8
+	}
+}
+
+func (fb *frameBuilder) checkLimits(record *query.FluxRecord) error {
+	if fb.maxRows > 0 && fb.totalRows >= fb.maxRows {
+		return resultLimitExceededError{Limit: fb.maxRows, Unit: "rows"}
+	}
+
+	size := 0
+	for _, col := range fb.columns {
+		size += valueSize(record.ValueByKey(col.name))
+	}
+	if fb.maxBytes > 0 && fb.totalBytes+size > fb.maxBytes {
+		return resultLimitExceededError{Limit: fb.maxBytes, Unit: "bytes"}
+	}
+
+	fb.totalRows++
+	fb.totalBytes += size
+	return nil
+}
+
 func getTableID(record *query.FluxRecord, groupColumns []string) []interface{} {
 	result := make([]interface{}, len(groupColumns))
 
@@ -293,6 +340,10 @@ func isTableIDEqual(id1 []interface{}, id2 []interface{}) bool {
 }
 
 func (fb *frameBuilder) Append(record *query.FluxRecord) error {
+	if err := fb.checkLimits(record); err != nil {
+		return err
+	}
+
 	table := getTableID(record, fb.groupKeyColumnNames)
 	if (fb.currentGroupKey == nil) || !isTableIDEqual(table, fb.currentGroupKey) {
 		fb.totalSeries++
@@ -13,9 +13,16 @@ import (
 
 const maxPointsEnforceFactor float64 = 10
 
+// queryLimits bound the results read from a query, to prevent memory issues.
+type queryLimits struct {
+	maxSeries int // max number of series
+	maxRows   int // max number of rows of all series, 0 for no limit
+	maxBytes  int // max estimated size of all series, 0 for no limit
+}
+
 // executeQuery runs a flux query using the queryModel to interpolate the query and the runner to execute it.
-// maxSeries somehow limits the response.
-func executeQuery(ctx context.Context, query queryModel, runner queryRunner, maxSeries int) (dr backend.DataResponse) {
+// limits somehow limit the response.
+func executeQuery(ctx context.Context, query queryModel, runner queryRunner, limits queryLimits) (dr backend.DataResponse) {
 	dr = backend.DataResponse{}
 
 	flux := interpolate(query)
@@ -30,7 +37,10 @@ func executeQuery(ctx context.Context, query queryModel, runner queryRunner, max
 		// we only enforce a larger number than maxDataPoints
 		maxPointsEnforced := int(float64(query.MaxDataPoints) * maxPointsEnforceFactor)
 
-		dr = readDataFrames(tables, maxPointsEnforced, maxSeries)
+		dr = readDataFrames(ctx, tables, maxPointsEnforced, limits)
+		if query.ResultFormat == wideResultFormat {
+			dr.Frames = pivotToWide(dr.Frames)
+		}
 
 		if dr.Error != nil {
 			// we check if a too-many-data-points error happened, and if it is so,
@@ -62,16 +72,35 @@ func executeQuery(ctx context.Context, query queryModel, runner queryRunner, max
 	return dr
 }
 
-func readDataFrames(result *api.QueryTableResult, maxPoints int, maxSeries int) (dr backend.DataResponse) {
-	glog.Debug("Reading data frames from query result", "maxPoints", maxPoints, "maxSeries", maxSeries)
+// readDataFrames reads the tables of a result into data frames. Reading stops
+// when ctx is done, and when the rows or bytes limit is reached, in which case
+// the frames read so far are returned with a notice.
+func readDataFrames(ctx context.Context, result *api.QueryTableResult, maxPoints int, limits queryLimits) (dr backend.DataResponse) {
+	glog.Debug("Reading data frames from query result", "maxPoints", maxPoints, "maxSeries", limits.maxSeries,
+		"maxRows", limits.maxRows, "maxBytes", limits.maxBytes)
 	dr = backend.DataResponse{}
+	defer func() {
+		// Closing the result stops the response from being streamed when
+		// reading stops early.
+		if err := result.Close(); err != nil {
+			glog.Warn("Failed to close query result", "err", err)
+		}
+	}()
 
 	builder := &frameBuilder{
 		maxPoints: maxPoints,
-		maxSeries: maxSeries,
+		maxSeries: limits.maxSeries,
+		maxRows:   limits.maxRows,
+		maxBytes:  limits.maxBytes,
 	}
 
+	var truncated *resultLimitExceededError
 	for result.Next() {
+		if err := ctx.Err(); err != nil {
+			dr.Error = err
+			break
+		}
+
 		// Observe when there is new grouping key producing new table
 		if result.TableChanged() {
 			if builder.frames != nil {
@@ -93,7 +122,12 @@ func readDataFrames(result *api.QueryTableResult, maxPoints int, maxSeries int)
 
 		err := builder.Append(result.Record())
 		if err != nil {
-			dr.Error = err
+			var limitErr resultLimitExceededError
+			if errors.As(err, &limitErr) {
+				truncated = &limitErr
+			} else {
+				dr.Error = err
+			}
 			break
 		}
 	}
@@ -106,8 +140,16 @@ func readDataFrames(result *api.QueryTableResult, maxPoints int, maxSeries int)
 	}
 
 	// result.Err() is probably more important then the other errors
-	if result.Err() != nil {
+	if result.Err() != nil && truncated == nil {
 		dr.Error = result.Err()
 	}
+
+	if truncated != nil && len(dr.Frames) > 0 {
+		dr.Frames[0].AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text: fmt.Sprintf("The results have been truncated at %d %s to prevent memory issues. "+
+				"Try reducing the time range or aggregating the data with aggregateWindow().", truncated.Limit, truncated.Unit),
+		})
+	}
 	return dr
 }
@@ -62,7 +62,7 @@ func executeMockedQuery(t *testing.T, name string, query queryModel) *backend.Da
 		testDataPath: name + ".csv",
 	}
 
-	dr := executeQuery(context.Background(), query, runner, 50)
+	dr := executeQuery(context.Background(), query, runner, queryLimits{maxSeries: 50})
 	return &dr
 }
 
@@ -231,7 +231,7 @@ func TestRealQuery(t *testing.T) {
 		dr := executeQuery(context.Background(), queryModel{
 			MaxDataPoints: 100,
 			RawQuery:      "buckets()",
-		}, runner, 50)
+		}, runner, queryLimits{maxSeries: 50})
 		err = experimental.CheckGoldenDataResponse(filepath.Join("testdata", "buckets-real.golden.txt"), &dr, true)
 		require.NoError(t, err)
 	})
@@ -308,3 +308,94 @@ func TestTimestampFirst(t *testing.T) {
 	require.Equal(t, "Time", dr.Frames[0].Fields[0].Name)
 	require.Equal(t, "Value", dr.Frames[0].Fields[1].Name)
 }
+
+func TestMaxRowsExceeded(t *testing.T) {
+	runner := &MockRunner{testDataPath: "grouping.csv"}
+	dr := executeQuery(context.Background(), queryModel{MaxDataPoints: 100}, runner, queryLimits{maxSeries: 50, maxRows: 5})
+
+	// the rows read so far are returned with a notice
+	require.NoError(t, dr.Error)
+	require.Len(t, dr.Frames, 2)
+	require.Equal(t, 3, dr.Frames[0].Rows())
+	require.Equal(t, 2, dr.Frames[1].Rows())
+	require.Equal(t, []data.Notice{{
+		Severity: data.NoticeSeverityWarning,
+		Text:     "The results have been truncated at 5 rows to prevent memory issues. Try reducing the time range or aggregating the data with aggregateWindow().",
+	}}, dr.Frames[0].Meta.Notices)
+}
+
+func TestMaxBytesExceeded(t *testing.T) {
+	runner := &MockRunner{testDataPath: "grouping.csv"}
+	dr := executeQuery(context.Background(), queryModel{MaxDataPoints: 100}, runner, queryLimits{maxSeries: 50, maxBytes: 50})
+
+	// a row with a time and a null value takes 24 bytes, one with a value 32
+	require.NoError(t, dr.Error)
+	require.Len(t, dr.Frames, 1)
+	require.Equal(t, 1, dr.Frames[0].Rows())
+	require.Len(t, dr.Frames[0].Meta.Notices, 1)
+	require.Contains(t, dr.Frames[0].Meta.Notices[0].Text, "truncated at 50 bytes")
+}
+
+func TestWideResultFormat(t *testing.T) {
+	runner := &MockRunner{testDataPath: "grouping.csv"}
+	dr := executeQuery(context.Background(), queryModel{MaxDataPoints: 100, ResultFormat: wideResultFormat}, runner,
+		queryLimits{maxSeries: 50})
+	require.NoError(t, dr.Error)
+	require.Len(t, dr.Frames, 1)
+
+	frame := dr.Frames[0]
+	require.Equal(t, "system", frame.Name)
+	require.Equal(t, data.TimeSeriesTypeWide, frame.TimeSeriesSchema().Type)
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, 10, frame.Rows())
+	require.Equal(t, time.Date(2020, 5, 5, 18, 38, 50, 0, time.UTC), frame.Fields[0].At(0))
+
+	for i, name := range []string{"load1", "load15", "load5"} {
+		field := frame.Fields[i+1]
+		require.Equal(t, name, field.Name)
+		require.Equal(t, data.Labels{"host": "hostname"}, field.Labels)
+	}
+	require.Equal(t, pointer.Float64(3.56), frame.Fields[1].At(1))
+	require.Nil(t, frame.Fields[1].At(2))
+	require.Equal(t, pointer.Float64(3.04), frame.Fields[3].At(2))
+	require.NotNil(t, frame.Meta)
+}
+
+func TestPivotToWideDifferentMeasurements(t *testing.T) {
+	t1 := time.Date(2020, 6, 5, 12, 6, 0, 0, time.UTC)
+	t2 := time.Date(2020, 6, 5, 12, 7, 0, 0, time.UTC)
+	frames := pivotToWide(data.Frames{
+		data.NewFrame("cpu",
+			data.NewField("Time", nil, []*time.Time{&t1}),
+			data.NewField("usage", data.Labels{"host": "a"}, []*float64{pointer.Float64(1)}),
+		),
+		data.NewFrame("mem",
+			data.NewField("Time", nil, []*time.Time{&t2}),
+			data.NewField("usage", nil, []*int64{pointer.Int64(2)}),
+		),
+		data.NewFrame("table",
+			data.NewField("_value", nil, []*float64{pointer.Float64(3)}),
+			data.NewField("_value2", nil, []*float64{pointer.Float64(4)}),
+		),
+	})
+
+	require.Len(t, frames, 2)
+	require.Equal(t, "", frames[0].Name)
+	require.Equal(t, data.Labels{"host": "a", "_measurement": "cpu"}, frames[0].Fields[1].Labels)
+	require.Equal(t, data.Labels{"_measurement": "mem"}, frames[0].Fields[2].Labels)
+	require.Equal(t, pointer.Float64(1), frames[0].Fields[1].At(0))
+	require.Nil(t, frames[0].Fields[1].At(1))
+	require.Equal(t, pointer.Int64(2), frames[0].Fields[2].At(1))
+	require.Equal(t, "table", frames[1].Name)
+}
+
+func TestQueryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := Query(ctx, &models.DatasourceInfo{URL: "http://localhost:8086", Organization: "org"}, backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"query": "buckets()"}`)}},
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, res.Responses["A"].Error, context.Canceled)
+}
@@ -28,15 +28,24 @@ func Query(ctx context.Context, dsInfo *models.DatasourceInfo, tsdbQuery backend
 
 	timeRange := tsdbQuery.Queries[0].TimeRange
 	for _, query := range tsdbQuery.Queries {
+		// Don't start queries once the request is cancelled.
+		if err := ctx.Err(); err != nil {
+			tRes.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+
 		qm, err := getQueryModel(query, timeRange, dsInfo)
 		if err != nil {
 			tRes.Responses[query.RefID] = backend.DataResponse{Error: err}
 			continue
 		}
 
-		// If the default changes also update labels/placeholder in config page.
-		maxSeries := dsInfo.MaxSeries
-		res := executeQuery(ctx, *qm, r, maxSeries)
+		// If the defaults change also update labels/placeholders in config page.
+		res := executeQuery(ctx, *qm, r, queryLimits{
+			maxSeries: dsInfo.MaxSeries,
+			maxRows:   dsInfo.MaxRows,
+			maxBytes:  dsInfo.MaxBytes,
+		})
 
 		tRes.Responses[query.RefID] = res
 	}
@@ -9,6 +9,9 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
 )
 
+// wideResultFormat pivots the tables of a result into a wide frame.
+const wideResultFormat = "wide"
+
 // queryOptions represents datasource configuration options
 type queryOptions struct {
 	Bucket        string `json:"bucket"`
@@ -18,8 +21,9 @@ type queryOptions struct {
 
 // queryModel represents a query.
 type queryModel struct {
-	RawQuery string       `json:"query"`
-	Options  queryOptions `json:"options"`
+	RawQuery     string       `json:"query"`
+	Options      queryOptions `json:"options"`
+	ResultFormat string       `json:"fluxFormat"`
 
 	// Not from JSON
 	TimeRange     backend.TimeRange `json:"-"`
@@ -0,0 +1,99 @@
+package flux
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// pivotToWide joins the time series frames built from the tables of a result
+// into a single wide frame. The frame has one value field per table, named
+// after the _field of the table and labelled with its tags. Frames that are
+// not a simple time series, such as tables with several value columns, are
+// returned as they are, after the wide frame.
+func pivotToWide(frames data.Frames) data.Frames {
+	var series, others data.Frames
+	for _, frame := range frames {
+		if isSimpleTimeSeries(frame) {
+			series = append(series, frame)
+		} else {
+			others = append(others, frame)
+		}
+	}
+	if len(series) == 0 {
+		return frames
+	}
+
+	// The wide frame has a row per distinct timestamp of all series.
+	rows := map[int64]int{}
+	var times []time.Time
+	for _, frame := range series {
+		for i := 0; i < frame.Rows(); i++ {
+			t, ok := frame.Fields[0].At(i).(*time.Time)
+			if !ok || t == nil {
+				continue
+			}
+			if _, ok := rows[t.UnixNano()]; !ok {
+				rows[t.UnixNano()] = 0
+				times = append(times, *t)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	for i, t := range times {
+		rows[t.UnixNano()] = i
+	}
+
+	// The measurement is the name of the frames. When the series are of
+	// different measurements it becomes a label of the value fields instead.
+	name := series[0].Name
+	for _, frame := range series {
+		if frame.Name != name {
+			name = ""
+			break
+		}
+	}
+
+	wide := data.NewFrame(name, data.NewField("Time", nil, times))
+	for _, frame := range series {
+		valueField := frame.Fields[1]
+		field := data.NewFieldFromFieldType(valueField.Type(), len(times))
+		field.Name = valueField.Name
+		field.Labels = valueField.Labels.Copy()
+		if name == "" && frame.Name != "" {
+			if field.Labels == nil {
+				field.Labels = data.Labels{}
+			}
+			field.Labels["_measurement"] = frame.Name
+		}
+
+		for i := 0; i < frame.Rows(); i++ {
+			t, ok := frame.Fields[0].At(i).(*time.Time)
+			if !ok || t == nil {
+				continue
+			}
+			field.Set(rows[t.UnixNano()], valueField.At(i))
+		}
+		wide.Fields = append(wide.Fields, field)
+	}
+
+	// Notices were added to the first frame of the result.
+	if frames[0].Meta != nil && isSimpleTimeSeries(frames[0]) {
+		wide.SetMeta(frames[0].Meta)
+	}
+
+	return append(data.Frames{wide}, others...)
+}
+
+// isSimpleTimeSeries checks whether a frame has a time field named Time
+// followed by a single nullable value field, as built from a table with the
+// usual _time and _value columns.
+func isSimpleTimeSeries(frame *data.Frame) bool {
+	return len(frame.Fields) == 2 &&
+		frame.Fields[0].Name == "Time" &&
+		frame.Fields[0].Type() == data.FieldTypeNullableTime &&
+		frame.Fields[1].Type().Nullable()
+}
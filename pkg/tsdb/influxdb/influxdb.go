@@ -64,6 +64,14 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 		if maxSeries == 0 {
 			maxSeries = 1000
 		}
+		maxRows := jsonData.MaxRows
+		if maxRows == 0 {
+			maxRows = 1000000
+		}
+		maxBytes := jsonData.MaxBytes
+		if maxBytes == 0 {
+			maxBytes = 100 * 1024 * 1024
+		}
 		model := &models.DatasourceInfo{
 			HTTPClient:    client,
 			URL:           settings.URL,
@@ -74,6 +82,8 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			DefaultBucket: jsonData.DefaultBucket,
 			Organization:  jsonData.Organization,
 			MaxSeries:     maxSeries,
+			MaxRows:       maxRows,
+			MaxBytes:      maxBytes,
 			Token:         settings.DecryptedSecureJSONData["token"],
 		}
 		return model, nil
@@ -101,6 +111,7 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
 	}
+	query.FromAlert = req.Headers["FromAlert"] == "true"
 
 	rawQuery, err := query.Build(req)
 	if err != nil {
@@ -22,6 +22,7 @@ func (qp *InfluxdbQueryParser) Parse(query backend.DataQuery) (*Query, error) {
 	useRawQuery := model.Get("rawQuery").MustBool(false)
 	alias := model.Get("alias").MustString("")
 	tz := model.Get("tz").MustString("")
+	resultFormat := model.Get("resultFormat").MustString("")
 
 	measurement := model.Get("measurement").MustString("")
 
@@ -50,16 +51,18 @@ func (qp *InfluxdbQueryParser) Parse(query backend.DataQuery) (*Query, error) {
 	}
 
 	return &Query{
-		Measurement: measurement,
-		Policy:      policy,
-		GroupBy:     groupBys,
-		Tags:        tags,
-		Selects:     selects,
-		RawQuery:    rawQuery,
-		Interval:    interval,
-		Alias:       alias,
-		UseRawQuery: useRawQuery,
-		Tz:          tz,
+		RefID:        query.RefID,
+		Measurement:  measurement,
+		Policy:       policy,
+		GroupBy:      groupBys,
+		Tags:         tags,
+		Selects:      selects,
+		RawQuery:     rawQuery,
+		Interval:     interval,
+		Alias:        alias,
+		UseRawQuery:  useRawQuery,
+		Tz:           tz,
+		ResultFormat: resultFormat,
 	}, nil
 }
 
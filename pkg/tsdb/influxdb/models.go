@@ -3,16 +3,20 @@ package influxdb
 import "time"
 
 type Query struct {
-	Measurement string
-	Policy      string
-	Tags        []*Tag
-	GroupBy     []*QueryPart
-	Selects     []*Select
-	RawQuery    string
-	UseRawQuery bool
-	Alias       string
-	Interval    time.Duration
-	Tz          string
+	RefID        string
+	Measurement  string
+	Policy       string
+	Tags         []*Tag
+	GroupBy      []*QueryPart
+	Selects      []*Select
+	RawQuery     string
+	UseRawQuery  bool
+	Alias        string
+	Interval     time.Duration
+	Tz           string
+	ResultFormat string
+	// FromAlert is set for queries of alert rules, which expect time series.
+	FromAlert bool
 }
 
 type Tag struct {
@@ -2,9 +2,11 @@ package influxdb
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,38 +19,256 @@ type ResponseParser struct{}
 
 var (
 	legendFormat = regexp.MustCompile(`\[\[([\@\/\w-]+)(\.[\@\/\w-]+)*\]\]*|\$(\s*([\@\w-]+?))*`)
+
+	groupByStatement     = regexp.MustCompile(`(?i)\bgroup\s+by\b`)
+	groupByTimeStatement = regexp.MustCompile(`(?is)\bgroup\s+by\b.*\btime\s*\(`)
 )
 
 func (rp *ResponseParser) Parse(buf io.ReadCloser, query *Query) *backend.QueryDataResponse {
 	resp := backend.NewQueryDataResponse()
 	queryRes := backend.DataResponse{}
 
+	refID := query.RefID
+	if refID == "" {
+		refID = "A"
+	}
+
 	response, jsonErr := parseJSON(buf)
 	if jsonErr != nil {
 		queryRes.Error = jsonErr
-		resp.Responses["A"] = queryRes
+		resp.Responses[refID] = queryRes
 		return resp
 	}
 
 	if response.Error != "" {
 		queryRes.Error = fmt.Errorf(response.Error)
-		resp.Responses["A"] = queryRes
+		resp.Responses[refID] = queryRes
 		return resp
 	}
 
+	// There is a result per statement of the query. The frames of a failed
+	// statement are replaced with an empty frame carrying its error.
 	frames := data.Frames{}
-	for _, result := range response.Results {
-		frames = append(frames, transformRows(result.Series, query)...)
+	var errs []string
+	for i, result := range response.Results {
 		if result.Error != "" {
-			queryRes.Error = fmt.Errorf(result.Error)
+			errs = append(errs, result.Error)
+			frame := data.NewFrame("")
+			frame.AppendNotices(data.Notice{Severity: data.NoticeSeverityError, Text: result.Error})
+			frames = append(frames, frame)
+			continue
 		}
+
+		if isTableResult(result, query, i) {
+			if len(result.Series) > 0 {
+				frames = append(frames, transformRowsToTable(result.Series))
+			}
+		} else {
+			frames = append(frames, transformRows(result.Series, query)...)
+		}
+	}
+	if len(errs) > 0 {
+		queryRes.Error = errors.New(strings.Join(errs, "; "))
 	}
 	queryRes.Frames = frames
-	resp.Responses["A"] = queryRes
+	resp.Responses[refID] = queryRes
 
 	return resp
 }
 
+// isTableResult checks whether the result of the statementIndex-th statement
+// of a query is a table rather than time series. This is the case when the
+// query is formatted as a table, for SHOW statements and other statements
+// whose results have no time column, and for aggregations grouped by tags but
+// not by time, whose results have a single row per series. Alert rules
+// expect time series, so their results are only tables when they have no
+// time column.
+func isTableResult(result Result, query *Query, statementIndex int) bool {
+	if query.ResultFormat == "table" && !query.FromAlert {
+		return true
+	}
+
+	singleRows := true
+	for _, row := range result.Series {
+		if len(row.Columns) == 0 || row.Columns[0] != "time" {
+			return true
+		}
+		if len(row.Values) > 1 {
+			singleRows = false
+		}
+	}
+	if !singleRows || query.FromAlert {
+		return false
+	}
+
+	if !query.UseRawQuery {
+		hasGroupByTime := false
+		for _, groupBy := range query.GroupBy {
+			if groupBy.Type == "time" {
+				hasGroupByTime = true
+			}
+		}
+		return len(query.GroupBy) > 0 && !hasGroupByTime
+	}
+
+	statements := splitStatements(query.RawQuery)
+	if statementIndex >= len(statements) {
+		return false
+	}
+	statement := statements[statementIndex]
+	return groupByStatement.MatchString(statement) && !groupByTimeStatement.MatchString(statement)
+}
+
+// splitStatements splits a raw query into its semicolon-separated statements,
+// ignoring semicolons in quoted strings and identifiers.
+func splitStatements(rawQuery string) []string {
+	var statements []string
+	var quote rune
+	start := 0
+	for i, c := range rawQuery {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';':
+			statements = append(statements, rawQuery[start:i])
+			start = i + 1
+		}
+	}
+	statements = append(statements, rawQuery[start:])
+
+	// InfluxDB doesn't return results for empty statements.
+	nonEmpty := statements[:0]
+	for _, statement := range statements {
+		if strings.TrimSpace(statement) != "" {
+			nonEmpty = append(nonEmpty, statement)
+		}
+	}
+	return nonEmpty
+}
+
+// transformRowsToTable returns the series of a statement as a single table,
+// with a column per tag followed by the columns of the series. The types of
+// the columns are the types of their values.
+func transformRowsToTable(rows []Row) *data.Frame {
+	var tagKeys, columns []string
+	seenTags, seenColumns := map[string]bool{}, map[string]bool{}
+	name := rows[0].Name
+	for _, row := range rows {
+		for key := range row.Tags {
+			if !seenTags[key] {
+				seenTags[key] = true
+				tagKeys = append(tagKeys, key)
+			}
+		}
+		for _, column := range row.Columns {
+			if !seenColumns[column] {
+				seenColumns[column] = true
+				columns = append(columns, column)
+			}
+		}
+		if row.Name != name {
+			name = ""
+		}
+	}
+	sort.Strings(tagKeys)
+
+	fields := make([]*data.Field, 0, len(tagKeys)+len(columns))
+	for _, key := range tagKeys {
+		values := []*string{}
+		for _, row := range rows {
+			for range row.Values {
+				if value, ok := row.Tags[key]; ok {
+					values = append(values, &value)
+				} else {
+					values = append(values, nil)
+				}
+			}
+		}
+		fields = append(fields, data.NewField(key, nil, values))
+	}
+
+	for _, column := range columns {
+		var values []interface{}
+		for _, row := range rows {
+			index := -1
+			for i, c := range row.Columns {
+				if c == column {
+					index = i
+				}
+			}
+			for _, rowValues := range row.Values {
+				if index < 0 || index >= len(rowValues) {
+					values = append(values, nil)
+				} else {
+					values = append(values, rowValues[index])
+				}
+			}
+		}
+		fields = append(fields, newTableField(column, values))
+	}
+
+	frame := data.NewFrame(name, fields...)
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+	return frame
+}
+
+// newTableField returns a field with the values of a column. Columns with
+// only numbers, strings or booleans get a field of that type, the time column
+// a time field, and columns with mixed types a string field.
+func newTableField(name string, values []interface{}) *data.Field {
+	if name == "time" {
+		times := make([]*time.Time, len(values))
+		for i, value := range values {
+			if t, err := parseTimestamp(value); err == nil {
+				times[i] = &t
+			}
+		}
+		return data.NewField(name, nil, times)
+	}
+
+	var hasNumbers, hasStrings, hasBools bool
+	for _, value := range values {
+		switch value.(type) {
+		case json.Number:
+			hasNumbers = true
+		case string:
+			hasStrings = true
+		case bool:
+			hasBools = true
+		}
+	}
+
+	switch {
+	case hasNumbers && !hasStrings && !hasBools:
+		numbers := make([]*float64, len(values))
+		for i, value := range values {
+			numbers[i] = parseValue(value)
+		}
+		return data.NewField(name, nil, numbers)
+	case hasBools && !hasNumbers && !hasStrings:
+		bools := make([]*bool, len(values))
+		for i, value := range values {
+			if b, ok := value.(bool); ok {
+				bools[i] = &b
+			}
+		}
+		return data.NewField(name, nil, bools)
+	default:
+		strs := make([]*string, len(values))
+		for i, value := range values {
+			if value != nil {
+				str := fmt.Sprintf("%v", value)
+				strs[i] = &str
+			}
+		}
+		return data.NewField(name, nil, strs)
+	}
+}
+
 func parseJSON(buf io.ReadCloser) (Response, error) {
 	var response Response
 	dec := json.NewDecoder(buf)
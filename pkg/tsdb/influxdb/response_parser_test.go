@@ -524,4 +524,184 @@ func TestInfluxdbResponseParser(t *testing.T) {
 		_, err := parseTimestamp("hello")
 		require.Error(t, err)
 	})
+
+	t.Run("Influxdb response parser with SHOW statement", func(t *testing.T) {
+		parser := &ResponseParser{}
+
+		response := `
+		{
+			"results": [
+				{
+					"series": [
+						{
+							"name": "cpu",
+							"columns": ["key","value"],
+							"values": [
+								["host","server1"],
+								["host","server2"]
+							]
+						}
+					]
+				}
+			]
+		}
+		`
+
+		query := &Query{RefID: "B", UseRawQuery: true, RawQuery: `SHOW TAG VALUES FROM "cpu" WITH KEY = "host"`}
+		result := parser.Parse(prepare(response), query)
+
+		require.NoError(t, result.Responses["B"].Error)
+		testFrame := data.NewFrame("cpu",
+			data.NewField("key", nil, []*string{pointer.String("host"), pointer.String("host")}),
+			data.NewField("value", nil, []*string{pointer.String("server1"), pointer.String("server2")}),
+		)
+		testFrame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+		if diff := cmp.Diff(data.Frames{testFrame}, result.Responses["B"].Frames, data.FrameTestCompareOptions()...); diff != "" {
+			t.Errorf("Result mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Influxdb response parser with aggregation grouped by tags", func(t *testing.T) {
+		parser := &ResponseParser{}
+
+		response := `
+		{
+			"results": [
+				{
+					"series": [
+						{
+							"name": "cpu",
+							"tags": {"host": "server1"},
+							"columns": ["time","mean","status"],
+							"values": [[111,1.5,"ok"]]
+						},
+						{
+							"name": "cpu",
+							"tags": {"host": "server2"},
+							"columns": ["time","mean","status"],
+							"values": [[111,null,true]]
+						}
+					]
+				}
+			]
+		}
+		`
+
+		query := &Query{UseRawQuery: true, RawQuery: `SELECT mean("usage"), last("status") AS "status" FROM "cpu" WHERE time > now() - 1h GROUP BY "host"`}
+		result := parser.Parse(prepare(response), query)
+
+		require.NoError(t, result.Responses["A"].Error)
+		t1 := time.Date(1970, 1, 1, 0, 1, 51, 0, time.UTC)
+		testFrame := data.NewFrame("cpu",
+			data.NewField("host", nil, []*string{pointer.String("server1"), pointer.String("server2")}),
+			data.NewField("time", nil, []*time.Time{&t1, &t1}),
+			data.NewField("mean", nil, []*float64{pointer.Float64(1.5), nil}),
+			data.NewField("status", nil, []*string{pointer.String("ok"), pointer.String("true")}),
+		)
+		testFrame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+		if diff := cmp.Diff(data.Frames{testFrame}, result.Responses["A"].Frames, data.FrameTestCompareOptions()...); diff != "" {
+			t.Errorf("Result mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Influxdb response parser keeps time series for alert rules", func(t *testing.T) {
+		parser := &ResponseParser{}
+
+		response := `
+		{
+			"results": [
+				{
+					"series": [
+						{
+							"name": "cpu",
+							"tags": {"host": "server1"},
+							"columns": ["time","mean"],
+							"values": [[111,1.5]]
+						},
+						{
+							"name": "cpu",
+							"tags": {"host": "server2"},
+							"columns": ["time","mean"],
+							"values": [[111,2.5]]
+						}
+					]
+				}
+			]
+		}
+		`
+
+		query := &Query{
+			UseRawQuery:  true,
+			RawQuery:     `SELECT mean("usage") FROM "cpu" WHERE time > now() - 1h GROUP BY "host"`,
+			ResultFormat: "table",
+			FromAlert:    true,
+		}
+		result := parser.Parse(prepare(response), query)
+
+		require.NoError(t, result.Responses["A"].Error)
+		frames := result.Responses["A"].Frames
+		require.Len(t, frames, 2)
+		for _, frame := range frames {
+			require.Equal(t, data.TimeSeriesTypeWide, frame.TimeSeriesSchema().Type)
+		}
+		require.Equal(t, data.Labels{"host": "server2"}, frames[1].Fields[1].Labels)
+	})
+
+	t.Run("Influxdb response parser with multiple statements", func(t *testing.T) {
+		parser := &ResponseParser{}
+
+		response := `
+		{
+			"results": [
+				{
+					"statement_id": 0,
+					"series": [
+						{
+							"name": "cpu",
+							"tags": {"host": "server1"},
+							"columns": ["time","mean"],
+							"values": [[111,1],[171,2]]
+						}
+					]
+				},
+				{
+					"statement_id": 1,
+					"series": [
+						{
+							"name": "cpu",
+							"tags": {"host": "server1"},
+							"columns": ["time","max"],
+							"values": [[111,3]]
+						}
+					]
+				},
+				{
+					"statement_id": 2,
+					"error": "database not found: db"
+				}
+			]
+		}
+		`
+
+		query := &Query{UseRawQuery: true, RawQuery: `SELECT mean("usage") FROM "cpu" GROUP BY time(1m), "host";
+			SELECT max("usage") FROM "cpu" GROUP BY "host";
+			SELECT * FROM "db"..."cpu"`}
+		result := parser.Parse(prepare(response), query)
+
+		frames := result.Responses["A"].Frames
+		require.Len(t, frames, 3)
+		require.Equal(t, "cpu.mean { host: server1 }", frames[0].Name)
+		require.Equal(t, data.TimeSeriesTypeWide, frames[0].TimeSeriesSchema().Type)
+		require.Equal(t, data.VisType(data.VisTypeTable), frames[1].Meta.PreferredVisualization)
+		require.Equal(t, "host", frames[1].Fields[0].Name)
+		require.Equal(t, []data.Notice{{Severity: data.NoticeSeverityError, Text: "database not found: db"}}, frames[2].Meta.Notices)
+		require.EqualError(t, result.Responses["A"].Error, "database not found: db")
+	})
+
+	t.Run("Influxdb response parser splitStatements", func(t *testing.T) {
+		require.Equal(t, []string{
+			`SELECT "a;b" FROM "cpu" WHERE "host" = 'x;y'`,
+			` SHOW MEASUREMENTS`,
+		}, splitStatements(`SELECT "a;b" FROM "cpu" WHERE "host" = 'x;y'; SHOW MEASUREMENTS;`))
+	})
 }
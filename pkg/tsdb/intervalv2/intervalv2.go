@@ -111,6 +111,22 @@ func GetIntervalFrom(dsInterval, queryInterval string, queryIntervalMS int64, de
 	return parsedInterval, nil
 }
 
+// AlignTimeRange rounds t down to a multiple of step. The offset, in seconds,
+// shifts the alignment so that steps line up with the boundaries of a time zone.
+// This matches how the Prometheus query editor aligns range queries.
+func AlignTimeRange(t time.Time, step time.Duration, offset int64) time.Time {
+	if step <= 0 {
+		return t
+	}
+
+	shifted := t.UnixNano() + offset*int64(time.Second)
+	aligned := shifted - shifted%int64(step)
+	if shifted < 0 && shifted%int64(step) != 0 {
+		aligned -= int64(step)
+	}
+	return time.Unix(0, aligned-offset*int64(time.Second))
+}
+
 func ParseIntervalStringToTimeDuration(interval string) (time.Duration, error) {
 	formattedInterval := strings.Replace(strings.Replace(interval, "<", "", 1), ">", "", 1)
 	isPureNum, err := regexp.MatchString(`^\d+$`, formattedInterval)
@@ -127,3 +127,25 @@ func TestGetIntervalFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestAlignTimeRange(t *testing.T) {
+	testCases := []struct {
+		name     string
+		t        time.Time
+		step     time.Duration
+		offset   int64
+		expected time.Time
+	}{
+		{"aligned to step", time.Unix(1641889537, 0), 10 * time.Second, 0, time.Unix(1641889530, 0)},
+		{"already aligned", time.Unix(1641889530, 0), 10 * time.Second, 0, time.Unix(1641889530, 0)},
+		{"sub-second step", time.Unix(1641889537, 730*int64(time.Millisecond)), 200 * time.Millisecond, 0, time.Unix(1641889537, 600*int64(time.Millisecond))},
+		{"day step with utc offset", time.Date(2022, 1, 11, 20, 0, 0, 0, time.UTC), 24 * time.Hour, 7200, time.Date(2022, 1, 10, 22, 0, 0, 0, time.UTC)},
+		{"zero step", time.Unix(1641889537, 0), 0, 0, time.Unix(1641889537, 0)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, tc.expected.Equal(AlignTimeRange(tc.t, tc.step, tc.offset)))
+		})
+	}
+}
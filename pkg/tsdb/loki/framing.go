@@ -0,0 +1,135 @@
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/prometheus/common/model"
+)
+
+func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (data.Frames, error) {
+	switch result := value.Data.Result.(type) {
+	case loghttp.Matrix:
+		return matrixToFrames(result, query), nil
+	case loghttp.Vector:
+		return vectorToFrames(result, query), nil
+	case loghttp.Scalar:
+		return scalarToFrames(result), nil
+	case loghttp.Streams:
+		return streamsToFrames(result)
+	default:
+		return data.Frames{}, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
+	}
+}
+
+func matrixToFrames(matrix loghttp.Matrix, query *lokiQuery) data.Frames {
+	frames := data.Frames{}
+
+	for _, v := range matrix {
+		name := formatLegend(v.Metric, query)
+		tags := metricToLabels(v.Metric)
+		timeVector := make([]time.Time, 0, len(v.Values))
+		values := make([]float64, 0, len(v.Values))
+
+		for _, k := range v.Values {
+			timeVector = append(timeVector, time.Unix(k.Timestamp.Unix(), 0).UTC())
+			values = append(values, float64(k.Value))
+		}
+
+		frames = append(frames, data.NewFrame(name,
+			data.NewField("time", nil, timeVector),
+			data.NewField("value", tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name})))
+	}
+
+	return frames
+}
+
+func vectorToFrames(vector loghttp.Vector, query *lokiQuery) data.Frames {
+	frames := data.Frames{}
+
+	for _, v := range vector {
+		name := formatLegend(v.Metric, query)
+		frames = append(frames, data.NewFrame(name,
+			data.NewField("time", nil, []time.Time{v.Timestamp.Time().UTC()}),
+			data.NewField("value", metricToLabels(v.Metric), []float64{float64(v.Value)}).
+				SetConfig(&data.FieldConfig{DisplayNameFromDS: name})))
+	}
+
+	return frames
+}
+
+func scalarToFrames(scalar loghttp.Scalar) data.Frames {
+	return data.Frames{data.NewFrame("",
+		data.NewField("time", nil, []time.Time{scalar.Timestamp.Time().UTC()}),
+		data.NewField("value", nil, []float64{float64(scalar.Value)}))}
+}
+
+// streamsToFrames converts log streams into one frame per stream. The stream
+// labels are set on the line field and, encoded as JSON, in the labels field
+// of every line. Every line gets an id that is stable across requests, so that
+// results of overlapping queries can be deduplicated.
+func streamsToFrames(streams loghttp.Streams) (data.Frames, error) {
+	frames := data.Frames{}
+
+	for _, stream := range streams {
+		labels := data.Labels(stream.Labels)
+		labelsText := labels.String()
+		labelsJSON, err := json.Marshal(labels)
+		if err != nil {
+			return nil, err
+		}
+
+		timeVector := make([]time.Time, 0, len(stream.Entries))
+		lines := make([]string, 0, len(stream.Entries))
+		lineLabels := make([]string, 0, len(stream.Entries))
+		ids := make([]string, 0, len(stream.Entries))
+		seen := make(map[string]int, len(stream.Entries))
+
+		for _, entry := range stream.Entries {
+			id := lineID(labelsText, entry)
+			// identical lines with the same timestamp get a counter suffix
+			if n := seen[id]; n > 0 {
+				seen[id] = n + 1
+				id = fmt.Sprintf("%s_%d", id, n)
+			} else {
+				seen[id] = 1
+			}
+
+			timeVector = append(timeVector, entry.Timestamp.UTC())
+			lines = append(lines, entry.Line)
+			lineLabels = append(lineLabels, string(labelsJSON))
+			ids = append(ids, id)
+		}
+
+		frame := data.NewFrame(labelsText,
+			data.NewField("time", nil, timeVector),
+			data.NewField("line", labels, lines),
+			data.NewField("labels", nil, lineLabels),
+			data.NewField("id", nil, ids))
+		frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeLogs})
+
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+func lineID(labels string, entry loghttp.Entry) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(labels))
+	_, _ = h.Write([]byte(fmt.Sprint(entry.Timestamp.UnixNano())))
+	_, _ = h.Write([]byte(entry.Line))
+	return fmt.Sprintf("%d_%x", entry.Timestamp.UnixNano(), h.Sum64())
+}
+
+func metricToLabels(metric model.Metric) data.Labels {
+	labels := make(data.Labels, len(metric))
+	for k, v := range metric {
+		labels[string(k)] = string(v)
+	}
+	return labels
+}
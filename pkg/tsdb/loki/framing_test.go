@@ -2,14 +2,20 @@ package loki
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/experimental"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/stretchr/testify/require"
 )
@@ -30,6 +36,8 @@ func TestSuccessResponse(t *testing.T) {
 		{name: "parse a matrix response with NaN", filepath: "matrix_nan"},
 		// you can produce Infinity by using `quantile_over_time(42,` (value larger than 1)
 		{name: "parse a matrix response with Infinity", filepath: "matrix_inf"},
+		{name: "parse a simple streams response", filepath: "streams_simple"},
+		{name: "parse a simple vector response", filepath: "vector_simple"},
 	}
 
 	for _, test := range tt {
@@ -40,7 +48,7 @@ func TestSuccessResponse(t *testing.T) {
 			bytes, err := os.ReadFile(responseFileName)
 			require.NoError(t, err)
 
-			frames, err := runQuery(makeMockedClient(200, "application/json", bytes), &lokiQuery{})
+			frames, err := runQuery(makeMockedClient(200, "application/json", bytes), &lokiQuery{QueryType: QueryTypeRange})
 			require.NoError(t, err)
 
 			dr := &backend.DataResponse{
@@ -101,7 +109,7 @@ func TestErrorResponse(t *testing.T) {
 
 	for _, test := range tt {
 		t.Run(test.name, func(t *testing.T) {
-			frames, err := runQuery(makeMockedClient(400, test.contentType, test.body), &lokiQuery{})
+			frames, err := runQuery(makeMockedClient(400, test.contentType, test.body), &lokiQuery{QueryType: QueryTypeRange})
 
 			require.Len(t, frames, 0)
 			require.Error(t, err)
@@ -114,9 +122,11 @@ type MockedRoundTripper struct {
 	statusCode    int
 	responseBytes []byte
 	contentType   string
+	lastRequest   *http.Request
 }
 
 func (mockedRT *MockedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	mockedRT.lastRequest = req
 	header := http.Header{}
 	header.Add("Content-Type", mockedRT.contentType)
 	return &http.Response{
@@ -136,3 +146,55 @@ func makeMockedClient(statusCode int, contentType string, responseBytes []byte)
 
 	return client
 }
+
+func TestInstantQuery(t *testing.T) {
+	bytes, err := os.ReadFile(filepath.Join("testdata", "vector_simple.json"))
+	require.NoError(t, err)
+
+	rt := &MockedRoundTripper{statusCode: 200, responseBytes: bytes, contentType: "application/json"}
+	client := &client.DefaultClient{
+		Address: "http://localhost:9999",
+		Tripperware: func(t http.RoundTripper) http.RoundTripper {
+			return rt
+		},
+	}
+
+	frames, err := runQuery(client, &lokiQuery{QueryType: QueryTypeInstant, Expr: "count_over_time({app=\"grafana\"}[5m])", MaxLines: 10})
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+	require.Equal(t, "/loki/api/v1/query", rt.lastRequest.URL.Path)
+}
+
+func TestQueryDataUnsupportedQueryType(t *testing.T) {
+	bytes, err := os.ReadFile(filepath.Join("testdata", "vector_simple.json"))
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bytes)
+	}))
+	t.Cleanup(srv.Close)
+
+	tracer, err := tracing.InitializeTracerForTest()
+	require.NoError(t, err)
+	s := &Service{
+		im:     datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider(), log.New("tsdb.loki.test"))),
+		plog:   log.New("tsdb.loki.test"),
+		tracer: tracer,
+	}
+
+	res, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{URL: srv.URL, JSONData: []byte(`{}`)},
+		},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"expr": "count_over_time({app=\"grafana\"}[5m])", "queryType": "instant"}`)},
+			{RefID: "B", JSON: []byte(`{"expr": "up", "queryType": "stream"}`)},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, res.Responses["A"].Error)
+	require.Len(t, res.Responses["A"].Frames, 2)
+	require.EqualError(t, res.Responses["B"].Error, `unsupported query type: "stream"`)
+}
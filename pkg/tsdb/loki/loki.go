@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,9 +34,10 @@ type Service struct {
 }
 
 func ProvideService(httpClientProvider httpclient.Provider, tracer tracing.Tracer) *Service {
+	plog := log.New("tsdb.loki")
 	return &Service{
-		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
-		plog:   log.New("tsdb.loki"),
+		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider, plog)),
+		plog:   plog,
 		tracer: tracer,
 	}
 }
@@ -51,8 +53,19 @@ type datasourceInfo struct {
 	BasicAuthUser     string
 	BasicAuthPassword string
 	TimeInterval      string `json:"timeInterval"`
+	MaxLines          int    `json:"-"`
 }
 
+// QueryType values select which Loki API endpoint is used.
+const (
+	QueryTypeRange   = "range"
+	QueryTypeInstant = "instant"
+)
+
+// defaultMaxLines is the log line limit used when neither the query nor the
+// data source configure one. It matches the frontend default.
+const defaultMaxLines = 1000
+
 type QueryModel struct {
 	QueryType    string `json:"queryType"`
 	Expr         string `json:"expr"`
@@ -60,9 +73,12 @@ type QueryModel struct {
 	Interval     string `json:"interval"`
 	IntervalMS   int    `json:"intervalMS"`
 	Resolution   int64  `json:"resolution"`
+	MaxLines     int    `json:"maxLines"`
+	// Instant is the query type of queries saved before queryType existed.
+	Instant bool `json:"instant"`
 }
 
-func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+func newInstanceSettings(httpClientProvider httpclient.Provider, plog log.Logger) datasource.InstanceFactoryFunc {
 	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		opts, err := settings.HTTPClientOptions()
 		if err != nil {
@@ -79,17 +95,31 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
-		jsonData := datasourceInfo{}
+		jsonData := struct {
+			TimeInterval string `json:"timeInterval"`
+			// the frontend stores maxLines as a string
+			MaxLines string `json:"maxLines"`
+		}{}
 		err = json.Unmarshal(settings.JSONData, &jsonData)
 		if err != nil {
 			return nil, fmt.Errorf("error reading settings: %w", err)
 		}
 
+		maxLines := defaultMaxLines
+		if jsonData.MaxLines != "" {
+			if parsed, err := strconv.Atoi(jsonData.MaxLines); err == nil && parsed > 0 {
+				maxLines = parsed
+			} else {
+				plog.Warn("Invalid maxLines setting, using the default", "maxLines", jsonData.MaxLines, "default", defaultMaxLines)
+			}
+		}
+
 		model := &datasourceInfo{
 			HTTPClient:        client,
 			URL:               settings.URL,
 			TLSClientConfig:   tlsClientConfig,
 			TimeInterval:      jsonData.TimeInterval,
+			MaxLines:          maxLines,
 			BasicAuthUser:     settings.BasicAuthUser,
 			BasicAuthPassword: settings.DecryptedSecureJSONData["basicAuthPassword"],
 		}
@@ -118,13 +148,13 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		},
 	}
 
-	queries, err := parseQuery(req)
+	queries, err := parseQuery(dsInfo, req)
 	if err != nil {
 		return result, err
 	}
 
 	for _, query := range queries {
-		s.plog.Debug("Sending query", "start", query.Start, "end", query.End, "step", query.Step, "query", query.Expr)
+		s.plog.Debug("Sending query", "type", query.QueryType, "start", query.Start, "end", query.End, "step", query.Step, "query", query.Expr)
 		_, span := s.tracer.Start(ctx, "alerting.loki")
 		span.SetAttributes("expr", query.Expr, attribute.Key("expr").String(query.Expr))
 		span.SetAttributes("start_unixnano", query.Start, attribute.Key("start_unixnano").Int64(query.Start.UnixNano()))
@@ -133,7 +163,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 
 		frames, err := runQuery(client, query)
 		if err != nil {
-			return result, err
+			result.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
 		}
 		queryRes.Frames = frames
 		result.Responses[query.RefID] = queryRes
@@ -160,48 +191,21 @@ func formatLegend(metric model.Metric, query *lokiQuery) string {
 	return string(result)
 }
 
-func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (data.Frames, error) {
-	frames := data.Frames{}
-
-	//We are currently processing only matrix results (for alerting)
-	matrix, ok := value.Data.Result.(loghttp.Matrix)
-	if !ok {
-		return frames, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
-	}
-
-	for _, v := range matrix {
-		name := formatLegend(v.Metric, query)
-		tags := make(map[string]string, len(v.Metric))
-		timeVector := make([]time.Time, 0, len(v.Values))
-		values := make([]float64, 0, len(v.Values))
-
-		for k, v := range v.Metric {
-			tags[string(k)] = string(v)
-		}
-
-		for _, k := range v.Values {
-			timeVector = append(timeVector, time.Unix(k.Timestamp.Unix(), 0).UTC())
-			values = append(values, float64(k.Value))
-		}
-
-		frames = append(frames, data.NewFrame(name,
-			data.NewField("time", nil, timeVector),
-			data.NewField("value", tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name})))
-	}
-
-	return frames, nil
-}
-
 // we extracted this part of the functionality to make it easy to unit-test it
 func runQuery(client *client.DefaultClient, query *lokiQuery) (data.Frames, error) {
-	// `limit` only applies to log-producing queries, and we
-	// currently only support metric queries, so this can be set to any value.
-	limit := 1
-
-	// we do not use `interval`, so we set it to zero
-	interval := time.Duration(0)
-
-	value, err := client.QueryRange(query.Expr, limit, query.Start, query.End, logproto.BACKWARD, query.Step, interval, false)
+	var value *loghttp.QueryResponse
+	var err error
+
+	switch query.QueryType {
+	case QueryTypeInstant:
+		value, err = client.Query(query.Expr, query.MaxLines, query.End, logproto.BACKWARD, false)
+	case QueryTypeRange:
+		// we do not use `interval`, so we set it to zero
+		interval := time.Duration(0)
+		value, err = client.QueryRange(query.Expr, query.MaxLines, query.Start, query.End, logproto.BACKWARD, query.Step, interval, false)
+	default:
+		return data.Frames{}, fmt.Errorf("unsupported query type: %q", query.QueryType)
+	}
 	if err != nil {
 		return data.Frames{}, err
 	}
@@ -15,7 +15,7 @@ func BenchmarkMatrixJson(b *testing.B) {
 
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		_, _ = runQuery(makeMockedClient(200, "application/json", bytes), &lokiQuery{})
+		_, _ = runQuery(makeMockedClient(200, "application/json", bytes), &lokiQuery{QueryType: QueryTypeRange})
 	}
 }
 
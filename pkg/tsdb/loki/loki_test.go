@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/loki/pkg/loghttp"
 	p "github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
@@ -40,12 +43,28 @@ func TestLoki(t *testing.T) {
 	})
 }
 
+func TestInstanceSettings(t *testing.T) {
+	newInstance := newInstanceSettings(httpclient.NewProvider(), log.New("tsdb.loki.test"))
+
+	t.Run("reads max lines from the data source settings", func(t *testing.T) {
+		instance, err := newInstance(backend.DataSourceInstanceSettings{JSONData: []byte(`{"maxLines": "200"}`)})
+		require.NoError(t, err)
+		require.Equal(t, 200, instance.(*datasourceInfo).MaxLines)
+	})
+
+	t.Run("invalid max lines fall back to the default", func(t *testing.T) {
+		instance, err := newInstance(backend.DataSourceInstanceSettings{JSONData: []byte(`{"maxLines": "many"}`)})
+		require.NoError(t, err)
+		require.Equal(t, defaultMaxLines, instance.(*datasourceInfo).MaxLines)
+	})
+}
+
 func TestParseResponse(t *testing.T) {
-	t.Run("value is not of type matrix", func(t *testing.T) {
+	t.Run("value is of an unsupported type", func(t *testing.T) {
 		queryRes := data.Frames{}
 		value := loghttp.QueryResponse{
 			Data: loghttp.QueryResponseData{
-				Result: loghttp.Vector{},
+				Result: nil,
 			},
 		}
 		res, err := parseResponse(&value, nil)
@@ -52,7 +52,7 @@ func interpolateVariables(expr string, interval time.Duration, timeRange time.Du
 	return expr
 }
 
-func parseQuery(queryContext *backend.QueryDataRequest) ([]*lokiQuery, error) {
+func parseQuery(dsInfo *datasourceInfo, queryContext *backend.QueryDataRequest) ([]*lokiQuery, error) {
 	qs := []*lokiQuery{}
 	for _, query := range queryContext.Queries {
 		model := &QueryModel{}
@@ -61,6 +61,24 @@ func parseQuery(queryContext *backend.QueryDataRequest) ([]*lokiQuery, error) {
 			return nil, err
 		}
 
+		// unsupported query types fail when the query is run, so that the
+		// other queries of the request still get a response.
+		queryType := model.QueryType
+		if queryType == "" {
+			queryType = QueryTypeRange
+			if model.Instant {
+				queryType = QueryTypeInstant
+			}
+		}
+
+		maxLines := dsInfo.MaxLines
+		if model.MaxLines > 0 {
+			maxLines = model.MaxLines
+		}
+		if maxLines <= 0 {
+			maxLines = defaultMaxLines
+		}
+
 		start := query.TimeRange.From
 		end := query.TimeRange.To
 
@@ -77,12 +95,14 @@ func parseQuery(queryContext *backend.QueryDataRequest) ([]*lokiQuery, error) {
 		expr := interpolateVariables(model.Expr, interval, timeRange)
 
 		qs = append(qs, &lokiQuery{
+			QueryType:    queryType,
 			Expr:         expr,
 			Step:         step,
 			LegendFormat: model.LegendFormat,
 			Start:        start,
 			End:          end,
 			RefID:        query.RefID,
+			MaxLines:     maxLines,
 		})
 	}
 
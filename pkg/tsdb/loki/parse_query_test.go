@@ -29,10 +29,61 @@ func TestParseQuery(t *testing.T) {
 				},
 			},
 		}
-		models, err := parseQuery(queryContext)
+		models, err := parseQuery(&datasourceInfo{}, queryContext)
 		require.NoError(t, err)
 		require.Equal(t, time.Second*15, models[0].Step)
 		require.Equal(t, "go_goroutines 15s 15000 3000s 3000 3000000", models[0].Expr)
+		require.Equal(t, QueryTypeRange, models[0].QueryType)
+		require.Equal(t, defaultMaxLines, models[0].MaxLines)
+	})
+	t.Run("parsing instant query model with max lines", func(t *testing.T) {
+		queryContext := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					JSON:      []byte(`{"expr": "{app=\"grafana\"}", "queryType": "instant", "maxLines": 20, "refId": "A"}`),
+					TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+					Interval:  time.Second * 15,
+				},
+			},
+		}
+		models, err := parseQuery(&datasourceInfo{MaxLines: 500}, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, QueryTypeInstant, models[0].QueryType)
+		require.Equal(t, 20, models[0].MaxLines)
+	})
+	t.Run("parsing query model with max lines from the data source", func(t *testing.T) {
+		queryContext := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{
+					JSON:      []byte(`{"expr": "{app=\"grafana\"}", "refId": "A"}`),
+					TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+					Interval:  time.Second * 15,
+				},
+			},
+		}
+		models, err := parseQuery(&datasourceInfo{MaxLines: 500}, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, 500, models[0].MaxLines)
+	})
+	t.Run("parsing deprecated instant query model", func(t *testing.T) {
+		queryContext := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{JSON: []byte(`{"expr": "count_over_time({app=\"grafana\"}[5m])", "instant": true, "refId": "A"}`)},
+			},
+		}
+		models, err := parseQuery(&datasourceInfo{}, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, QueryTypeInstant, models[0].QueryType)
+	})
+	t.Run("parsing query model with an unknown query type", func(t *testing.T) {
+		queryContext := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{JSON: []byte(`{"expr": "up", "queryType": "stream", "refId": "A"}`)},
+			},
+		}
+		models, err := parseQuery(&datasourceInfo{}, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, "stream", models[0].QueryType)
 	})
 	t.Run("interpolate variables, range between 1s and 0.5s", func(t *testing.T) {
 		expr := "go_goroutines $__interval $__interval_ms $__range $__range_s $__range_ms"
@@ -3,10 +3,12 @@ package loki
 import "time"
 
 type lokiQuery struct {
+	QueryType    string
 	Expr         string
 	Step         time.Duration
 	LegendFormat string
 	Start        time.Time
 	End          time.Time
 	RefID        string
+	MaxLines     int
 }
@@ -2,6 +2,7 @@ package mssql
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -9,6 +10,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	mssql "github.com/denisenkom/go-mssqldb"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -88,6 +90,7 @@ func newInstanceSettings(cfg *setting.Cfg) datasource.InstanceFactoryFunc {
 			DSInfo:            dsInfo,
 			MetricColumnTypes: []string{"VARCHAR", "CHAR", "NVARCHAR", "NCHAR"},
 			RowLimit:          cfg.DataProxyRowLimit,
+			SetQueryTimeout:   setQueryTimeout,
 		}
 
 		queryResultTransformer := mssqlQueryResultTransformer{
@@ -99,6 +102,14 @@ func newInstanceSettings(cfg *setting.Cfg) datasource.InstanceFactoryFunc {
 }
 
 // ParseURL tries to parse an MSSQL URL string into a URL object.
+// setQueryTimeout makes the server refuse queries that it estimates to run
+// longer than the query timeout. SQL Server has no setting that stops queries
+// once they run longer, Grafana cancels those.
+func setQueryTimeout(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("SET QUERY_GOVERNOR_COST_LIMIT %d", int64(timeout/time.Second)))
+	return err
+}
+
 func ParseURL(u string) (*url.URL, error) {
 	logger.Debug("Parsing MSSQL URL", "url", u)
 
@@ -116,6 +116,9 @@ func newInstanceSettings(cfg *setting.Cfg, httpClientProvider httpclient.Provide
 			TimeColumnNames:   []string{"time", "time_sec"},
 			MetricColumnTypes: []string{"CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT"},
 			RowLimit:          cfg.DataProxyRowLimit,
+			// Grafana cancels queries after the query timeout by closing their
+			// connection. The server is told as well, so that it stops them.
+			SetQueryTimeout: (&queryTimeoutSetter{}).setQueryTimeout,
 		}
 
 		rowTransformer := mysqlQueryResultTransformer{
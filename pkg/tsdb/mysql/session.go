@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VividCortex/mysqlerr"
+	"github.com/go-sql-driver/mysql"
+)
+
+// execer runs statements on a connection, like *sql.Conn.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// queryTimeoutSetter makes the server stop SELECT statements running longer
+// than the query timeout. MySQL and MariaDB name the session variable
+// differently, so the MySQL variable is tried first and the setter remembers
+// when the server is MariaDB.
+type queryTimeoutSetter struct {
+	mu      sync.Mutex
+	mariaDB bool
+}
+
+func (s *queryTimeoutSetter) setQueryTimeout(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	return s.set(ctx, conn, timeout)
+}
+
+// set sets max_execution_time, in milliseconds, for MySQL and
+// max_statement_time, in seconds, for MariaDB.
+func (s *queryTimeoutSetter) set(ctx context.Context, conn execer, timeout time.Duration) error {
+	s.mu.Lock()
+	mariaDB := s.mariaDB
+	s.mu.Unlock()
+
+	if !mariaDB {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", timeout.Milliseconds()))
+		var driverErr *mysql.MySQLError
+		if !errors.As(err, &driverErr) || driverErr.Number != mysqlerr.ER_UNKNOWN_SYSTEM_VARIABLE {
+			return err
+		}
+
+		s.mu.Lock()
+		s.mariaDB = true
+		s.mu.Unlock()
+	}
+
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_statement_time = %d", int64(timeout/time.Second)))
+	return err
+}
@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VividCortex/mysqlerr"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTimeoutSetter(t *testing.T) {
+	t.Run("sets max_execution_time for MySQL", func(t *testing.T) {
+		conn := &fakeExecer{}
+		s := &queryTimeoutSetter{}
+
+		require.NoError(t, s.set(context.Background(), conn, 30*time.Second))
+		require.Equal(t, []string{"SET SESSION max_execution_time = 30000"}, conn.statements)
+	})
+
+	t.Run("sets max_statement_time for MariaDB and remembers it", func(t *testing.T) {
+		conn := &fakeExecer{errs: map[string]error{
+			"SET SESSION max_execution_time = 30000": &mysql.MySQLError{Number: mysqlerr.ER_UNKNOWN_SYSTEM_VARIABLE},
+		}}
+		s := &queryTimeoutSetter{}
+
+		require.NoError(t, s.set(context.Background(), conn, 30*time.Second))
+		require.NoError(t, s.set(context.Background(), conn, 30*time.Second))
+		require.Equal(t, []string{
+			"SET SESSION max_execution_time = 30000",
+			"SET SESSION max_statement_time = 30",
+			"SET SESSION max_statement_time = 30",
+		}, conn.statements)
+	})
+
+	t.Run("returns other errors", func(t *testing.T) {
+		conn := &fakeExecer{errs: map[string]error{
+			"SET SESSION max_execution_time = 30000": errors.New("connection lost"),
+		}}
+		s := &queryTimeoutSetter{}
+
+		require.EqualError(t, s.set(context.Background(), conn, 30*time.Second), "connection lost")
+		require.False(t, s.mariaDB)
+	})
+}
+
+type fakeExecer struct {
+	errs       map[string]error
+	statements []string
+}
+
+func (e *fakeExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	e.statements = append(e.statements, query)
+	return nil, e.errs[query]
+}
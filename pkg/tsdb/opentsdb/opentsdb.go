@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
@@ -23,25 +26,57 @@ import (
 	"golang.org/x/net/context/ctxhttp"
 )
 
+var logger = log.New("tsdb.opentsdb")
+
 type Service struct {
-	logger log.Logger
-	im     instancemgmt.InstanceManager
+	logger          log.Logger
+	im              instancemgmt.InstanceManager
+	resourceHandler backend.CallResourceHandler
 }
 
 func ProvideService(httpClientProvider httpclient.Provider) *Service {
-	return &Service{
-		logger: log.New("tsdb.opentsdb"),
+	s := &Service{
+		logger: logger,
 		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
 	}
+
+	s.resourceHandler = httpadapter.New(s.newResourceMux())
+
+	return s
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return s.resourceHandler.CallResource(ctx, req, sender)
 }
 
 type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
+	// MsResolution is set when the data source is configured for millisecond
+	// timestamps, the default being seconds.
+	MsResolution bool
+	LookupLimit  int
 }
 
 type DsAccess string
 
+const (
+	resolutionMilliseconds = 2
+	defaultLookupLimit     = 1000
+
+	// annotationQueryType is the query type of annotation queries, which return
+	// the annotations stored for a metric instead of its data points.
+	annotationQueryType = "annotation"
+)
+
+// fillPolicies are the downsampling fill policies supported by OpenTSDB.
+var fillPolicies = map[string]bool{
+	"none": true,
+	"nan":  true,
+	"null": true,
+	"zero": true,
+}
+
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
 	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		opts, err := settings.HTTPClientOptions()
@@ -54,9 +89,24 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		jsonData, err := simplejson.NewJson(settings.JSONData)
+		if err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
+			HTTPClient:   client,
+			URL:          settings.URL,
+			MsResolution: jsonData.Get("tsdbResolution").MustInt() == resolutionMilliseconds,
+			LookupLimit:  defaultLookupLimit,
+		}
+
+		// The lookup limit is saved as a string by the config editor.
+		lookupLimit := jsonData.Get("lookupLimit")
+		if limit, err := lookupLimit.Int(); err == nil && limit > 0 {
+			model.LookupLimit = limit
+		} else if limit, err := strconv.Atoi(lookupLimit.MustString()); err == nil && limit > 0 {
+			model.LookupLimit = limit
 		}
 
 		return model, nil
@@ -64,44 +114,107 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 }
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	var tsdbQuery OpenTsdbQuery
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
 
-	q := req.Queries[0]
+	resp := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		var frames data.Frames
+		var err error
+		if query.QueryType == annotationQueryType {
+			frames, err = s.executeAnnotationQuery(ctx, dsInfo, query)
+		} else {
+			frames, err = s.executeTimeSeriesQuery(ctx, dsInfo, query)
+		}
+		if err != nil {
+			resp.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+		for _, frame := range frames {
+			frame.RefID = query.RefID
+		}
+		resp.Responses[query.RefID] = backend.DataResponse{Frames: frames}
+	}
 
-	tsdbQuery.Start = q.TimeRange.From.UnixNano() / int64(time.Millisecond)
-	tsdbQuery.End = q.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	return resp, nil
+}
 
-	for _, query := range req.Queries {
-		metric := s.buildMetric(query)
-		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
+func (s *Service) executeTimeSeriesQuery(ctx context.Context, dsInfo *datasourceInfo, query backend.DataQuery) (data.Frames, error) {
+	if err := validateFillPolicy(query); err != nil {
+		return nil, err
 	}
 
-	// TODO: Don't use global variable
-	if setting.Env == setting.Dev {
-		s.logger.Debug("OpenTsdb request", "params", tsdbQuery)
+	tsdbQuery := newOpenTsdbQuery(dsInfo, query)
+	tsdbQuery.Queries = append(tsdbQuery.Queries, s.buildMetric(query))
+
+	responseData, err := s.doQuery(ctx, dsInfo, tsdbQuery)
+	if err != nil {
+		return nil, err
 	}
+	return s.toDataFrames(responseData, dsInfo.MsResolution)
+}
 
-	dsInfo, err := s.getDSInfo(req.PluginContext)
+// executeAnnotationQuery returns the annotations OpenTSDB stores for the
+// metric of the query, or the global annotations when isGlobal is set.
+func (s *Service) executeAnnotationQuery(ctx context.Context, dsInfo *datasourceInfo, query backend.DataQuery) (data.Frames, error) {
+	model, err := simplejson.NewJson(query.JSON)
 	if err != nil {
 		return nil, err
 	}
+	metric := model.Get("target").MustString()
+	if metric == "" {
+		return nil, fmt.Errorf("annotation query has no target metric")
+	}
 
-	request, err := s.createRequest(dsInfo, tsdbQuery)
+	tsdbQuery := newOpenTsdbQuery(dsInfo, query)
+	tsdbQuery.GlobalAnnotations = true
+	tsdbQuery.Queries = append(tsdbQuery.Queries, map[string]interface{}{
+		"metric":     metric,
+		"aggregator": "sum",
+	})
+
+	responseData, err := s.doQuery(ctx, dsInfo, tsdbQuery)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return nil, err
 	}
 
-	res, err := ctxhttp.Do(ctx, dsInfo.HTTPClient, request)
+	var annotations []OpenTsdbAnnotation
+	if len(responseData) > 0 {
+		annotations = responseData[0].Annotations
+		if model.Get("isGlobal").MustBool() {
+			annotations = responseData[0].GlobalAnnotations
+		}
+	}
+	return data.Frames{toAnnotationFrame(annotations)}, nil
+}
+
+func newOpenTsdbQuery(dsInfo *datasourceInfo, query backend.DataQuery) OpenTsdbQuery {
+	return OpenTsdbQuery{
+		Start:        query.TimeRange.From.UnixNano() / int64(time.Millisecond),
+		End:          query.TimeRange.To.UnixNano() / int64(time.Millisecond),
+		MsResolution: dsInfo.MsResolution,
+	}
+}
+
+func (s *Service) doQuery(ctx context.Context, dsInfo *datasourceInfo, tsdbQuery OpenTsdbQuery) ([]OpenTsdbResponse, error) {
+	// TODO: Don't use global variable
+	if setting.Env == setting.Dev {
+		s.logger.Debug("OpenTsdb request", "params", tsdbQuery)
+	}
+
+	request, err := s.createRequest(dsInfo, tsdbQuery)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return nil, err
 	}
 
-	result, err := s.parseResponse(res)
+	res, err := ctxhttp.Do(ctx, dsInfo.HTTPClient, request)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return nil, err
 	}
 
-	return result, nil
+	return s.parseResponse(res)
 }
 
 func (s *Service) createRequest(dsInfo *datasourceInfo, data OpenTsdbQuery) (*http.Request, error) {
@@ -127,9 +240,11 @@ func (s *Service) createRequest(dsInfo *datasourceInfo, data OpenTsdbQuery) (*ht
 	return req, nil
 }
 
-func (s *Service) parseResponse(res *http.Response) (*backend.QueryDataResponse, error) {
-	resp := backend.NewQueryDataResponse()
+// nanValue matches NaN data points, which OpenTSDB writes unquoted with the
+// nan fill policy, making the response invalid JSON.
+var nanValue = regexp.MustCompile(`:\s*NaN\b`)
 
+func (s *Service) parseResponse(res *http.Response) ([]OpenTsdbResponse, error) {
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
@@ -146,35 +261,93 @@ func (s *Service) parseResponse(res *http.Response) (*backend.QueryDataResponse,
 	}
 
 	var responseData []OpenTsdbResponse
-	err = json.Unmarshal(body, &responseData)
+	err = json.Unmarshal(nanValue.ReplaceAll(body, []byte(":null")), &responseData)
 	if err != nil {
 		s.logger.Info("Failed to unmarshal opentsdb response", "error", err, "status", res.Status, "body", string(body))
 		return nil, err
 	}
 
+	return responseData, nil
+}
+
+// toDataFrames converts the series of a query response to frames, one per
+// series, labelled with the tags of the series.
+func (s *Service) toDataFrames(responseData []OpenTsdbResponse, msResolution bool) (data.Frames, error) {
 	frames := data.Frames{}
 	for _, val := range responseData {
-		timeVector := make([]time.Time, 0, len(val.DataPoints))
-		values := make([]float64, 0, len(val.DataPoints))
-		name := val.Metric
-
+		points := make([]dataPoint, 0, len(val.DataPoints))
 		for timeString, value := range val.DataPoints {
 			timestamp, err := strconv.ParseInt(timeString, 10, 64)
 			if err != nil {
 				s.logger.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
 				return nil, err
 			}
-			timeVector = append(timeVector, time.Unix(timestamp, 0).UTC())
-			values = append(values, value)
+			t := time.Unix(timestamp, 0).UTC()
+			if msResolution {
+				t = time.Unix(0, timestamp*int64(time.Millisecond)).UTC()
+			}
+			points = append(points, dataPoint{time: t, value: value})
+		}
+		// Data points are returned as an object, without a defined order.
+		sort.Slice(points, func(i, j int) bool {
+			return points[i].time.Before(points[j].time)
+		})
+
+		timeVector := make([]time.Time, 0, len(points))
+		values := make([]*float64, 0, len(points))
+		for _, p := range points {
+			timeVector = append(timeVector, p.time)
+			values = append(values, p.value)
+		}
+
+		var labels data.Labels
+		if len(val.Tags) > 0 {
+			labels = data.Labels(val.Tags)
 		}
-		frames = append(frames, data.NewFrame(name,
+
+		frames = append(frames, data.NewFrame(val.Metric,
 			data.NewField("time", nil, timeVector),
-			data.NewField("value", nil, values)))
+			data.NewField("value", labels, values)))
 	}
-	result := resp.Responses["A"]
-	result.Frames = frames
-	resp.Responses["A"] = result
-	return resp, nil
+	return frames, nil
+}
+
+func toAnnotationFrame(annotations []OpenTsdbAnnotation) *data.Frame {
+	timeVector := make([]time.Time, 0, len(annotations))
+	timeEndVector := make([]*time.Time, 0, len(annotations))
+	textVector := make([]string, 0, len(annotations))
+	for _, annotation := range annotations {
+		timeVector = append(timeVector, time.Unix(int64(annotation.StartTime), 0).UTC())
+		var endTime *time.Time
+		if annotation.EndTime > 0 {
+			t := time.Unix(int64(annotation.EndTime), 0).UTC()
+			endTime = &t
+		}
+		timeEndVector = append(timeEndVector, endTime)
+		textVector = append(textVector, annotation.Description)
+	}
+
+	return data.NewFrame("annotations",
+		data.NewField("time", nil, timeVector),
+		data.NewField("timeEnd", nil, timeEndVector),
+		data.NewField("text", nil, textVector))
+}
+
+// validateFillPolicy checks the downsample fill policy of the query, which is
+// otherwise passed to OpenTSDB as is.
+func validateFillPolicy(query backend.DataQuery) error {
+	model, err := simplejson.NewJson(query.JSON)
+	if err != nil {
+		return err
+	}
+	if model.Get("disableDownsampling").MustBool() {
+		return nil
+	}
+	policy := model.Get("downsampleFillPolicy").MustString()
+	if policy != "" && !fillPolicies[policy] {
+		return fmt.Errorf("unsupported downsample fill policy %q", policy)
+	}
+	return nil
 }
 
 func (s *Service) buildMetric(query backend.DataQuery) map[string]interface{} {
@@ -197,8 +370,8 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]interface{} {
 			downsampleInterval = "1m" // default value for blank
 		}
 		downsample := downsampleInterval + "-" + model.Get("downsampleAggregator").MustString()
-		if model.Get("downsampleFillPolicy").MustString() != "none" {
-			metric["downsample"] = downsample + "-" + model.Get("downsampleFillPolicy").MustString()
+		if fillPolicy := model.Get("downsampleFillPolicy").MustString(); fillPolicy != "" && fillPolicy != "none" {
+			metric["downsample"] = downsample + "-" + fillPolicy
 		} else {
 			metric["downsample"] = downsample
 		}
@@ -1,8 +1,11 @@
 package opentsdb
 
 import (
+	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -10,6 +13,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,8 +59,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			data.NewField("time", nil, []time.Time{
 				time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC),
 			}),
-			data.NewField("value", nil, []float64{
-				50}),
+			data.NewField("value", nil, []*float64{
+				float64Ptr(50)}),
 		)
 
 		resp := http.Response{Body: ioutil.NopCloser(strings.NewReader(response))}
@@ -64,13 +68,73 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		result, err := service.parseResponse(&resp)
 		require.NoError(t, err)
 
-		frame := result.Responses["A"]
+		frames, err := service.toDataFrames(result, false)
+		require.NoError(t, err)
 
-		if diff := cmp.Diff(testFrame, frame.Frames[0], data.FrameTestCompareOptions()...); diff != "" {
+		if diff := cmp.Diff(testFrame, frames[0], data.FrameTestCompareOptions()...); diff != "" {
 			t.Errorf("Result mismatch (-want +got):\n%s", diff)
 		}
 	})
 
+	t.Run("Parse response should label frames with tags and sort data points", func(t *testing.T) {
+		response := `
+		[
+			{
+				"metric": "cpu",
+				"tags": {"host": "a", "env": "prod"},
+				"aggregateTags": ["core"],
+				"dps": {
+					"1405544206": NaN,
+					"1405544146": 50.0,
+					"1405544266": null
+				}
+			}
+		]`
+
+		resp := http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(response))}
+		result, err := service.parseResponse(&resp)
+		require.NoError(t, err)
+
+		frames, err := service.toDataFrames(result, false)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+
+		testFrame := data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{
+				time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC),
+				time.Date(2014, 7, 16, 20, 56, 46, 0, time.UTC),
+				time.Date(2014, 7, 16, 20, 57, 46, 0, time.UTC),
+			}),
+			data.NewField("value", data.Labels{"host": "a", "env": "prod"}, []*float64{float64Ptr(50), nil, nil}),
+		)
+		if diff := cmp.Diff(testFrame, frames[0], data.FrameTestCompareOptions()...); diff != "" {
+			t.Errorf("Result mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Parse response with millisecond resolution", func(t *testing.T) {
+		response := `[{"metric": "cpu", "dps": {"1405544146500": 1}}]`
+
+		resp := http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(response))}
+		result, err := service.parseResponse(&resp)
+		require.NoError(t, err)
+
+		frames, err := service.toDataFrames(result, true)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2014, 7, 16, 20, 55, 46, 500000000, time.UTC), frames[0].Fields[0].At(0))
+	})
+
+	t.Run("Validate fill policy", func(t *testing.T) {
+		err := validateFillPolicy(backend.DataQuery{JSON: []byte(`{"downsampleFillPolicy": "zero"}`)})
+		require.NoError(t, err)
+
+		err = validateFillPolicy(backend.DataQuery{JSON: []byte(`{"downsampleFillPolicy": "previous"}`)})
+		require.EqualError(t, err, `unsupported downsample fill policy "previous"`)
+
+		err = validateFillPolicy(backend.DataQuery{JSON: []byte(`{"disableDownsampling": true, "downsampleFillPolicy": "previous"}`)})
+		require.NoError(t, err)
+	})
+
 	t.Run("Build metric with downsampling enabled", func(t *testing.T) {
 		query := backend.DataQuery{
 			JSON: []byte(`
@@ -240,3 +304,161 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		require.Equal(t, float64(60), metricRateOptions["resetValue"])
 	})
 }
+
+func TestQueryData(t *testing.T) {
+	var requests []OpenTsdbQuery
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/api/query", req.URL.Path)
+		var tsdbQuery OpenTsdbQuery
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&tsdbQuery))
+		requests = append(requests, tsdbQuery)
+
+		switch tsdbQuery.Queries[0]["metric"] {
+		case "cpu":
+			_, _ = rw.Write([]byte(`[
+				{"metric": "cpu", "tags": {"host": "a"}, "dps": {"1405544146": 1}},
+				{"metric": "cpu", "tags": {"host": "b"}, "dps": {"1405544146": 2}}
+			]`))
+		case "deploys":
+			_, _ = rw.Write([]byte(`[{
+				"metric": "deploys",
+				"dps": {},
+				"annotations": [{"startTime": 1405544146, "description": "deploy"}],
+				"globalAnnotations": [{"startTime": 1405544146, "endTime": 1405544206, "description": "outage"}]
+			}]`))
+		default:
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	service := ProvideService(httpclient.NewProvider())
+	pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL, JSONData: []byte(`{}`)}}
+	timeRange := backend.TimeRange{From: time.Unix(1405544100, 0), To: time.Unix(1405544400, 0)}
+
+	t.Run("Should send a request per query and key responses by refId", func(t *testing.T) {
+		requests = nil
+		resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "aggregator": "sum", "downsampleAggregator": "avg", "downsampleFillPolicy": "null"}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"metric": "mem", "aggregator": "sum"}`)},
+				{RefID: "C", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "downsampleFillPolicy": "previous"}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, requests, 2)
+		require.Equal(t, int64(1405544100000), requests[0].Start)
+		require.Equal(t, "1m-avg-null", requests[0].Queries[0]["downsample"])
+
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 2)
+		require.Equal(t, "A", frames[0].RefID)
+		require.Equal(t, data.Labels{"host": "a"}, frames[0].Fields[1].Labels)
+		require.Equal(t, data.Labels{"host": "b"}, frames[1].Fields[1].Labels)
+
+		require.Error(t, resp.Responses["B"].Error)
+		require.EqualError(t, resp.Responses["C"].Error, `unsupported downsample fill policy "previous"`)
+	})
+
+	t.Run("Should return annotations", func(t *testing.T) {
+		requests = nil
+		resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Queries: []backend.DataQuery{
+				{RefID: "A", QueryType: annotationQueryType, TimeRange: timeRange, JSON: []byte(`{"target": "deploys"}`)},
+				{RefID: "B", QueryType: annotationQueryType, TimeRange: timeRange, JSON: []byte(`{"target": "deploys", "isGlobal": true}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, requests[0].GlobalAnnotations)
+
+		frame := resp.Responses["A"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, time.Unix(1405544146, 0).UTC(), frame.Fields[0].At(0))
+		require.Nil(t, frame.Fields[1].At(0))
+		require.Equal(t, "deploy", frame.Fields[2].At(0))
+
+		frame = resp.Responses["B"].Frames[0]
+		require.Equal(t, time.Unix(1405544206, 0).UTC(), *frame.Fields[1].At(0).(*time.Time))
+		require.Equal(t, "outage", frame.Fields[2].At(0))
+	})
+}
+
+func TestResources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/suggest":
+			require.Equal(t, "metrics", req.URL.Query().Get("type"))
+			require.Equal(t, "cp", req.URL.Query().Get("q"))
+			require.Equal(t, "50", req.URL.Query().Get("max"))
+			_, _ = rw.Write([]byte(`["cpu"]`))
+		case "/api/search/lookup":
+			require.Equal(t, "50", req.URL.Query().Get("limit"))
+			switch req.URL.Query().Get("m") {
+			case "cpu":
+				_, _ = rw.Write([]byte(`{"results": [{"metric": "cpu", "tags": {"host": "a", "env": "prod"}}, {"metric": "cpu", "tags": {"host": "b", "dc": "eu"}}]}`))
+			case "cpu{host=*,env=prod}":
+				_, _ = rw.Write([]byte(`{"results": [{"metric": "cpu", "tags": {"host": "b", "env": "prod"}}, {"metric": "cpu", "tags": {"host": "a", "env": "prod"}}]}`))
+			default:
+				rw.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	service := ProvideService(httpclient.NewProvider())
+	pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL, JSONData: []byte(`{"lookupLimit": "50"}`)}}
+
+	callResource := func(t *testing.T, path, query string) *backend.CallResourceResponse {
+		t.Helper()
+		sender := &fakeSender{}
+		err := service.CallResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: pluginCtx,
+			Method:        http.MethodGet,
+			Path:          path,
+			URL:           path + "?" + query,
+		}, sender)
+		require.NoError(t, err)
+		require.NotNil(t, sender.resp)
+		return sender.resp
+	}
+
+	t.Run("Should suggest metrics", func(t *testing.T) {
+		resp := callResource(t, "suggest", "type=metrics&q=cp")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["cpu"]`, string(resp.Body))
+	})
+
+	t.Run("Should reject an unknown suggest type", func(t *testing.T) {
+		resp := callResource(t, "suggest", "type=hosts&q=cp")
+		require.Equal(t, http.StatusBadRequest, resp.Status)
+	})
+
+	t.Run("Should return tag keys", func(t *testing.T) {
+		resp := callResource(t, "tag-keys", "metric=cpu")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["dc","env","host"]`, string(resp.Body))
+	})
+
+	t.Run("Should return tag values", func(t *testing.T) {
+		resp := callResource(t, "tag-values", "metric=cpu&key=host&filter=env%3Dprod")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["a","b"]`, string(resp.Body))
+	})
+}
+
+type fakeSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(resp *backend.CallResourceResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,200 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// suggestTypes are the types of names the /api/suggest API completes.
+var suggestTypes = map[string]bool{
+	"metrics": true,
+	"tagk":    true,
+	"tagv":    true,
+}
+
+func (s *Service) newResourceMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/suggest", s.handleSuggest)
+	mux.HandleFunc("/tag-keys", s.handleTagKeys)
+	mux.HandleFunc("/tag-values", s.handleTagValues)
+	return mux
+}
+
+// handleSuggest returns the metric names, tag keys or tag values starting
+// with the q parameter.
+func (s *Service) handleSuggest(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	suggestType := query.Get("type")
+	if !suggestTypes[suggestType] {
+		writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("unsupported suggest type %q", suggestType))
+		return
+	}
+
+	dsInfo, err := s.getDSInfo(httpadapter.PluginConfigFromContext(req.Context()))
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("unexpected error %v", err))
+		return
+	}
+
+	params := url.Values{
+		"type": []string{suggestType},
+		"q":    []string{query.Get("q")},
+		"max":  []string{strconv.Itoa(dsInfo.LookupLimit)},
+	}
+	var values []string
+	if code, err := s.doResourceRequest(req, dsInfo, "api/suggest", params, &values); err != nil {
+		writeResponse(rw, code, fmt.Sprintf("unexpected error %v", err))
+		return
+	}
+	if values == nil {
+		values = []string{}
+	}
+	writeJSONResponse(rw, values)
+}
+
+// handleTagKeys returns the tag keys of the series of a metric.
+func (s *Service) handleTagKeys(rw http.ResponseWriter, req *http.Request) {
+	metric := req.URL.Query().Get("metric")
+	if metric == "" {
+		writeResponse(rw, http.StatusBadRequest, "metric parameter is required")
+		return
+	}
+
+	results, code, err := s.lookup(req, metric)
+	if err != nil {
+		writeResponse(rw, code, fmt.Sprintf("unexpected error %v", err))
+		return
+	}
+
+	keys := map[string]bool{}
+	for _, r := range results.Results {
+		for key := range r.Tags {
+			keys[key] = true
+		}
+	}
+	writeJSONResponse(rw, sortedKeys(keys))
+}
+
+// handleTagValues returns the values of the key tag of the series of a
+// metric, optionally restricted by filter parameters such as env=prod.
+func (s *Service) handleTagValues(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	metric, key := query.Get("metric"), query.Get("key")
+	if metric == "" || key == "" {
+		writeResponse(rw, http.StatusBadRequest, "metric and key parameters are required")
+		return
+	}
+
+	tags := append([]string{key + "=*"}, query["filter"]...)
+	results, code, err := s.lookup(req, fmt.Sprintf("%s{%s}", metric, strings.Join(tags, ",")))
+	if err != nil {
+		writeResponse(rw, code, fmt.Sprintf("unexpected error %v", err))
+		return
+	}
+
+	values := map[string]bool{}
+	for _, r := range results.Results {
+		if value, ok := r.Tags[key]; ok {
+			values[value] = true
+		}
+	}
+	writeJSONResponse(rw, sortedKeys(values))
+}
+
+func (s *Service) lookup(req *http.Request, m string) (*lookupResponse, int, error) {
+	dsInfo, err := s.getDSInfo(httpadapter.PluginConfigFromContext(req.Context()))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	params := url.Values{
+		"m":     []string{m},
+		"limit": []string{strconv.Itoa(dsInfo.LookupLimit)},
+	}
+	var results lookupResponse
+	if code, err := s.doResourceRequest(req, dsInfo, "api/search/lookup", params, &results); err != nil {
+		return nil, code, err
+	}
+	return &results, http.StatusOK, nil
+}
+
+// doResourceRequest sends a GET request to the given OpenTSDB endpoint and
+// decodes the JSON response into v. It returns the status code to reply with
+// on failure.
+func (s *Service) doResourceRequest(req *http.Request, dsInfo *datasourceInfo, endpoint string, params url.Values, v interface{}) (int, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	u.Path = path.Join(u.Path, endpoint)
+	u.RawQuery = params.Encode()
+
+	tsdbReq, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := ctxhttp.Do(req.Context(), dsInfo.HTTPClient, tsdbReq)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	if res.StatusCode/100 != 2 {
+		s.logger.Info("Request failed", "status", res.Status, "body", string(body))
+		return res.StatusCode, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return http.StatusBadGateway, fmt.Errorf("failed to unmarshal opentsdb response: %w", err)
+	}
+	return http.StatusOK, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeJSONResponse(rw http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("error formatting response %v", err))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	writeResponseBytes(rw, http.StatusOK, body)
+}
+
+func writeResponseBytes(rw http.ResponseWriter, code int, msg []byte) {
+	rw.WriteHeader(code)
+	_, err := rw.Write(msg)
+	if err != nil {
+		logger.Error("Unable to write HTTP response", "error", err)
+	}
+}
+
+func writeResponse(rw http.ResponseWriter, code int, msg string) {
+	writeResponseBytes(rw, code, []byte(msg))
+}
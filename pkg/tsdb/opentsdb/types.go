@@ -1,12 +1,39 @@
 package opentsdb
 
+import "time"
+
 type OpenTsdbQuery struct {
-	Start   int64                    `json:"start"`
-	End     int64                    `json:"end"`
-	Queries []map[string]interface{} `json:"queries"`
+	Start             int64                    `json:"start"`
+	End               int64                    `json:"end"`
+	Queries           []map[string]interface{} `json:"queries"`
+	MsResolution      bool                     `json:"msResolution,omitempty"`
+	GlobalAnnotations bool                     `json:"globalAnnotations,omitempty"`
 }
 
 type OpenTsdbResponse struct {
-	Metric     string             `json:"metric"`
-	DataPoints map[string]float64 `json:"dps"`
+	Metric            string               `json:"metric"`
+	Tags              map[string]string    `json:"tags"`
+	AggregateTags     []string             `json:"aggregateTags"`
+	DataPoints        map[string]*float64  `json:"dps"`
+	Annotations       []OpenTsdbAnnotation `json:"annotations"`
+	GlobalAnnotations []OpenTsdbAnnotation `json:"globalAnnotations"`
+}
+
+type OpenTsdbAnnotation struct {
+	StartTime   float64 `json:"startTime"`
+	EndTime     float64 `json:"endTime"`
+	Description string  `json:"description"`
+}
+
+type dataPoint struct {
+	time  time.Time
+	value *float64
+}
+
+// lookupResponse is the response of the /api/search/lookup API.
+type lookupResponse struct {
+	Results []struct {
+		Metric string            `json:"metric"`
+		Tags   map[string]string `json:"tags"`
+	} `json:"results"`
 }
@@ -164,6 +164,11 @@ func (s *Service) generateConnectionString(dsInfo sqleng.DataSourceInfo) (string
 		return "", fmt.Errorf("TLS/SSL client certificate and key must both be specified")
 	}
 
+	// Run-time parameters in the connection string are set when the session starts.
+	if dsInfo.JsonData.QueryTimeout > 0 {
+		connStr += fmt.Sprintf(" statement_timeout=%d", dsInfo.JsonData.QueryTimeout*1000)
+	}
+
 	logger.Debug("Generated Postgres connection string successfully")
 	return connStr, nil
 }
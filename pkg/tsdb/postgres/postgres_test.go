@@ -39,6 +39,7 @@ func TestGenerateConnectionString(t *testing.T) {
 		expConnStr  string
 		expErr      string
 		uid         string
+		jsonData    sqleng.JsonData
 	}{
 		{
 			desc:        "Unix socket host",
@@ -108,6 +109,16 @@ func TestGenerateConnectionString(t *testing.T) {
 			expConnStr: "user='user' password='password' host='host' dbname='database' sslmode='verify-full' " +
 				"sslrootcert='i/am/coding/ca.crt' sslcert='i/am/coding/client.crt' sslkey='i/am/coding/client.key'",
 		},
+		{
+			desc:        "Query timeout",
+			host:        "host",
+			user:        "user",
+			password:    "password",
+			database:    "database",
+			tlsSettings: tlsSettings{Mode: "disable"},
+			jsonData:    sqleng.JsonData{QueryTimeout: 30},
+			expConnStr:  "user='user' password='password' host='host' dbname='database' sslmode='disable' statement_timeout=30000",
+		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -121,6 +132,7 @@ func TestGenerateConnectionString(t *testing.T) {
 				DecryptedSecureJSONData: map[string]string{"password": tt.password},
 				Database:                tt.database,
 				UID:                     tt.uid,
+				JsonData:                tt.jsonData,
 			}
 
 			connStr, err := svc.generateConnectionString(ds)
@@ -12,6 +12,7 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
@@ -66,8 +67,8 @@ func (s *Service) runQueries(ctx context.Context, client apiv1.API, queries []*P
 		timeRange := apiv1.Range{
 			Step: query.Step,
 			// Align query range to step. It rounds start and end down to a multiple of step.
-			Start: alignTimeRange(query.Start, query.Step, query.UtcOffsetSec),
-			End:   alignTimeRange(query.End, query.Step, query.UtcOffsetSec),
+			Start: intervalv2.AlignTimeRange(query.Start, query.Step, query.UtcOffsetSec),
+			End:   intervalv2.AlignTimeRange(query.End, query.Step, query.UtcOffsetSec),
 		}
 
 		if query.RangeQuery {
@@ -131,6 +132,24 @@ func (s *Service) executeTimeSeriesQuery(ctx context.Context, req *backend.Query
 	return s.runQueries(ctx, client, queries)
 }
 
+// QueryWithClient runs the queries of req with client, for data sources with
+// a Prometheus-compatible API. The frames are the same as the ones returned by
+// Prometheus data sources, timeInterval being their minimal step.
+func QueryWithClient(ctx context.Context, req *backend.QueryDataRequest, client apiv1.API, timeInterval string,
+	tracer tracing.Tracer) (*backend.QueryDataResponse, error) {
+	s := &Service{
+		intervalCalculator: intervalv2.NewCalculator(),
+		tracer:             tracer,
+	}
+
+	queries, err := s.parseTimeSeriesQuery(req, &DatasourceInfo{TimeInterval: timeInterval})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.runQueries(ctx, client, queries)
+}
+
 func formatLegend(metric model.Metric, query *PrometheusQuery) string {
 	var legend string
 
@@ -202,13 +221,21 @@ func (s *Service) parseTimeSeriesQuery(queryContext *backend.QueryDataRequest, d
 	return qs, nil
 }
 
+// queryTypes is the order in which the frames of the query types are returned.
+var queryTypes = []TimeSeriesQueryType{RangeQueryType, InstantQueryType, ExemplarQueryType}
+
 func parseTimeSeriesResponse(value map[TimeSeriesQueryType]interface{}, query *PrometheusQuery) (data.Frames, error) {
 	var (
 		frames     = data.Frames{}
 		nextFrames = data.Frames{}
 	)
 
-	for _, value := range value {
+	for _, queryType := range queryTypes {
+		value, ok := value[queryType]
+		if !ok {
+			continue
+		}
+
 		// Zero out the slice to prevent data corruption.
 		nextFrames = nextFrames[:0]
 
@@ -219,6 +246,8 @@ func parseTimeSeriesResponse(value map[TimeSeriesQueryType]interface{}, query *P
 			nextFrames = vectorToDataFrames(v, query, nextFrames)
 		case *model.Scalar:
 			nextFrames = scalarToDataFrames(v, query, nextFrames)
+		case *model.String:
+			nextFrames = stringToDataFrames(v, query, nextFrames)
 		case []apiv1.ExemplarQueryResult:
 			nextFrames = exemplarToDataFrames(v, query, nextFrames)
 		default:
@@ -226,12 +255,25 @@ func parseTimeSeriesResponse(value map[TimeSeriesQueryType]interface{}, query *P
 			continue
 		}
 
+		for _, frame := range nextFrames {
+			frame.RefID = query.RefId
+			frame.Meta.ExecutedQueryString = executedQueryString(query, queryType)
+		}
+
 		frames = append(frames, nextFrames...)
 	}
 
 	return frames, nil
 }
 
+// executedQueryString describes the query sent to Prometheus, shown in the query inspector.
+func executedQueryString(query *PrometheusQuery, queryType TimeSeriesQueryType) string {
+	if queryType == InstantQueryType {
+		return "Expr: " + query.Expr + "\nTime: " + query.End.UTC().Format(time.RFC3339)
+	}
+	return "Expr: " + query.Expr + "\nStep: " + query.Step.String()
+}
+
 func calculatePrometheusInterval(model *QueryModel, dsInfo *DatasourceInfo, query backend.DataQuery, intervalCalculator intervalv2.Calculator) (time.Duration, error) {
 	queryInterval := model.Interval
 
@@ -309,48 +351,47 @@ func interpolateVariables(model *QueryModel, interval time.Duration, timeRange t
 }
 
 func matrixToDataFrames(matrix model.Matrix, query *PrometheusQuery, frames data.Frames) data.Frames {
+	step := query.Step.Milliseconds()
+	start := intervalv2.AlignTimeRange(query.Start, query.Step, query.UtcOffsetSec).UnixMilli()
+	end := intervalv2.AlignTimeRange(query.End, query.Step, query.UtcOffsetSec).UnixMilli()
+
 	for _, v := range matrix {
 		tags := make(map[string]string, len(v.Metric))
 		for k, v := range v.Metric {
 			tags[string(k)] = string(v)
 		}
 
-		baseTimestamp := alignTimeRange(query.Start, query.Step, query.UtcOffsetSec).UnixMilli()
-		endTimestamp := alignTimeRange(query.End, query.Step, query.UtcOffsetSec).UnixMilli()
 		// For each step we create 1 data point. This results in range / step + 1 data points.
-		datapointsCount := int((endTimestamp-baseTimestamp)/query.Step.Milliseconds()) + 1
-
-		timeField := data.NewFieldFromFieldType(data.FieldTypeTime, datapointsCount)
-		valueField := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, datapointsCount)
-		idx := 0
+		datapointsCount := len(v.Values)
+		if step > 0 {
+			datapointsCount = int((end-start)/step) + 1
+		}
+		times := make([]time.Time, 0, datapointsCount)
+		values := make([]*float64, 0, datapointsCount)
 
+		// Missing steps are filled with null values, the same way the query editor does.
+		baseTimestamp := start
 		for _, pair := range v.Values {
 			timestamp := int64(pair.Timestamp)
-			value := float64(pair.Value)
-
-			for t := baseTimestamp; t < timestamp; t += query.Step.Milliseconds() {
-				timeField.Set(idx, time.Unix(0, t*1000000).UTC())
-				idx++
+			for t := baseTimestamp; step > 0 && t < timestamp; t += step {
+				times = append(times, time.UnixMilli(t).UTC())
+				values = append(values, nil)
 			}
 
-			timeField.Set(idx, time.Unix(pair.Timestamp.Unix(), 0).UTC())
-			if !math.IsNaN(value) {
-				valueField.Set(idx, &value)
-			}
-			baseTimestamp = timestamp + query.Step.Milliseconds()
-			idx++
+			times = append(times, time.UnixMilli(timestamp).UTC())
+			values = append(values, sampleValue(pair.Value))
+			baseTimestamp = timestamp + step
 		}
 
-		for t := baseTimestamp; t <= endTimestamp; t += query.Step.Milliseconds() {
-			timeField.Set(idx, time.Unix(0, t*1000000).UTC())
-			idx++
+		for t := baseTimestamp; step > 0 && t <= end; t += step {
+			times = append(times, time.UnixMilli(t).UTC())
+			values = append(values, nil)
 		}
 
 		name := formatLegend(v.Metric, query)
-		timeField.Name = data.TimeSeriesTimeFieldName
-		valueField.Name = data.TimeSeriesValueFieldName
+		timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, times)
+		valueField := data.NewField(data.TimeSeriesValueFieldName, tags, values)
 		valueField.Config = &data.FieldConfig{DisplayNameFromDS: name}
-		valueField.Labels = tags
 
 		frames = append(frames, newDataFrame(name, "matrix", timeField, valueField))
 	}
@@ -358,8 +399,17 @@ func matrixToDataFrames(matrix model.Matrix, query *PrometheusQuery, frames data
 	return frames
 }
 
+// sampleValue returns nil for NaN, which Prometheus uses for missing values.
+func sampleValue(v model.SampleValue) *float64 {
+	value := float64(v)
+	if math.IsNaN(value) {
+		return nil
+	}
+	return &value
+}
+
 func scalarToDataFrames(scalar *model.Scalar, query *PrometheusQuery, frames data.Frames) data.Frames {
-	timeVector := []time.Time{time.Unix(scalar.Timestamp.Unix(), 0).UTC()}
+	timeVector := []time.Time{time.UnixMilli(int64(scalar.Timestamp)).UTC()}
 	values := []float64{float64(scalar.Value)}
 	name := fmt.Sprintf("%g", values[0])
 
@@ -368,8 +418,20 @@ func scalarToDataFrames(scalar *model.Scalar, query *PrometheusQuery, frames dat
 		newDataFrame(
 			name,
 			"scalar",
-			data.NewField("Time", nil, timeVector),
-			data.NewField("Value", nil, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name}),
+			data.NewField(data.TimeSeriesTimeFieldName, nil, timeVector),
+			data.NewField(data.TimeSeriesValueFieldName, nil, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name}),
+		),
+	)
+}
+
+func stringToDataFrames(str *model.String, query *PrometheusQuery, frames data.Frames) data.Frames {
+	return append(
+		frames,
+		newDataFrame(
+			str.Value,
+			"string",
+			data.NewField(data.TimeSeriesTimeFieldName, nil, []time.Time{time.UnixMilli(int64(str.Timestamp)).UTC()}),
+			data.NewField(data.TimeSeriesValueFieldName, nil, []string{str.Value}),
 		),
 	)
 }
@@ -378,7 +440,7 @@ func vectorToDataFrames(vector model.Vector, query *PrometheusQuery, frames data
 	for _, v := range vector {
 		name := formatLegend(v.Metric, query)
 		tags := make(map[string]string, len(v.Metric))
-		timeVector := []time.Time{time.Unix(v.Timestamp.Unix(), 0).UTC()}
+		timeVector := []time.Time{time.UnixMilli(int64(v.Timestamp)).UTC()}
 		values := []float64{float64(v.Value)}
 
 		for k, v := range v.Metric {
@@ -390,8 +452,8 @@ func vectorToDataFrames(vector model.Vector, query *PrometheusQuery, frames data
 			newDataFrame(
 				name,
 				"vector",
-				data.NewField("Time", nil, timeVector),
-				data.NewField("Value", tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name}),
+				data.NewField(data.TimeSeriesTimeFieldName, nil, timeVector),
+				data.NewField(data.TimeSeriesValueFieldName, tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name}),
 			),
 		)
 	}
@@ -400,42 +462,78 @@ func vectorToDataFrames(vector model.Vector, query *PrometheusQuery, frames data
 }
 
 func exemplarToDataFrames(response []apiv1.ExemplarQueryResult, query *PrometheusQuery, frames data.Frames) data.Frames {
-	// TODO: this preallocation is very naive.
-	// We should figure out a better approximation here.
 	events := make([]ExemplarEvent, 0, len(response)*2)
+	labelNames := make(map[string]struct{})
 
 	for _, exemplarData := range response {
 		for _, exemplar := range exemplarData.Exemplars {
-			event := ExemplarEvent{}
-			exemplarTime := time.Unix(exemplar.Timestamp.Unix(), 0).UTC()
-			event.Time = exemplarTime
-			event.Value = float64(exemplar.Value)
-			event.Labels = make(map[string]string)
+			event := ExemplarEvent{
+				Time:   time.UnixMilli(int64(exemplar.Timestamp)).UTC(),
+				Value:  float64(exemplar.Value),
+				Labels: make(map[string]string, len(exemplar.Labels)+len(exemplarData.SeriesLabels)),
+			}
 
 			for label, value := range exemplar.Labels {
 				event.Labels[string(label)] = string(value)
+				labelNames[string(label)] = struct{}{}
 			}
 
 			for seriesLabel, seriesValue := range exemplarData.SeriesLabels {
 				event.Labels[string(seriesLabel)] = string(seriesValue)
+				labelNames[string(seriesLabel)] = struct{}{}
 			}
 
 			events = append(events, event)
 		}
 	}
 
-	// Sampling of exemplars
-	bucketedExemplars := make(map[string][]ExemplarEvent)
+	sampledExemplars := sampleExemplars(events, query.Step)
+
+	// Create DF from sampled exemplars
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(sampledExemplars))
+	timeField.Name = data.TimeSeriesTimeFieldName
+	valueField := data.NewFieldFromFieldType(data.FieldTypeFloat64, len(sampledExemplars))
+	valueField.Name = data.TimeSeriesValueFieldName
+
+	sortedLabelNames := make([]string, 0, len(labelNames))
+	for label := range labelNames {
+		sortedLabelNames = append(sortedLabelNames, label)
+	}
+	sort.Strings(sortedLabelNames)
+
+	dataFields := make([]*data.Field, 0, len(sortedLabelNames)+2)
+	dataFields = append(dataFields, timeField, valueField)
+	for _, label := range sortedLabelNames {
+		// Exemplars without the label get an empty value, so all fields have the same length.
+		values := make([]string, len(sampledExemplars))
+		for i, exemplar := range sampledExemplars {
+			values[i] = exemplar.Labels[label]
+		}
+		dataFields = append(dataFields, data.NewField(label, nil, values))
+	}
+
+	for i, exemplar := range sampledExemplars {
+		timeField.Set(i, exemplar.Time)
+		valueField.Set(i, exemplar.Value)
+	}
+
+	return append(frames, newDataFrame("exemplar", "exemplar", dataFields...))
+}
+
+// sampleExemplars reduces the density of exemplars, so we are not showing too
+// many of them. Exemplars are bucketed by step and in each bucket the highest
+// value is kept, along with values at least 2 standard deviations below the
+// previously kept one.
+func sampleExemplars(events []ExemplarEvent, step time.Duration) []ExemplarEvent {
+	bucketedExemplars := make(map[int64][]ExemplarEvent)
 	values := make([]float64, 0, len(events))
 
 	// Create bucketed exemplars based on aligned timestamp
 	for _, event := range events {
-		alignedTs := fmt.Sprintf("%.0f", math.Floor(float64(event.Time.Unix())/query.Step.Seconds())*query.Step.Seconds())
-		_, ok := bucketedExemplars[alignedTs]
-		if !ok {
-			bucketedExemplars[alignedTs] = make([]ExemplarEvent, 0)
+		alignedTs := intervalv2.AlignTimeRange(event.Time, step, 0).UnixNano()
+		if step <= 0 {
+			alignedTs = 0
 		}
-
 		bucketedExemplars[alignedTs] = append(bucketedExemplars[alignedTs], event)
 		values = append(values, event.Value)
 	}
@@ -443,77 +541,54 @@ func exemplarToDataFrames(response []apiv1.ExemplarQueryResult, query *Prometheu
 	// Calculate standard deviation
 	standardDeviation := deviation(values)
 
-	// Create slice with all of the bucketed exemplars
-	sampledBuckets := make([]string, len(bucketedExemplars))
-	for bucketTimes := range bucketedExemplars {
-		sampledBuckets = append(sampledBuckets, bucketTimes)
+	sampledBuckets := make([]int64, 0, len(bucketedExemplars))
+	for bucketTime := range bucketedExemplars {
+		sampledBuckets = append(sampledBuckets, bucketTime)
 	}
-	sort.Strings(sampledBuckets)
+	sort.Slice(sampledBuckets, func(i, j int) bool {
+		return sampledBuckets[i] < sampledBuckets[j]
+	})
 
-	// Sample exemplars based ona value, so we are not showing too many of them
-	sampleExemplars := make([]ExemplarEvent, 0, len(sampledBuckets))
+	sampledExemplars := make([]ExemplarEvent, 0, len(sampledBuckets))
 	for _, bucket := range sampledBuckets {
 		exemplarsInBucket := bucketedExemplars[bucket]
 		if len(exemplarsInBucket) == 1 {
-			sampleExemplars = append(sampleExemplars, exemplarsInBucket[0])
-		} else {
-			bucketValues := make([]float64, len(exemplarsInBucket))
-			for _, exemplar := range exemplarsInBucket {
-				bucketValues = append(bucketValues, exemplar.Value)
-			}
-			sort.Slice(bucketValues, func(i, j int) bool {
-				return bucketValues[i] > bucketValues[j]
-			})
-
-			sampledBucketValues := make([]float64, 0)
-			for _, value := range bucketValues {
-				if len(sampledBucketValues) == 0 {
-					sampledBucketValues = append(sampledBucketValues, value)
-				} else {
-					// Then take values only when at least 2 standard deviation distance to previously taken value
-					prev := sampledBucketValues[len(sampledBucketValues)-1]
-					if standardDeviation != 0 && prev-value >= float64(2)*standardDeviation {
-						sampledBucketValues = append(sampledBucketValues, value)
-					}
-				}
-			}
-			for _, valueBucket := range sampledBucketValues {
-				for _, exemplar := range exemplarsInBucket {
-					if exemplar.Value == valueBucket {
-						sampleExemplars = append(sampleExemplars, exemplar)
-					}
-				}
-			}
+			sampledExemplars = append(sampledExemplars, exemplarsInBucket[0])
+			continue
 		}
-	}
 
-	// Create DF from sampled exemplars
-	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(sampleExemplars))
-	timeField.Name = "Time"
-	valueField := data.NewFieldFromFieldType(data.FieldTypeFloat64, len(sampleExemplars))
-	valueField.Name = "Value"
-	labelsVector := make(map[string][]string, len(sampleExemplars))
-
-	for i, exemplar := range sampleExemplars {
-		timeField.Set(i, exemplar.Time)
-		valueField.Set(i, exemplar.Value)
+		bucketValues := make([]float64, 0, len(exemplarsInBucket))
+		for _, exemplar := range exemplarsInBucket {
+			bucketValues = append(bucketValues, exemplar.Value)
+		}
+		sort.Sort(sort.Reverse(sort.Float64Slice(bucketValues)))
 
-		for label, value := range exemplar.Labels {
-			if labelsVector[label] == nil {
-				labelsVector[label] = make([]string, 0)
+		sampledBucketValues := make([]float64, 0, len(bucketValues))
+		for _, value := range bucketValues {
+			if len(sampledBucketValues) == 0 {
+				// First value is max and is always added
+				sampledBucketValues = append(sampledBucketValues, value)
+				continue
+			}
+			// Then take values only when at least 2 standard deviation distance to previously taken value
+			prev := sampledBucketValues[len(sampledBucketValues)-1]
+			if standardDeviation != 0 && prev-value >= float64(2)*standardDeviation {
+				sampledBucketValues = append(sampledBucketValues, value)
 			}
-
-			labelsVector[label] = append(labelsVector[label], value)
 		}
-	}
 
-	dataFields := make([]*data.Field, 0, len(labelsVector)+2)
-	dataFields = append(dataFields, timeField, valueField)
-	for label, vector := range labelsVector {
-		dataFields = append(dataFields, data.NewField(label, nil, vector))
+		// Find the exemplars for the sampled values
+		for _, value := range sampledBucketValues {
+			for _, exemplar := range exemplarsInBucket {
+				if exemplar.Value == value {
+					sampledExemplars = append(sampledExemplars, exemplar)
+					break
+				}
+			}
+		}
 	}
 
-	return append(frames, newDataFrame("exemplar", "exemplar", dataFields...))
+	return sampledExemplars
 }
 
 func deviation(values []float64) float64 {
@@ -540,10 +615,6 @@ func newDataFrame(name string, typ string, fields ...*data.Field) *data.Frame {
 	return frame
 }
 
-func alignTimeRange(t time.Time, step time.Duration, offset int64) time.Time {
-	return time.Unix(int64(math.Floor((float64(t.Unix()+offset)/step.Seconds()))*step.Seconds()-float64(offset)), 0)
-}
-
 func isVariableInterval(interval string) bool {
 	if interval == varInterval || interval == varIntervalMs || interval == varRateInterval {
 		return true
@@ -572,6 +572,96 @@ func TestPrometheus_parseTimeSeriesResponse(t *testing.T) {
 		require.Equal(t, res[0].Fields[1].At(1), 0.003535405)
 	})
 
+	t.Run("exemplars with different labels should have fields of the same length", func(t *testing.T) {
+		value := make(map[TimeSeriesQueryType]interface{})
+		value[ExemplarQueryType] = []apiv1.ExemplarQueryResult{
+			{
+				SeriesLabels: p.LabelSet{"__name__": "tns_request_duration_seconds_bucket"},
+				Exemplars: []apiv1.Exemplar{
+					{Labels: p.LabelSet{"traceID": "test1"}, Value: 0.1, Timestamp: 60500},
+					{Labels: p.LabelSet{"spanID": "span2"}, Value: 0.2, Timestamp: 1500},
+				},
+			},
+		}
+		query := &PrometheusQuery{Step: time.Minute}
+		res, err := parseTimeSeriesResponse(value, query)
+		require.NoError(t, err)
+
+		require.Len(t, res, 1)
+		require.Len(t, res[0].Fields, 5)
+		for _, field := range res[0].Fields {
+			require.Equal(t, 2, field.Len())
+		}
+		// Sorted by time bucket, with millisecond precision
+		require.Equal(t, time.UnixMilli(1500).UTC(), res[0].Fields[0].At(0))
+		require.Equal(t, time.UnixMilli(60500).UTC(), res[0].Fields[0].At(1))
+		require.Equal(t, "spanID", res[0].Fields[3].Name)
+		require.Equal(t, "span2", res[0].Fields[3].At(0))
+		require.Equal(t, "traceID", res[0].Fields[4].Name)
+		require.Equal(t, "", res[0].Fields[4].At(0))
+		require.Equal(t, "test1", res[0].Fields[4].At(1))
+	})
+
+	t.Run("range and instant responses should be returned in order", func(t *testing.T) {
+		value := make(map[TimeSeriesQueryType]interface{})
+		value[InstantQueryType] = p.Vector{
+			&p.Sample{Metric: p.Metric{"app": "Application"}, Value: 2, Timestamp: 5500},
+		}
+		value[RangeQueryType] = p.Matrix{
+			&p.SampleStream{
+				Metric: p.Metric{"app": "Application"},
+				Values: []p.SamplePair{{Value: 1, Timestamp: 5000}},
+			},
+		}
+		query := &PrometheusQuery{
+			RefId: "A",
+			Expr:  "up",
+			Step:  time.Second,
+			Start: time.Unix(5, 0),
+			End:   time.Unix(5, 500*int64(time.Millisecond)),
+		}
+		res, err := parseTimeSeriesResponse(value, query)
+		require.NoError(t, err)
+
+		require.Len(t, res, 2)
+		require.Equal(t, "matrix", res[0].Meta.Custom.(map[string]string)["resultType"])
+		require.Equal(t, "Expr: up\nStep: 1s", res[0].Meta.ExecutedQueryString)
+		require.Equal(t, "vector", res[1].Meta.Custom.(map[string]string)["resultType"])
+		require.Equal(t, "Expr: up\nTime: 1970-01-01T00:00:05Z", res[1].Meta.ExecutedQueryString)
+		require.Equal(t, time.UnixMilli(5500).UTC(), res[1].Fields[0].At(0))
+		for _, frame := range res {
+			require.Equal(t, "A", frame.RefID)
+		}
+	})
+
+	t.Run("matrix response with unaligned data points should be parsed", func(t *testing.T) {
+		value := make(map[TimeSeriesQueryType]interface{})
+		value[RangeQueryType] = p.Matrix{
+			&p.SampleStream{
+				Metric: p.Metric{"app": "Application"},
+				Values: []p.SamplePair{
+					{Value: 1, Timestamp: 1500},
+					{Value: 2, Timestamp: 2500},
+					{Value: 3, Timestamp: 3500},
+				},
+			},
+		}
+		query := &PrometheusQuery{
+			Step:  1 * time.Second,
+			Start: time.Unix(1, 0).UTC(),
+			End:   time.Unix(3, 0).UTC(),
+		}
+		res, err := parseTimeSeriesResponse(value, query)
+		require.NoError(t, err)
+
+		require.Len(t, res, 1)
+		require.Equal(t, 4, res[0].Fields[0].Len())
+		require.Equal(t, time.UnixMilli(1000).UTC(), res[0].Fields[0].At(0))
+		require.Nil(t, res[0].Fields[1].At(0))
+		require.Equal(t, time.UnixMilli(1500).UTC(), res[0].Fields[0].At(1))
+		require.Equal(t, time.UnixMilli(3500).UTC(), res[0].Fields[0].At(3))
+	})
+
 	t.Run("matrix response should be parsed normally", func(t *testing.T) {
 		values := []p.SamplePair{
 			{Value: 1, Timestamp: 1000},
@@ -724,6 +814,19 @@ func TestPrometheus_parseTimeSeriesResponse(t *testing.T) {
 	})
 }
 
+func TestPrometheus_parseTimeSeriesResponse_string(t *testing.T) {
+	value := make(map[TimeSeriesQueryType]interface{})
+	value[InstantQueryType] = &p.String{Value: "hello", Timestamp: 1500}
+
+	res, err := parseTimeSeriesResponse(value, &PrometheusQuery{})
+	require.NoError(t, err)
+
+	require.Len(t, res, 1)
+	require.Equal(t, "hello", res[0].Name)
+	require.Equal(t, time.UnixMilli(1500).UTC(), res[0].Fields[0].At(0))
+	require.Equal(t, "hello", res[0].Fields[1].At(0))
+}
+
 func queryContext(json string, timeRange backend.TimeRange) *backend.QueryDataRequest {
 	return &backend.QueryDataRequest{
 		Queries: []backend.DataQuery{
@@ -28,6 +28,17 @@ const MetaKeyExecutedQueryString = "executedQueryString"
 
 var ErrConnectionFailed = errors.New("failed to connect to server - please inspect Grafana server log for details")
 
+// ErrQueryCanceled is returned for queries that were canceled before they completed,
+// for example because the dashboard that sent them was closed.
+var ErrQueryCanceled = errors.New("query canceled")
+
+// ErrQueryTimeout is returned for queries that ran longer than the query timeout of the data source.
+var ErrQueryTimeout = errors.New("query timed out")
+
+// ErrQueryTimeoutNotSet is returned for queries that are not run because the
+// query timeout of the data source could not be set on the server.
+var ErrQueryTimeoutNotSet = errors.New("failed to set the query timeout on the server - please inspect Grafana server log for details")
+
 // SQLMacroEngine interpolates macros into sql. It takes in the Query to have access to query context and
 // timeRange to be able to generate queries that use from and to.
 type SQLMacroEngine interface {
@@ -64,6 +75,10 @@ type JsonData struct {
 	Encrypt             string `json:"encrypt"`
 	Servername          string `json:"servername"`
 	TimeInterval        string `json:"timeInterval"`
+	// QueryTimeout is the maximum execution time of a query in seconds.
+	QueryTimeout int `json:"queryTimeout"`
+	// MaxRows limits the rows read from a result set, below the global row limit.
+	MaxRows int64 `json:"maxRows"`
 }
 
 type DataSourceInfo struct {
@@ -84,6 +99,10 @@ type DataPluginConfiguration struct {
 	TimeColumnNames   []string
 	MetricColumnTypes []string
 	RowLimit          int64
+	// SetQueryTimeout tells the server to stop the queries of a connection
+	// that run longer than the query timeout. It is called before every query
+	// of a data source with a query timeout.
+	SetQueryTimeout func(ctx context.Context, conn *sql.Conn, timeout time.Duration) error
 }
 type DataSourceHandler struct {
 	macroEngine            SQLMacroEngine
@@ -94,6 +113,8 @@ type DataSourceHandler struct {
 	log                    log.Logger
 	dsInfo                 DataSourceInfo
 	rowLimit               int64
+	queryTimeout           time.Duration
+	setQueryTimeout        func(ctx context.Context, conn *sql.Conn, timeout time.Duration) error
 }
 type QueryJson struct {
 	RawSql       string  `json:"rawSql"`
@@ -132,6 +153,12 @@ func NewQueryDataHandler(config DataPluginConfiguration, queryResultTransformer
 		log:                    log,
 		dsInfo:                 config.DSInfo,
 		rowLimit:               config.RowLimit,
+		queryTimeout:           time.Duration(config.DSInfo.JsonData.QueryTimeout) * time.Second,
+		setQueryTimeout:        config.SetQueryTimeout,
+	}
+
+	if maxRows := config.DSInfo.JsonData.MaxRows; maxRows > 0 && (queryDataHandler.rowLimit <= 0 || maxRows < queryDataHandler.rowLimit) {
+		queryDataHandler.rowLimit = maxRows
 	}
 
 	if len(config.TimeColumnNames) > 0 {
@@ -256,20 +283,21 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		return
 	}
 
+	// The driver cancels the query on the server once the context is done,
+	// so closed dashboards and timed out queries don't keep running there.
+	queryContext, cancel := e.newQueryContext(queryContext)
+	defer cancel()
+
 	session := e.engine.NewSession()
 	defer session.Close()
 	db := session.DB()
 
-	rows, err := db.QueryContext(queryContext, interpolatedQuery)
+	rows, closeRows, err := e.query(queryContext, db, interpolatedQuery)
 	if err != nil {
-		errAppendDebug("db query error", e.transformQueryError(err), interpolatedQuery)
+		errAppendDebug("db query error", e.queryError(queryContext, err), interpolatedQuery)
 		return
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			e.log.Warn("Failed to close rows", "err", err)
-		}
-	}()
+	defer closeRows()
 
 	qm, err := e.newProcessCfg(query, queryContext, rows, interpolatedQuery)
 	if err != nil {
@@ -281,10 +309,20 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 	stringConverters := e.queryResultTransformer.GetConverterList()
 	frame, err := sqlutil.FrameFromRows(rows.Rows, e.rowLimit, sqlutil.ToConverters(stringConverters...)...)
 	if err != nil {
-		errAppendDebug("convert frame from rows error", err, interpolatedQuery)
+		errAppendDebug("convert frame from rows error", e.queryError(queryContext, err), interpolatedQuery)
+		return
+	}
+	if err := rows.Err(); err != nil {
+		errAppendDebug("db query error", e.queryError(queryContext, err), interpolatedQuery)
 		return
 	}
 
+	if e.rowLimit > 0 && int64(frame.Rows()) >= e.rowLimit {
+		// Cancel the rest of the result set, instead of reading it when the
+		// rows are closed.
+		cancel()
+	}
+
 	if frame.Meta == nil {
 		frame.Meta = &data.FrameMeta{}
 	}
@@ -368,6 +406,78 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 	ch <- queryResult
 }
 
+// query runs a query. Queries of data sources with a query timeout run on a
+// connection that the server was told the timeout first. The returned function
+// closes the rows and the connection.
+func (e *DataSourceHandler) query(ctx context.Context, db *core.DB, query string) (*core.Rows, func(), error) {
+	if e.setQueryTimeout == nil || e.queryTimeout <= 0 {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		return rows, func() { e.closeRows(rows.Rows) }, nil
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeConn := func() {
+		if err := conn.Close(); err != nil {
+			e.log.Warn("Failed to close connection", "err", err)
+		}
+	}
+
+	if err := e.setQueryTimeout(ctx, conn, e.queryTimeout); err != nil {
+		closeConn()
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		e.log.Error("Failed to set the query timeout on the server", "err", err)
+		return nil, nil, ErrQueryTimeoutNotSet
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		closeConn()
+		return nil, nil, err
+	}
+	return &core.Rows{Rows: rows}, func() {
+		e.closeRows(rows)
+		closeConn()
+	}, nil
+}
+
+func (e *DataSourceHandler) closeRows(rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		e.log.Warn("Failed to close rows", "err", err)
+	}
+}
+
+// newQueryContext returns the context a query runs with, which is done when
+// the query timeout of the data source is reached.
+func (e *DataSourceHandler) newQueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.queryTimeout > 0 {
+		return context.WithTimeout(ctx, e.queryTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// queryError returns a clear error for queries that were canceled or timed
+// out, which drivers report in different ways.
+func (e *DataSourceHandler) queryError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		if e.queryTimeout > 0 {
+			return fmt.Errorf("%w after %s", ErrQueryTimeout, e.queryTimeout)
+		}
+		return ErrQueryTimeout
+	case context.Canceled:
+		return ErrQueryCanceled
+	}
+	return e.transformQueryError(err)
+}
+
 // Interpolate provides global macros/substitutions for all sql datasources.
 var Interpolate = func(query backend.DataQuery, timeRange backend.TimeRange, timeInterval string, sql string) (string, error) {
 	minInterval, err := intervalv2.GetIntervalFrom(timeInterval, query.Interval.String(), query.Interval.Milliseconds(), time.Second*60)
@@ -1,8 +1,14 @@
 package sqleng
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
@@ -10,6 +16,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 	"github.com/grafana/grafana/pkg/infra/log"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xorcare/pointer"
@@ -413,6 +420,140 @@ func TestSQLEngine(t *testing.T) {
 	})
 }
 
+func TestQueryLimits(t *testing.T) {
+	newHandler := func(t *testing.T, jsonData JsonData) *DataSourceHandler {
+		t.Helper()
+		handler, err := NewQueryDataHandler(DataPluginConfiguration{
+			DriverName:       "sqlite3",
+			ConnectionString: filepath.Join(t.TempDir(), "test.db"),
+			DSInfo:           DataSourceInfo{JsonData: jsonData},
+			RowLimit:         1000,
+		}, &sqliteQueryResultTransformer{}, &testMacroEngine{}, log.New("test"))
+		require.NoError(t, err)
+		t.Cleanup(handler.Dispose)
+
+		_, err = handler.engine.Exec("CREATE TABLE numbers (x INTEGER)")
+		require.NoError(t, err)
+		_, err = handler.engine.Exec("WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c LIMIT 100) INSERT INTO numbers SELECT x FROM c")
+		require.NoError(t, err)
+		return handler
+	}
+
+	request := func(sql string) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID: "A",
+				JSON:  []byte(fmt.Sprintf(`{"rawSql": %q, "format": "table"}`, sql)),
+			}},
+		}
+	}
+
+	const countTo100 = "SELECT x FROM numbers"
+
+	t.Run("Should cut off result sets at the max rows of the data source", func(t *testing.T) {
+		handler := newHandler(t, JsonData{MaxRows: 10})
+
+		resp, err := handler.QueryData(context.Background(), request(countTo100))
+		require.NoError(t, err)
+
+		res := resp.Responses["A"]
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		require.Equal(t, 10, res.Frames[0].Rows())
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, res.Frames[0].Meta.Notices[0].Severity)
+	})
+
+	t.Run("Should not raise the global row limit", func(t *testing.T) {
+		handler := newHandler(t, JsonData{MaxRows: 5000})
+		require.Equal(t, int64(1000), handler.rowLimit)
+	})
+
+	t.Run("Should report canceled queries", func(t *testing.T) {
+		handler := newHandler(t, JsonData{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		resp, err := handler.QueryData(ctx, request(countTo100))
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, ErrQueryCanceled)
+	})
+
+	t.Run("Should set the query timeout on the connection of every query", func(t *testing.T) {
+		var timeouts []time.Duration
+		handler := newHandler(t, JsonData{QueryTimeout: 30})
+		handler.setQueryTimeout = func(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+			timeouts = append(timeouts, timeout)
+			return conn.PingContext(ctx)
+		}
+
+		resp, err := handler.QueryData(context.Background(), request(countTo100))
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Equal(t, 100, resp.Responses["A"].Frames[0].Rows())
+		require.Equal(t, []time.Duration{30 * time.Second}, timeouts)
+	})
+
+	t.Run("Should not run queries when the query timeout cannot be set", func(t *testing.T) {
+		handler := newHandler(t, JsonData{QueryTimeout: 30})
+		handler.setQueryTimeout = func(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+			return errors.New("unknown system variable")
+		}
+
+		resp, err := handler.QueryData(context.Background(), request(countTo100))
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, ErrQueryTimeoutNotSet)
+	})
+
+	t.Run("Should report timed out queries", func(t *testing.T) {
+		handler := newHandler(t, JsonData{QueryTimeout: 30})
+		require.Equal(t, 30*time.Second, handler.queryTimeout)
+
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		err := handler.queryError(ctx, errors.New("driver error"))
+		require.ErrorIs(t, err, ErrQueryTimeout)
+		require.Equal(t, "query timed out after 30s", err.Error())
+	})
+}
+
+// sqliteQueryResultTransformer scans integer columns, which have no scan type
+// in the SQLite driver.
+type sqliteQueryResultTransformer struct {
+	testQueryResultTransformer
+}
+
+func (t *sqliteQueryResultTransformer) GetConverterList() []sqlutil.StringConverter {
+	return []sqlutil.StringConverter{
+		{
+			Name:          "handle INTEGER",
+			InputScanKind: reflect.Interface,
+			InputTypeName: "INTEGER",
+			Replacer: &sqlutil.StringFieldReplacer{
+				OutputFieldType: data.FieldTypeNullableInt64,
+				ReplaceFunc: func(in *string) (interface{}, error) {
+					if in == nil {
+						return nil, nil
+					}
+					v, err := strconv.ParseInt(*in, 10, 64)
+					if err != nil {
+						return nil, err
+					}
+					return &v, nil
+				},
+			},
+		},
+	}
+}
+
+type testMacroEngine struct{}
+
+func (m *testMacroEngine) Interpolate(query *backend.DataQuery, timeRange backend.TimeRange, sql string) (string, error) {
+	return sql, nil
+}
+
 type testQueryResultTransformer struct {
 	transformQueryErrorWasCalled bool
 }
@@ -0,0 +1,155 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const defaultSearchLimit = 20
+
+type searchResponse struct {
+	Traces []*traceSearchMetadata `json:"traces"`
+}
+
+type traceSearchMetadata struct {
+	TraceID           string `json:"traceID"`
+	RootServiceName   string `json:"rootServiceName"`
+	RootTraceName     string `json:"rootTraceName"`
+	StartTimeUnixNano string `json:"startTimeUnixNano"`
+	DurationMs        int64  `json:"durationMs"`
+}
+
+func (s *Service) querySearch(ctx context.Context, dsInfo *datasourceInfo, model *QueryModel, timeRange backend.TimeRange) (backend.DataResponse, error) {
+	queryRes := backend.DataResponse{}
+
+	params, err := searchParams(model, timeRange)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", dsInfo.URL+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return queryRes, err
+	}
+	s.tlog.Debug("Tempo search request", "url", req.URL.String())
+
+	resp, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return queryRes, fmt.Errorf("failed get to tempo: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return queryRes, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		queryRes.Error = fmt.Errorf("failed to search traces Status: %s Body: %s", resp.Status, string(body))
+		return queryRes, nil
+	}
+
+	var response searchResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return queryRes, fmt.Errorf("failed to parse tempo search response: %w", err)
+	}
+
+	queryRes.Frames = data.Frames{searchResponseToFrame(response.Traces)}
+	return queryRes, nil
+}
+
+// searchParams builds the query parameters of the Tempo search API. The
+// service and span name filters are added to the logfmt encoded tags.
+func searchParams(model *QueryModel, timeRange backend.TimeRange) (url.Values, error) {
+	tags := strings.TrimSpace(model.Search)
+	if model.ServiceName != "" {
+		tags += fmt.Sprintf(" service.name=%q", model.ServiceName)
+	}
+	if model.SpanName != "" {
+		tags += fmt.Sprintf(" name=%q", model.SpanName)
+	}
+
+	params := url.Values{}
+	params.Set("tags", strings.TrimSpace(tags))
+
+	for name, value := range map[string]string{"minDuration": model.MinDuration, "maxDuration": model.MaxDuration} {
+		value = strings.ReplaceAll(value, " ", "")
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+		}
+		params.Set(name, value)
+	}
+
+	limit := model.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("invalid limit %d", limit)
+	}
+	params.Set("limit", strconv.FormatInt(limit, 10))
+
+	if !timeRange.From.IsZero() && !timeRange.To.IsZero() {
+		params.Set("start", strconv.FormatInt(timeRange.From.Unix(), 10))
+		params.Set("end", strconv.FormatInt(timeRange.To.Unix(), 10))
+	}
+
+	return params, nil
+}
+
+// searchResponseToFrame converts the found traces to a table with the most
+// recent traces first.
+func searchResponseToFrame(traces []*traceSearchMetadata) *data.Frame {
+	sort.SliceStable(traces, func(i, j int) bool {
+		return traces[i].startTime().After(traces[j].startTime())
+	})
+
+	traceIDs := make([]string, 0, len(traces))
+	traceNames := make([]string, 0, len(traces))
+	startTimes := make([]time.Time, 0, len(traces))
+	durations := make([]float64, 0, len(traces))
+
+	for _, trace := range traces {
+		traceIDs = append(traceIDs, trace.TraceID)
+		traceNames = append(traceNames, strings.TrimSpace(trace.RootServiceName+" "+trace.RootTraceName))
+		startTimes = append(startTimes, trace.startTime())
+		durations = append(durations, float64(trace.DurationMs))
+	}
+
+	frame := data.NewFrame("Traces",
+		data.NewField("traceID", nil, traceIDs).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Trace ID"}),
+		data.NewField("traceName", nil, traceNames).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Trace name"}),
+		data.NewField("startTime", nil, startTimes).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Start time"}),
+		data.NewField("duration", nil, durations).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Duration", Unit: "ms"}),
+	)
+	frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+	return frame
+}
+
+func (t *traceSearchMetadata) startTime() time.Time {
+	nanos, err := strconv.ParseInt(t.StartTimeUnixNano, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos).UTC()
+}
@@ -0,0 +1,131 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchParams(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Unix(1000, 0),
+		To:   time.Unix(2000, 0),
+	}
+
+	t.Run("builds tags and defaults", func(t *testing.T) {
+		params, err := searchParams(&QueryModel{
+			Search:      "http.status_code=500",
+			ServiceName: "api",
+			SpanName:    "GET /users",
+			MinDuration: "1 s",
+		}, timeRange)
+		require.NoError(t, err)
+
+		assert.Equal(t, `http.status_code=500 service.name="api" name="GET /users"`, params.Get("tags"))
+		assert.Equal(t, "1s", params.Get("minDuration"))
+		assert.Empty(t, params.Get("maxDuration"))
+		assert.Equal(t, "20", params.Get("limit"))
+		assert.Equal(t, "1000", params.Get("start"))
+		assert.Equal(t, "2000", params.Get("end"))
+	})
+
+	t.Run("rejects invalid durations", func(t *testing.T) {
+		_, err := searchParams(&QueryModel{MaxDuration: "ten seconds"}, timeRange)
+		require.Error(t, err)
+	})
+
+	t.Run("rejects negative limits", func(t *testing.T) {
+		_, err := searchParams(&QueryModel{Limit: -1}, timeRange)
+		require.Error(t, err)
+	})
+}
+
+func TestQuerySearch(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/search", r.URL.Path)
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"traces": [
+			{"traceID": "a1", "rootServiceName": "api", "rootTraceName": "GET /", "startTimeUnixNano": "1000000000", "durationMs": 10},
+			{"traceID": "b2", "rootServiceName": "db", "startTimeUnixNano": "2000000000", "durationMs": 5}
+		]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+	service := &Service{
+		tlog:     log.New("tempo-test"),
+		features: featuremgmt.WithFeatures(featuremgmt.FlagTempoSearch),
+		im:       fakeInstanceManager{dsInfo: dsInfo},
+	}
+
+	model, err := json.Marshal(map[string]interface{}{"queryType": "nativeSearch", "search": "foo=bar", "limit": 5})
+	require.NoError(t, err)
+
+	res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{RefID: "A", JSON: model}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "limit=5&tags=foo%3Dbar", query)
+
+	dr := res.Responses["A"]
+	require.NoError(t, dr.Error)
+	require.Len(t, dr.Frames, 1)
+
+	frame := dr.Frames[0]
+	assert.Equal(t, "A", frame.RefID)
+	assert.Equal(t, data.VisTypeTable, string(frame.Meta.PreferredVisualization))
+	require.Equal(t, 2, frame.Rows())
+	// most recent first
+	assert.Equal(t, "b2", frame.Fields[0].At(0))
+	assert.Equal(t, "db", frame.Fields[1].At(0))
+	assert.Equal(t, "api GET /", frame.Fields[1].At(1))
+	assert.Equal(t, time.Unix(2, 0).UTC(), frame.Fields[2].At(0))
+	assert.Equal(t, 10.0, frame.Fields[3].At(1))
+
+	t.Run("requires the feature toggle", func(t *testing.T) {
+		service.features = featuremgmt.WithFeatures()
+		res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: model}},
+		})
+		require.NoError(t, err)
+		require.Error(t, res.Responses["A"].Error)
+	})
+
+	t.Run("unknown query types fail only their own response", func(t *testing.T) {
+		service.features = featuremgmt.WithFeatures(featuremgmt.FlagTempoSearch)
+		unknown, err := json.Marshal(map[string]interface{}{"queryType": "unknown"})
+		require.NoError(t, err)
+
+		res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: model}, {RefID: "B", JSON: unknown}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Len(t, res.Responses["A"].Frames, 1)
+		require.EqualError(t, res.Responses["B"].Error, `unsupported query type: "unknown"`)
+	})
+}
+
+type fakeInstanceManager struct {
+	dsInfo *datasourceInfo
+}
+
+func (f fakeInstanceManager) Get(pluginContext backend.PluginContext) (instancemgmt.Instance, error) {
+	return f.dsInfo, nil
+}
+
+func (f fakeInstanceManager) Do(pluginContext backend.PluginContext, fn instancemgmt.InstanceCallbackFunc) error {
+	return nil
+}
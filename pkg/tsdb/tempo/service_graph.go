@@ -0,0 +1,251 @@
+package tempo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/prometheus/client_golang/api"
+	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// Metrics generated by the Tempo service graph processor. Each series
+// describes the requests between a client and a server service.
+const (
+	serviceGraphSecondsMetric = "traces_service_graph_request_server_seconds_sum"
+	serviceGraphTotalMetric   = "traces_service_graph_request_total"
+	serviceGraphFailedMetric  = "traces_service_graph_request_failed_total"
+)
+
+var serviceGraphMetrics = []string{serviceGraphSecondsMetric, serviceGraphTotalMetric, serviceGraphFailedMetric}
+
+type serviceGraphStats struct {
+	total   float64
+	seconds float64
+	failed  float64
+}
+
+type serviceGraphEdge struct {
+	serviceGraphStats
+	source string
+	target string
+}
+
+func (s *Service) queryServiceGraph(ctx context.Context, pluginCtx backend.PluginContext, dsInfo *datasourceInfo,
+	model *QueryModel, timeRange backend.TimeRange) (backend.DataResponse, error) {
+	queryRes := backend.DataResponse{}
+
+	if dsInfo.ServiceMapDatasourceUID == "" {
+		queryRes.Error = fmt.Errorf("no data source configured for the service graph")
+		return queryRes, nil
+	}
+
+	promAPI, err := s.serviceGraphAPI(ctx, pluginCtx, dsInfo.ServiceMapDatasourceUID)
+	if err != nil {
+		return queryRes, err
+	}
+
+	metrics, err := queryServiceGraphMetrics(ctx, promAPI, model.ServiceMapQuery, timeRange)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes, nil
+	}
+
+	queryRes.Frames = serviceGraphFrames(metrics, timeRange.To.Sub(timeRange.From))
+	return queryRes, nil
+}
+
+// serviceGraphAPI creates a Prometheus API client for the data source that
+// holds the service graph metrics.
+func (s *Service) serviceGraphAPI(ctx context.Context, pluginCtx backend.PluginContext, uid string) (apiv1.API, error) {
+	user, err := s.serviceGraphUser(ctx, pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := s.dataSourceCache.GetDatasourceByUID(ctx, uid, user, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service graph data source: %w", err)
+	}
+	if ds.Type != models.DS_PROMETHEUS {
+		return nil, fmt.Errorf("service graph data source must be of type %s, got %s", models.DS_PROMETHEUS, ds.Type)
+	}
+
+	httpClient, err := s.dataSourcesService.GetHTTPClient(ds, s.httpClientProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := api.NewClient(api.Config{Address: ds.Url, RoundTripper: httpClient.Transport})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiv1.NewAPI(client), nil
+}
+
+// serviceGraphUser returns the user running the query, so that the service
+// graph data source is looked up with their permissions. Queries without a
+// user, such as alert rule evaluations, look it up in the organization of the
+// query.
+func (s *Service) serviceGraphUser(ctx context.Context, pluginCtx backend.PluginContext) (*models.SignedInUser, error) {
+	if pluginCtx.User == nil {
+		return &models.SignedInUser{OrgId: pluginCtx.OrgID}, nil
+	}
+
+	if pluginCtx.User.Login == "" {
+		return &models.SignedInUser{
+			OrgId:       pluginCtx.OrgID,
+			OrgRole:     models.RoleType(pluginCtx.User.Role),
+			IsAnonymous: true,
+		}, nil
+	}
+
+	query := &models.GetSignedInUserQuery{Login: pluginCtx.User.Login, OrgId: pluginCtx.OrgID}
+	if err := s.sqlStore.GetSignedInUser(ctx, query); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if query.Result.OrgId != pluginCtx.OrgID {
+		return nil, errors.New("user is not a member of the organization")
+	}
+
+	return query.Result, nil
+}
+
+// queryServiceGraphMetrics returns the increase of every service graph metric
+// over the time range, keyed by the metric name.
+func queryServiceGraphMetrics(ctx context.Context, promAPI apiv1.API, selector string, timeRange backend.TimeRange) (map[string]model.Vector, error) {
+	rangeSeconds := int64(math.Max(timeRange.To.Sub(timeRange.From).Seconds(), 1))
+	metrics := make(map[string]model.Vector, len(serviceGraphMetrics))
+
+	for _, metric := range serviceGraphMetrics {
+		expr := fmt.Sprintf("sum by (client, server)(increase(%s%s[%ds]))", metric, selector, rangeSeconds)
+		value, _, err := promAPI.Query(ctx, expr, timeRange.To)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query service graph metric %s: %w", metric, err)
+		}
+
+		vector, ok := value.(model.Vector)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result type %s for service graph metric %s", value.Type(), metric)
+		}
+		metrics[metric] = vector
+	}
+
+	return metrics, nil
+}
+
+// serviceGraphFrames builds the node and edge frames of the node graph. Every
+// series is an edge between a client and a server, and its stats are
+// attributed to the server node, so nodes show the requests they handled.
+func serviceGraphFrames(metrics map[string]model.Vector, timeRange time.Duration) data.Frames {
+	nodes := map[string]*serviceGraphStats{}
+	edges := map[string]*serviceGraphEdge{}
+
+	collect := func(metric string, add func(stats *serviceGraphStats, v float64)) {
+		for _, sample := range metrics[metric] {
+			client := string(sample.Metric["client"])
+			server := string(sample.Metric["server"])
+			value := float64(sample.Value)
+
+			edgeID := client + "_" + server
+			edge, ok := edges[edgeID]
+			if !ok {
+				edge = &serviceGraphEdge{source: client, target: server}
+				edges[edgeID] = edge
+			}
+			add(&edge.serviceGraphStats, value)
+
+			if _, ok := nodes[server]; !ok {
+				nodes[server] = &serviceGraphStats{}
+			}
+			add(nodes[server], value)
+
+			if _, ok := nodes[client]; !ok {
+				nodes[client] = &serviceGraphStats{}
+			}
+		}
+	}
+	collect(serviceGraphTotalMetric, func(stats *serviceGraphStats, v float64) { stats.total += v })
+	collect(serviceGraphSecondsMetric, func(stats *serviceGraphStats, v float64) { stats.seconds += v })
+	collect(serviceGraphFailedMetric, func(stats *serviceGraphStats, v float64) { stats.failed += v })
+
+	rangeSeconds := timeRange.Seconds()
+
+	nodeIDs := make([]string, 0, len(nodes))
+	for id := range nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	nodeFrame := data.NewFrame("Nodes",
+		data.NewField("id", nil, nodeIDs),
+		data.NewField("title", nil, nodeIDs).SetConfig(&data.FieldConfig{DisplayName: "Service name"}),
+		data.NewField("mainStat", nil, make([]float64, len(nodeIDs))).
+			SetConfig(&data.FieldConfig{DisplayName: "Average response time", Unit: "ms/r"}),
+		data.NewField("secondaryStat", nil, make([]float64, len(nodeIDs))).
+			SetConfig(&data.FieldConfig{DisplayName: "Requests per second", Unit: "r/sec"}),
+		data.NewField("arc__success", nil, make([]float64, len(nodeIDs))).
+			SetConfig(&data.FieldConfig{DisplayName: "Success", Color: map[string]interface{}{"mode": "fixed", "fixedColor": "green"}}),
+		data.NewField("arc__failed", nil, make([]float64, len(nodeIDs))).
+			SetConfig(&data.FieldConfig{DisplayName: "Failed", Color: map[string]interface{}{"mode": "fixed", "fixedColor": "red"}}),
+	)
+	for i, id := range nodeIDs {
+		node := nodes[id]
+		// NaN is not shown in the node graph, which is the case for root
+		// clients that did not handle any requests.
+		mainStat, secondaryStat, success, failed := math.NaN(), math.NaN(), 1.0, 0.0
+		if node.total > 0 {
+			mainStat = node.seconds / node.total * 1000
+			if rangeSeconds > 0 {
+				secondaryStat = math.Round(node.total/rangeSeconds*100) / 100
+			}
+			failed = node.failed / node.total
+			success = 1 - failed
+		}
+		nodeFrame.Fields[2].Set(i, mainStat)
+		nodeFrame.Fields[3].Set(i, secondaryStat)
+		nodeFrame.Fields[4].Set(i, success)
+		nodeFrame.Fields[5].Set(i, failed)
+	}
+
+	edgeIDs := make([]string, 0, len(edges))
+	for id := range edges {
+		edgeIDs = append(edgeIDs, id)
+	}
+	sort.Strings(edgeIDs)
+
+	edgeFrame := data.NewFrame("Edges",
+		data.NewField("id", nil, edgeIDs),
+		data.NewField("source", nil, make([]string, len(edgeIDs))),
+		data.NewField("target", nil, make([]string, len(edgeIDs))),
+		data.NewField("mainStat", nil, make([]float64, len(edgeIDs))).
+			SetConfig(&data.FieldConfig{DisplayName: "Requests", Unit: "r"}),
+		data.NewField("secondaryStat", nil, make([]float64, len(edgeIDs))).
+			SetConfig(&data.FieldConfig{DisplayName: "Average response time", Unit: "ms/r"}),
+	)
+	for i, id := range edgeIDs {
+		edge := edges[id]
+		avg := math.NaN()
+		if edge.total > 0 {
+			avg = edge.seconds / edge.total * 1000
+		}
+		edgeFrame.Fields[1].Set(i, edge.source)
+		edgeFrame.Fields[2].Set(i, edge.target)
+		edgeFrame.Fields[3].Set(i, edge.total)
+		edgeFrame.Fields[4].Set(i, avg)
+	}
+
+	for _, frame := range []*data.Frame{nodeFrame, edgeFrame} {
+		frame.SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeNodeGraph})
+	}
+
+	return data.Frames{nodeFrame, edgeFrame}
+}
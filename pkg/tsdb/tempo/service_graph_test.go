@@ -0,0 +1,120 @@
+package tempo
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/prometheus/client_golang/api"
+	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceGraphFrames(t *testing.T) {
+	sample := func(client, server string, value float64) *model.Sample {
+		return &model.Sample{
+			Metric: model.Metric{"client": model.LabelValue(client), "server": model.LabelValue(server)},
+			Value:  model.SampleValue(value),
+		}
+	}
+
+	metrics := map[string]model.Vector{
+		serviceGraphTotalMetric: {
+			sample("user", "api", 100),
+			sample("api", "db", 50),
+		},
+		serviceGraphSecondsMetric: {
+			sample("user", "api", 10),
+			sample("api", "db", 1),
+		},
+		serviceGraphFailedMetric: {
+			sample("api", "db", 5),
+		},
+	}
+
+	frames := serviceGraphFrames(metrics, 100*time.Second)
+	require.Len(t, frames, 2)
+
+	nodes, edges := frames[0], frames[1]
+	assert.Equal(t, data.VisTypeNodeGraph, string(nodes.Meta.PreferredVisualization))
+	assert.Equal(t, data.VisTypeNodeGraph, string(edges.Meta.PreferredVisualization))
+
+	require.Equal(t, 3, nodes.Rows())
+	assert.Equal(t, []interface{}{"api", "api", 100.0, 1.0, 1.0, 0.0}, nodes.RowCopy(0))
+	assert.Equal(t, []interface{}{"db", "db", 20.0, 0.5, 0.9, 0.1}, nodes.RowCopy(1))
+	// root clients did not handle any requests
+	assert.Equal(t, "user", nodes.Fields[0].At(2))
+	assert.True(t, math.IsNaN(nodes.Fields[2].At(2).(float64)))
+
+	require.Equal(t, 2, edges.Rows())
+	assert.Equal(t, []interface{}{"api_db", "api", "db", 50.0, 20.0}, edges.RowCopy(0))
+	assert.Equal(t, []interface{}{"user_api", "user", "api", 100.0, 100.0}, edges.RowCopy(1))
+}
+
+func TestQueryServiceGraphMetrics(t *testing.T) {
+	rt := &mockedRoundTripper{responseBytes: []byte(`{
+		"status": "success",
+		"data": {
+			"resultType": "vector",
+			"result": [{"metric": {"client": "api", "server": "db"}, "value": [1000, "42"]}]
+		}
+	}`)}
+	client, err := api.NewClient(api.Config{Address: "http://localhost:9090", RoundTripper: rt})
+	require.NoError(t, err)
+
+	timeRange := backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)}
+	metrics, err := queryServiceGraphMetrics(context.Background(), apiv1.NewAPI(client), `{client="api"}`, timeRange)
+	require.NoError(t, err)
+
+	require.Len(t, metrics, len(serviceGraphMetrics))
+	assert.Equal(t, model.SampleValue(42), metrics[serviceGraphTotalMetric][0].Value)
+
+	require.NoError(t, rt.lastRequest.ParseForm())
+	assert.Equal(t, `sum by (client, server)(increase(traces_service_graph_request_failed_total{client="api"}[3600s]))`, rt.lastRequest.Form.Get("query"))
+	assert.Equal(t, "3600", rt.lastRequest.Form.Get("time"))
+}
+
+type mockedRoundTripper struct {
+	responseBytes []byte
+	lastRequest   *http.Request
+}
+
+func (rt *mockedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.lastRequest = req
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(rt.responseBytes)),
+	}, nil
+}
+
+func TestServiceGraphUser(t *testing.T) {
+	s := &Service{}
+
+	t.Run("queries without a user use the organization of the query", func(t *testing.T) {
+		user, err := s.serviceGraphUser(context.Background(), backend.PluginContext{OrgID: 3})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), user.OrgId)
+		assert.Zero(t, user.UserId)
+	})
+
+	t.Run("anonymous users keep their role", func(t *testing.T) {
+		user, err := s.serviceGraphUser(context.Background(), backend.PluginContext{
+			OrgID: 2,
+			User:  &backend.User{Role: "Viewer"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), user.OrgId)
+		assert.Equal(t, models.ROLE_VIEWER, user.OrgRole)
+		assert.True(t, user.IsAnonymous)
+	})
+}
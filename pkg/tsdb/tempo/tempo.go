@@ -13,28 +13,69 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"go.opentelemetry.io/collector/model/otlp"
 )
 
+const (
+	queryTypeTraceID    = "traceId"
+	queryTypeSearch     = "nativeSearch"
+	queryTypeServiceMap = "serviceMap"
+)
+
 type Service struct {
-	im   instancemgmt.InstanceManager
-	tlog log.Logger
+	im                 instancemgmt.InstanceManager
+	tlog               log.Logger
+	features           featuremgmt.FeatureToggles
+	httpClientProvider httpclient.Provider
+	dataSourceCache    datasources.CacheService
+	dataSourcesService *datasources.Service
+	sqlStore           *sqlstore.SQLStore
 }
 
-func ProvideService(httpClientProvider httpclient.Provider) *Service {
+func ProvideService(httpClientProvider httpclient.Provider, features featuremgmt.FeatureToggles,
+	dataSourceCache datasources.CacheService, dataSourcesService *datasources.Service, sqlStore *sqlstore.SQLStore) *Service {
 	return &Service{
-		tlog: log.New("tsdb.tempo"),
-		im:   datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
+		tlog:               log.New("tsdb.tempo"),
+		im:                 datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
+		features:           features,
+		httpClientProvider: httpClientProvider,
+		dataSourceCache:    dataSourceCache,
+		dataSourcesService: dataSourcesService,
+		sqlStore:           sqlStore,
 	}
 }
 
 type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
+	// ServiceMapDatasourceUID is the uid of the Prometheus data source that
+	// holds the service graph metrics generated by Tempo.
+	ServiceMapDatasourceUID string
 }
 
 type QueryModel struct {
-	TraceID string `json:"query"`
+	QueryType string `json:"queryType"`
+	TraceID   string `json:"query"`
+
+	// Search
+	Search      string `json:"search"`
+	ServiceName string `json:"serviceName"`
+	SpanName    string `json:"spanName"`
+	MinDuration string `json:"minDuration"`
+	MaxDuration string `json:"maxDuration"`
+	Limit       int64  `json:"limit"`
+
+	// Service graph
+	ServiceMapQuery string `json:"serviceMapQuery"`
+}
+
+type jsonData struct {
+	ServiceMap struct {
+		DatasourceUID string `json:"datasourceUid"`
+	} `json:"serviceMap"`
 }
 
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
@@ -49,9 +90,17 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		var data jsonData
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &data); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
+			HTTPClient:              client,
+			URL:                     settings.URL,
+			ServiceMapDatasourceUID: data.ServiceMap.DatasourceUID,
 		}
 		return model, nil
 	}
@@ -59,28 +108,63 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	result := backend.NewQueryDataResponse()
-	queryRes := backend.DataResponse{}
-	refID := req.Queries[0].RefID
-
-	model := &QueryModel{}
-	err := json.Unmarshal(req.Queries[0].JSON, model)
-	if err != nil {
-		return result, err
-	}
 
 	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, query := range req.Queries {
+		model := &QueryModel{}
+		err := json.Unmarshal(query.JSON, model)
+		if err != nil {
+			return result, err
+		}
+
+		// query types that can't be run fail only their own response.
+		var queryRes backend.DataResponse
+		switch model.QueryType {
+		case "", queryTypeTraceID:
+			queryRes, err = s.queryTrace(ctx, dsInfo, model)
+		case queryTypeSearch:
+			if !s.features.IsEnabled(featuremgmt.FlagTempoSearch) {
+				queryRes.Error = fmt.Errorf("query type %q requires the %s feature toggle", model.QueryType, featuremgmt.FlagTempoSearch)
+				break
+			}
+			queryRes, err = s.querySearch(ctx, dsInfo, model, query.TimeRange)
+		case queryTypeServiceMap:
+			if !s.features.IsEnabled(featuremgmt.FlagTempoServiceGraph) {
+				queryRes.Error = fmt.Errorf("query type %q requires the %s feature toggle", model.QueryType, featuremgmt.FlagTempoServiceGraph)
+				break
+			}
+			queryRes, err = s.queryServiceGraph(ctx, req.PluginContext, dsInfo, model, query.TimeRange)
+		default:
+			queryRes.Error = fmt.Errorf("unsupported query type: %q", model.QueryType)
+		}
+		if err != nil {
+			return &backend.QueryDataResponse{}, err
+		}
+
+		for _, frame := range queryRes.Frames {
+			frame.RefID = query.RefID
+		}
+		result.Responses[query.RefID] = queryRes
+	}
+
+	return result, nil
+}
+
+func (s *Service) queryTrace(ctx context.Context, dsInfo *datasourceInfo, model *QueryModel) (backend.DataResponse, error) {
+	queryRes := backend.DataResponse{}
+
 	request, err := s.createRequest(ctx, dsInfo, model.TraceID)
 	if err != nil {
-		return result, err
+		return queryRes, err
 	}
 
 	resp, err := dsInfo.HTTPClient.Do(request)
 	if err != nil {
-		return result, fmt.Errorf("failed get to tempo: %w", err)
+		return queryRes, fmt.Errorf("failed get to tempo: %w", err)
 	}
 
 	defer func() {
@@ -91,30 +175,28 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return queryRes, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		queryRes.Error = fmt.Errorf("failed to get trace with id: %s Status: %s Body: %s", model.TraceID, resp.Status, string(body))
-		result.Responses[refID] = queryRes
-		return result, nil
+		return queryRes, nil
 	}
 
 	otTrace, err := otlp.NewProtobufTracesUnmarshaler().UnmarshalTraces(body)
 
 	if err != nil {
-		return &backend.QueryDataResponse{}, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
+		return queryRes, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
 	}
 
 	frame, err := TraceToFrame(otTrace)
 	if err != nil {
-		return &backend.QueryDataResponse{}, fmt.Errorf("failed to transform trace %v to data frame: %w", model.TraceID, err)
+		return queryRes, fmt.Errorf("failed to transform trace %v to data frame: %w", model.TraceID, err)
 	}
-	frame.RefID = refID
-	frames := []*data.Frame{frame}
-	queryRes.Frames = frames
-	result.Responses[refID] = queryRes
-	return result, nil
+	if frame != nil {
+		queryRes.Frames = []*data.Frame{frame}
+	}
+	return queryRes, nil
 }
 
 func (s *Service) createRequest(ctx context.Context, dsInfo *datasourceInfo, traceID string) (*http.Request, error) {
@@ -0,0 +1,231 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	latencyUniform = "uniform"
+	latencyNormal  = "normal"
+
+	// maxInjectedRows and maxInjectedSeries limit the rows across all the
+	// series of a query and the number of series, so that a query cannot take
+	// the server down.
+	maxInjectedRows   = 5000000
+	maxInjectedSeries = 1000
+)
+
+type failureQueryWrapper struct {
+	Failure failureQuery `json:"failure"`
+}
+
+type failureQuery struct {
+	SeriesCount int `json:"seriesCount"`
+	// ErrorRate is the probability, between 0 and 1, of the query failing.
+	ErrorRate    float64 `json:"errorRate"`
+	ErrorMessage string  `json:"errorMessage"`
+	// Latency is added before responding, drawn from LatencyDistribution,
+	// which is constant unless uniform or normal. For uniform latencies
+	// LatencyJitterMs is the maximum deviation from LatencyMs, for normal
+	// latencies it is the standard deviation.
+	LatencyDistribution string `json:"latencyDistribution"`
+	LatencyMs           int64  `json:"latencyMs"`
+	LatencyJitterMs     int64  `json:"latencyJitterMs"`
+	// PartialRate is the probability of the series being cut short, down to
+	// no rows at all.
+	PartialRate float64 `json:"partialRate"`
+	// NaNRate and NullRate are the probabilities of a burst of BurstLength
+	// NaN or null values starting at any point.
+	NaNRate     float64 `json:"nanRate"`
+	NullRate    float64 `json:"nullRate"`
+	BurstLength int     `json:"burstLength"`
+	// OversizeRows is the number of rows of each series regardless of the
+	// interval and max data points of the query, to exceed size limits.
+	OversizeRows int `json:"oversizeRows"`
+	// Seed makes the injected failures reproducible.
+	Seed int64 `json:"seed"`
+}
+
+// handleFailureInjectionScenario returns random walks that misbehave in
+// controlled ways, to test how panels and alert rules handle failing, slow
+// and incomplete data sources.
+func (s *Service) handleFailureInjectionScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query json: %v", err)
+		}
+		wrapper := &failureQueryWrapper{}
+		if err := json.Unmarshal(q.JSON, wrapper); err != nil {
+			return nil, fmt.Errorf("failed to parse query json: %v", err)
+		}
+		failure := wrapper.Failure
+
+		seed := failure.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rng := rand.New(rand.NewSource(seed))
+
+		if err := sleepContext(ctx, failure.latency(rng)); err != nil {
+			resp.Responses[q.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+
+		if rng.Float64() < failure.ErrorRate {
+			msg := failure.ErrorMessage
+			if msg == "" {
+				msg = "injected failure"
+			}
+			resp.Responses[q.RefID] = backend.DataResponse{Error: errors.New(msg)}
+			continue
+		}
+
+		respD := backend.DataResponse{}
+		seriesCount := failure.SeriesCount
+		if seriesCount < 1 {
+			seriesCount = 1
+		}
+		if seriesCount > maxInjectedSeries {
+			seriesCount = maxInjectedSeries
+		}
+		rows := failure.rows(q, seriesCount)
+		partial := rng.Float64() < failure.PartialRate
+		for i := 0; i < seriesCount; i++ {
+			frame := failure.randomWalk(q, model, i, rows, rng)
+			if partial {
+				frame = truncateFrame(frame, rng.Intn(frame.Rows()+1))
+				frame.AppendNotices(data.Notice{
+					Severity: data.NoticeSeverityWarning,
+					Text:     "The series has been cut short by failure injection.",
+				})
+			}
+			respD.Frames = append(respD.Frames, frame)
+		}
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+// latency returns the delay to add to a query.
+func (f failureQuery) latency(rng *rand.Rand) time.Duration {
+	ms := float64(f.LatencyMs)
+	jitter := float64(f.LatencyJitterMs)
+
+	switch f.LatencyDistribution {
+	case latencyUniform:
+		ms += (rng.Float64()*2 - 1) * jitter
+	case latencyNormal:
+		ms += rng.NormFloat64() * jitter
+	}
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// rows returns the number of rows of each of the seriesCount series of a
+// query. There is a row per interval of the query, up to its max data points,
+// or OversizeRows rows, as long as the series have at most maxInjectedRows
+// rows in total.
+func (f failureQuery) rows(q backend.DataQuery, seriesCount int) int {
+	rows := 0
+	if f.OversizeRows > 0 {
+		rows = f.OversizeRows
+	} else if q.Interval > 0 {
+		rows = int(q.TimeRange.To.Sub(q.TimeRange.From) / q.Interval)
+		if q.MaxDataPoints > 0 && int64(rows) > q.MaxDataPoints {
+			rows = int(q.MaxDataPoints)
+		}
+	}
+	if rows > maxInjectedRows/seriesCount {
+		rows = maxInjectedRows / seriesCount
+	}
+	return rows
+}
+
+// randomWalk returns a random walk of rows points spread across the time range
+// of the query, with bursts of NaN and null values.
+func (f failureQuery) randomWalk(q backend.DataQuery, model *simplejson.Json, index int, rows int, rng *rand.Rand) *data.Frame {
+	from, to := q.TimeRange.From, q.TimeRange.To
+	var step time.Duration
+	if rows > 0 {
+		step = to.Sub(from) / time.Duration(rows)
+	}
+
+	burstLength := f.BurstLength
+	if burstLength < 1 {
+		burstLength = 1
+	}
+
+	timeVec := make([]time.Time, rows)
+	floatVec := make([]*float64, rows)
+	walker := rng.Float64() * 100
+	nanLeft, nullLeft := 0, 0
+	for i := 0; i < rows; i++ {
+		timeVec[i] = from.Add(time.Duration(i) * step)
+
+		if nanLeft == 0 && nullLeft == 0 {
+			if rng.Float64() < f.NaNRate {
+				nanLeft = burstLength
+			} else if rng.Float64() < f.NullRate {
+				nullLeft = burstLength
+			}
+		}
+
+		switch {
+		case nullLeft > 0:
+			nullLeft--
+		case nanLeft > 0:
+			nanLeft--
+			v := math.NaN()
+			floatVec[i] = &v
+		default:
+			v := walker
+			floatVec[i] = &v
+		}
+		walker += rng.Float64() - 0.5
+	}
+
+	return data.NewFrame("",
+		data.NewField("time", nil, timeVec),
+		data.NewField(frameNameForQuery(q, model, index), parseLabels(model), floatVec),
+	)
+}
+
+// truncateFrame returns a frame with the first rows of frame.
+func truncateFrame(frame *data.Frame, rows int) *data.Frame {
+	truncated := frame.EmptyCopy()
+	for i := 0; i < rows; i++ {
+		truncated.AppendRow(frame.RowCopy(i)...)
+	}
+	return truncated
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
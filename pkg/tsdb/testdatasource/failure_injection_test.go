@@ -0,0 +1,143 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureInjectionScenario(t *testing.T) {
+	s := &Service{}
+	to := time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	query := func(ctx context.Context, failure map[string]interface{}) backend.DataResponse {
+		failure["seed"] = 42
+		failure["seriesCount"] = 2
+		model, err := json.Marshal(map[string]interface{}{
+			"scenarioId": "failure_injection",
+			"labels":     "host=a",
+			"failure":    failure,
+		})
+		require.NoError(t, err)
+		resp, err := s.handleFailureInjectionScenario(ctx, &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				Interval:  time.Minute,
+				TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+				JSON:      model,
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses["A"]
+	}
+
+	t.Run("Should return a series per interval without failures", func(t *testing.T) {
+		respD := query(context.Background(), map[string]interface{}{})
+		require.NoError(t, respD.Error)
+		require.Len(t, respD.Frames, 2)
+		for _, frame := range respD.Frames {
+			assert.Equal(t, 60, frame.Rows())
+			assert.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+			for i := 0; i < frame.Rows(); i++ {
+				v, ok := frame.Fields[1].ConcreteAt(i)
+				require.True(t, ok)
+				assert.False(t, math.IsNaN(v.(float64)))
+			}
+		}
+	})
+
+	t.Run("Should fail at the error rate", func(t *testing.T) {
+		respD := query(context.Background(), map[string]interface{}{"errorRate": 1, "errorMessage": "boom"})
+		require.EqualError(t, respD.Error, "boom")
+		assert.Empty(t, respD.Frames)
+
+		respD = query(context.Background(), map[string]interface{}{"errorRate": 1})
+		require.EqualError(t, respD.Error, "injected failure")
+	})
+
+	t.Run("Should cut the series short with a warning", func(t *testing.T) {
+		respD := query(context.Background(), map[string]interface{}{"partialRate": 1})
+		require.NoError(t, respD.Error)
+		require.Len(t, respD.Frames, 2)
+		for _, frame := range respD.Frames {
+			assert.LessOrEqual(t, frame.Rows(), 60)
+			require.NotNil(t, frame.Meta)
+			require.Len(t, frame.Meta.Notices, 1)
+		}
+	})
+
+	t.Run("Should return bursts of NaN and null values", func(t *testing.T) {
+		respD := query(context.Background(), map[string]interface{}{"nanRate": 0.1, "nullRate": 0.1, "burstLength": 3})
+		require.NoError(t, respD.Error)
+
+		nans, nulls := 0, 0
+		field := respD.Frames[0].Fields[1]
+		for i := 0; i < field.Len(); i++ {
+			v, ok := field.ConcreteAt(i)
+			switch {
+			case !ok:
+				nulls++
+			case math.IsNaN(v.(float64)):
+				nans++
+			}
+		}
+		assert.Greater(t, nans, 0)
+		assert.Greater(t, nulls, 0)
+	})
+
+	t.Run("Should return oversized series", func(t *testing.T) {
+		respD := query(context.Background(), map[string]interface{}{"oversizeRows": 10000})
+		require.NoError(t, respD.Error)
+		assert.Equal(t, 10000, respD.Frames[0].Rows())
+	})
+
+	t.Run("Should stop waiting when the request is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		respD := query(ctx, map[string]interface{}{"latencyMs": 60000})
+		require.ErrorIs(t, respD.Error, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+}
+
+func TestFailureLatency(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	assert.Equal(t, 100*time.Millisecond, failureQuery{LatencyMs: 100, LatencyJitterMs: 50}.latency(rng))
+
+	for i := 0; i < 100; i++ {
+		d := failureQuery{LatencyDistribution: latencyUniform, LatencyMs: 100, LatencyJitterMs: 50}.latency(rng)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.LessOrEqual(t, d, 150*time.Millisecond)
+
+		d = failureQuery{LatencyDistribution: latencyNormal, LatencyMs: 10, LatencyJitterMs: 100}.latency(rng)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+	}
+}
+
+func TestFailureRows(t *testing.T) {
+	to := time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC)
+	q := backend.DataQuery{
+		Interval:  time.Second,
+		TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+	}
+
+	assert.Equal(t, 3600, failureQuery{}.rows(q, 1))
+
+	q.MaxDataPoints = 100
+	assert.Equal(t, 100, failureQuery{}.rows(q, 1))
+	assert.Equal(t, 10000, failureQuery{OversizeRows: 10000}.rows(q, 1))
+
+	assert.Equal(t, maxInjectedRows, failureQuery{OversizeRows: 2 * maxInjectedRows}.rows(q, 1))
+	assert.Equal(t, maxInjectedRows/maxInjectedSeries, failureQuery{OversizeRows: maxInjectedRows}.rows(q, maxInjectedSeries))
+}
@@ -0,0 +1,198 @@
+package testdatasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+var validReplayFileName = regexp.MustCompile(`^[\w-]+\.(json|arrow)$`)
+
+type replayQueryWrapper struct {
+	Replay replayQuery `json:"replay"`
+}
+
+type replayQuery struct {
+	// FileName is the name of a recording in the testdata directory of the
+	// data path, either a query response as returned by /api/ds/query or an
+	// Apache Arrow frame.
+	FileName string `json:"fileName"`
+	// Content is a query response as returned by /api/ds/query, or a list of
+	// data frames, used instead of a file.
+	Content string `json:"content"`
+	// RefID is the recorded query to replay. It defaults to the one of the
+	// query, or to all recorded queries if there is none with that ID.
+	RefID string `json:"refId"`
+	// KeepTime disables shifting the recorded times to the query time range.
+	KeepTime bool `json:"keepTime"`
+}
+
+// handleReplayScenario returns recorded query responses, for example of a
+// data source a bug was reported for. Unless disabled, times are shifted so
+// that the latest recorded time is the end of the time range of the query.
+func (s *Service) handleReplayScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		wrapper := &replayQueryWrapper{}
+		if err := json.Unmarshal(q.JSON, wrapper); err != nil {
+			return nil, fmt.Errorf("failed to parse query json: %v", err)
+		}
+		replay := wrapper.Replay
+
+		recorded, err := s.loadRecording(replay)
+		if err != nil {
+			resp.Responses[q.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+
+		refID := replay.RefID
+		if refID == "" {
+			refID = q.RefID
+		}
+		respD, ok := recorded.Responses[refID]
+		if !ok {
+			if replay.RefID != "" {
+				resp.Responses[q.RefID] = backend.DataResponse{
+					Error: fmt.Errorf("recording has no response for query %q", replay.RefID),
+				}
+				continue
+			}
+			respD = mergeResponses(recorded)
+		}
+
+		if !replay.KeepTime {
+			shiftFrames(respD.Frames, q.TimeRange.To)
+		}
+		for _, frame := range respD.Frames {
+			frame.RefID = q.RefID
+		}
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+func (s *Service) loadRecording(replay replayQuery) (*backend.QueryDataResponse, error) {
+	if replay.Content != "" {
+		return parseRecording([]byte(replay.Content))
+	}
+	if replay.FileName == "" {
+		return backend.NewQueryDataResponse(), nil
+	}
+
+	if !validReplayFileName.MatchString(replay.FileName) {
+		return nil, fmt.Errorf("invalid recording file name: %q", replay.FileName)
+	}
+	filePath := filepath.Join(s.cfg.DataPath, "testdata", filepath.Clean(filepath.Join("/", replay.FileName)))
+
+	// Can ignore gosec G304 here, because we check the file pattern above
+	// nolint:gosec
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %v", err)
+	}
+
+	if strings.HasSuffix(replay.FileName, ".arrow") {
+		frame, err := data.UnmarshalArrowFrame(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %v", err)
+		}
+		resp := backend.NewQueryDataResponse()
+		resp.Responses[frame.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+		return resp, nil
+	}
+	return parseRecording(b)
+}
+
+// parseRecording parses a query response, or a list of data frames as found
+// in the query inspector.
+func parseRecording(b []byte) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		var frames data.Frames
+		if err := json.Unmarshal(b, &frames); err != nil {
+			return nil, fmt.Errorf("failed to parse recorded frames: %v", err)
+		}
+		for _, frame := range frames {
+			respD := resp.Responses[frame.RefID]
+			respD.Frames = append(respD.Frames, frame)
+			resp.Responses[frame.RefID] = respD
+		}
+		return resp, nil
+	}
+
+	if err := json.Unmarshal(b, resp); err != nil {
+		return nil, fmt.Errorf("failed to parse recorded response: %v", err)
+	}
+	return resp, nil
+}
+
+// mergeResponses returns the frames of all responses, ordered by query.
+func mergeResponses(resp *backend.QueryDataResponse) backend.DataResponse {
+	refIDs := make([]string, 0, len(resp.Responses))
+	for refID := range resp.Responses {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	merged := backend.DataResponse{}
+	for _, refID := range refIDs {
+		respD := resp.Responses[refID]
+		merged.Frames = append(merged.Frames, respD.Frames...)
+		if merged.Error == nil {
+			merged.Error = respD.Error
+		}
+	}
+	return merged
+}
+
+// shiftFrames shifts the values of all time fields so that the latest of them
+// is to.
+func shiftFrames(frames data.Frames, to time.Time) {
+	var latest time.Time
+	forEachTime(frames, func(t time.Time) time.Time {
+		if t.After(latest) {
+			latest = t
+		}
+		return t
+	})
+	if latest.IsZero() {
+		return
+	}
+
+	shift := to.Sub(latest)
+	forEachTime(frames, func(t time.Time) time.Time {
+		return t.Add(shift)
+	})
+}
+
+func forEachTime(frames data.Frames, fn func(time.Time) time.Time) {
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			switch field.Type() {
+			case data.FieldTypeTime:
+				for i := 0; i < field.Len(); i++ {
+					field.Set(i, fn(field.At(i).(time.Time)))
+				}
+			case data.FieldTypeNullableTime:
+				for i := 0; i < field.Len(); i++ {
+					if t, ok := field.ConcreteAt(i); ok {
+						field.SetConcrete(i, fn(t.(time.Time)))
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayScenario(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.DataPath = t.TempDir()
+	s := &Service{cfg: cfg}
+
+	t1 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+	recorded := backend.NewQueryDataResponse()
+	recorded.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+		data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{t1, t2}),
+			data.NewField("value", data.Labels{"host": "a"}, []float64{1, 2}),
+		),
+	}}
+	recorded.Responses["B"] = backend.DataResponse{Frames: data.Frames{
+		data.NewFrame("mem",
+			data.NewField("time", nil, []*time.Time{&t1, nil}),
+			data.NewField("value", nil, []float64{3, 4}),
+		),
+	}}
+	recording, err := json.Marshal(recorded)
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.DataPath, "testdata"), 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.DataPath, "testdata", "recording.json"), recording, 0600))
+
+	to := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	replay := func(refID string, replay map[string]interface{}) backend.DataResponse {
+		model, err := json.Marshal(map[string]interface{}{"scenarioId": "replay", "replay": replay})
+		require.NoError(t, err)
+		resp, err := s.handleReplayScenario(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     refID,
+				TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+				JSON:      model,
+			}},
+		})
+		require.NoError(t, err)
+		return resp.Responses[refID]
+	}
+
+	t.Run("Should replay the response of the query from a file shifted to the time range", func(t *testing.T) {
+		respD := replay("A", map[string]interface{}{"fileName": "recording.json"})
+		require.NoError(t, respD.Error)
+		require.Len(t, respD.Frames, 1)
+
+		frame := respD.Frames[0]
+		assert.Equal(t, "cpu", frame.Name)
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, to.Add(-time.Minute), frame.Fields[0].At(0).(time.Time).UTC())
+		assert.Equal(t, to, frame.Fields[0].At(1).(time.Time).UTC())
+		assert.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+	})
+
+	t.Run("Should replay another recorded query and keep the times", func(t *testing.T) {
+		respD := replay("C", map[string]interface{}{"content": string(recording), "refId": "B", "keepTime": true})
+		require.NoError(t, respD.Error)
+		require.Len(t, respD.Frames, 1)
+
+		frame := respD.Frames[0]
+		assert.Equal(t, "C", frame.RefID)
+		assert.Equal(t, t1, frame.Fields[0].At(0).(*time.Time).UTC())
+		assert.Nil(t, frame.Fields[0].At(1))
+	})
+
+	t.Run("Should replay all recorded queries when none has the ID of the query", func(t *testing.T) {
+		respD := replay("C", map[string]interface{}{"fileName": "recording.json"})
+		require.NoError(t, respD.Error)
+		require.Len(t, respD.Frames, 2)
+		assert.Equal(t, "cpu", respD.Frames[0].Name)
+		assert.Equal(t, "mem", respD.Frames[1].Name)
+
+		// the latest time of all frames is the end of the range
+		assert.Equal(t, to, respD.Frames[0].Fields[0].At(1).(time.Time).UTC())
+		assert.Equal(t, to.Add(-time.Minute), respD.Frames[1].Fields[0].At(0).(*time.Time).UTC())
+	})
+
+	t.Run("Should replay a list of frames", func(t *testing.T) {
+		frames, err := json.Marshal(recorded.Responses["A"].Frames)
+		require.NoError(t, err)
+
+		respD := replay("A", map[string]interface{}{"content": string(frames)})
+		require.NoError(t, respD.Error)
+		require.Len(t, respD.Frames, 1)
+		assert.Equal(t, 2, respD.Frames[0].Rows())
+	})
+
+	t.Run("Should replay recorded errors", func(t *testing.T) {
+		respD := replay("A", map[string]interface{}{"content": `{"results": {"A": {"error": "query timed out"}}}`})
+		require.EqualError(t, respD.Error, "query timed out")
+	})
+
+	t.Run("Should not allow non file name chars", func(t *testing.T) {
+		for _, fileName := range []string{"../recording.json", "recording.csv"} {
+			respD := replay("A", map[string]interface{}{"fileName": fileName})
+			require.EqualError(t, respD.Error, fmt.Sprintf("invalid recording file name: %q", fileName))
+		}
+	})
+
+	t.Run("Should return an error for unknown recorded queries", func(t *testing.T) {
+		respD := replay("A", map[string]interface{}{"fileName": "recording.json", "refId": "Z"})
+		require.EqualError(t, respD.Error, `recording has no response for query "Z"`)
+	})
+}
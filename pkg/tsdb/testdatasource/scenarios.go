@@ -43,6 +43,8 @@ const (
 	rawFrameQuery                     queryType = "raw_frame"
 	csvFileQueryType                  queryType = "csv_file"
 	csvContentQueryType               queryType = "csv_content"
+	replayQueryType                   queryType = "replay"
+	failureInjectionQuery             queryType = "failure_injection"
 )
 
 type queryType string
@@ -210,6 +212,18 @@ Timestamps will line up evenly on timeStepSeconds (For example, 60 seconds means
 		handler: s.handleCsvContentScenario,
 	})
 
+	s.registerScenario(&Scenario{
+		ID:      string(replayQueryType),
+		Name:    "Replay recorded response",
+		handler: s.handleReplayScenario,
+	})
+
+	s.registerScenario(&Scenario{
+		ID:      string(failureInjectionQuery),
+		Name:    "Failure injection",
+		handler: s.handleFailureInjectionScenario,
+	})
+
 	s.queryMux.HandleFunc("", s.handleFallbackScenario)
 }
 
@@ -0,0 +1,53 @@
+import React, { FormEvent, useState } from 'react';
+import { Button, Field, Input, Modal } from '@grafana/ui';
+
+export interface Props {
+  invalidPassword: boolean;
+  onSubmit: (password: string) => void;
+  onCancel: () => void;
+  onDismiss: () => void;
+}
+
+export function SnapshotPasswordModal({ invalidPassword, onSubmit, onCancel, onDismiss }: Props): JSX.Element {
+  const [password, setPassword] = useState('');
+
+  const onCancelClick = () => {
+    onDismiss();
+    onCancel();
+  };
+
+  const onFormSubmit = (event: FormEvent) => {
+    event.preventDefault();
+    onDismiss();
+    onSubmit(password);
+  };
+
+  return (
+    <Modal title="Password protected snapshot" isOpen onDismiss={onCancelClick}>
+      <form onSubmit={onFormSubmit}>
+        <Field
+          label="Password"
+          description="Enter the password you received together with the link to this snapshot."
+          invalid={invalidPassword}
+          error={invalidPassword ? 'Invalid password' : undefined}
+        >
+          <Input
+            type="password"
+            value={password}
+            autoFocus
+            onChange={(event) => setPassword(event.currentTarget.value)}
+            aria-label="Snapshot password"
+          />
+        </Field>
+        <Modal.ButtonRow>
+          <Button variant="secondary" type="button" onClick={onCancelClick} fill="outline">
+            Cancel
+          </Button>
+          <Button type="submit" disabled={password === ''}>
+            View snapshot
+          </Button>
+        </Modal.ButtonRow>
+      </form>
+    </Modal>
+  );
+}
@@ -10,6 +10,11 @@ import { getDashboardSrv } from './DashboardSrv';
 import { getDatasourceSrv } from 'app/features/plugins/datasource_srv';
 import { getBackendSrv, locationService } from '@grafana/runtime';
 import { appEvents } from '../../../core/core';
+import { lastValueFrom } from 'rxjs';
+import { ShowModalReactEvent } from '../../../types/events';
+import { SnapshotPasswordModal } from '../components/SnapshotPasswordModal/SnapshotPasswordModal';
+
+const snapshotPasswordHeader = 'X-Grafana-Snapshot-Password';
 
 export class DashboardLoaderSrv {
   constructor() {}
@@ -34,9 +39,7 @@ export class DashboardLoaderSrv {
     if (type === 'script') {
       promise = this._loadScriptedDashboard(slug);
     } else if (type === 'snapshot') {
-      promise = backendSrv.get('/api/snapshots/' + slug).catch(() => {
-        return this._dashboardLoadFailed('Snapshot not found', true);
-      });
+      promise = this._loadSnapshot(slug);
     } else if (type === 'ds') {
       promise = this._loadFromDatasource(slug); // explore dashboards as code
     } else {
@@ -65,6 +68,40 @@ export class DashboardLoaderSrv {
     return promise;
   }
 
+  _loadSnapshot(key: string, password?: string): Promise<any> {
+    const headers: Record<string, string> = password !== undefined ? { [snapshotPasswordHeader]: password } : {};
+
+    // retry is set so that a 401 for a password protected snapshot does not end the session
+    return lastValueFrom(
+      getBackendSrv().fetch({ url: `/api/snapshots/${key}`, headers, showErrorAlert: false, retry: 1 })
+    )
+      .then((response) => response.data)
+      .catch((err) => {
+        if (err.status === 401) {
+          return this._promptSnapshotPassword(key, password !== undefined);
+        }
+        if (err.status === 410) {
+          return this._dashboardLoadFailed('Snapshot view limit reached', true);
+        }
+        return this._dashboardLoadFailed('Snapshot not found', true);
+      });
+  }
+
+  _promptSnapshotPassword(key: string, invalidPassword: boolean): Promise<any> {
+    return new Promise((resolve) => {
+      appEvents.publish(
+        new ShowModalReactEvent({
+          component: SnapshotPasswordModal,
+          props: {
+            invalidPassword,
+            onSubmit: (password: string) => resolve(this._loadSnapshot(key, password)),
+            onCancel: () => resolve(this._dashboardLoadFailed('Snapshot is password protected', true)),
+          },
+        })
+      );
+    });
+  }
+
   _loadScriptedDashboard(file: string) {
     const url = 'public/dashboards/' + file.replace(/\.(?!js)/, '/') + '?' + new Date().getTime();
 
@@ -113,9 +113,23 @@ export default class CloudMonitoringDatasource extends DataSourceWithBackend<
   }
 
   applyTemplateVariables(
-    { metricQuery, refId, queryType, sloQuery }: CloudMonitoringQuery,
+    { metricQuery, refId, queryType, sloQuery, promQLQuery }: CloudMonitoringQuery,
     scopedVars: ScopedVars
   ): Record<string, any> {
+    if (queryType === QueryType.PROMQL && promQLQuery) {
+      return {
+        datasource: this.getRef(),
+        refId,
+        intervalMs: this.intervalMs,
+        type: 'timeSeriesQuery',
+        queryType,
+        promQLQuery: {
+          ...this.interpolateProps(promQLQuery, scopedVars),
+          projectName: this.templateSrv.replace(promQLQuery.projectName || this.getDefaultProject(), scopedVars),
+        },
+      };
+    }
+
     return {
       datasource: this.getRef(),
       refId,
@@ -251,7 +265,7 @@ export default class CloudMonitoringDatasource extends DataSourceWithBackend<
   }
 
   migrateQuery(query: CloudMonitoringQuery): CloudMonitoringQuery {
-    if (!query.hasOwnProperty('metricQuery')) {
+    if (!query.hasOwnProperty('metricQuery') && query.queryType !== QueryType.PROMQL) {
       const { hide, refId, datasource, key, queryType, maxLines, metric, intervalMs, type, ...rest } = query as any;
       return {
         refId,
@@ -287,6 +301,10 @@ export default class CloudMonitoringDatasource extends DataSourceWithBackend<
       return !!selectorName && !!serviceId && !!sloId && !!projectName;
     }
 
+    if (query.queryType === QueryType.PROMQL) {
+      return !!query.promQLQuery?.expr;
+    }
+
     if (query.queryType && query.queryType === QueryType.METRICS && query.metricQuery.editorMode === EditorMode.MQL) {
       return !!query.metricQuery.projectName && !!query.metricQuery.query;
     }
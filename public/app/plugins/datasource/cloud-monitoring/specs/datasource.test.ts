@@ -3,7 +3,7 @@ import { DataSourceInstanceSettings, toUtc } from '@grafana/data';
 
 import CloudMonitoringDataSource from '../datasource';
 import { TemplateSrv } from 'app/features/templating/template_srv';
-import { CloudMonitoringOptions } from '../types';
+import { CloudMonitoringOptions, CloudMonitoringQuery, QueryType } from '../types';
 import { backendSrv } from 'app/core/services/backend_srv'; // will use the version in __mocks__
 import { TimeSrv } from 'app/features/dashboard/services/TimeSrv';
 import { CustomVariableModel } from '../../../../features/variables/types';
@@ -196,6 +196,36 @@ describe('CloudMonitoringDataSource', () => {
       });
     });
   });
+
+  describe('when applying template variables to a PromQL query', () => {
+    it('should interpolate the expression and default the project', () => {
+      const templateSrv = initTemplateSrv('node');
+      const { ds } = getTestcontext({ templateSrv });
+      const query = ({
+        refId: 'A',
+        queryType: QueryType.PROMQL,
+        promQLQuery: { projectName: '', expr: 'up{job="$test"}', legendFormat: '{{instance}}' },
+      } as unknown) as CloudMonitoringQuery;
+
+      expect(ds.filterQuery(query)).toBe(true);
+      expect(ds.applyTemplateVariables(ds.migrateQuery(query), {})).toMatchObject({
+        queryType: QueryType.PROMQL,
+        promQLQuery: { projectName: 'testproject', expr: 'up{job="node"}', legendFormat: '{{instance}}' },
+      });
+      expect(ds.applyTemplateVariables(query, {}).metricQuery).toBeUndefined();
+    });
+
+    it('should filter out queries without an expression', () => {
+      const { ds } = getTestcontext();
+      const query = ({
+        refId: 'A',
+        queryType: QueryType.PROMQL,
+        promQLQuery: { projectName: 'testproject', expr: '' },
+      } as unknown) as CloudMonitoringQuery;
+
+      expect(ds.filterQuery(query)).toBe(false);
+    });
+  });
 });
 
 function initTemplateSrv(values: any, multi = false) {
@@ -20,6 +20,7 @@ export enum MetricFindQueryTypes {
   Selectors = 'selectors',
   SLOServices = 'sloServices',
   SLO = 'slo',
+  PROMQL = 'promQL',
 }
 
 export interface CloudMonitoringVariableQuery extends DataQuery {
@@ -137,11 +138,21 @@ export interface SLOQuery extends BaseQuery {
   goal?: number;
 }
 
+export interface PromQLQuery {
+  projectName: string;
+  expr: string;
+  legendFormat?: string;
+  step?: string;
+  range?: boolean;
+  instant?: boolean;
+}
+
 export interface CloudMonitoringQuery extends DataQuery {
   datasourceId?: number; // Should not be necessary anymore
   queryType: QueryType;
   metricQuery: MetricQuery;
   sloQuery?: SLOQuery;
+  promQLQuery?: PromQLQuery;
   intervalMs: number;
   type: string;
 }
@@ -44,6 +44,18 @@ export const ConfigEditor: FC<Props> = (props: Props) => {
             onChange={onUpdateDatasourceJsonDataOption(props, 'customMetricsNamespaces')}
           />
         </InlineField>
+        <InlineField
+          label="Allowed assume role ARNs"
+          labelWidth={28}
+          tooltip="Roles, besides the assume role ARN, that metric queries may assume to query other accounts. Queries can only assume roles listed here."
+        >
+          <Input
+            width={60}
+            placeholder="arn:aws:iam::123456789012:role/grafana,arn:aws:iam::210987654321:role/grafana"
+            value={options.jsonData.allowedAssumeRoleArns || ''}
+            onChange={onUpdateDatasourceJsonDataOption(props, 'allowedAssumeRoleArns')}
+          />
+        </InlineField>
       </ConnectionConfig>
 
       <h3 className="page-heading">CloudWatch Logs</h3>
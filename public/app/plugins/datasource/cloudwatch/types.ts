@@ -43,6 +43,9 @@ export interface CloudWatchMetricsQuery extends DataQuery {
   //common props
   id: string;
   region: string;
+  // the backend fans the query out to every combination of these regions and roles
+  regions?: string[];
+  assumeRoleArns?: string[];
   namespace: string;
   period?: string;
   alias?: string;
@@ -118,6 +121,8 @@ export interface CloudWatchJsonData extends AwsAuthDataSourceJsonData {
   timeField?: string;
   database?: string;
   customMetricsNamespaces?: string;
+  // Comma separated roles, besides the one of the data source, that metric queries may assume.
+  allowedAssumeRoleArns?: string;
   endpoint?: string;
   // Time string like 15s, 10m etc, see rangeUtils.intervalToMs.
   logsTimeout?: string;
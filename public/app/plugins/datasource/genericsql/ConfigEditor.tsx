@@ -0,0 +1,104 @@
+import React from 'react';
+import {
+  DataSourcePluginOptionsEditorProps,
+  onUpdateDatasourceJsonDataOption,
+  onUpdateDatasourceJsonDataOptionSelect,
+  onUpdateDatasourceSecureJsonDataOption,
+  SelectableValue,
+  updateDatasourcePluginJsonDataOption,
+  updateDatasourcePluginResetOption,
+} from '@grafana/data';
+import { InlineField, Input, LegacyForms, Select } from '@grafana/ui';
+import { GenericSQLOptions, GenericSQLSecureJsonData, MacroDialect } from './types';
+
+const { SecretFormField } = LegacyForms;
+
+const dialects: Array<SelectableValue<MacroDialect>> = [
+  { label: 'ANSI SQL', value: 'ansi', description: 'EXTRACT and TIMESTAMP literals, e.g. PostgreSQL compatible stores' },
+  { label: 'SQLite', value: 'sqlite', description: "strftime('%s', ...) based time macros" },
+  { label: 'ClickHouse', value: 'clickhouse', description: 'toUnixTimestamp and toDateTime based time macros' },
+];
+
+export type Props = DataSourcePluginOptionsEditorProps<GenericSQLOptions, GenericSQLSecureJsonData>;
+
+export const ConfigEditor = (props: Props) => {
+  const { options } = props;
+  const { jsonData, secureJsonFields } = options;
+  const secureJsonData = (options.secureJsonData || {}) as GenericSQLSecureJsonData;
+
+  const onNumberChange = (key: 'queryTimeout' | 'maxRows') => (event: React.FormEvent<HTMLInputElement>) => {
+    const value = parseInt(event.currentTarget.value, 10);
+    updateDatasourcePluginJsonDataOption(props, key, isNaN(value) ? undefined : value);
+  };
+
+  return (
+    <>
+      <h3 className="page-heading">Connection</h3>
+      <div className="gf-form-group">
+        <InlineField
+          label="Driver"
+          labelWidth={20}
+          tooltip="Name of a registered database/sql driver, e.g. sqlite3. The driver must be listed in allowed_drivers of the [plugin.genericsql] configuration section."
+        >
+          <Input
+            width={40}
+            placeholder="sqlite3"
+            value={jsonData.driver || ''}
+            onChange={onUpdateDatasourceJsonDataOption(props, 'driver')}
+          />
+        </InlineField>
+        <InlineField label="Macro dialect" labelWidth={20} tooltip="SQL dialect used to expand time macros.">
+          <Select
+            width={40}
+            options={dialects}
+            value={dialects.find((d) => d.value === (jsonData.dialect || 'ansi'))}
+            onChange={onUpdateDatasourceJsonDataOptionSelect(props, 'dialect')}
+          />
+        </InlineField>
+        <div className="gf-form">
+          <SecretFormField
+            isConfigured={Boolean(secureJsonFields && secureJsonFields.connectionString)}
+            value={secureJsonData.connectionString || ''}
+            label="Connection string"
+            aria-label="Connection string"
+            labelWidth={10}
+            inputWidth={20}
+            placeholder="/var/lib/grafana/data.db"
+            onReset={() => updateDatasourcePluginResetOption(props, 'connectionString')}
+            onChange={onUpdateDatasourceSecureJsonDataOption(props, 'connectionString')}
+          />
+        </div>
+      </div>
+
+      <h3 className="page-heading">Query limits</h3>
+      <div className="gf-form-group">
+        <InlineField label="Min time interval" labelWidth={20} tooltip="Lower limit for the $__interval macro.">
+          <Input
+            width={40}
+            placeholder="1m"
+            value={jsonData.timeInterval || ''}
+            onChange={onUpdateDatasourceJsonDataOption(props, 'timeInterval')}
+          />
+        </InlineField>
+        <InlineField label="Query timeout" labelWidth={20} tooltip="Maximum execution time of a query in seconds.">
+          <Input
+            type="number"
+            width={40}
+            placeholder="No timeout"
+            value={jsonData.queryTimeout ?? ''}
+            onChange={onNumberChange('queryTimeout')}
+          />
+        </InlineField>
+        <InlineField label="Max rows" labelWidth={20} tooltip="Maximum number of rows read from a query result.">
+          <Input
+            type="number"
+            width={40}
+            placeholder="Server default"
+            value={jsonData.maxRows ?? ''}
+            onChange={onNumberChange('maxRows')}
+          />
+        </InlineField>
+      </div>
+    </>
+  );
+};
@@ -0,0 +1,47 @@
+import React from 'react';
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { InlineField, Select, TextArea } from '@grafana/ui';
+import { GenericSQLDatasource } from './datasource';
+import { GenericSQLOptions, GenericSQLQuery, ResultFormat } from './types';
+
+const formats: Array<SelectableValue<ResultFormat>> = [
+  { label: 'Time series', value: 'time_series' },
+  { label: 'Table', value: 'table' },
+];
+
+type Props = QueryEditorProps<GenericSQLDatasource, GenericSQLQuery, GenericSQLOptions>;
+
+export const QueryEditor = ({ query, onChange, onRunQuery }: Props) => {
+  const onKeyDown = (event: React.KeyboardEvent<HTMLTextAreaElement>) => {
+    if (event.key === 'Enter' && (event.shiftKey || event.ctrlKey)) {
+      event.preventDefault();
+      onRunQuery();
+    }
+  };
+
+  return (
+    <>
+      <TextArea
+        name="Query"
+        className="slate-query-field"
+        value={query.rawSql || ''}
+        rows={6}
+        placeholder="SELECT $__timeGroupAlias(time, $__interval), avg(value) AS value FROM metrics WHERE $__timeFilter(time) GROUP BY 1 ORDER BY 1 (Run with Shift+Enter)"
+        onBlur={onRunQuery}
+        onChange={(e) => onChange({ ...query, rawSql: e.currentTarget.value })}
+        onKeyDown={onKeyDown}
+      />
+      <InlineField label="Format as" labelWidth={12}>
+        <Select
+          width={20}
+          options={formats}
+          value={formats.find((f) => f.value === (query.format || 'time_series'))}
+          onChange={(v) => {
+            onChange({ ...query, format: v.value });
+            onRunQuery();
+          }}
+        />
+      </InlineField>
+    </>
+  );
+};
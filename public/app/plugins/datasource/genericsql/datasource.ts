@@ -0,0 +1,20 @@
+import { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
+import { DataSourceWithBackend, getTemplateSrv } from '@grafana/runtime';
+import { GenericSQLOptions, GenericSQLQuery } from './types';
+
+export class GenericSQLDatasource extends DataSourceWithBackend<GenericSQLQuery, GenericSQLOptions> {
+  constructor(instanceSettings: DataSourceInstanceSettings<GenericSQLOptions>) {
+    super(instanceSettings);
+  }
+
+  filterQuery(query: GenericSQLQuery): boolean {
+    return !query.hide && !!query.rawSql;
+  }
+
+  applyTemplateVariables(query: GenericSQLQuery, scopedVars: ScopedVars): GenericSQLQuery {
+    return {
+      ...query,
+      rawSql: getTemplateSrv().replace(query.rawSql ?? '', scopedVars),
+    };
+  }
+}
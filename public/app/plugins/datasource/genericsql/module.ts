@@ -0,0 +1,8 @@
+import { DataSourcePlugin } from '@grafana/data';
+import { GenericSQLDatasource } from './datasource';
+import { QueryEditor } from './QueryEditor';
+import { ConfigEditor } from './ConfigEditor';
+
+export const plugin = new DataSourcePlugin(GenericSQLDatasource)
+  .setQueryEditor(QueryEditor)
+  .setConfigEditor(ConfigEditor);
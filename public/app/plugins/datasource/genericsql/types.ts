@@ -0,0 +1,22 @@
+import { DataQuery, DataSourceJsonData } from '@grafana/data';
+
+export type ResultFormat = 'time_series' | 'table';
+
+export type MacroDialect = 'ansi' | 'sqlite' | 'clickhouse';
+
+export interface GenericSQLQuery extends DataQuery {
+  format?: ResultFormat;
+  rawSql?: string;
+}
+
+export interface GenericSQLOptions extends DataSourceJsonData {
+  driver?: string;
+  dialect?: MacroDialect;
+  timeInterval?: string;
+  queryTimeout?: number;
+  maxRows?: number;
+}
+
+export interface GenericSQLSecureJsonData {
+  connectionString?: string;
+}
@@ -4,7 +4,7 @@ import { TemplateSrv } from 'app/features/templating/template_srv';
 import createMockQuery from '../__mocks__/query';
 import { singleVariable, subscriptionsVariable } from '../__mocks__/variables';
 import AzureMonitorDatasource from '../datasource';
-import { AzureDataSourceJsonData, AzureQueryType, DatasourceValidationResult } from '../types';
+import { AzureDataSourceJsonData, AzureMonitorQuery, AzureQueryType, DatasourceValidationResult } from '../types';
 
 const templateSrv = new TemplateSrv();
 
@@ -75,6 +75,46 @@ describe('AzureMonitorDatasource', () => {
       });
     });
   });
+  describe('When filtering queries', () => {
+    const query = (azureMonitor: object) =>
+      ({
+        refId: 'A',
+        queryType: AzureQueryType.AzureMonitor,
+        azureMonitor: {
+          resourceGroup: 'rg',
+          resourceName: 'vm',
+          metricDefinition: 'Microsoft.Compute/virtualMachines',
+          metricName: 'Percentage CPU',
+          aggregation: 'Average',
+          ...azureMonitor,
+        },
+      } as AzureMonitorQuery);
+
+    it('should require a resource name for queries of a single resource', () => {
+      expect(ctx.ds.azureMonitorDatasource.filterQuery(query({}))).toBe(true);
+      expect(ctx.ds.azureMonitorDatasource.filterQuery(query({ resourceName: undefined }))).toBe(false);
+    });
+
+    it('should not require a resource name for queries of a resource group', () => {
+      expect(
+        ctx.ds.azureMonitorDatasource.filterQuery(query({ scope: 'resourceGroup', resourceName: undefined }))
+      ).toBe(true);
+      expect(
+        ctx.ds.azureMonitorDatasource.filterQuery(
+          query({ scope: 'resourceGroup', resourceGroup: undefined, resourceName: undefined })
+        )
+      ).toBe(false);
+    });
+
+    it('should not require a resource group for queries of a subscription', () => {
+      expect(
+        ctx.ds.azureMonitorDatasource.filterQuery(
+          query({ scope: 'subscription', resourceGroup: undefined, resourceName: undefined })
+        )
+      ).toBe(true);
+    });
+  });
+
   describe('When performing getSubscriptions', () => {
     const response = {
       value: [
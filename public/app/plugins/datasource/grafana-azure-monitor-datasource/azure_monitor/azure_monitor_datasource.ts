@@ -51,13 +51,13 @@ export default class AzureMonitorDatasource extends DataSourceWithBackend<AzureM
   }
 
   filterQuery(item: AzureMonitorQuery): boolean {
+    const scope = item.azureMonitor?.scope;
     return !!(
       item.hide !== true &&
       item.azureMonitor &&
-      item.azureMonitor.resourceGroup &&
-      item.azureMonitor.resourceGroup !== defaultDropdownValue &&
-      item.azureMonitor.resourceName &&
-      item.azureMonitor.resourceName !== defaultDropdownValue &&
+      (scope === 'subscription' ||
+        (item.azureMonitor.resourceGroup && item.azureMonitor.resourceGroup !== defaultDropdownValue)) &&
+      (scope || (item.azureMonitor.resourceName && item.azureMonitor.resourceName !== defaultDropdownValue)) &&
       item.azureMonitor.metricDefinition &&
       item.azureMonitor.metricDefinition !== defaultDropdownValue &&
       item.azureMonitor.metricName &&
@@ -107,6 +107,7 @@ export default class AzureMonitorDatasource extends DataSourceWithBackend<AzureM
       subscription: subscriptionId,
       queryType: AzureQueryType.AzureMonitor,
       azureMonitor: {
+        scope: item.scope,
         resourceGroup,
         resourceName,
         metricDefinition,
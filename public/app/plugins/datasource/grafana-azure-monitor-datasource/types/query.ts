@@ -37,7 +37,14 @@ export interface AzureMonitorQuery extends DataQuery {
 /**
  * Azure Monitor Metrics sub-query properties
  */
+/**
+ * Queries target a single resource by default, or all the resources of their metric definition (resource type)
+ * in a resource group or subscription.
+ */
+export type AzureMetricQueryScope = 'resourceGroup' | 'subscription';
+
 export interface AzureMetricQuery {
+  scope?: AzureMetricQueryScope;
   resourceGroup?: string;
 
   /** Resource type */
@@ -28,6 +28,7 @@ describe('graphiteDatasource', () => {
     jest.clearAllMocks();
 
     const instanceSettings = {
+      id: 1,
       url: '/api/datasources/proxy/1',
       name: 'graphiteProd',
       jsonData: {
@@ -405,7 +406,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/tags/autoComplete/tags');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/tags/autoComplete/tags');
       expect(requestOptions.params.expr).toEqual([]);
       expect(results).not.toBe(null);
     });
@@ -415,7 +416,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/tags/autoComplete/tags');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/tags/autoComplete/tags');
       expect(requestOptions.params.expr).toEqual(['server=backend_01']);
       expect(results).not.toBe(null);
     });
@@ -425,7 +426,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/tags/autoComplete/tags');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/tags/autoComplete/tags');
       expect(requestOptions.params.expr).toEqual(['server=backend_01']);
       expect(results).not.toBe(null);
     });
@@ -435,7 +436,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/tags/autoComplete/values');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/tags/autoComplete/values');
       expect(requestOptions.params.tag).toBe('server');
       expect(requestOptions.params.expr).toEqual([]);
       expect(results).not.toBe(null);
@@ -446,7 +447,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/tags/autoComplete/values');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/tags/autoComplete/values');
       expect(requestOptions.params.tag).toBe('server');
       expect(requestOptions.params.expr).toEqual(['server=~backend*']);
       expect(results).not.toBe(null);
@@ -457,7 +458,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/tags/autoComplete/values');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/tags/autoComplete/values');
       expect(requestOptions.params.tag).toBe('server');
       expect(requestOptions.params.expr).toEqual([]);
       expect(results).not.toBe(null);
@@ -468,7 +469,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/tags/autoComplete/values');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/tags/autoComplete/values');
       expect(requestOptions.params.tag).toBe('server');
       expect(requestOptions.params.expr).toEqual(['server=~backend*']);
       expect(results).not.toBe(null);
@@ -485,7 +486,7 @@ describe('graphiteDatasource', () => {
       ctx.ds.metricFindQuery('[[foo]]').then((data: any) => {
         results = data;
       });
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/metrics/find');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/metrics/find');
       expect(requestOptions.method).toEqual('POST');
       expect(requestOptions.headers).toHaveProperty('Content-Type', 'application/x-www-form-urlencoded');
       expect(requestOptions.data).toMatch(`query=bar`);
@@ -497,7 +498,7 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/metrics/find');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/metrics/find');
       expect(requestOptions.params).toEqual({});
       expect(requestOptions.data).toEqual('query=app.backend*');
       expect(results).not.toBe(null);
@@ -508,12 +509,26 @@ describe('graphiteDatasource', () => {
         results = data;
       });
 
-      expect(requestOptions.url).toBe('/api/datasources/proxy/1/metrics/find');
+      expect(requestOptions.url).toBe('/api/datasources/1/resources/metrics/find');
       expect(requestOptions.params).toEqual({});
       expect(requestOptions.data).toEqual('query=app.*');
       expect(results).not.toBe(null);
     });
 
+    it('should send metric find queries to Graphite with direct access', () => {
+      const ds = new GraphiteDatasource(
+        { id: 1, url: 'http://localhost:8080', name: 'graphiteProd', jsonData: {} },
+        ctx.templateSrv
+      );
+      ds.metricFindQuery('app.*').then((data: any) => {
+        results = data;
+      });
+
+      expect(requestOptions.url).toBe('http://localhost:8080/metrics/find');
+      expect(requestOptions.data).toEqual('query=app.*');
+      expect(results).not.toBe(null);
+    });
+
     it('should request expanded metrics', () => {
       ctx.ds.metricFindQuery('expand(*.servers.*)').then((data: any) => {
         results = data;
@@ -531,7 +531,7 @@ export class GraphiteDatasource
     }
 
     return lastValueFrom(
-      this.doGraphiteRequest(httpOptions).pipe(
+      this.doResourceRequest(httpOptions).pipe(
         map((results: any) => {
           return _map(results.data, (metric) => {
             return {
@@ -667,7 +667,7 @@ export class GraphiteDatasource
       httpOptions.params.from = this.translateTime(options.range.from, false, options.timezone);
       httpOptions.params.until = this.translateTime(options.range.to, true, options.timezone);
     }
-    return lastValueFrom(this.doGraphiteRequest(httpOptions).pipe(mapToTags()));
+    return lastValueFrom(this.doResourceRequest(httpOptions).pipe(mapToTags()));
   }
 
   getTagValuesAutoComplete(expressions: any[], tag: any, valuePrefix: any, optionalOptions: any) {
@@ -694,7 +694,7 @@ export class GraphiteDatasource
       httpOptions.params.from = this.translateTime(options.range.from, false, options.timezone);
       httpOptions.params.until = this.translateTime(options.range.to, true, options.timezone);
     }
-    return lastValueFrom(this.doGraphiteRequest(httpOptions).pipe(mapToTags()));
+    return lastValueFrom(this.doResourceRequest(httpOptions).pipe(mapToTags()));
   }
 
   getVersion(optionalOptions: any) {
@@ -821,6 +821,28 @@ export class GraphiteDatasource
       );
   }
 
+  /**
+   * Sends a request to the resource endpoints of the backend, which proxies
+   * metric and tag lookups to Graphite with the data source credentials.
+   * With direct (browser) access, the request is sent to Graphite itself.
+   */
+  doResourceRequest(options: { method?: string; url: string; requestId?: any }) {
+    const directMode = this.url.match(/^http/);
+    if (directMode) {
+      return this.doGraphiteRequest(options);
+    }
+
+    options.url = `/api/datasources/${this.id}/resources${options.url}`;
+
+    return getBackendSrv()
+      .fetch(options)
+      .pipe(
+        catchError((err: any) => {
+          return throwError(reduceError(err));
+        })
+      );
+  }
+
   buildGraphiteParams(options: any, scopedVars?: ScopedVars): string[] {
     const graphiteOptions = ['from', 'until', 'rawData', 'format', 'maxDataPoints', 'cacheTimeout'];
     const cleanOptions = [],
@@ -35,11 +35,15 @@ const versions = [
 export type Props = DataSourcePluginOptionsEditorProps<InfluxOptions>;
 type State = {
   maxSeries: string | undefined;
+  maxRows: string | undefined;
+  maxBytes: string | undefined;
 };
 
 export class ConfigEditor extends PureComponent<Props, State> {
   state = {
     maxSeries: '',
+    maxRows: '',
+    maxBytes: '',
   };
 
   htmlPrefix: string;
@@ -47,9 +51,18 @@ export class ConfigEditor extends PureComponent<Props, State> {
   constructor(props: Props) {
     super(props);
     this.state.maxSeries = props.options.jsonData.maxSeries?.toString() || '';
+    this.state.maxRows = props.options.jsonData.maxRows?.toString() || '';
+    this.state.maxBytes = props.options.jsonData.maxBytes?.toString() || '';
     this.htmlPrefix = uniqueId('influxdb-config');
   }
 
+  onLimitChange = (key: 'maxRows' | 'maxBytes', value: string) => {
+    // Like for max series, the input state is duplicated to allow writing freely inside the input.
+    this.setState({ [key]: value } as Pick<State, typeof key>);
+    const val = parseInt(value, 10);
+    updateDatasourcePluginJsonDataOption(this.props, key, Number.isFinite(val) ? val : undefined);
+  };
+
   // 1x
   onResetPassword = () => {
     updateDatasourcePluginResetOption(this.props, 'password');
@@ -340,6 +353,40 @@ export class ConfigEditor extends PureComponent<Props, State> {
               />
             </InlineField>
           </div>
+          {options.jsonData.version === InfluxVersion.Flux && (
+            <>
+              <div className="gf-form-inline">
+                <InlineField
+                  labelWidth={20}
+                  label="Max rows"
+                  tooltip="Limit the number of rows of all series of a query that Grafana will process. When the limit is reached the results are truncated. Defaults to 1000000."
+                >
+                  <Input
+                    placeholder="1000000"
+                    type="number"
+                    className="width-10"
+                    value={this.state.maxRows}
+                    onChange={(event) => this.onLimitChange('maxRows', event.currentTarget.value)}
+                  />
+                </InlineField>
+              </div>
+              <div className="gf-form-inline">
+                <InlineField
+                  labelWidth={20}
+                  label="Max bytes"
+                  tooltip="Limit the estimated size of the results of a query that Grafana will process. When the limit is reached the results are truncated. Defaults to 104857600 (100 MiB)."
+                >
+                  <Input
+                    placeholder="104857600"
+                    type="number"
+                    className="width-10"
+                    value={this.state.maxBytes}
+                    onChange={(event) => this.onLimitChange('maxBytes', event.currentTarget.value)}
+                  />
+                </InlineField>
+              </div>
+            </>
+          )}
         </div>
       </>
     );
@@ -1,5 +1,5 @@
 import React, { PureComponent } from 'react';
-import { InfluxQuery } from '../types';
+import { FluxResultFormat, InfluxQuery } from '../types';
 import { SelectableValue } from '@grafana/data';
 import { cx, css } from '@emotion/css';
 import {
@@ -25,6 +25,11 @@ type Props = {
   datasource: InfluxDatasource;
 };
 
+const resultFormats: Array<SelectableValue<FluxResultFormat>> = [
+  { label: 'Tables', description: 'A frame per table of the result', value: 'tables' },
+  { label: 'Wide time series', description: 'A single frame with a field per _field and tags', value: 'wide' },
+];
+
 const samples: Array<SelectableValue<string>> = [
   { label: 'Show buckets', description: 'List the available buckets (table)', value: 'buckets()' },
   {
@@ -97,6 +102,14 @@ export class FluxQueryEditor extends PureComponent<Props> {
     this.props.onRunQuery();
   };
 
+  onResultFormatChange = (val: SelectableValue<FluxResultFormat>) => {
+    this.props.onChange({
+      ...this.props.query,
+      fluxFormat: val.value,
+    });
+    this.props.onRunQuery();
+  };
+
   onSampleChange = (val: SelectableValue<string>) => {
     this.props.onChange({
       ...this.props.query,
@@ -201,6 +214,12 @@ export class FluxQueryEditor extends PureComponent<Props> {
             Flux language syntax
           </LinkButton>
           <Segment options={samples} value="Sample Query" onChange={this.onSampleChange} />
+          <InlineFormLabel width={6}>Format as</InlineFormLabel>
+          <Segment
+            options={resultFormats}
+            value={resultFormats.find((format) => format.value === query.fluxFormat) ?? resultFormats[0]}
+            onChange={this.onResultFormatChange}
+          />
           <div className="gf-form gf-form--grow">
             <div className="gf-form-label gf-form-label--grow"></div>
           </div>
@@ -15,6 +15,8 @@ export interface InfluxOptions extends DataSourceJsonData {
   organization?: string;
   defaultBucket?: string;
   maxSeries?: number;
+  maxRows?: number;
+  maxBytes?: number;
 }
 
 export interface InfluxSecureJsonData {
@@ -43,6 +45,9 @@ export interface InfluxQueryTag {
 
 export type ResultFormat = 'time_series' | 'table' | 'logs';
 
+// Flux tables are returned as a frame per table by default, or pivoted into a wide time series frame.
+export type FluxResultFormat = 'tables' | 'wide';
+
 export interface InfluxQuery extends DataQuery {
   policy?: string;
   measurement?: string;
@@ -60,5 +65,6 @@ export interface InfluxQuery extends DataQuery {
   fill?: string;
   rawQuery?: boolean;
   query?: string;
+  fluxFormat?: FluxResultFormat;
   alias?: string;
 }
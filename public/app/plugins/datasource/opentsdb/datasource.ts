@@ -15,9 +15,11 @@ import {
 } from 'lodash';
 import { lastValueFrom, Observable, of } from 'rxjs';
 import { catchError, map } from 'rxjs/operators';
-import { FetchResponse, getBackendSrv } from '@grafana/runtime';
+import { BackendDataSourceResponse, FetchResponse, getBackendSrv, toDataQueryResponse } from '@grafana/runtime';
 import {
   AnnotationEvent,
+  DataFrame,
+  DataFrameView,
   DataQueryRequest,
   DataQueryResponse,
   DataSourceApi,
@@ -124,38 +126,46 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
   }
 
   annotationQuery(options: any): Promise<AnnotationEvent[]> {
-    const start = this.convertToTSDBTime(options.rangeRaw.from, false, options.timezone);
-    const end = this.convertToTSDBTime(options.rangeRaw.to, true, options.timezone);
-    const qs = [];
-    const eventList: any[] = [];
-
-    qs.push({ aggregator: 'sum', metric: options.annotation.target });
-
-    const queries = compact(qs);
+    const target = this.templateSrv.replace(options.annotation.target, options.scopedVars || {}, 'pipe');
+    const queries = [
+      {
+        refId: 'annotationQuery',
+        queryType: 'annotation',
+        datasource: this.getRef(),
+        target,
+        isGlobal: !!options.annotation.isGlobal,
+      },
+    ];
 
     return lastValueFrom(
-      this.performTimeSeriesQuery(queries, start, end).pipe(
-        map((results) => {
-          if (results.data[0]) {
-            let annotationObject = results.data[0].annotations;
-            if (options.annotation.isGlobal) {
-              annotationObject = results.data[0].globalAnnotations;
-            }
-            if (annotationObject) {
-              each(annotationObject, (annotation) => {
-                const event = {
-                  text: annotation.description,
-                  time: Math.floor(annotation.startTime) * 1000,
+      getBackendSrv()
+        .fetch<BackendDataSourceResponse>({
+          url: '/api/ds/query',
+          method: 'POST',
+          data: {
+            from: options.range.from.valueOf().toString(),
+            to: options.range.to.valueOf().toString(),
+            queries,
+          },
+        })
+        .pipe(
+          map(({ data }) => {
+            const eventList: AnnotationEvent[] = [];
+            const frames = toDataQueryResponse({ data }).data as DataFrame[];
+            for (const frame of frames) {
+              const view = new DataFrameView<{ time: number; timeEnd?: number; text: string }>(frame);
+              view.forEach((row) => {
+                eventList.push({
                   annotation: options.annotation,
-                };
-
-                eventList.push(event);
+                  time: row.time,
+                  timeEnd: row.timeEnd ?? undefined,
+                  text: row.text,
+                });
               });
             }
-          }
-          return eventList;
-        })
-      )
+            return eventList;
+          })
+        )
     );
   }
 
@@ -223,7 +233,7 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
   }
 
   _performSuggestQuery(query: string, type: string): Observable<any> {
-    return this._get('/api/suggest', { type, q: query, max: this.lookupLimit }).pipe(
+    return this._getResource('suggest', { type, q: query }).pipe(
       map((result: any) => {
         return result.data;
       })
@@ -238,25 +248,10 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
     const keysArray = keys.split(',').map((key: any) => {
       return key.trim();
     });
-    const key = keysArray[0];
-    let keysQuery = key + '=*';
-
-    if (keysArray.length > 1) {
-      keysQuery += ',' + keysArray.splice(1).join(',');
-    }
 
-    const m = metric + '{' + keysQuery + '}';
-
-    return this._get('/api/search/lookup', { m: m, limit: this.lookupLimit }).pipe(
+    return this._getResource('tag-values', { metric, key: keysArray[0], filter: keysArray.slice(1) }).pipe(
       map((result: any) => {
-        result = result.data.results;
-        const tagvs: any[] = [];
-        each(result, (r) => {
-          if (tagvs.indexOf(r.tags[key]) === -1) {
-            tagvs.push(r.tags[key]);
-          }
-        });
-        return tagvs;
+        return result.data;
       })
     );
   }
@@ -266,22 +261,25 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
       return of([]);
     }
 
-    return this._get('/api/search/lookup', { m: metric, limit: 1000 }).pipe(
+    return this._getResource('tag-keys', { metric }).pipe(
       map((result: any) => {
-        result = result.data.results;
-        const tagks: any[] = [];
-        each(result, (r) => {
-          each(r.tags, (tagv, tagk) => {
-            if (tagks.indexOf(tagk) === -1) {
-              tagks.push(tagk);
-            }
-          });
-        });
-        return tagks;
+        return result.data;
       })
     );
   }
 
+  /**
+   * Metric and tag lookups go through the resource endpoints of the backend,
+   * which applies the configured lookup limit.
+   */
+  _getResource(path: string, params: Record<string, any>): Observable<FetchResponse> {
+    return getBackendSrv().fetch({
+      method: 'GET',
+      url: `/api/datasources/${this.id}/resources/${path}`,
+      params,
+    });
+  }
+
   _get(
     relativeUrl: string,
     params?: { type?: string; q?: string; max?: number; m?: any; limit?: number }
@@ -25,7 +25,7 @@ describe('opentsdb', () => {
     const fetchMock = jest.spyOn(backendSrv, 'fetch');
     fetchMock.mockImplementation(() => of(createFetchResponse(data)));
 
-    const instanceSettings = { url: '', jsonData: { tsdbVersion: 1 } };
+    const instanceSettings = { id: 1, url: '', jsonData: { tsdbVersion: 1 } };
     const replace = jest.fn((value) => value);
     const templateSrv: any = {
       replace,
@@ -43,7 +43,7 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('metrics(pew)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/suggest');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/1/resources/suggest');
       expect(fetchMock.mock.calls[0][0].params?.type).toBe('metrics');
       expect(fetchMock.mock.calls[0][0].params?.q).toBe('pew');
       expect(results).not.toBe(null);
@@ -55,8 +55,8 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('tag_names(cpu)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
-      expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/1/resources/tag-keys');
+      expect(fetchMock.mock.calls[0][0].params?.metric).toBe('cpu');
       expect(results).not.toBe(null);
     });
 
@@ -66,8 +66,10 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('tag_values(cpu, hostname)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
-      expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu{hostname=*}');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/1/resources/tag-values');
+      expect(fetchMock.mock.calls[0][0].params?.metric).toBe('cpu');
+      expect(fetchMock.mock.calls[0][0].params?.key).toBe('hostname');
+      expect(fetchMock.mock.calls[0][0].params?.filter).toEqual([]);
       expect(results).not.toBe(null);
     });
 
@@ -77,8 +79,10 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('tag_values(cpu, hostname, env=$env)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
-      expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu{hostname=*,env=$env}');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/1/resources/tag-values');
+      expect(fetchMock.mock.calls[0][0].params?.metric).toBe('cpu');
+      expect(fetchMock.mock.calls[0][0].params?.key).toBe('hostname');
+      expect(fetchMock.mock.calls[0][0].params?.filter).toEqual(['env=$env']);
       expect(results).not.toBe(null);
     });
 
@@ -88,8 +92,10 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('tag_values(cpu, hostname, env=$env, region=$region)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
-      expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu{hostname=*,env=$env,region=$region}');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/1/resources/tag-values');
+      expect(fetchMock.mock.calls[0][0].params?.metric).toBe('cpu');
+      expect(fetchMock.mock.calls[0][0].params?.key).toBe('hostname');
+      expect(fetchMock.mock.calls[0][0].params?.filter).toEqual(['env=$env', 'region=$region']);
       expect(results).not.toBe(null);
     });
 
@@ -99,7 +105,7 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('suggest_tagk(foo)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/suggest');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/1/resources/suggest');
       expect(fetchMock.mock.calls[0][0].params?.type).toBe('tagk');
       expect(fetchMock.mock.calls[0][0].params?.q).toBe('foo');
       expect(results).not.toBe(null);
@@ -111,7 +117,7 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('suggest_tagv(bar)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/suggest');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/1/resources/suggest');
       expect(fetchMock.mock.calls[0][0].params?.type).toBe('tagv');
       expect(fetchMock.mock.calls[0][0].params?.q).toBe('bar');
       expect(results).not.toBe(null);
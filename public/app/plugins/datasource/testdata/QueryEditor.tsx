@@ -10,7 +10,7 @@ import { RandomWalkEditor, StreamingClientEditor } from './components';
 
 // Types
 import { TestDataDataSource } from './datasource';
-import { CSVWave, NodesQuery, TestDataQuery, USAQuery } from './types';
+import { CSVWave, FailureQuery, NodesQuery, ReplayQuery, TestDataQuery, USAQuery } from './types';
 import { PredictablePulseEditor } from './components/PredictablePulseEditor';
 import { CSVWavesEditor } from './components/CSVWaveEditor';
 import { defaultCSVWaveQuery, defaultPulseQuery, defaultQuery } from './constants';
@@ -21,8 +21,10 @@ import { defaultStreamQuery } from './runStreams';
 import { CSVFileEditor } from './components/CSVFileEditor';
 import { CSVContentEditor } from './components/CSVContentEditor';
 import { USAQueryEditor, usaQueryModes } from './components/USAQueryEditor';
+import { ReplayEditor } from './components/ReplayEditor';
+import { FailureInjectionEditor } from './components/FailureInjectionEditor';
 
-const showLabelsFor = ['random_walk', 'predictable_pulse'];
+const showLabelsFor = ['random_walk', 'predictable_pulse', 'failure_injection'];
 const endpoints = [
   { value: 'datasources', label: 'Data Sources' },
   { value: 'search', label: 'Search' },
@@ -150,6 +152,14 @@ export const QueryEditor = ({ query, datasource, onChange, onRunQuery }: Props)
     onUpdate({ ...query, usa });
   };
 
+  const onReplayChange = (replay?: ReplayQuery) => {
+    onUpdate({ ...query, replay });
+  };
+
+  const onFailureChange = (failure?: FailureQuery) => {
+    onUpdate({ ...query, failure });
+  };
+
   const onCSVWaveChange = (csvWave?: CSVWave[]) => {
     onUpdate({ ...query, csvWave });
   };
@@ -258,6 +268,10 @@ export const QueryEditor = ({ query, datasource, onChange, onRunQuery }: Props)
       )}
 
       {scenarioId === 'usa' && <USAQueryEditor onChange={onUSAStatsChange} query={query.usa ?? {}} />}
+      {scenarioId === 'replay' && <ReplayEditor onChange={onReplayChange} query={query.replay ?? {}} />}
+      {scenarioId === 'failure_injection' && (
+        <FailureInjectionEditor onChange={onFailureChange} query={query.failure ?? {}} />
+      )}
       {scenarioId === 'grafana_api' && (
         <InlineField labelWidth={14} label="Endpoint">
           <Select
@@ -0,0 +1,120 @@
+import React from 'react';
+import { InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+import { SelectableValue } from '@grafana/data';
+import { FailureQuery } from '../types';
+
+export interface Props {
+  onChange: (value: FailureQuery) => void;
+  query: FailureQuery;
+}
+
+type NumberKey = Exclude<keyof FailureQuery, 'errorMessage' | 'latencyDistribution'>;
+
+interface NumberField {
+  label: string;
+  id: NumberKey;
+  placeholder: string;
+  tooltip?: string;
+  min?: number;
+  max?: number;
+  step?: number;
+}
+
+const rateTooltip = 'Probability between 0 and 1';
+
+const seriesFields: NumberField[] = [
+  { label: 'Series count', id: 'seriesCount', placeholder: '1', min: 1, step: 1 },
+  { label: 'Seed', id: 'seed', placeholder: 'random', tooltip: 'Set to make the failures reproducible', step: 1 },
+];
+
+const failureFields: NumberField[] = [
+  { label: 'Error rate', id: 'errorRate', placeholder: '0', tooltip: rateTooltip, min: 0, max: 1, step: 0.1 },
+  { label: 'Partial rate', id: 'partialRate', placeholder: '0', tooltip: rateTooltip, min: 0, max: 1, step: 0.1 },
+  {
+    label: 'Oversize rows',
+    id: 'oversizeRows',
+    placeholder: 'none',
+    tooltip: 'Number of rows to return regardless of the interval and max data points',
+    min: 0,
+    step: 1000,
+  },
+];
+
+const burstFields: NumberField[] = [
+  { label: 'NaN rate', id: 'nanRate', placeholder: '0', tooltip: rateTooltip, min: 0, max: 1, step: 0.01 },
+  { label: 'Null rate', id: 'nullRate', placeholder: '0', tooltip: rateTooltip, min: 0, max: 1, step: 0.01 },
+  { label: 'Burst length', id: 'burstLength', placeholder: '1', min: 1, step: 1 },
+];
+
+const latencyFields: NumberField[] = [
+  { label: 'Latency (ms)', id: 'latencyMs', placeholder: '0', min: 0, step: 100 },
+  {
+    label: 'Jitter (ms)',
+    id: 'latencyJitterMs',
+    placeholder: '0',
+    tooltip: 'Maximum deviation of uniform latencies, standard deviation of normal latencies',
+    min: 0,
+    step: 100,
+  },
+];
+
+const latencyDistributions: Array<SelectableValue<FailureQuery['latencyDistribution']>> = [
+  { label: 'Constant', value: 'constant' },
+  { label: 'Uniform', value: 'uniform' },
+  { label: 'Normal', value: 'normal' },
+];
+
+export function FailureInjectionEditor({ query, onChange }: Props) {
+  const renderFields = (fields: NumberField[]) =>
+    fields.map(({ label, id, placeholder, tooltip, min, max, step }) => (
+      <InlineField label={label} labelWidth={14} key={id} tooltip={tooltip}>
+        <Input
+          width={12}
+          type="number"
+          min={min}
+          max={max}
+          step={step}
+          value={query[id] ?? ''}
+          placeholder={placeholder}
+          onChange={(v) => {
+            const value = v.currentTarget.value;
+            onChange({ ...query, [id]: value === '' ? undefined : Number(value) });
+          }}
+        />
+      </InlineField>
+    ));
+
+  return (
+    <>
+      <InlineFieldRow>{renderFields(seriesFields)}</InlineFieldRow>
+      <InlineFieldRow>
+        {renderFields(failureFields)}
+        <InlineField label="Error message">
+          <Input
+            width={32}
+            value={query.errorMessage ?? ''}
+            placeholder="injected failure"
+            onChange={(v) => {
+              onChange({ ...query, errorMessage: v.currentTarget.value });
+            }}
+          />
+        </InlineField>
+      </InlineFieldRow>
+      <InlineFieldRow>
+        <InlineField label="Latency" labelWidth={14}>
+          <Select
+            menuShouldPortal
+            width={12}
+            options={latencyDistributions}
+            value={query.latencyDistribution ?? 'constant'}
+            onChange={(v) => {
+              onChange({ ...query, latencyDistribution: v.value });
+            }}
+          />
+        </InlineField>
+        {renderFields(latencyFields)}
+      </InlineFieldRow>
+      <InlineFieldRow>{renderFields(burstFields)}</InlineFieldRow>
+    </>
+  );
+}
@@ -0,0 +1,60 @@
+import React from 'react';
+import { CodeEditor, InlineField, InlineFieldRow, InlineSwitch, Input } from '@grafana/ui';
+import { ReplayQuery } from '../types';
+
+export interface Props {
+  onChange: (value: ReplayQuery) => void;
+  query: ReplayQuery;
+}
+
+export function ReplayEditor({ query, onChange }: Props) {
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField
+          labelWidth={14}
+          label="File"
+          tooltip="A recording in the testdata directory of the Grafana data path, either a query response (JSON) or an Apache Arrow frame"
+        >
+          <Input
+            width={32}
+            value={query.fileName ?? ''}
+            placeholder="recording.json"
+            onChange={(v) => {
+              onChange({ ...query, fileName: v.currentTarget.value });
+            }}
+          />
+        </InlineField>
+        <InlineField label="Query" tooltip="The recorded query to replay. Defaults to the one with the ID of this query">
+          <Input
+            width={8}
+            value={query.refId ?? ''}
+            placeholder="A"
+            onChange={(v) => {
+              onChange({ ...query, refId: v.currentTarget.value });
+            }}
+          />
+        </InlineField>
+        <InlineField label="Keep time" tooltip="Replay the recorded times instead of shifting them to the time range">
+          <InlineSwitch
+            value={!!query.keepTime}
+            onChange={(v) => {
+              onChange({ ...query, keepTime: v.currentTarget.checked });
+            }}
+          />
+        </InlineField>
+      </InlineFieldRow>
+      {!query.fileName && (
+        <CodeEditor
+          height={300}
+          language="json"
+          value={query.content ?? ''}
+          onBlur={(content) => onChange({ ...query, content })}
+          onSave={(content) => onChange({ ...query, content })}
+          showMiniMap={false}
+          showLineNumbers={true}
+        />
+      )}
+    </>
+  );
+}
@@ -22,6 +22,8 @@ export interface TestDataQuery extends DataQuery {
   csvContent?: string;
   rawFrameContent?: string;
   usa?: USAQuery;
+  replay?: ReplayQuery;
+  failure?: FailureQuery;
 }
 
 export interface NodesQuery {
@@ -52,6 +54,28 @@ export interface CSVWave {
   labels?: string;
 }
 
+export interface ReplayQuery {
+  fileName?: string; // in the testdata directory of the data path
+  content?: string; // query response or frames (JSON)
+  refId?: string;
+  keepTime?: boolean;
+}
+
+export interface FailureQuery {
+  seriesCount?: number;
+  errorRate?: number; // 0-1
+  errorMessage?: string;
+  latencyDistribution?: 'constant' | 'uniform' | 'normal';
+  latencyMs?: number;
+  latencyJitterMs?: number;
+  partialRate?: number; // 0-1
+  nanRate?: number; // 0-1
+  nullRate?: number; // 0-1
+  burstLength?: number;
+  oversizeRows?: number;
+  seed?: number;
+}
+
 export interface USAQuery {
   mode?: string;
   period?: string;